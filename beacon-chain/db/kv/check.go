@@ -0,0 +1,47 @@
+package kv
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/boltdb/bolt"
+	"github.com/pkg/errors"
+)
+
+// CheckIntegrity verifies the consistency of the pages and buckets of the database file at the
+// given path, which is opened read-only. A running beacon node holds an exclusive lock on its
+// database, so one of its backups is checked instead.
+func CheckIntegrity(dbPath string) error {
+	if _, err := os.Stat(dbPath); err != nil {
+		return err
+	}
+	boltDB, err := bolt.Open(dbPath, 0600, &bolt.Options{Timeout: 1 * time.Second, ReadOnly: true})
+	if err != nil {
+		if err == bolt.ErrTimeout {
+			return errors.New("cannot obtain database lock, database may be in use by another process")
+		}
+		return errors.Wrap(err, "could not open database")
+	}
+	defer boltDB.Close()
+
+	var problems []string
+	if err := boltDB.View(func(tx *bolt.Tx) error {
+		for err := range tx.Check() {
+			problems = append(problems, err.Error())
+		}
+		for _, bucket := range [][]byte{blocksBucket, stateBucket, chainMetadataBucket, checkpointBucket} {
+			if tx.Bucket(bucket) == nil {
+				problems = append(problems, fmt.Sprintf("missing bucket %s", bucket))
+			}
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("database is corrupted: %s", strings.Join(problems, "; "))
+	}
+	return nil
+}
@@ -0,0 +1,51 @@
+package kv
+
+import (
+	"context"
+	"os"
+	"path"
+	"strings"
+	"testing"
+
+	eth "github.com/prysmaticlabs/ethereumapis/eth/v1alpha1"
+)
+
+func TestCheckIntegrity(t *testing.T) {
+	db := setupDB(t)
+	defer teardownDB(t, db)
+	if err := db.SaveBlock(context.Background(), &eth.SignedBeaconBlock{Block: &eth.BeaconBlock{Slot: 5}}); err != nil {
+		t.Fatal(err)
+	}
+	// The database is closed first, as it would be locked by a running node.
+	if err := db.db.Close(); err != nil {
+		t.Fatal(err)
+	}
+	dbPath := path.Join(db.DatabasePath(), databaseFileName)
+
+	if err := CheckIntegrity(dbPath); err != nil {
+		t.Errorf("Expected database to pass the check: %v", err)
+	}
+
+	// Both meta pages are needed to open the database, their magic number is cleared.
+	file, err := os.OpenFile(dbPath, os.O_WRONLY, 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, offset := range []int64{16, int64(os.Getpagesize()) + 16} {
+		if _, err := file.WriteAt(make([]byte, 4), offset); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := file.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := CheckIntegrity(dbPath); err == nil || !strings.Contains(err.Error(), "invalid database") {
+		t.Errorf("Expected corrupted database error, received %v", err)
+	}
+}
+
+func TestCheckIntegrity_NoDatabase(t *testing.T) {
+	if err := CheckIntegrity("/does/not/exist/beaconchain.db"); !os.IsNotExist(err) {
+		t.Errorf("Expected missing database error, received %v", err)
+	}
+}
@@ -2,6 +2,7 @@
 package main
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"runtime"
@@ -9,6 +10,7 @@ import (
 
 	golog "github.com/ipfs/go-log"
 	joonix "github.com/joonix/log"
+	"github.com/prysmaticlabs/prysm/beacon-chain/db/kv"
 	"github.com/prysmaticlabs/prysm/beacon-chain/flags"
 	"github.com/prysmaticlabs/prysm/beacon-chain/node"
 	"github.com/prysmaticlabs/prysm/shared/cmd"
@@ -88,6 +90,16 @@ func main() {
 	app.Usage = "this is a beacon chain implementation for Ethereum 2.0"
 	app.Action = startNode
 	app.Version = version.GetVersion()
+	app.Commands = []cli.Command{
+		{
+			Name:      "db-check",
+			Usage:     "checks the integrity of a beacon chain database, exiting with an error if it's corrupted",
+			ArgsUsage: "<database file>",
+			Description: `checks the pages and buckets of the database file, e.g. $DATADIR/beaconchaindata/beaconchain.db.
+The database of a running node is locked, one of its backups can be checked instead`,
+			Action: checkDB,
+		},
+	}
 
 	app.Flags = appFlags
 
@@ -141,6 +153,19 @@ func main() {
 	}
 }
 
+// checkDB checks the integrity of the database file given as argument.
+func checkDB(ctx *cli.Context) error {
+	dbPath := ctx.Args().First()
+	if dbPath == "" {
+		return errors.New("the database file to check must be given as argument")
+	}
+	if err := kv.CheckIntegrity(dbPath); err != nil {
+		return err
+	}
+	logrus.WithField("prefix", "main").WithField("path", dbPath).Info("Database integrity check passed")
+	return nil
+}
+
 func startNode(ctx *cli.Context) error {
 	verbosity := ctx.GlobalString(cmd.VerbosityFlag.Name)
 	level, err := logrus.ParseLevel(verbosity)
@@ -93,6 +93,12 @@ func NewBeaconNode(ctx *cli.Context) (*BeaconNode, error) {
 			params.UseDemoBeaconConfig()
 		}
 	}
+	if slotDuration := featureconfig.Get().E2ESlotDuration; slotDuration > 0 {
+		log.WithField("secondsPerSlot", slotDuration).Warn("Using end-to-end test slot duration")
+		c := params.BeaconConfig()
+		c.SecondsPerSlot = slotDuration
+		params.OverrideBeaconConfig(c)
+	}
 
 	beacon := &BeaconNode{
 		ctx:             ctx,
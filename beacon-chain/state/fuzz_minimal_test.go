@@ -0,0 +1,19 @@
+package state
+
+import (
+	"testing"
+
+	"github.com/prysmaticlabs/prysm/shared/params"
+	"github.com/prysmaticlabs/prysm/shared/testutil"
+)
+
+func TestFuzzBeaconStateSSZRoundTrip_Minimal(t *testing.T) {
+	config := params.BeaconConfig()
+	defer params.OverrideBeaconConfig(config)
+	params.UseMinimalConfig()
+	testutil.ResetCache()
+	defer testutil.ResetCache()
+
+	genesis, _ := testutil.DeterministicGenesisState(t, 64)
+	fuzzBeaconStateSSZRoundTrip(t, genesis, 2, 100)
+}
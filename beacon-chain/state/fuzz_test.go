@@ -0,0 +1,89 @@
+package state
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/gogo/protobuf/proto"
+	fuzz "github.com/google/gofuzz"
+	"github.com/prysmaticlabs/go-ssz"
+	pb "github.com/prysmaticlabs/prysm/proto/beacon/p2p/v1"
+	"github.com/prysmaticlabs/prysm/shared/stateutil"
+	"github.com/prysmaticlabs/prysm/shared/testutil"
+)
+
+func TestFuzzBeaconStateSSZRoundTrip_10(t *testing.T) {
+	genesis, _ := testutil.DeterministicGenesisState(t, 64)
+	fuzzBeaconStateSSZRoundTrip(t, genesis, 0, 10)
+}
+
+func TestFuzzBeaconStateSSZRoundTrip_100(t *testing.T) {
+	genesis, _ := testutil.DeterministicGenesisState(t, 64)
+	fuzzBeaconStateSSZRoundTrip(t, genesis, 1, 100)
+}
+
+// fuzzBeaconStateSSZRoundTrip mutates the genesis state and checks that every mutation encodes,
+// decodes and re-encodes to the same bytes, with the same hash tree root. Only fields that
+// keep the state valid SSZ are fuzzed, fixed size vectors keep their length.
+func fuzzBeaconStateSSZRoundTrip(t *testing.T, genesis *pb.BeaconState, seed int64, iterations uint64) {
+	fuzzer := fuzz.NewWithSeed(seed).NilChance(0)
+
+	for i := uint64(0); i < iterations; i++ {
+		state := proto.Clone(genesis).(*pb.BeaconState)
+		fuzzer.Fuzz(&state.GenesisTime)
+		fuzzer.Fuzz(&state.Slot)
+		fuzzer.Fuzz(&state.Eth1DepositIndex)
+		fuzzer.Fuzz(&state.Fork.Epoch)
+		fuzzer.Fuzz(&state.FinalizedCheckpoint.Epoch)
+		fuzzer.Fuzz(&state.CurrentJustifiedCheckpoint.Epoch)
+		for j := range state.Balances {
+			fuzzer.Fuzz(&state.Balances[j])
+		}
+		for _, root := range state.BlockRoots {
+			fuzzBytes(fuzzer, root)
+		}
+		for _, mix := range state.RandaoMixes {
+			fuzzBytes(fuzzer, mix)
+		}
+		for _, validator := range state.Validators {
+			fuzzer.Fuzz(&validator.EffectiveBalance)
+			fuzzer.Fuzz(&validator.Slashed)
+			fuzzer.Fuzz(&validator.ExitEpoch)
+		}
+
+		encoded, err := ssz.Marshal(state)
+		if err != nil {
+			t.Fatalf("Could not encode state on iteration %d: %v", i, err)
+		}
+		decoded := &pb.BeaconState{}
+		if err := ssz.Unmarshal(encoded, decoded); err != nil {
+			t.Fatalf("Could not decode state on iteration %d: %v", i, err)
+		}
+		reencoded, err := ssz.Marshal(decoded)
+		if err != nil {
+			t.Fatalf("Could not re-encode state on iteration %d: %v", i, err)
+		}
+		if !bytes.Equal(encoded, reencoded) {
+			t.Fatalf("Encoding differs after a round trip on iteration %d", i)
+		}
+
+		root, err := stateutil.HashTreeRootState(state)
+		if err != nil {
+			t.Fatal(err)
+		}
+		decodedRoot, err := stateutil.HashTreeRootState(decoded)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if root != decodedRoot {
+			t.Fatalf("Hash tree root changed after a round trip on iteration %d: %#x != %#x", i, root, decodedRoot)
+		}
+	}
+}
+
+// fuzzBytes overwrites b with random bytes without changing its length.
+func fuzzBytes(fuzzer *fuzz.Fuzzer, b []byte) {
+	for i := range b {
+		fuzzer.Fuzz(&b[i])
+	}
+}
@@ -0,0 +1,218 @@
+package endtoend
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/pkg/errors"
+)
+
+// artifactsDirEnvVar is the env var giving the directory artifacts of failed runs are copied to. When
+// it's not set, they go to TEST_UNDECLARED_OUTPUTS_DIR, which bazel keeps in the test outputs.
+const artifactsDirEnvVar = "E2E_ARTIFACTS_DIR"
+
+// maxArtifactsSize caps the bytes copied out of a failed run, counted before compression.
+const maxArtifactsSize = 512 << 20
+
+// datadirsArchiveName is the tarball the datadirs of a failed run are written to.
+const datadirsArchiveName = "datadirs.tar.gz"
+
+// chainDBNames are the databases left out of the datadirs archive unless end2EndConfig.keepDB is set,
+// as they grow to gigabytes.
+var chainDBNames = map[string]bool{
+	"beaconchain.db": true,
+	"slasher.db":     true,
+	"chaindata":      true,
+}
+
+// artifactsDir returns where the artifacts of failed runs are copied, empty if nowhere.
+func artifactsDir() string {
+	if dir := os.Getenv(artifactsDirEnvVar); dir != "" {
+		return dir
+	}
+	return os.Getenv("TEST_UNDECLARED_OUTPUTS_DIR")
+}
+
+// saveArtifacts copies the logs and datadirs of the run to the artifacts directory when the test
+// failed, as tmpPath doesn't outlive the sandbox. It's meant to be deferred before any process is
+// started, so it runs once they are all stopped.
+func saveArtifacts(t testing.TB, config *end2EndConfig) {
+	if !t.Failed() {
+		return
+	}
+	dir := artifactsDir()
+	if dir == "" {
+		t.Logf("Not saving the logs of the failed run, set %s to keep them", artifactsDirEnvVar)
+		return
+	}
+	outputDir := path.Join(dir, t.Name())
+	skipped, err := collectArtifacts(config.tmpPath, outputDir, config.keepDB, maxArtifactsSize)
+	if err != nil {
+		t.Errorf("Could not save the logs of the failed run: %v", err)
+		return
+	}
+	t.Logf("Logs and datadirs of the failed run were copied to %s", outputDir)
+	if len(skipped) > 0 {
+		t.Logf("Left out past the %d MB cap: %s", maxArtifactsSize>>20, strings.Join(skipped, ", "))
+	}
+}
+
+// collectArtifacts copies the log files and reports at the root of tmpPath to outputDir, then
+// archives the directories of tmpPath to datadirs.tar.gz in it. The chain databases are only archived when
+// keepDB is set. At most maxSize bytes are copied: logs that don't fit are cut to their latest
+// output and files that don't fit are left out of the archive. It returns what was cut or left out.
+func collectArtifacts(tmpPath string, outputDir string, keepDB bool, maxSize int64) ([]string, error) {
+	entries, err := ioutil.ReadDir(tmpPath)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(outputDir, os.ModePerm); err != nil {
+		return nil, err
+	}
+	budget := maxSize
+	var skipped []string
+	var dirs []string
+	for _, entry := range entries {
+		if entry.IsDir() {
+			dirs = append(dirs, entry.Name())
+			continue
+		}
+		isReport := entry.Name() == resultsFileName || entry.Name() == junitFileName
+		if !isLogFile(entry.Name()) && !isReport {
+			continue
+		}
+		copied, err := copyLogTail(path.Join(tmpPath, entry.Name()), path.Join(outputDir, entry.Name()), budget)
+		if err != nil {
+			return nil, err
+		}
+		if copied < entry.Size() {
+			skipped = append(skipped, entry.Name())
+		}
+		budget -= copied
+	}
+	if len(dirs) == 0 {
+		return skipped, nil
+	}
+	archived, err := archiveDirs(tmpPath, dirs, path.Join(outputDir, datadirsArchiveName), keepDB, budget)
+	if err != nil {
+		return nil, err
+	}
+	return append(skipped, archived...), nil
+}
+
+// isLogFile reports whether the file is a log or a rotated segment of one, such as beacon-0.log.1.
+func isLogFile(name string) bool {
+	return strings.HasSuffix(name, ".log") || strings.Contains(name, ".log.")
+}
+
+// copyLogTail copies the last maxSize bytes of the log file at src to dst, returning how many were copied.
+func copyLogTail(src string, dst string, maxSize int64) (int64, error) {
+	in, err := os.Open(src)
+	if err != nil {
+		return 0, err
+	}
+	defer in.Close()
+	info, err := in.Stat()
+	if err != nil {
+		return 0, err
+	}
+	if maxSize <= 0 {
+		return 0, nil
+	}
+	if info.Size() > maxSize {
+		if _, err := in.Seek(info.Size()-maxSize, io.SeekStart); err != nil {
+			return 0, err
+		}
+	}
+	out, err := os.Create(dst)
+	if err != nil {
+		return 0, err
+	}
+	copied, err := io.CopyN(out, in, maxSize)
+	if err != nil && err != io.EOF {
+		_ = out.Close()
+		return 0, errors.Wrapf(err, "could not copy %s", src)
+	}
+	return copied, out.Close()
+}
+
+// archiveDirs writes the regular files under the given directories of root to a gzipped tarball at
+// dst, with paths relative to root, until maxSize bytes are archived. It returns the files left out.
+func archiveDirs(root string, dirs []string, dst string, keepDB bool, maxSize int64) ([]string, error) {
+	file, err := os.Create(dst)
+	if err != nil {
+		return nil, err
+	}
+	gz := gzip.NewWriter(file)
+	tw := tar.NewWriter(gz)
+	var skipped []string
+	budget := maxSize
+	for _, dir := range dirs {
+		err := filepath.Walk(path.Join(root, dir), func(name string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			if chainDBNames[info.Name()] && !keepDB {
+				if info.IsDir() {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if !info.Mode().IsRegular() {
+				return nil
+			}
+			rel, err := filepath.Rel(root, name)
+			if err != nil {
+				return err
+			}
+			if info.Size() > budget {
+				skipped = append(skipped, rel)
+				return nil
+			}
+			budget -= info.Size()
+			return addToArchive(tw, name, rel, info)
+		})
+		if err != nil {
+			_ = file.Close()
+			return nil, errors.Wrapf(err, "could not archive %s", dir)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		_ = file.Close()
+		return nil, err
+	}
+	if err := gz.Close(); err != nil {
+		_ = file.Close()
+		return nil, err
+	}
+	return skipped, file.Close()
+}
+
+// addToArchive writes the file at name to the tarball under the given relative path.
+func addToArchive(tw *tar.Writer, name string, rel string, info os.FileInfo) error {
+	header, err := tar.FileInfoHeader(info, "")
+	if err != nil {
+		return err
+	}
+	header.Name = filepath.ToSlash(rel)
+	if err := tw.WriteHeader(header); err != nil {
+		return err
+	}
+	file, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	// The file may still grow if a process outlived the run, only the size in the header fits.
+	if _, err := io.CopyN(tw, file, info.Size()); err != nil {
+		return errors.Wrapf(err, "could not copy %s", rel)
+	}
+	return nil
+}
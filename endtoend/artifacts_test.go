@@ -0,0 +1,173 @@
+package endtoend
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+// writeRunFiles lays out the files of a run under dir, as the started processes would.
+func writeRunFiles(t *testing.T, dir string, files map[string]string) {
+	for name, content := range files {
+		name = path.Join(dir, name)
+		if err := os.MkdirAll(path.Dir(name), os.ModePerm); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(name, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// archivedFiles returns the names of the files in the gzipped tarball.
+func archivedFiles(t *testing.T, name string) []string {
+	file, err := os.Open(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	gz, err := gzip.NewReader(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tr := tar.NewReader(gz)
+	var names []string
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		names = append(names, header.Name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func TestCollectArtifacts(t *testing.T) {
+	files := map[string]string{
+		"beacon-0.log":                    "beacon 0",
+		"beacon-0.log.1":                  "beacon 0 rotated",
+		"validator-0.log":                 "validator 0",
+		"genesis.ssz":                     "genesis",
+		"eth2-beacon-node-0/network-keys": "key",
+		"eth2-beacon-node-0/beaconchaindata/beaconchain.db": "db",
+		"eth1data/geth/chaindata/000001.ldb":                "chain",
+		"eth1data/keystore/dev":                             "keystore",
+	}
+	tests := []struct {
+		name     string
+		keepDB   bool
+		maxSize  int64
+		logs     map[string]string
+		archived []string
+		skipped  []string
+	}{
+		{
+			name:    "chain databases left out",
+			maxSize: 1 << 20,
+			logs: map[string]string{
+				"beacon-0.log":    "beacon 0",
+				"beacon-0.log.1":  "beacon 0 rotated",
+				"validator-0.log": "validator 0",
+			},
+			archived: []string{"eth1data/keystore/dev", "eth2-beacon-node-0/network-keys"},
+		},
+		{
+			name:    "chain databases kept",
+			keepDB:  true,
+			maxSize: 1 << 20,
+			logs: map[string]string{
+				"beacon-0.log":    "beacon 0",
+				"beacon-0.log.1":  "beacon 0 rotated",
+				"validator-0.log": "validator 0",
+			},
+			archived: []string{
+				"eth1data/geth/chaindata/000001.ldb",
+				"eth1data/keystore/dev",
+				"eth2-beacon-node-0/beaconchaindata/beaconchain.db",
+				"eth2-beacon-node-0/network-keys",
+			},
+		},
+		{
+			name: "capped",
+			// The logs come first, the last one is cut to its latest output and no file is archived.
+			maxSize: 30,
+			logs: map[string]string{
+				"beacon-0.log":    "beacon 0",
+				"beacon-0.log.1":  "beacon 0 rotated",
+				"validator-0.log": "ator 0",
+			},
+			skipped: []string{"validator-0.log", "eth1data/keystore/dev", "eth2-beacon-node-0/network-keys"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir, err := ioutil.TempDir("", "artifacts")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.RemoveAll(dir)
+			tmpPath := path.Join(dir, "run")
+			writeRunFiles(t, tmpPath, files)
+			outputDir := path.Join(dir, "outputs")
+
+			skipped, err := collectArtifacts(tmpPath, outputDir, tt.keepDB, tt.maxSize)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(skipped, tt.skipped) {
+				t.Errorf("Expected %v to be left out, received %v", tt.skipped, skipped)
+			}
+			for name, want := range tt.logs {
+				content, err := ioutil.ReadFile(path.Join(outputDir, name))
+				if err != nil {
+					t.Fatal(err)
+				}
+				if string(content) != want {
+					t.Errorf("Expected %s to hold %q, received %q", name, want, content)
+				}
+			}
+			if _, err := os.Stat(path.Join(outputDir, "genesis.ssz")); !os.IsNotExist(err) {
+				t.Errorf("Expected only log files to be copied, received genesis.ssz: %v", err)
+			}
+			if archived := archivedFiles(t, path.Join(outputDir, datadirsArchiveName)); !reflect.DeepEqual(archived, tt.archived) {
+				t.Errorf("Expected archive to hold %v, received %v", tt.archived, archived)
+			}
+		})
+	}
+}
+
+func TestArtifactsDir(t *testing.T) {
+	for _, name := range []string{artifactsDirEnvVar, "TEST_UNDECLARED_OUTPUTS_DIR"} {
+		previous, ok := os.LookupEnv(name)
+		if ok {
+			defer os.Setenv(name, previous)
+		} else {
+			defer os.Unsetenv(name)
+		}
+	}
+	if err := os.Setenv("TEST_UNDECLARED_OUTPUTS_DIR", "/bazel/outputs"); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Unsetenv(artifactsDirEnvVar); err != nil {
+		t.Fatal(err)
+	}
+	if dir := artifactsDir(); dir != "/bazel/outputs" {
+		t.Errorf("Expected bazel outputs directory, received %q", dir)
+	}
+	if err := os.Setenv(artifactsDirEnvVar, "/ci/artifacts"); err != nil {
+		t.Fatal(err)
+	}
+	if dir := artifactsDir(); dir != "/ci/artifacts" {
+		t.Errorf("Expected %s to take precedence, received %q", artifactsDirEnvVar, dir)
+	}
+}
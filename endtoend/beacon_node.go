@@ -1,176 +1,1070 @@
 package endtoend
 
 import (
-	"bufio"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"fmt"
 	"io"
 	"io/ioutil"
+	mathRand "math/rand"
 	"os"
 	"os/exec"
 	"path"
+	"regexp"
 	"strings"
+	"sync"
+	"syscall"
 	"testing"
 	"time"
 
-	"github.com/bazelbuild/rules_go/go/tools/bazel"
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/libp2p/go-libp2p-core/crypto"
+	"github.com/libp2p/go-libp2p-core/peer"
 	"github.com/pkg/errors"
 	ev "github.com/prysmaticlabs/prysm/endtoend/evaluators"
+	"github.com/prysmaticlabs/prysm/shared/iputils"
+	"github.com/prysmaticlabs/prysm/shared/params"
+	"github.com/prysmaticlabs/prysm/shared/testutil"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
 )
 
 type beaconNodeInfo struct {
-	processID   int
-	datadir     string
-	rpcPort     uint64
-	monitorPort uint64
-	grpcPort    uint64
-	multiAddr   string
+	index        int
+	processID    int // Zero for nodes run in containers.
+	process      nodeProcess
+	logFile      *os.File
+	logRotation  *rotatingWriter
+	datadir      string
+	rpcPort      uint64
+	monitorPort  uint64
+	grpcPort     uint64
+	p2pTCPPort   uint64
+	p2pUDPPort   uint64
+	multiAddr    string
+	peers        []string
+	tlsCert      string // Certificate the gRPC server is dialed with, plaintext is used when empty.
+	alive        bool
+	restartCount int
+	connLock     sync.Mutex
+	rpcConn      *cachedConn
+	// processLock guards processID, resourceSamples and exitExpected, which the resource and health
+	// monitors use from their own goroutines. See monitorResources and MonitorNodeHealth.
+	processLock     sync.Mutex
+	resourceSamples []resourceSample
+	// exitExpected is set while the process is stopped on purpose, until the node is launched again.
+	exitExpected bool
+}
+
+// cachedConn is a connection dialed once, by whichever caller needs it first.
+type cachedConn struct {
+	once sync.Once
+	conn *grpc.ClientConn
+	err  error
 }
 
 type end2EndConfig struct {
-	minimalConfig  bool
-	tmpPath        string
-	epochsToRun    uint64
-	numValidators  uint64
-	numBeaconNodes uint64
-	enableSSZCache bool
-	contractAddr   common.Address
-	evaluators     []ev.Evaluator
+	minimalConfig      bool
+	tmpPath            string
+	epochsToRun        uint64
+	numValidators      uint64
+	numBeaconNodes     uint64
+	enableSSZCache     bool
+	contractAddr       common.Address
+	nodeStartupTimeout time.Duration
+	restartNodeAtEpoch uint64 // When non-zero, the beacon node at restartNodeIndex is restarted at this epoch.
+	restartNodeIndex   int
+	killNodeAtEpoch    uint64 // When non-zero, nodesToKill random beacon nodes are killed at this epoch.
+	nodesToKill        uint64
+	depositsAtEpoch    uint64 // When non-zero, numMidRunDeposits new validators are deposited at this epoch.
+	numMidRunDeposits  uint64
+	portOffset         uint64 // Shifts the fixed eth1 ports, so several suites can run side by side.
+	evaluators         []ev.Evaluator
+	logEvaluators      []logEvaluator
+	// metricsOutputDir is where the metrics of every beacon node are written at the end of each epoch,
+	// relative to tmpPath unless absolute. No metrics are captured when it's empty.
+	metricsOutputDir  string
+	metricsEvaluators []metricsEvaluator
+	// extraBeaconFlags are appended to the flags of every beacon node after all the computed flags,
+	// so they can be used to enable experimental features or to override the defaults.
+	extraBeaconFlags []string
+	// perNodeFlags are appended to the flags of the beacon node with the given index, after extraBeaconFlags.
+	perNodeFlags map[int][]string
+	// numValidatorsPerNode is the amount of validators run by the validator client of each beacon node.
+	// When zero, numValidators are split evenly among the beacon nodes.
+	numValidatorsPerNode uint64
+	// contractDeploymentBlock is the eth1 block the deposit contract was deployed in, the beacon
+	// nodes start looking for deposits from there.
+	contractDeploymentBlock uint64
+	// testSlasher runs a slasher against the first beacon node and checks it detects a double vote.
+	testSlasher bool
+	// bootNodeENR is the record of the boot node the beacon nodes discover each other through.
+	bootNodeENR string
+	// staticPeers additionally peers every beacon node with all the others through --peer flags,
+	// rather than relying on discovery alone. The nodes are then started one at a time.
+	staticPeers bool
+	// maxLogFileSizeMB is the size beacon node log files are rotated at, to beacon-N.log.1, .2 and
+	// so on. Logs are never rotated when it's zero.
+	maxLogFileSizeMB uint64
+	// genesisStateFile is an SSZ genesis state the beacon nodes start from, instead of computing it
+	// from the deposits on the eth1 chain. The validators in it are not deposited.
+	genesisStateFile string
+	// partitionAtEpoch, when non-zero, cuts the p2p connections between the first and the second half
+	// of the beacon nodes at this epoch, for partitionEpochs epochs. See PartitionNodes.
+	partitionAtEpoch uint64
+	partitionEpochs  uint64
+	// partitionedNodes, when set, are the indices of the beacon nodes cut off from the others by
+	// the partition, instead of the first half.
+	partitionedNodes []int
+	// depositBatchSize, when non-zero, splits the deposits of the validators in batches sent
+	// depositDelay apart, in the background, so validators keep joining once the chain started.
+	depositBatchSize uint64
+	depositDelay     time.Duration
+	// doubleProposalAtEpoch, when non-zero, signs two conflicting block headers of the validator at
+	// slashedValidatorIndex at this epoch and submits them to the slasher, so the validator gets
+	// slashed. It requires testSlasher.
+	doubleProposalAtEpoch uint64
+	slashedValidatorIndex uint64
+	// eth1Endpoint and eth1WSEndpoint, when set, point the beacon nodes at an existing eth1 node
+	// instead of starting a dev chain. The deposit contract at contractAddr must already be deployed
+	// on it and, as there is no funded account to deposit from, genesisStateFile must be set.
+	eth1Endpoint   string
+	eth1WSEndpoint string
+	// keepDB includes the chain databases in the datadirs copied out of failed runs, which are left
+	// out by default as they grow to gigabytes. See saveArtifacts.
+	keepDB bool
+	// junitReport also writes the evaluator results as JUnit XML to results.xml, next to results.json.
+	junitReport bool
+	// perNodeClientType is the client run by the beacon node with the given index, Prysm for the
+	// nodes past its end. Nodes running other clients must come last, they run no validators and
+	// are only peered with the Prysm nodes. See startLighthouseNodes.
+	perNodeClientType []clientType
+	// slotDurationSeconds, when set, overrides the seconds per slot of the beacon config on every
+	// Prysm node and validator client, and in the E2E itself, to run epochs faster.
+	slotDurationSeconds uint64
+	// useInteropGenesis generates a genesis state holding numValidators deterministic interop
+	// validators to tmpPath once the config is checked, and starts the chain from it as from
+	// genesisStateFile. The evaluators requiring deposits are skipped.
+	useInteropGenesis bool
+	// beaconNodeImage, when set, runs the Prysm beacon nodes in containers of this image, e.g. a
+	// published release, instead of the built beacon-chain binary. See dockerLauncher.
+	beaconNodeImage string
+	// maxNodeRSSMB, when set, fails the run when the resident memory of a beacon node exceeds it, as
+	// sampled at every epoch. See NodeMemoryEvaluator.
+	maxNodeRSSMB uint64
+	// verbosity is the log level of the beacon nodes, info when empty. Debug output makes the log
+	// files grow quickly and slows down the log helpers, which only rely on info messages.
+	verbosity string
+	// perNodeVerbosity overrides verbosity for the beacon node with the given index, e.g. to run the
+	// node under investigation at debug while the others stay at info.
+	perNodeVerbosity map[int]string
+	// archive runs beacon node 0 with --archive, keeping the committees, balances and validator set
+	// changes of past epochs, and checks it serves them with ArchivedCommitteesEvaluator. The node is
+	// never killed.
+	archive bool
+	// nodeLatencyMs, when set, delays the p2p packets sent by every beacon node by this many
+	// milliseconds, varying by up to nodeLatencyJitterMs, for the whole run. See SetNodeLatency.
+	nodeLatencyMs       uint64
+	nodeLatencyJitterMs uint64
+	// tlsRPC serves the gRPC API of the beacon nodes over TLS, with a self-signed certificate
+	// generated to tmpPath for the run, which the E2E, the validator clients and the slasher dial
+	// the nodes with. The JSON gateway isn't checked, as it only dials the node in plaintext.
+	tlsRPC bool
+	// topology is how the beacon nodes are peered, a full mesh when unset. The star and chain
+	// topologies turn discovery off and only peer the nodes through --peer flags, to stress gossip
+	// relaying. No node can be killed in them, as it could cut the others off.
+	topology peerTopology
+	// lateNodeAtEpoch, when set, starts one more beacon node at this epoch, with an empty database
+	// and no validators, peered with the running nodes. It must sync from them within
+	// lateNodeSyncSlots of its start, two epochs when zero. See LateNodeSyncedEvaluator.
+	lateNodeAtEpoch   uint64
+	lateNodeSyncSlots uint64
+	// submittedDeposits records the deposits sent to the deposit contract during the run, it's set
+	// by runEndToEndTest.
+	submittedDeposits *depositLog
+	// maxDepositLatencyEpochs, when set, fails the run when a submitted deposit isn't counted by the
+	// beacon chain within this many epochs. See DepositProcessingLatencyEvaluator.
+	maxDepositLatencyEpochs uint64
+	// continueOnFailure keeps running every evaluator until the last epoch after one failed, and
+	// fails the run at the end with the failures grouped by evaluator. The run still stops right
+	// away when a beacon node crashes or the E2E itself can't go on.
+	continueOnFailure bool
 }
 
 var beaconNodeLogFileName = "beacon-%d.log"
 
-// startBeaconNodes starts the requested amount of beacon nodes, passing in the deposit contract given.
-func startBeaconNodes(t *testing.T, config *end2EndConfig) []*beaconNodeInfo {
-	numNodes := config.numBeaconNodes
+// beaconNodeShutdownTimeout is how long a beacon node is given to exit after SIGTERM before it is killed.
+const beaconNodeShutdownTimeout = 5 * time.Second
+
+// defaultNodeStartupTimeout is used when end2EndConfig.nodeStartupTimeout is not set.
+const defaultNodeStartupTimeout = 72 * time.Second
+
+// defaultVerbosity is the log level of the beacon nodes when end2EndConfig.verbosity is not set.
+const defaultVerbosity = "info"
 
-	nodeInfo := []*beaconNodeInfo{}
-	for i := uint64(0); i < numNodes; i++ {
-		newNode := startNewBeaconNode(t, config, nodeInfo)
-		nodeInfo = append(nodeInfo, newNode)
+// startupTimeout returns how long to wait for a started process to log its readiness.
+func (c *end2EndConfig) startupTimeout() time.Duration {
+	if c.nodeStartupTimeout == 0 {
+		return defaultNodeStartupTimeout
 	}
+	return c.nodeStartupTimeout
+}
 
-	return nodeInfo
+// nodeVerbosity returns the log level of the beacon node with the given index.
+func (c *end2EndConfig) nodeVerbosity(index int) string {
+	if verbosity, ok := c.perNodeVerbosity[index]; ok {
+		return verbosity
+	}
+	if c.verbosity == "" {
+		return defaultVerbosity
+	}
+	return c.verbosity
 }
 
-func startNewBeaconNode(t *testing.T, config *end2EndConfig, beaconNodes []*beaconNodeInfo) *beaconNodeInfo {
-	tmpPath := config.tmpPath
-	index := len(beaconNodes)
-	binaryPath, found := bazel.FindBinary("beacon-chain", "beacon-chain")
-	if !found {
-		t.Log(binaryPath)
-		t.Fatal("beacon chain binary not found")
+// eth1HTTPProvider returns the HTTP endpoint of the eth1 node used by the run.
+func (c *end2EndConfig) eth1HTTPProvider() string {
+	if c.eth1Endpoint != "" {
+		return c.eth1Endpoint
+	}
+	return fmt.Sprintf("http://127.0.0.1:%d", c.eth1HTTPPort())
+}
+
+// eth1WSProvider returns the websocket endpoint of the eth1 node used by the run.
+func (c *end2EndConfig) eth1WSProvider() string {
+	if c.eth1WSEndpoint != "" {
+		return c.eth1WSEndpoint
+	}
+	return fmt.Sprintf("ws://127.0.0.1:%d", c.eth1WSPort())
+}
+
+// validatorRange returns the first interop validator index and the amount of validators run by
+// the validator client of the given beacon node. When the validators can't be split evenly,
+// the last validator client runs the remainder.
+func (c *end2EndConfig) validatorRange(nodeIndex uint64) (startIndex uint64, count uint64) {
+	if c.numValidatorsPerNode != 0 {
+		return c.numValidatorsPerNode * nodeIndex, c.numValidatorsPerNode
+	}
+	perNode := c.numValidators / c.numPrysmNodes()
+	startIndex = perNode * nodeIndex
+	if nodeIndex == c.numPrysmNodes()-1 {
+		return startIndex, c.numValidators - startIndex
+	}
+	return startIndex, perNode
+}
+
+func (c *end2EndConfig) eth1HTTPPort() uint64 {
+	return 8545 + c.portOffset
+}
+
+func (c *end2EndConfig) eth1WSPort() uint64 {
+	return 8546 + c.portOffset
+}
+
+// minimalEnvVar is the env var that switches suites to the minimal config when set to 1.
+const minimalEnvVar = "MINIMAL"
+
+// applyMinimalEnv switches the config to the minimal config when the MINIMAL env var is set to 1,
+// halving the epochs to run and the validators to keep the run short. Suites already using the
+// minimal config are left as they are. It returns whether the config was changed.
+func applyMinimalEnv(c *end2EndConfig) bool {
+	if os.Getenv(minimalEnvVar) != "1" || c.minimalConfig {
+		return false
+	}
+	testutil.ResetCache()
+	params.UseMinimalConfig()
+	c.minimalConfig = true
+	c.epochsToRun /= 2
+	if c.epochsToRun == 0 {
+		c.epochsToRun = 1
+	}
+	// The chain doesn't start with fewer validators than the minimal config requires at genesis.
+	c.numValidators /= 2
+	if minValidators := params.BeaconConfig().MinGenesisActiveValidatorCount; c.numValidators < minValidators {
+		c.numValidators = minValidators
+	}
+	return true
+}
+
+// validateConfig fails the test if the config is missing required fields or is inconsistent.
+func validateConfig(t testing.TB, c *end2EndConfig) {
+	if err := checkConfig(c); err != nil {
+		t.Fatalf("Invalid end to end config: %v", err)
+	}
+}
+
+func checkConfig(c *end2EndConfig) error {
+	if c.numBeaconNodes == 0 {
+		return errors.New("numBeaconNodes must be at least 1")
+	}
+	if c.numValidators == 0 {
+		return errors.New("numValidators must be at least 1")
+	}
+	if err := checkClientTypes(c); err != nil {
+		return err
+	}
+	if c.numValidators < c.numPrysmNodes() {
+		return fmt.Errorf("%d validators are not enough for %d beacon nodes, each needs at least one", c.numValidators, c.numPrysmNodes())
+	}
+	if c.restartNodeIndex < 0 || uint64(c.restartNodeIndex) >= c.numPrysmNodes() {
+		return fmt.Errorf("restartNodeIndex %d is not the index of one of the %d Prysm beacon nodes", c.restartNodeIndex, c.numPrysmNodes())
+	}
+	if c.numValidatorsPerNode*c.numPrysmNodes() > c.numValidators {
+		return fmt.Errorf(
+			"%d validators per node on %d beacon nodes need more than the %d deposited validators",
+			c.numValidatorsPerNode,
+			c.numPrysmNodes(),
+			c.numValidators,
+		)
+	}
+	if c.depositsAtEpoch > 0 && c.numMidRunDeposits == 0 {
+		return errors.New("numMidRunDeposits must be at least 1 when depositsAtEpoch is set")
+	}
+	if c.depositsAtEpoch > 0 && c.genesisStateFile != "" {
+		return errors.New("depositsAtEpoch can't be used with genesisStateFile, deposits are not processed from eth1")
+	}
+	if c.useInteropGenesis && c.genesisStateFile != "" {
+		return errors.New("genesisStateFile can't be set with useInteropGenesis, which generates it")
+	}
+	if c.depositsAtEpoch > 0 && c.useInteropGenesis {
+		return errors.New("depositsAtEpoch can't be used with useInteropGenesis, deposits are not processed from eth1")
+	}
+	if c.depositDelay > 0 && c.depositBatchSize == 0 {
+		return errors.New("depositBatchSize must be at least 1 when depositDelay is set")
+	}
+	if c.epochsToRun == 0 {
+		return errors.New("epochsToRun must be at least 1")
+	}
+	if c.contractAddr == (common.Address{}) {
+		return errors.New("contractAddr must be set")
+	}
+	if (c.eth1Endpoint == "") != (c.eth1WSEndpoint == "") {
+		return errors.New("eth1Endpoint and eth1WSEndpoint must be set together")
+	}
+	if c.eth1Endpoint != "" && c.genesisStateFile == "" && !c.useInteropGenesis {
+		return errors.New("genesisStateFile or useInteropGenesis must be set when using an external eth1 node, there is no account to deposit from")
+	}
+	if c.bootNodeENR == "" {
+		return errors.New("bootNodeENR must be set")
+	}
+	if c.nodesToKill >= c.numPrysmNodes() {
+		return fmt.Errorf("cannot kill %d out of %d beacon nodes, at least one must stay alive", c.nodesToKill, c.numPrysmNodes())
+	}
+	if c.partitionAtEpoch > 0 && c.numPrysmNodes() < 2 {
+		return errors.New("at least 2 beacon nodes are needed to partition them")
+	}
+	if c.partitionAtEpoch > 0 && c.partitionEpochs == 0 {
+		return errors.New("partitionEpochs must be at least 1 when partitionAtEpoch is set")
+	}
+	if len(c.partitionedNodes) > 0 {
+		if c.partitionAtEpoch == 0 {
+			return errors.New("partitionAtEpoch must be set when partitionedNodes is set")
+		}
+		partitioned := make(map[int]bool)
+		for _, index := range c.partitionedNodes {
+			if index < 0 || index >= int(c.numPrysmNodes()) {
+				return fmt.Errorf("cannot partition beacon node %d, only %d beacon nodes are run", index, c.numPrysmNodes())
+			}
+			if partitioned[index] {
+				return fmt.Errorf("beacon node %d is listed twice in partitionedNodes", index)
+			}
+			partitioned[index] = true
+		}
+		if len(partitioned) == int(c.numPrysmNodes()) {
+			return errors.New("at least one beacon node must be left out of partitionedNodes")
+		}
+	}
+	if c.doubleProposalAtEpoch > 0 && !c.testSlasher {
+		return errors.New("testSlasher must be set when doubleProposalAtEpoch is set, the double proposal is submitted to the slasher")
+	}
+	if c.doubleProposalAtEpoch > 0 && c.slashedValidatorIndex >= c.numValidators {
+		return fmt.Errorf("cannot slash validator %d, only %d validators are deposited", c.slashedValidatorIndex, c.numValidators)
+	}
+	if c.nodeLatencyJitterMs > 0 && c.nodeLatencyMs == 0 {
+		return errors.New("nodeLatencyMs must be set when nodeLatencyJitterMs is set")
 	}
+	if c.topology != FullMeshTopology {
+		switch {
+		case c.topology != StarTopology && c.topology != ChainTopology:
+			return fmt.Errorf("unknown %s", c.topology)
+		case c.staticPeers:
+			return fmt.Errorf("staticPeers peers every beacon node with all the others, it can't be used with the %s topology", c.topology)
+		case c.numPrysmNodes() != c.numBeaconNodes:
+			return fmt.Errorf("other clients are peered with every Prysm node, they can't run in the %s topology", c.topology)
+		case c.killNodeAtEpoch > 0:
+			return fmt.Errorf("killing beacon nodes could split the %s topology", c.topology)
+		}
+	}
+	if c.lateNodeAtEpoch > 0 && c.lateNodeAtEpoch+1 >= c.epochsToRun {
+		return fmt.Errorf("the late node joining at epoch %d can't be checked in a run of %d epochs, it must join before epoch %d", c.lateNodeAtEpoch, c.epochsToRun, c.epochsToRun-1)
+	}
+	if c.lateNodeAtEpoch > 0 && c.topology != FullMeshTopology {
+		return fmt.Errorf("the late node is peered with every beacon node, it can't join the %s topology", c.topology)
+	}
+	if c.archive && c.numPrysmNodes() < 2 {
+		return errors.New("at least 2 beacon nodes are needed to compare the archive node with the others")
+	}
+	if c.verbosity != "" {
+		if _, err := logrus.ParseLevel(c.verbosity); err != nil {
+			return errors.Wrap(err, "invalid verbosity")
+		}
+	}
+	for index, verbosity := range c.perNodeVerbosity {
+		if _, err := logrus.ParseLevel(verbosity); err != nil {
+			return errors.Wrapf(err, "invalid verbosity for beacon node %d", index)
+		}
+	}
+	if c.tmpPath == "" {
+		return errors.New("tmpPath must be set")
+	}
+	file, err := ioutil.TempFile(c.tmpPath, "write-check")
+	if err != nil {
+		return errors.Wrapf(err, "tmpPath %s is not writable", c.tmpPath)
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	return os.Remove(file.Name())
+}
 
-	stdOutFile, err := os.Create(path.Join(tmpPath, fmt.Sprintf(beaconNodeLogFileName, index)))
+// startBeaconNodes starts the requested amount of beacon nodes, failing the test if any of them
+// can't be started. The nodes already started are stopped by launchBeaconNodes before the test
+// fails, as the caller only defers stopBeaconNodes once they are returned.
+func startBeaconNodes(ctx context.Context, t testing.TB, config *end2EndConfig) []*beaconNodeInfo {
+	nodes, err := launchBeaconNodes(ctx, t, config)
 	if err != nil {
+		logNodeStartFailure(t, config.tmpPath, err)
 		t.Fatal(err)
 	}
+	if os.Getenv(aggregatedLogsEnvVar) == "1" {
+		aggregateBeaconLogs(ctx, t, config.tmpPath, nodes)
+	}
+	return nodes
+}
+
+// launchBeaconNodes starts the requested amount of Prysm beacon nodes, passing in the deposit contract given.
+// The nodes are launched concurrently and find each other through the boot node. With staticPeers,
+// every node is also given the p2p address of all the other nodes up front. Other topologies only
+// give each node the addresses of its peers in the topology. A node given static peers is only
+// launched once they have started their p2p server, see peerTopology.startAfter. When a node can't be
+// started, the others are stopped and an error is returned, so it can be used outside of go test.
+// The error wraps the NodeStartError of the first node that failed.
+func launchBeaconNodes(ctx context.Context, logger Logger, config *end2EndConfig) ([]*beaconNodeInfo, error) {
+	numNodes := int(config.numPrysmNodes())
+	if config.beaconNodeImage == "" {
+		// A missing binary is reported by the launcher of each node.
+		if binaryPath, err := findBinary("beacon-chain"); err == nil {
+			if err := checkBinaryVersion(logger, binaryPath, minBeaconChainVersion); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	ports := make([]nodePorts, numNodes)
+	peerAddrs := make([]string, numNodes)
+	for i := 0; i < numNodes; i++ {
+		var err error
+		ports[i], err = freePorts.nodePorts()
+		if err != nil {
+			return nil, &NodeStartError{NodeIndex: i, Stage: "port allocation", Cause: err}
+		}
+		peerAddr, err := generateP2PKey(config.tmpPath, i, ports[i].p2pTCP)
+		if err != nil {
+			return nil, &NodeStartError{NodeIndex: i, Stage: "p2p key generation", Cause: err}
+		}
+		peerAddrs[i] = peerAddr
+	}
+
+	type startResult struct {
+		index int
+		node  *beaconNodeInfo
+		err   error
+	}
+	results := make(chan startResult, numNodes)
+	// started is closed once the node is started or failed to, launched tells which.
+	started := make([]chan struct{}, numNodes)
+	launched := make([]bool, numNodes)
+	for i := range started {
+		started[i] = make(chan struct{})
+	}
+	for i := 0; i < numNodes; i++ {
+		var peers []string
+		for _, p := range config.topology.staticPeers(i, numNodes, config.staticPeers) {
+			peers = append(peers, peerAddrs[p])
+		}
+		after := config.topology.startAfter(i, numNodes, config.staticPeers)
+		go func(index int, ports nodePorts, peers []string, after []int) {
+			defer close(started[index])
+			for _, peer := range after {
+				<-started[peer]
+				if !launched[peer] {
+					err := &NodeStartError{NodeIndex: index, Stage: "launch", Cause: fmt.Errorf("static peer %d did not start", peer)}
+					results <- startResult{index: index, err: err}
+					return
+				}
+			}
+			node, err := startNewBeaconNode(ctx, logger, config, index, ports, peers)
+			launched[index] = err == nil
+			results <- startResult{index: index, node: node, err: err}
+		}(i, ports[i], peers, after)
+	}
+
+	nodeInfo := make([]*beaconNodeInfo, numNodes)
+	startErrs := make([]error, numNodes)
+	var failed int
+	for i := 0; i < numNodes; i++ {
+		result := <-results
+		if result.err != nil {
+			startErrs[result.index] = result.err
+			failed++
+			continue
+		}
+		nodeInfo[result.index] = result.node
+	}
+	if failed > 0 {
+		var firstErr error
+		var otherErrs []string
+		for i, err := range startErrs {
+			switch {
+			case err != nil && firstErr == nil:
+				firstErr = err
+			case err != nil:
+				otherErrs = append(otherErrs, err.Error())
+			case nodeInfo[i] != nil:
+				if stopErr := nodeInfo[i].Stop(beaconNodeShutdownTimeout); stopErr != nil {
+					otherErrs = append(otherErrs, fmt.Sprintf("could not stop beacon node %d: %v", i, stopErr))
+				}
+			}
+		}
+		if len(otherErrs) == 0 {
+			return nil, fmt.Errorf("failed to start %d of %d beacon nodes: %w", failed, numNodes, firstErr)
+		}
+		return nil, fmt.Errorf("failed to start %d of %d beacon nodes: %w\n%s", failed, numNodes, firstErr, strings.Join(otherErrs, "\n"))
+	}
+
+	multiAddrs := make([]string, numNodes)
+	for i, node := range nodeInfo {
+		multiAddrs[i] = node.multiAddr
+	}
+	logger.Logf("Started %d beacon nodes with multiaddrs: %s", numNodes, strings.Join(multiAddrs, ", "))
+	return nodeInfo, nil
+}
+
+// generateP2PKey writes a new p2p private key for the node to the tmp path and returns the
+// multiaddr the node will be reachable at once it is started with that key.
+func generateP2PKey(tmpPath string, index int, tcpPort uint64) (string, error) {
+	privKey, _, err := crypto.GenerateSecp256k1Key(rand.Reader)
+	if err != nil {
+		return "", err
+	}
+	rawBytes, err := privKey.Raw()
+	if err != nil {
+		return "", err
+	}
+	if err := ioutil.WriteFile(p2pKeyPath(tmpPath, index), []byte(hex.EncodeToString(rawBytes)), 0600); err != nil {
+		return "", err
+	}
+	id, err := peer.IDFromPrivateKey(privKey)
+	if err != nil {
+		return "", err
+	}
+	// Beacon nodes listen on the first external IPv4 address of the machine.
+	ip, err := iputils.ExternalIPv4()
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("/ip4/%s/tcp/%d/p2p/%s", ip, tcpPort, id.Pretty()), nil
+}
+
+func p2pKeyPath(tmpPath string, index int) string {
+	return path.Join(tmpPath, fmt.Sprintf("p2p-key-%d", index))
+}
+
+// startNewBeaconNode starts the beacon node with the given index and ports, statically peered to the given
+// multiaddrs if any. It returns an error rather than failing the test so it can be called from any goroutine.
+func startNewBeaconNode(
+	ctx context.Context,
+	logger Logger,
+	config *end2EndConfig,
+	index int,
+	ports nodePorts,
+	peers []string,
+) (*beaconNodeInfo, error) {
+	stdOutFile, err := os.Create(path.Join(config.tmpPath, fmt.Sprintf(beaconNodeLogFileName, index)))
+	if err != nil {
+		return nil, &NodeStartError{NodeIndex: index, Stage: "log setup", Cause: err}
+	}
+	node := &beaconNodeInfo{
+		index:       index,
+		logFile:     stdOutFile,
+		datadir:     fmt.Sprintf("%s/eth2-beacon-node-%d", config.tmpPath, index),
+		rpcPort:     ports.rpc,
+		monitorPort: ports.monitoring,
+		grpcPort:    ports.grpcGateway,
+		p2pTCPPort:  ports.p2pTCP,
+		p2pUDPPort:  ports.p2pUDP,
+		peers:       peers,
+	}
+	if config.tlsRPC {
+		node.tlsCert = config.tlsCertFile()
+	}
+	if config.maxLogFileSizeMB > 0 {
+		node.logRotation, err = newRotatingWriter(stdOutFile.Name(), int64(config.maxLogFileSizeMB)*1024*1024)
+		if err != nil {
+			_ = stdOutFile.Close()
+			return nil, &NodeStartError{NodeIndex: index, Stage: "log setup", Cause: errors.Wrap(err, "could not open log file for rotation")}
+		}
+	}
+	if err := node.launch(ctx, logger, config, true /*clearDB*/, 0 /*logOffset*/); err != nil {
+		if node.logRotation != nil {
+			_ = node.logRotation.Close()
+		}
+		_ = stdOutFile.Close()
+		return nil, &NodeStartError{NodeIndex: index, Stage: "launch", Cause: err}
+	}
+
+	node.multiAddr, err = getMultiAddrFromLogFile(stdOutFile)
+	if err != nil {
+		_ = node.Stop(beaconNodeShutdownTimeout)
+		return nil, &NodeStartError{NodeIndex: index, Stage: "multiaddr lookup", Cause: err}
+	}
+	if err := waitForNodeReady(ctx, node, config.startupTimeout()); err != nil {
+		_ = node.Stop(beaconNodeShutdownTimeout)
+		return nil, &NodeStartError{NodeIndex: index, Stage: "readiness check", Cause: err}
+	}
+	if err := pollHealthz(node, config.startupTimeout()); err != nil {
+		_ = node.Stop(beaconNodeShutdownTimeout)
+		return nil, &NodeStartError{NodeIndex: index, Stage: "health check", Cause: err}
+	}
+	return node, nil
+}
+
+// pollHealthz waits for the beacon node to report itself healthy on its monitoring port, which
+// also catches a node whose HTTP server failed to bind.
+func pollHealthz(node *beaconNodeInfo, timeout time.Duration) error {
+	return ev.PollHealthz(node.monitorPort, timeout)
+}
+
+// Restart kills the beacon node and starts it again with the same datadir, ports and peers.
+// The database is kept so the node has to catch up from where it was stopped.
+func (b *beaconNodeInfo) Restart(ctx context.Context, t testing.TB, config *end2EndConfig) error {
+	b.expectExit()
+	if err := b.process.kill(); err != nil {
+		return errors.Wrapf(err, "could not kill beacon node %d", b.index)
+	}
+	b.alive = false
+	// The connection to the killed process is broken, the next call to GRPCConn dials the new one.
+	if err := b.Close(); err != nil {
+		return errors.Wrapf(err, "could not close connection to beacon node %d", b.index)
+	}
+
+	// The restarted node logs to the same file, so only look for the startup text after the current end.
+	// Once rotated, the log file is the oldest segment and the startup text is looked for in the later ones.
+	logOffset, err := b.logFile.Seek(0, io.SeekEnd)
+	if err != nil {
+		return err
+	}
+	if err := b.launch(ctx, t, config, false /*clearDB*/, logOffset); err != nil {
+		return &NodeStartError{NodeIndex: b.index, Stage: "restart", Cause: err}
+	}
+	if err := pollHealthz(b, config.startupTimeout()); err != nil {
+		return &NodeStartError{NodeIndex: b.index, Stage: "health check", Cause: err}
+	}
+	b.restartCount++
+	t.Logf("Restarted beacon node %d with %s, restarts: %d", b.index, b.process, b.restartCount)
+	return nil
+}
+
+// killBeaconNodes kills the given amount of randomly picked beacon nodes out of the candidates,
+// the killed nodes are marked as no longer alive so they are skipped for evaluation.
+func killBeaconNodes(t testing.TB, candidates []*beaconNodeInfo, amount uint64) {
+	if amount > uint64(len(candidates)) {
+		t.Fatalf("Cannot kill %d beacon nodes, only %d can be killed", amount, len(candidates))
+	}
+	for _, i := range mathRand.Perm(len(candidates))[:amount] {
+		node := candidates[i]
+		node.expectExit()
+		if err := node.process.kill(); err != nil {
+			t.Fatalf("Could not kill beacon node %d: %v", node.index, err)
+		}
+		node.alive = false
+		t.Logf("Killed beacon node %d with %s", node.index, node.process)
+	}
+}
+
+// aliveBeaconNodes returns the beacon nodes that have not been killed.
+func aliveBeaconNodes(nodes []*beaconNodeInfo) []*beaconNodeInfo {
+	alive := make([]*beaconNodeInfo, 0, len(nodes))
+	for _, node := range nodes {
+		if node.alive {
+			alive = append(alive, node)
+		}
+	}
+	return alive
+}
+
+// launch runs the beacon chain binary for the node and waits until it has started its p2p server.
+// Only log output written after logOffset is searched for the startup text. The process is killed
+// when the context is cancelled.
+func (b *beaconNodeInfo) launch(ctx context.Context, logger Logger, config *end2EndConfig, clearDB bool, logOffset int64) error {
+	tmpPath := config.tmpPath
+	index := b.index
 
 	args := []string{
-		"--no-genesis-delay",
-		"--verbosity=debug",
-		"--force-clear-db",
-		"--no-discovery",
+		fmt.Sprintf("--verbosity=%s", config.nodeVerbosity(index)),
+		"--log-format=json",
 		"--new-cache",
 		"--enable-shuffled-index-cache",
 		"--enable-skip-slots-cache",
 		"--enable-attestation-cache",
-		"--http-web3provider=http://127.0.0.1:8545",
-		"--web3provider=ws://127.0.0.1:8546",
-		fmt.Sprintf("--datadir=%s/eth2-beacon-node-%d", tmpPath, index),
+		fmt.Sprintf("--http-web3provider=%s", config.eth1HTTPProvider()),
+		fmt.Sprintf("--web3provider=%s", config.eth1WSProvider()),
+		fmt.Sprintf("--datadir=%s", b.datadir),
 		fmt.Sprintf("--deposit-contract=%s", config.contractAddr.Hex()),
-		fmt.Sprintf("--rpc-port=%d", 4000+index),
-		fmt.Sprintf("--p2p-udp-port=%d", 12000+index),
-		fmt.Sprintf("--p2p-tcp-port=%d", 13000+index),
-		fmt.Sprintf("--monitoring-port=%d", 8080+index),
-		fmt.Sprintf("--grpc-gateway-port=%d", 3200+index),
-		fmt.Sprintf("--contract-deployment-block=%d", 0),
+		fmt.Sprintf("--rpc-port=%d", b.rpcPort),
+		fmt.Sprintf("--p2p-udp-port=%d", b.p2pUDPPort),
+		fmt.Sprintf("--p2p-tcp-port=%d", b.p2pTCPPort),
+		fmt.Sprintf("--p2p-priv-key=%s", p2pKeyPath(tmpPath, index)),
+		fmt.Sprintf("--monitoring-port=%d", b.monitorPort),
+		fmt.Sprintf("--grpc-gateway-port=%d", b.grpcPort),
+		fmt.Sprintf("--contract-deployment-block=%d", config.contractDeploymentBlock),
 	}
 
+	if config.topology.discovery() {
+		args = append(args, fmt.Sprintf("--bootstrap-node=%s", config.bootNodeENR))
+	} else {
+		args = append(args, "--no-discovery")
+	}
+
+	if config.genesisStateFile != "" {
+		args = append(args, fmt.Sprintf("--interop-genesis-state=%s", config.genesisStateFile))
+	} else {
+		args = append(args, "--no-genesis-delay")
+	}
+	if clearDB {
+		args = append(args, "--force-clear-db")
+	}
 	if config.minimalConfig {
 		args = append(args, "--minimal-config")
 	}
 	if config.enableSSZCache {
 		args = append(args, "--enable-ssz-cache")
 	}
+	if config.archive && index == 0 {
+		args = append(args, "--archive")
+	}
+	if config.tlsRPC {
+		args = append(args, fmt.Sprintf("--tls-cert=%s", config.tlsCertFile()), fmt.Sprintf("--tls-key=%s", config.tlsKeyFile()))
+	}
+	if config.slotDurationSeconds > 0 {
+		args = append(args, fmt.Sprintf("--e2e-config-slot-duration=%d", config.slotDurationSeconds))
+	}
+
+	// Static peers are only dialed at startup, launchBeaconNodes starts the node once they are listening.
+	for _, peerAddr := range b.peers {
+		args = append(args, fmt.Sprintf("--peer=%s", peerAddr))
+	}
+	extraFlags := append(append([]string{}, config.extraBeaconFlags...), config.perNodeFlags[index]...)
+	args, err := mergeFlags(args, extraFlags)
+	if err != nil {
+		return errors.Wrapf(err, "invalid extra flags for beacon node %d", index)
+	}
+	if err := verifyNoDuplicateFlags(args); err != nil {
+		return errors.Wrapf(err, "invalid flags for beacon node %d", index)
+	}
 
-	// After the first node is made, have all following nodes connect to all previously made nodes.
-	if index >= 1 {
-		for p := 0; p < index; p++ {
-			args = append(args, fmt.Sprintf("--peer=%s", beaconNodes[p].multiAddr))
+	logger.Logf("Starting beacon chain with flags: %s", strings.Join(args, " "))
+	var output io.Writer = b.logFile
+	if b.logRotation != nil {
+		output = b.logRotation
+	}
+	process, err := config.beaconNodeLauncher().start(ctx, b, args, output)
+	if err != nil {
+		return errors.Wrap(err, "failed to start beacon node")
+	}
+	b.process = process
+	b.processLock.Lock()
+	b.processID = process.pid()
+	b.exitExpected = false
+	b.processLock.Unlock()
+	b.alive = true
+
+	if err := waitForTextInFileAfter(ctx, b.logFile, logOffset, "Node started p2p server", config.startupTimeout()); err != nil {
+		_ = process.kill()
+		return errors.Wrap(err, "could not find multiaddr, this means the node had issues starting")
+	}
+	return nil
+}
+
+// stopBeaconNodes terminates every beacon node and closes its log file, it is meant to be
+// deferred right after the nodes are started so it runs whether or not the test failed.
+func stopBeaconNodes(t testing.TB, nodes []*beaconNodeInfo) {
+	for _, node := range nodes {
+		if err := node.Stop(beaconNodeShutdownTimeout); err != nil {
+			t.Errorf("Could not stop beacon node %d: %v", node.index, err)
 		}
 	}
+}
 
-	t.Logf("Starting beacon chain with flags: %s", strings.Join(args, " "))
-	cmd := exec.Command(binaryPath, args...)
-	cmd.Stdout = stdOutFile
-	cmd.Stderr = stdOutFile
-	if err := cmd.Start(); err != nil {
-		t.Fatalf("Failed to start beacon node: %v", err)
+// Stop sends SIGTERM to the beacon node and waits for it to exit, sending SIGKILL if it is
+// still alive after the timeout. Containers are stopped the same way by docker, then removed.
+// The log file is flushed and closed afterwards.
+func (b *beaconNodeInfo) Stop(timeout time.Duration) error {
+	b.expectExit()
+	if err := b.process.stop(timeout); err != nil {
+		return err
+	}
+	b.alive = false
+	if err := b.Close(); err != nil {
+		return errors.Wrap(err, "could not close connection")
 	}
 
-	if err = waitForTextInFile(stdOutFile, "Node started p2p server"); err != nil {
-		t.Fatalf("could not find multiaddr for node %d, this means the node had issues starting: %v", index, err)
+	if b.logRotation != nil {
+		if err := b.logRotation.Close(); err != nil {
+			return errors.Wrap(err, "could not close rotated log file")
+		}
+	}
+	if err := b.logFile.Sync(); err != nil {
+		return errors.Wrap(err, "could not flush log file")
 	}
+	return b.logFile.Close()
+}
 
-	multiAddr, err := getMultiAddrFromLogFile(stdOutFile.Name())
-	if err != nil {
-		t.Fatalf("could not get multiaddr for node %d: %v", index, err)
+// GRPCConn returns the connection to the RPC server of the beacon node, dialing it on the first call.
+// The dial options are only used by the call that dials, the connection is then shared by all the
+// callers until Close.
+func (b *beaconNodeInfo) GRPCConn(opts ...grpc.DialOption) (*grpc.ClientConn, error) {
+	b.connLock.Lock()
+	if b.rpcConn == nil {
+		b.rpcConn = &cachedConn{}
 	}
+	cached := b.rpcConn
+	b.connLock.Unlock()
 
-	return &beaconNodeInfo{
-		processID:   cmd.Process.Pid,
-		datadir:     fmt.Sprintf("%s/eth2-beacon-node-%d", tmpPath, index),
-		rpcPort:     4000 + uint64(index),
-		monitorPort: 8080 + uint64(index),
-		grpcPort:    3200 + uint64(index),
-		multiAddr:   multiAddr,
+	cached.once.Do(func() {
+		transport, err := b.transportOption()
+		if err != nil {
+			cached.err = err
+			return
+		}
+		opts = append([]grpc.DialOption{transport}, opts...)
+		cached.conn, cached.err = grpc.Dial(fmt.Sprintf("127.0.0.1:%d", b.rpcPort), opts...)
+	})
+	return cached.conn, cached.err
+}
+
+// Close closes the connection returned by GRPCConn, if any. It's called when the node is stopped
+// or restarted, the next call to GRPCConn dials a new connection.
+func (b *beaconNodeInfo) Close() error {
+	b.connLock.Lock()
+	cached := b.rpcConn
+	b.rpcConn = nil
+	b.connLock.Unlock()
+
+	if cached == nil {
+		return nil
+	}
+	// Waits for a dial in progress, or prevents one from starting.
+	cached.once.Do(func() {})
+	if cached.conn == nil {
+		return nil
 	}
+	return cached.conn.Close()
 }
 
-func getMultiAddrFromLogFile(name string) (string, error) {
-	byteContent, err := ioutil.ReadFile(name)
-	if err != nil {
-		return "", err
+// stopProcess sends SIGTERM to the started command and waits for it to exit,
+// killing it if it's still running after the timeout.
+func stopProcess(cmd *exec.Cmd, timeout time.Duration) error {
+	// Waiting also reaps the process, so it doesn't linger as a zombie once it exits.
+	exited := make(chan error, 1)
+	go func() {
+		exited <- cmd.Wait()
+	}()
+	pid := cmd.Process.Pid
+	// The signal error is ignored since the process may have already exited on its own.
+	_ = syscall.Kill(pid, syscall.SIGTERM)
+	select {
+	case <-exited:
+	case <-time.After(timeout):
+		if err := syscall.Kill(pid, syscall.SIGKILL); err != nil {
+			return errors.Wrapf(err, "could not kill process %d", pid)
+		}
+		<-exited
+	}
+	return nil
+}
+
+// repeatableFlags can be given several times to a beacon node, every occurrence adds a value.
+var repeatableFlags = map[string]bool{
+	"--peer": true,
+}
+
+// mergeFlags appends the extra flags to the computed ones, an extra flag replaces the computed
+// flag with the same name. An error is returned if the extra flags give the same flag different values.
+func mergeFlags(computed []string, extra []string) ([]string, error) {
+	extraValues := make(map[string]string)
+	var merged []string
+	for _, flag := range extra {
+		name := flagName(flag)
+		if repeatableFlags[name] {
+			merged = append(merged, flag)
+			continue
+		}
+		if previous, ok := extraValues[name]; ok {
+			if previous != flag {
+				return nil, fmt.Errorf("conflicting values for flag %s: %s and %s", name, previous, flag)
+			}
+			continue
+		}
+		extraValues[name] = flag
+		merged = append(merged, flag)
+	}
+
+	var result []string
+	for _, flag := range computed {
+		if _, overridden := extraValues[flagName(flag)]; !overridden {
+			result = append(result, flag)
+		}
+	}
+	return append(result, merged...), nil
+}
+
+// verifyNoDuplicateFlags returns an error listing the flags given more than once in args, other
+// than the repeatable ones, as a flag accidentally added twice to the computed flags would silently
+// take only one of its values.
+func verifyNoDuplicateFlags(args []string) error {
+	seen := make(map[string]bool)
+	var duplicates []string
+	for _, flag := range args {
+		name := flagName(flag)
+		if repeatableFlags[name] {
+			continue
+		}
+		if seen[name] {
+			duplicates = append(duplicates, name)
+			continue
+		}
+		seen[name] = true
+	}
+	if len(duplicates) > 0 {
+		return fmt.Errorf("duplicate flags: %s", strings.Join(duplicates, ", "))
+	}
+	return nil
+}
+
+// flagName returns the name of a flag given as --name=value.
+func flagName(flag string) string {
+	return strings.SplitN(flag, "=", 2)[0]
+}
+
+// p2pStartedMsg is logged by the beacon node once its p2p server is started, along with its multiaddr.
+const p2pStartedMsg = "Node started p2p server"
+
+// multiAddrRegex matches the multiaddr field of the log line printed when the p2p server starts.
+// Other fields may be logged between the message and the multiaddr, and the value may contain escaped characters.
+var multiAddrRegex = regexp.MustCompile(`msg="Node started p2p server".*?\bmultiAddr="(?P<multiAddr>(?:[^"\\]|\\.)+)"`)
+
+// getMultiAddrFromLogFile returns the multiaddr the beacon node logged when its p2p server started.
+// The file is the one the node was started with, the segments it was rotated to are followed. Logs
+// written in the text format, by nodes started with --log-format=text, are matched by multiAddrRegex.
+func getMultiAddrFromLogFile(file *os.File) (string, error) {
+	multiAddr, err := waitForLogField(context.Background(), file, p2pStartedMsg, "multiAddr", 0)
+	if err == nil {
+		return multiAddr, nil
+	}
+	log, openErr := openLog(file.Name())
+	if openErr != nil {
+		return "", openErr
+	}
+	defer log.Close()
+	byteContent, readErr := ioutil.ReadAll(log)
+	if readErr != nil {
+		return "", readErr
 	}
 	contents := string(byteContent)
 
-	searchText := "\"Node started p2p server\" multiAddr=\""
-	startIdx := strings.Index(contents, searchText)
-	if startIdx == -1 {
-		return "", fmt.Errorf("did not find peer text in %s", contents)
+	match := multiAddrRegex.FindStringSubmatch(contents)
+	if match == nil {
+		return "", errors.Wrapf(err, "did not find peer text in %s", contents)
 	}
-	startIdx += len(searchText)
-	endIdx := strings.Index(contents[startIdx:], "\"")
-	if endIdx == -1 {
-		return "", fmt.Errorf("did not find peer text in %s", contents)
+	for i, name := range multiAddrRegex.SubexpNames() {
+		if name == "multiAddr" {
+			return match[i], nil
+		}
 	}
-	return contents[startIdx : startIdx+endIdx], nil
+	return "", errors.New("multiaddr group missing from regexp")
 }
 
-func waitForTextInFile(file *os.File, text string) error {
-	wait := 0
-	// Cap the wait in case there are issues starting.
-	maxWait := 36
-	for wait < maxWait {
-		time.Sleep(2 * time.Second)
-		// Rewind the file pointer to the start of the file so we can read it again.
-		_, err := file.Seek(0, io.SeekStart)
+// waitForTextInFile polls the file until the text is found, giving up after maxWait
+// or as soon as the context is cancelled.
+func waitForTextInFile(ctx context.Context, file *os.File, text string, maxWait time.Duration) error {
+	return waitForTextInFileAfter(ctx, file, 0, text, maxWait)
+}
+
+// waitForLogField polls the log file until an entry with the message is logged in the JSON format
+// and returns the value of its field, numbers and booleans in their text form. Like
+// waitForTextInFile, it gives up after maxWait or as soon as the context is cancelled. The log is
+// checked once before waiting, so a zero maxWait only looks at what was already logged.
+func waitForLogField(ctx context.Context, file *os.File, msg string, field string, maxWait time.Duration) (string, error) {
+	pollInterval := 2 * time.Second
+	tail := &fileTail{file: file}
+	defer tail.close()
+	deadline := time.Now().Add(maxWait)
+	for {
+		lines, err := tail.readLines()
 		if err != nil {
-			return errors.Wrap(err, "could not rewind file to start")
+			return "", err
+		}
+		for _, line := range lines {
+			if !strings.HasPrefix(line, "{") {
+				continue
+			}
+			entry, err := parseJSONLogEntry(line)
+			if err != nil || entry["msg"] != msg {
+				continue
+			}
+			value, ok := entry[field]
+			if !ok {
+				return "", fmt.Errorf("\"%s\" was logged without %s", msg, field)
+			}
+			return jsonFieldString(value), nil
 		}
+		if !time.Now().Before(deadline) {
+			return "", fmt.Errorf("no JSON entry \"%s\" logged within %v", msg, maxWait)
+		}
+		select {
+		case <-ctx.Done():
+			return "", errors.Wrapf(ctx.Err(), "stopped waiting for \"%s\"", msg)
+		case <-time.After(pollInterval):
+		}
+	}
+}
 
-		scanner := bufio.NewScanner(file)
-		for scanner.Scan() {
-			if strings.Contains(scanner.Text(), text) {
-				return nil
+// waitForTextInFileAfter is like waitForTextInFile, but ignores the content before the given offset.
+func waitForTextInFileAfter(ctx context.Context, file *os.File, offset int64, text string, maxWait time.Duration) error {
+	pollInterval := 2 * time.Second
+	tail := &fileTail{file: file, offset: offset}
+	defer tail.close()
+	wait := time.Duration(0)
+	for wait < maxWait {
+		select {
+		case <-ctx.Done():
+			lastLines, err := lastLinesOfFile(file.Name(), 20)
+			if err != nil {
+				return err
 			}
+			return errors.Wrapf(ctx.Err(), "stopped waiting for \"%s\", last lines of logs:\n%s", text, lastLines)
+		case <-time.After(pollInterval):
 		}
-		if err := scanner.Err(); err != nil {
+		// Only the content appended since the previous poll is scanned.
+		found, err := tail.contains(text)
+		if err != nil {
 			return err
 		}
-		wait += 2
+		if found {
+			return nil
+		}
+		wait += pollInterval
 	}
 	contents, err := ioutil.ReadFile(file.Name())
 	if err != nil {
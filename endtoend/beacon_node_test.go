@@ -0,0 +1,858 @@
+package endtoend
+
+import (
+	"context"
+	"io/ioutil"
+	"net"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/bazelbuild/rules_go/go/tools/bazel"
+	"github.com/ethereum/go-ethereum/common"
+	ptypes "github.com/gogo/protobuf/types"
+	eth "github.com/prysmaticlabs/ethereumapis/eth/v1alpha1"
+	"github.com/prysmaticlabs/prysm/shared/params"
+	"google.golang.org/grpc"
+)
+
+// startFakeSlowNode writes the p2p startup log line to the file after the given delay,
+// mimicking a beacon node that takes a while to come up.
+func startFakeSlowNode(t *testing.T, delay time.Duration) *os.File {
+	file, err := ioutil.TempFile("", "beacon-fake-*.log")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go func() {
+		time.Sleep(delay)
+		if _, err := file.WriteString("level=info msg=\"Node started p2p server\"\n"); err != nil {
+			t.Error(err)
+		}
+	}()
+	return file
+}
+
+func TestWaitForTextInFile_TimeoutFires(t *testing.T) {
+	file := startFakeSlowNode(t, 8*time.Second)
+	defer os.Remove(file.Name())
+
+	err := waitForTextInFile(context.Background(), file, "Node started p2p server", 4*time.Second)
+	if err == nil {
+		t.Fatal("Expected timeout error for slow starting node")
+	}
+	if !strings.Contains(err.Error(), "could not find requested text") {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}
+
+func TestWaitForTextInFile_WaitsForSlowNode(t *testing.T) {
+	file := startFakeSlowNode(t, 3*time.Second)
+	defer os.Remove(file.Name())
+
+	if err := waitForTextInFile(context.Background(), file, "Node started p2p server", 10*time.Second); err != nil {
+		t.Fatalf("Expected to find text before timeout: %v", err)
+	}
+}
+
+func TestEnd2EndConfig_StartupTimeout(t *testing.T) {
+	config := &end2EndConfig{}
+	if config.startupTimeout() != defaultNodeStartupTimeout {
+		t.Errorf("Expected default timeout %v, received %v", defaultNodeStartupTimeout, config.startupTimeout())
+	}
+	config.nodeStartupTimeout = 2 * time.Minute
+	if config.startupTimeout() != 2*time.Minute {
+		t.Errorf("Expected configured timeout %v, received %v", 2*time.Minute, config.startupTimeout())
+	}
+}
+
+func TestEnd2EndConfig_NodeVerbosity(t *testing.T) {
+	config := &end2EndConfig{}
+	if config.nodeVerbosity(0) != "info" {
+		t.Errorf("Expected info verbosity by default, received %s", config.nodeVerbosity(0))
+	}
+	config.verbosity = "warn"
+	config.perNodeVerbosity = map[int]string{1: "debug"}
+	if config.nodeVerbosity(0) != "warn" {
+		t.Errorf("Expected configured verbosity warn, received %s", config.nodeVerbosity(0))
+	}
+	if config.nodeVerbosity(1) != "debug" {
+		t.Errorf("Expected node verbosity debug, received %s", config.nodeVerbosity(1))
+	}
+}
+
+func TestStartBeaconNodes_Parallel(t *testing.T) {
+	config := &end2EndConfig{
+		tmpPath:        bazel.TestTmpDir(),
+		numBeaconNodes: 4,
+		minimalConfig:  true,
+	}
+	bootNode := startBootNode(t, config)
+	defer stopBootNode(t, bootNode)
+	config.bootNodeENR = bootNode.enr
+	nodes := startBeaconNodes(context.Background(), t, config)
+	defer stopBeaconNodes(t, nodes)
+
+	if len(nodes) != 4 {
+		t.Fatalf("Expected 4 beacon nodes, received %d", len(nodes))
+	}
+	seen := make(map[string]int)
+	for _, node := range nodes {
+		if node.multiAddr == "" {
+			t.Errorf("Node %d has no multiaddr", node.index)
+		}
+		if other, ok := seen[node.multiAddr]; ok {
+			t.Errorf("Nodes %d and %d share multiaddr %s", other, node.index, node.multiAddr)
+		}
+		seen[node.multiAddr] = node.index
+	}
+}
+
+func TestCheckConfig(t *testing.T) {
+	validConfig := func() *end2EndConfig {
+		return &end2EndConfig{
+			tmpPath:        os.TempDir(),
+			epochsToRun:    5,
+			numValidators:  64,
+			numBeaconNodes: 4,
+			contractAddr:   common.HexToAddress("0x4689a3C63CE249355C8a573B5974db21D2d1b8Ef"),
+			bootNodeENR:    "enr:-IS4QIrMgVOYuw2mq68f9hRWHa4lCBNDCjU_vNj9UqDk8n7D1kMGzrsS9vRYnD1XrBkVzrbGhgGCWuJpvExaKNUxbnMBgmlkgnY0gmlwhH8AAAGJc2VjcDI1NmsxoQJ2Tlf7eRHpSvgKQ3j4Pv2OUBmnRhSH-eUrXLJHC5gNc4N1ZHCCD6A",
+		}
+	}
+	tests := []struct {
+		name     string
+		modify   func(c *end2EndConfig)
+		errorMsg string
+	}{
+		{
+			name:   "valid config",
+			modify: func(c *end2EndConfig) {},
+		},
+		{
+			name:     "no beacon nodes",
+			modify:   func(c *end2EndConfig) { c.numBeaconNodes = 0 },
+			errorMsg: "numBeaconNodes must be at least 1",
+		},
+		{
+			name:     "no validators",
+			modify:   func(c *end2EndConfig) { c.numValidators = 0 },
+			errorMsg: "numValidators must be at least 1",
+		},
+		{
+			name:     "restarted node out of range",
+			modify:   func(c *end2EndConfig) { c.restartNodeIndex = 4 },
+			errorMsg: "restartNodeIndex 4 is not the index of one of the 4 Prysm beacon nodes",
+		},
+		{
+			name:     "negative restarted node",
+			modify:   func(c *end2EndConfig) { c.restartNodeIndex = -1 },
+			errorMsg: "restartNodeIndex -1 is not the index of one of the 4 Prysm beacon nodes",
+		},
+		{
+			name: "no validators and no beacon nodes",
+			modify: func(c *end2EndConfig) {
+				c.numValidators = 0
+				c.numBeaconNodes = 0
+			},
+			errorMsg: "numBeaconNodes must be at least 1",
+		},
+		{
+			name:   "validators not divisible by beacon nodes",
+			modify: func(c *end2EndConfig) { c.numValidators = 65 },
+		},
+		{
+			name:     "fewer validators than beacon nodes",
+			modify:   func(c *end2EndConfig) { c.numValidators = 3 },
+			errorMsg: "3 validators are not enough for 4 beacon nodes",
+		},
+		{
+			name: "validators per node",
+			modify: func(c *end2EndConfig) {
+				c.numValidators = 65
+				c.numValidatorsPerNode = 8
+			},
+		},
+		{
+			name:     "too many validators per node",
+			modify:   func(c *end2EndConfig) { c.numValidatorsPerNode = 17 },
+			errorMsg: "need more than the 64 deposited validators",
+		},
+		{
+			name:     "mid-run deposits without validators",
+			modify:   func(c *end2EndConfig) { c.depositsAtEpoch = 1 },
+			errorMsg: "numMidRunDeposits must be at least 1",
+		},
+		{
+			name: "mid-run deposits with a genesis state file",
+			modify: func(c *end2EndConfig) {
+				c.depositsAtEpoch = 1
+				c.numMidRunDeposits = 1
+				c.genesisStateFile = "genesis.ssz"
+			},
+			errorMsg: "depositsAtEpoch can't be used with genesisStateFile",
+		},
+		{
+			name: "external eth1 node without websocket endpoint",
+			modify: func(c *end2EndConfig) {
+				c.eth1Endpoint = "https://goerli.infura.io/v3/key"
+				c.genesisStateFile = "genesis.ssz"
+			},
+			errorMsg: "eth1Endpoint and eth1WSEndpoint must be set together",
+		},
+		{
+			name: "external eth1 node without a genesis state file",
+			modify: func(c *end2EndConfig) {
+				c.eth1Endpoint = "https://goerli.infura.io/v3/key"
+				c.eth1WSEndpoint = "wss://goerli.infura.io/ws/v3/key"
+			},
+			errorMsg: "genesisStateFile or useInteropGenesis must be set when using an external eth1 node",
+		},
+		{
+			name: "external eth1 node with an interop genesis",
+			modify: func(c *end2EndConfig) {
+				c.eth1Endpoint = "https://goerli.infura.io/v3/key"
+				c.eth1WSEndpoint = "wss://goerli.infura.io/ws/v3/key"
+				c.useInteropGenesis = true
+			},
+		},
+		{
+			name: "interop genesis with a genesis state file",
+			modify: func(c *end2EndConfig) {
+				c.useInteropGenesis = true
+				c.genesisStateFile = "genesis.ssz"
+			},
+			errorMsg: "genesisStateFile can't be set with useInteropGenesis",
+		},
+		{
+			name: "mid-run deposits with an interop genesis",
+			modify: func(c *end2EndConfig) {
+				c.depositsAtEpoch = 1
+				c.numMidRunDeposits = 1
+				c.useInteropGenesis = true
+			},
+			errorMsg: "depositsAtEpoch can't be used with useInteropGenesis",
+		},
+		{
+			name:     "more client types than beacon nodes",
+			modify:   func(c *end2EndConfig) { c.perNodeClientType = make([]clientType, 5) },
+			errorMsg: "5 client types given for 4 beacon nodes",
+		},
+		{
+			name:     "first node not running prysm",
+			modify:   func(c *end2EndConfig) { c.perNodeClientType = []clientType{LighthouseClient} },
+			errorMsg: "the first beacon node must run Prysm",
+		},
+		{
+			name: "prysm node after a lighthouse node",
+			modify: func(c *end2EndConfig) {
+				c.perNodeClientType = []clientType{PrysmClient, LighthouseClient, PrysmClient}
+			},
+			errorMsg: "beacon node 2 runs Prysm after a node running another client",
+		},
+		{
+			name: "killing all the prysm nodes",
+			modify: func(c *end2EndConfig) {
+				c.perNodeClientType = []clientType{PrysmClient, PrysmClient, LighthouseClient, LighthouseClient}
+				c.killNodeAtEpoch = 2
+				c.nodesToKill = 2
+			},
+			errorMsg: "cannot kill 2 out of 2 beacon nodes",
+		},
+		{
+			name:     "deposit delay without batches",
+			modify:   func(c *end2EndConfig) { c.depositDelay = time.Second },
+			errorMsg: "depositBatchSize must be at least 1",
+		},
+		{
+			name:     "no epochs to run",
+			modify:   func(c *end2EndConfig) { c.epochsToRun = 0 },
+			errorMsg: "epochsToRun must be at least 1",
+		},
+		{
+			name:     "zero contract address",
+			modify:   func(c *end2EndConfig) { c.contractAddr = common.Address{} },
+			errorMsg: "contractAddr must be set",
+		},
+		{
+			name:     "no boot node",
+			modify:   func(c *end2EndConfig) { c.bootNodeENR = "" },
+			errorMsg: "bootNodeENR must be set",
+		},
+		{
+			name:     "all beacon nodes killed",
+			modify:   func(c *end2EndConfig) { c.nodesToKill = 4 },
+			errorMsg: "at least one must stay alive",
+		},
+		{
+			name: "partition of a single beacon node",
+			modify: func(c *end2EndConfig) {
+				c.numBeaconNodes = 1
+				c.partitionAtEpoch = 1
+				c.partitionEpochs = 1
+			},
+			errorMsg: "at least 2 beacon nodes are needed to partition them",
+		},
+		{
+			name:     "partition without duration",
+			modify:   func(c *end2EndConfig) { c.partitionAtEpoch = 1 },
+			errorMsg: "partitionEpochs must be at least 1",
+		},
+		{
+			name:     "double proposal without slasher",
+			modify:   func(c *end2EndConfig) { c.doubleProposalAtEpoch = 2 },
+			errorMsg: "testSlasher must be set when doubleProposalAtEpoch is set",
+		},
+		{
+			name: "double proposal of unknown validator",
+			modify: func(c *end2EndConfig) {
+				c.testSlasher = true
+				c.doubleProposalAtEpoch = 2
+				c.slashedValidatorIndex = 64
+			},
+			errorMsg: "cannot slash validator 64, only 64 validators are deposited",
+		},
+		{
+			name:     "no tmp path",
+			modify:   func(c *end2EndConfig) { c.tmpPath = "" },
+			errorMsg: "tmpPath must be set",
+		},
+		{
+			name:     "partitioned nodes without partition",
+			modify:   func(c *end2EndConfig) { c.partitionedNodes = []int{1} },
+			errorMsg: "partitionAtEpoch must be set when partitionedNodes is set",
+		},
+		{
+			name: "unknown partitioned node",
+			modify: func(c *end2EndConfig) {
+				c.partitionAtEpoch = 1
+				c.partitionEpochs = 1
+				c.partitionedNodes = []int{4}
+			},
+			errorMsg: "cannot partition beacon node 4, only 4 beacon nodes are run",
+		},
+		{
+			name: "partitioned node listed twice",
+			modify: func(c *end2EndConfig) {
+				c.partitionAtEpoch = 1
+				c.partitionEpochs = 1
+				c.partitionedNodes = []int{1, 1}
+			},
+			errorMsg: "beacon node 1 is listed twice in partitionedNodes",
+		},
+		{
+			name: "every node partitioned",
+			modify: func(c *end2EndConfig) {
+				c.partitionAtEpoch = 1
+				c.partitionEpochs = 1
+				c.partitionedNodes = []int{3, 2, 1, 0}
+			},
+			errorMsg: "at least one beacon node must be left out of partitionedNodes",
+		},
+		{
+			name:     "latency jitter without latency",
+			modify:   func(c *end2EndConfig) { c.nodeLatencyJitterMs = 20 },
+			errorMsg: "nodeLatencyMs must be set when nodeLatencyJitterMs is set",
+		},
+		{
+			name: "archive with a single beacon node",
+			modify: func(c *end2EndConfig) {
+				c.numBeaconNodes = 1
+				c.archive = true
+			},
+			errorMsg: "at least 2 beacon nodes are needed to compare the archive node with the others",
+		},
+		{
+			name:     "late node joining in the last epoch",
+			modify:   func(c *end2EndConfig) { c.lateNodeAtEpoch = 4 },
+			errorMsg: "the late node joining at epoch 4 can't be checked in a run of 5 epochs, it must join before epoch 4",
+		},
+		{
+			name: "late node in star topology",
+			modify: func(c *end2EndConfig) {
+				c.lateNodeAtEpoch = 2
+				c.topology = StarTopology
+			},
+			errorMsg: "the late node is peered with every beacon node, it can't join the star topology",
+		},
+		{
+			name:     "unknown topology",
+			modify:   func(c *end2EndConfig) { c.topology = peerTopology(7) },
+			errorMsg: "unknown topology(7)",
+		},
+		{
+			name: "static peers in chain topology",
+			modify: func(c *end2EndConfig) {
+				c.topology = ChainTopology
+				c.staticPeers = true
+			},
+			errorMsg: "staticPeers peers every beacon node with all the others, it can't be used with the chain topology",
+		},
+		{
+			name: "other clients in star topology",
+			modify: func(c *end2EndConfig) {
+				c.topology = StarTopology
+				c.numBeaconNodes = 3
+				c.perNodeClientType = []clientType{PrysmClient, PrysmClient, LighthouseClient}
+			},
+			errorMsg: "other clients are peered with every Prysm node, they can't run in the star topology",
+		},
+		{
+			name: "killed nodes in chain topology",
+			modify: func(c *end2EndConfig) {
+				c.topology = ChainTopology
+				c.killNodeAtEpoch = 2
+				c.nodesToKill = 1
+			},
+			errorMsg: "killing beacon nodes could split the chain topology",
+		},
+		{
+			name:     "invalid verbosity",
+			modify:   func(c *end2EndConfig) { c.verbosity = "loud" },
+			errorMsg: "invalid verbosity: not a valid logrus Level",
+		},
+		{
+			name:     "invalid node verbosity",
+			modify:   func(c *end2EndConfig) { c.perNodeVerbosity = map[int]string{2: "loud"} },
+			errorMsg: "invalid verbosity for beacon node 2",
+		},
+		{
+			name:     "tmp path not writable",
+			modify:   func(c *end2EndConfig) { c.tmpPath = "/nonexistent/e2e" },
+			errorMsg: "is not writable",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := validConfig()
+			tt.modify(config)
+			err := checkConfig(config)
+			if tt.errorMsg == "" {
+				if err != nil {
+					t.Errorf("Unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.errorMsg) {
+				t.Errorf("Expected error containing %q, received %v", tt.errorMsg, err)
+			}
+		})
+	}
+}
+
+func TestApplyMinimalEnv(t *testing.T) {
+	defer params.UseMainnetConfig()
+	defer os.Unsetenv(minimalEnvVar)
+	newConfig := func() *end2EndConfig {
+		return &end2EndConfig{
+			epochsToRun:    10,
+			numBeaconNodes: 4,
+			numValidators:  params.MainnetConfig().MinGenesisActiveValidatorCount,
+		}
+	}
+
+	if err := os.Setenv(minimalEnvVar, "0"); err != nil {
+		t.Fatal(err)
+	}
+	config := newConfig()
+	if applyMinimalEnv(config) || config.minimalConfig {
+		t.Errorf("Expected config to be left as is without %s=1", minimalEnvVar)
+	}
+
+	if err := os.Setenv(minimalEnvVar, "1"); err != nil {
+		t.Fatal(err)
+	}
+	config = newConfig()
+	if !applyMinimalEnv(config) {
+		t.Fatalf("Expected config to be changed with %s=1", minimalEnvVar)
+	}
+	if !config.minimalConfig {
+		t.Error("Expected minimal config to be enabled")
+	}
+	if config.epochsToRun != 5 {
+		t.Errorf("Expected 5 epochs to run, received %d", config.epochsToRun)
+	}
+	minValidators := params.MinimalSpecConfig().MinGenesisActiveValidatorCount
+	maxValidators := params.MainnetConfig().MinGenesisActiveValidatorCount / 2
+	if config.numValidators < minValidators || config.numValidators > maxValidators {
+		t.Errorf("Expected between %d and %d validators, received %d", minValidators, maxValidators, config.numValidators)
+	}
+	if config.numValidators < config.numBeaconNodes {
+		t.Errorf("Expected at least one validator per beacon node, received %d", config.numValidators)
+	}
+	if params.BeaconConfig().SlotsPerEpoch != params.MinimalSpecConfig().SlotsPerEpoch {
+		t.Error("Expected beacon config to be the minimal config")
+	}
+
+	config = newConfig()
+	config.epochsToRun = 1
+	config.numValidators = 4
+	applyMinimalEnv(config)
+	if config.epochsToRun != 1 || config.numValidators != minValidators {
+		t.Errorf("Expected at least 1 epoch and %d validators, received %d and %d", minValidators, config.epochsToRun, config.numValidators)
+	}
+
+	config = newConfig()
+	config.minimalConfig = true
+	if applyMinimalEnv(config) || config.epochsToRun != 10 {
+		t.Error("Expected config already using the minimal config to be left as is")
+	}
+}
+
+func TestStartBeaconNodes_ExtraFlags(t *testing.T) {
+	config := &end2EndConfig{
+		tmpPath:          bazel.TestTmpDir(),
+		numBeaconNodes:   1,
+		minimalConfig:    true,
+		extraBeaconFlags: []string{"--verbosity=trace"},
+	}
+	bootNode := startBootNode(t, config)
+	defer stopBootNode(t, bootNode)
+	config.bootNodeENR = bootNode.enr
+	nodes := startBeaconNodes(context.Background(), t, config)
+	defer stopBeaconNodes(t, nodes)
+
+	// Trace verbosity is the only level that turns on the libp2p debug loggers.
+	lines, err := SearchNodeLog(nodes[0], `\bDEBUG\b`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(lines) == 0 {
+		t.Error("Expected trace level output in beacon node log")
+	}
+}
+
+func TestStartBeaconNodes_PerNodeVerbosity(t *testing.T) {
+	config := &end2EndConfig{
+		tmpPath:          bazel.TestTmpDir(),
+		numBeaconNodes:   2,
+		minimalConfig:    true,
+		perNodeVerbosity: map[int]string{1: "debug"},
+	}
+	bootNode := startBootNode(t, config)
+	defer stopBootNode(t, bootNode)
+	config.bootNodeENR = bootNode.enr
+	nodes := startBeaconNodes(context.Background(), t, config)
+	defer stopBeaconNodes(t, nodes)
+
+	for _, node := range nodes {
+		lines, err := SearchNodeLog(node, `"level":"debug"`)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if debug := len(lines) > 0; debug != (node.index == 1) {
+			t.Errorf("Unexpected debug output in the log of beacon node %d: %v", node.index, debug)
+		}
+	}
+}
+
+func TestGetMultiAddrFromLogFile(t *testing.T) {
+	tests := []struct {
+		name      string
+		log       string
+		multiAddr string
+		wantErr   bool
+	}{
+		{
+			name: "current log format",
+			log: `time="2020-01-20 10:11:12" level=info msg="Starting beacon node" prefix=node
+time="2020-01-20 10:11:13" level=info msg="Node started p2p server" multiAddr="/ip4/10.0.0.5/tcp/13000/p2p/16Uiu2HAmHJg5o8F5sBuDvC9FcJNXHbg2ruuuF6nuxDGBCFkFBH3n" prefix=p2p
+`,
+			multiAddr: "/ip4/10.0.0.5/tcp/13000/p2p/16Uiu2HAmHJg5o8F5sBuDvC9FcJNXHbg2ruuuF6nuxDGBCFkFBH3n",
+		},
+		{
+			name: "JSON log format",
+			log: `{"level":"info","msg":"Starting beacon node","prefix":"node"}
+{"level":"info","msg":"Node started p2p server","multiAddr":"/ip6/::1/tcp/13000/p2p/Qm\"x\"+y=z","prefix":"p2p"}
+`,
+			multiAddr: `/ip6/::1/tcp/13000/p2p/Qm"x"+y=z`,
+		},
+		{
+			name:    "JSON entry without multiaddr",
+			log:     `{"level":"info","msg":"Node started p2p server","prefix":"p2p"}` + "\n",
+			wantErr: true,
+		},
+		{
+			name:      "additional fields and special characters",
+			log:       `level=info msg="Node started p2p server" id=3 multiAddr="/ip6/::1/tcp/13000/p2p/Qm\"x\"+y=z" prefix=p2p` + "\n",
+			multiAddr: `/ip6/::1/tcp/13000/p2p/Qm\"x\"+y=z`,
+		},
+		{
+			name:    "malformed log",
+			log:     `level=info msg="Node started p2p server" multiAddr=/ip4/10.0.0.5/tcp/13000` + "\n",
+			wantErr: true,
+		},
+		{
+			name:    "missing log line",
+			log:     `level=info msg="Starting beacon node" prefix=node` + "\n",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file, err := ioutil.TempFile("", "beacon-*.log")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.Remove(file.Name())
+			if _, err := file.WriteString(tt.log); err != nil {
+				t.Fatal(err)
+			}
+
+			multiAddr, err := getMultiAddrFromLogFile(file)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("Expected error, received multiaddr %s", multiAddr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if multiAddr != tt.multiAddr {
+				t.Errorf("Expected multiaddr %s, received %s", tt.multiAddr, multiAddr)
+			}
+		})
+	}
+}
+
+// startStubBeaconNode starts a shell script standing in for the beacon node binary.
+func startStubBeaconNode(t *testing.T, script string) *beaconNodeInfo {
+	logFile, err := ioutil.TempFile("", "beacon-stub-*.log")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cmd := exec.Command("sh", "-c", script)
+	cmd.Stdout = logFile
+	cmd.Stderr = logFile
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	return &beaconNodeInfo{processID: cmd.Process.Pid, process: &hostProcess{cmd: cmd}, logFile: logFile, alive: true}
+}
+
+func TestBeaconNodeInfo_Stop(t *testing.T) {
+	tests := []struct {
+		name   string
+		script string
+	}{
+		{
+			name:   "exits on SIGTERM",
+			script: "sleep 60",
+		},
+		{
+			name:   "ignores SIGTERM",
+			script: "trap '' TERM; while true; do sleep 1; done",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			node := startStubBeaconNode(t, tt.script)
+			defer os.Remove(node.logFile.Name())
+
+			if err := node.Stop(time.Second); err != nil {
+				t.Fatal(err)
+			}
+			if err := syscall.Kill(node.processID, 0); err != syscall.ESRCH {
+				t.Errorf("Expected process %d to be gone, received %v", node.processID, err)
+			}
+			if node.alive {
+				t.Error("Expected node to no longer be alive")
+			}
+		})
+	}
+}
+
+// countingListener counts the connections accepted by the wrapped listener.
+type countingListener struct {
+	net.Listener
+	accepted int32
+}
+
+func (l *countingListener) Accept() (net.Conn, error) {
+	conn, err := l.Listener.Accept()
+	if err == nil {
+		atomic.AddInt32(&l.accepted, 1)
+	}
+	return conn, err
+}
+
+func TestBeaconNodeInfo_GRPCConn(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	counting := &countingListener{Listener: listener}
+	server := grpc.NewServer()
+	eth.RegisterNodeServer(server, &versionServer{})
+	go func() {
+		if err := server.Serve(counting); err != nil {
+			t.Error(err)
+		}
+	}()
+	defer server.Stop()
+
+	node := &beaconNodeInfo{rpcPort: uint64(listener.Addr().(*net.TCPAddr).Port)}
+	defer func() {
+		if err := node.Close(); err != nil {
+			t.Error(err)
+		}
+	}()
+	conns := make([]*grpc.ClientConn, 10)
+	var wg sync.WaitGroup
+	for i := range conns {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			conn, err := node.GRPCConn(grpc.WithBlock())
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			if _, err := eth.NewNodeClient(conn).GetVersion(context.Background(), &ptypes.Empty{}); err != nil {
+				t.Error(err)
+			}
+			conns[i] = conn
+		}(i)
+	}
+	wg.Wait()
+
+	for i, conn := range conns {
+		if conn != conns[0] {
+			t.Errorf("Expected goroutine %d to receive the shared connection", i)
+		}
+	}
+	if accepted := atomic.LoadInt32(&counting.accepted); accepted != 1 {
+		t.Errorf("Expected 1 TCP connection, received %d", accepted)
+	}
+}
+
+func TestMergeFlags(t *testing.T) {
+	computed := []string{"--verbosity=debug", "--force-clear-db", "--peer=/ip4/10.0.0.5/tcp/13000"}
+	tests := []struct {
+		name     string
+		extra    []string
+		expected []string
+		errorMsg string
+	}{
+		{
+			name:     "no extra flags",
+			expected: computed,
+		},
+		{
+			name:     "new flag",
+			extra:    []string{"--enable-ssz-cache"},
+			expected: []string{"--verbosity=debug", "--force-clear-db", "--peer=/ip4/10.0.0.5/tcp/13000", "--enable-ssz-cache"},
+		},
+		{
+			name:     "overrides computed flag",
+			extra:    []string{"--verbosity=trace"},
+			expected: []string{"--force-clear-db", "--peer=/ip4/10.0.0.5/tcp/13000", "--verbosity=trace"},
+		},
+		{
+			name:     "identical duplicates",
+			extra:    []string{"--verbosity=trace", "--verbosity=trace"},
+			expected: []string{"--force-clear-db", "--peer=/ip4/10.0.0.5/tcp/13000", "--verbosity=trace"},
+		},
+		{
+			name:     "repeatable flag",
+			extra:    []string{"--peer=/ip4/10.0.0.6/tcp/13000"},
+			expected: []string{"--verbosity=debug", "--force-clear-db", "--peer=/ip4/10.0.0.5/tcp/13000", "--peer=/ip4/10.0.0.6/tcp/13000"},
+		},
+		{
+			name:     "conflicting duplicates",
+			extra:    []string{"--verbosity=trace", "--verbosity=info"},
+			errorMsg: "conflicting values for flag --verbosity",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			merged, err := mergeFlags(computed, tt.extra)
+			if tt.errorMsg != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.errorMsg) {
+					t.Errorf("Expected error containing %q, received %v", tt.errorMsg, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if strings.Join(merged, " ") != strings.Join(tt.expected, " ") {
+				t.Errorf("Expected flags %v, received %v", tt.expected, merged)
+			}
+		})
+	}
+}
+
+func TestVerifyNoDuplicateFlags(t *testing.T) {
+	clean := []string{
+		"--verbosity=debug",
+		"--force-clear-db",
+		"--peer=/ip4/10.0.0.5/tcp/13000",
+		"--peer=/ip4/10.0.0.6/tcp/13000",
+	}
+	if err := verifyNoDuplicateFlags(clean); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	duplicated := []string{
+		"--verbosity=debug",
+		"--datadir=/tmp/eth2-beacon-node-0",
+		"--force-clear-db",
+		"--verbosity=debug",
+		"--datadir=/tmp/eth2-beacon-node-1",
+	}
+	err := verifyNoDuplicateFlags(duplicated)
+	if err == nil || err.Error() != "duplicate flags: --verbosity, --datadir" {
+		t.Errorf("Expected error listing the duplicate flags, received %v", err)
+	}
+}
+
+func TestEnd2EndConfig_ValidatorRange(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   *end2EndConfig
+		expected [][2]uint64
+	}{
+		{
+			name:     "even split",
+			config:   &end2EndConfig{numValidators: 64, numBeaconNodes: 4},
+			expected: [][2]uint64{{0, 16}, {16, 16}, {32, 16}, {48, 16}},
+		},
+		{
+			name:     "last client runs the remainder",
+			config:   &end2EndConfig{numValidators: 64, numBeaconNodes: 3},
+			expected: [][2]uint64{{0, 21}, {21, 21}, {42, 22}},
+		},
+		{
+			name:     "validators per node",
+			config:   &end2EndConfig{numValidators: 64, numBeaconNodes: 3, numValidatorsPerNode: 8},
+			expected: [][2]uint64{{0, 8}, {8, 8}, {16, 8}},
+		},
+		{
+			name: "lighthouse nodes run no validators",
+			config: &end2EndConfig{
+				numValidators:     64,
+				numBeaconNodes:    3,
+				perNodeClientType: []clientType{PrysmClient, PrysmClient, LighthouseClient},
+			},
+			expected: [][2]uint64{{0, 32}, {32, 32}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for i, expected := range tt.expected {
+				startIndex, count := tt.config.validatorRange(uint64(i))
+				if startIndex != expected[0] || count != expected[1] {
+					t.Errorf(
+						"Expected validator client %d to run %d validators from index %d, received %d from index %d",
+						i, expected[1], expected[0], count, startIndex,
+					)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,76 @@
+// +build !skip_e2e_benchmark
+
+package endtoend
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	ev "github.com/prysmaticlabs/prysm/endtoend/evaluators"
+	"github.com/prysmaticlabs/prysm/shared/params"
+	"github.com/prysmaticlabs/prysm/shared/testutil"
+)
+
+// BenchmarkE2EChainThroughput runs a minimal network of 2 beacon nodes and 64 validators, and
+// reports how many epochs per minute it completes. An epoch counts as completed when the
+// FinalizationOccurs evaluator passes for it, so only epochs that finalize in time are counted.
+// Build with the skip_e2e_benchmark tag to leave it out.
+func BenchmarkE2EChainThroughput(b *testing.B) {
+	testutil.ResetCache()
+	params.UseMinimalConfig()
+
+	var epochs int
+	var elapsed time.Duration
+	for i := 0; i < b.N; i++ {
+		// Starting the cluster isn't part of what is measured, the timer only runs from the first
+		// finalized epoch to the last one.
+		b.StopTimer()
+		// Every run writes to the same directory, a beacon node must not start from the database
+		// of the previous run.
+		if err := os.RemoveAll(suiteTmpPath(b)); err != nil {
+			b.Fatal(err)
+		}
+		const epochsToRun = 8
+		var completed []time.Time
+		var epoch uint64
+		occurs := ev.FinalizationOccurs()
+		finality := occurs
+		finality.Policy = func(currentEpoch uint64) bool {
+			epoch = currentEpoch
+			return occurs.Policy(currentEpoch)
+		}
+		finality.Evaluation = func(conns *ev.NodeConns) error {
+			if err := occurs.Evaluation(conns); err != nil {
+				return err
+			}
+			completed = append(completed, time.Now())
+			if len(completed) == 1 {
+				b.StartTimer()
+			}
+			if epoch == epochsToRun-1 {
+				b.StopTimer()
+			}
+			return nil
+		}
+		config := &end2EndConfig{
+			minimalConfig:  true,
+			epochsToRun:    epochsToRun,
+			numBeaconNodes: 2,
+			numValidators:  64,
+			portOffset:     800,
+			evaluators:     []ev.Evaluator{finality},
+		}
+		runEndToEndTest(b, config)
+		b.StopTimer()
+		if len(completed) < 2 {
+			b.Fatalf("Expected at least 2 finalized epochs to time, received %d", len(completed))
+		}
+		for j := 1; j < len(completed); j++ {
+			b.Logf("Epoch completed in %s", completed[j].Sub(completed[j-1]))
+		}
+		epochs += len(completed) - 1
+		elapsed += completed[len(completed)-1].Sub(completed[0])
+	}
+	b.ReportMetric(float64(epochs)/elapsed.Minutes(), "epochs/min")
+}
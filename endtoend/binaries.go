@@ -0,0 +1,133 @@
+package endtoend
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/bazelbuild/rules_go/go/tools/bazel"
+	"github.com/pkg/errors"
+)
+
+// binaryEnvVars are the env vars giving the path to the Prysm binaries started by the E2E, so it
+// can run with plain go test or against locally built binaries.
+var binaryEnvVars = map[string]string{
+	"beacon-chain": "PRYSM_E2E_BEACON_BINARY",
+	"slasher":      "PRYSM_E2E_SLASHER_BINARY",
+	"validator":    "PRYSM_E2E_VALIDATOR_BINARY",
+}
+
+// bazelFindBinary looks up a binary in the runfiles of the test, it's replaced in tests.
+var bazelFindBinary = bazel.FindBinary
+
+// findBinary returns the path to the Prysm binary with the given name, which is also the bazel
+// package building it. The path in its env var is used first, then the binary on PATH, and the
+// one built by bazel last. The error lists every place that was looked at.
+func findBinary(name string) (string, error) {
+	var looked []string
+	if envVar, ok := binaryEnvVars[name]; ok {
+		if binaryPath := os.Getenv(envVar); binaryPath != "" {
+			info, err := os.Stat(binaryPath)
+			if err != nil {
+				return "", fmt.Errorf("%s binary set in %s not found: %v", name, envVar, err)
+			}
+			if info.IsDir() {
+				return "", fmt.Errorf("%s binary set in %s is a directory: %s", name, envVar, binaryPath)
+			}
+			return binaryPath, nil
+		}
+		looked = append(looked, fmt.Sprintf("%s (not set)", envVar))
+	}
+	if binaryPath, err := exec.LookPath(name); err == nil {
+		return binaryPath, nil
+	}
+	looked = append(looked, "PATH")
+	binaryPath, found := bazelFindBinary(name, name)
+	if found {
+		return binaryPath, nil
+	}
+	looked = append(looked, fmt.Sprintf("bazel runfiles (%s)", binaryPath))
+	return "", fmt.Errorf("%s binary not found, looked in %s", name, strings.Join(looked, ", "))
+}
+
+// semver is the release version of a binary.
+type semver struct {
+	major, minor, patch uint64
+}
+
+func (v semver) String() string {
+	return fmt.Sprintf("v%d.%d.%d", v.major, v.minor, v.patch)
+}
+
+// less reports whether v is an older release than other.
+func (v semver) less(other semver) bool {
+	if v.major != other.major {
+		return v.major < other.major
+	}
+	if v.minor != other.minor {
+		return v.minor < other.minor
+	}
+	return v.patch < other.patch
+}
+
+// minBeaconChainVersion is the oldest beacon-chain release the E2E accepts. Raise it when the E2E
+// starts relying on flags or behavior of a newer release.
+var minBeaconChainVersion = semver{major: 0, minor: 3, patch: 0}
+
+// semverRegex matches a release version such as v0.3.1 in the output of --version, the v prefix
+// telling it apart from the other numbers printed.
+var semverRegex = regexp.MustCompile(`\bv(\d+)\.(\d+)\.(\d+)\b`)
+
+// errNoReleaseVersion is returned by parseBinaryVersion for binaries that don't report a release,
+// as local builds only report their git commit.
+var errNoReleaseVersion = errors.New("no release version")
+
+// parseBinaryVersion returns the release version printed by a binary run with --version.
+func parseBinaryVersion(output string) (semver, error) {
+	match := semverRegex.FindStringSubmatch(output)
+	if match == nil {
+		return semver{}, errNoReleaseVersion
+	}
+	var parts [3]uint64
+	for i := range parts {
+		var err error
+		if parts[i], err = strconv.ParseUint(match[i+1], 10, 64); err != nil {
+			return semver{}, errors.Wrapf(err, "invalid version %s", match[0])
+		}
+	}
+	return semver{major: parts[0], minor: parts[1], patch: parts[2]}, nil
+}
+
+// checkBinaryVersion runs the binary with --version and returns an error telling to rebuild it when
+// it's an older release than minVersion, rather than letting the run fail on a missing flag. Local
+// builds, which don't report a release, are assumed to be up to date.
+func checkBinaryVersion(logger Logger, binaryPath string, minVersion semver) error {
+	ctx, cancel := context.WithTimeout(context.Background(), versionTimeout)
+	defer cancel()
+	output, err := exec.CommandContext(ctx, binaryPath, "--version").Output()
+	if err != nil {
+		return errors.Wrapf(err, "could not get the version of %s", binaryPath)
+	}
+	version, err := parseBinaryVersion(string(output))
+	if err == errNoReleaseVersion {
+		logger.Logf("Not checking %s is at least %s, it reports no release: %s", binaryPath, minVersion, strings.TrimSpace(string(output)))
+		return nil
+	}
+	if err != nil {
+		return errors.Wrapf(err, "could not parse the version of %s", binaryPath)
+	}
+	if version.less(minVersion) {
+		return fmt.Errorf(
+			"%s is %s, older than the %s required by the E2E, rebuild it or point %s to a newer binary",
+			binaryPath,
+			version,
+			minVersion,
+			binaryEnvVars["beacon-chain"],
+		)
+	}
+	return nil
+}
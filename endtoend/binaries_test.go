@@ -0,0 +1,246 @@
+package endtoend
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
+	"testing"
+)
+
+// setEnv sets the env var for the duration of the test, an empty value unsets it.
+func setEnv(t *testing.T, key string, value string) func() {
+	previous, wasSet := os.LookupEnv(key)
+	var err error
+	if value == "" {
+		err = os.Unsetenv(key)
+	} else {
+		err = os.Setenv(key, value)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	return func() {
+		if wasSet {
+			_ = os.Setenv(key, previous)
+		} else {
+			_ = os.Unsetenv(key)
+		}
+	}
+}
+
+// stubBazelFindBinary makes the bazel lookup return the given path.
+func stubBazelFindBinary(binaryPath string, found bool) func() {
+	previous := bazelFindBinary
+	bazelFindBinary = func(_ string, _ string) (string, bool) {
+		return binaryPath, found
+	}
+	return func() {
+		bazelFindBinary = previous
+	}
+}
+
+// writeExecutable writes an empty executable with the given name to dir.
+func writeExecutable(t *testing.T, dir string, name string) string {
+	binaryPath := path.Join(dir, name)
+	if err := ioutil.WriteFile(binaryPath, nil, 0755); err != nil {
+		t.Fatal(err)
+	}
+	return binaryPath
+}
+
+func TestFindBinary_EnvVar(t *testing.T) {
+	dir, err := ioutil.TempDir("", "binaries")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	binaryPath := writeExecutable(t, dir, "my-beacon-chain")
+	defer setEnv(t, "PRYSM_E2E_BEACON_BINARY", binaryPath)()
+	defer stubBazelFindBinary("/bazel/beacon-chain", true)()
+
+	found, err := findBinary("beacon-chain")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if found != binaryPath {
+		t.Errorf("Expected binary from the env var %s, received %s", binaryPath, found)
+	}
+}
+
+func TestFindBinary_EnvVarNotFound(t *testing.T) {
+	defer setEnv(t, "PRYSM_E2E_VALIDATOR_BINARY", "/does/not/exist")()
+	defer stubBazelFindBinary("/bazel/validator", true)()
+
+	_, err := findBinary("validator")
+	if err == nil || !strings.Contains(err.Error(), "validator binary set in PRYSM_E2E_VALIDATOR_BINARY not found") {
+		t.Errorf("Expected error about the missing binary, received %v", err)
+	}
+}
+
+func TestFindBinary_Path(t *testing.T) {
+	dir, err := ioutil.TempDir("", "binaries")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	binaryPath := writeExecutable(t, dir, "validator")
+	defer setEnv(t, "PRYSM_E2E_VALIDATOR_BINARY", "")()
+	defer setEnv(t, "PATH", dir)()
+	defer stubBazelFindBinary("/bazel/validator", true)()
+
+	found, err := findBinary("validator")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if found != binaryPath {
+		t.Errorf("Expected binary from PATH %s, received %s", binaryPath, found)
+	}
+}
+
+func TestFindBinary_Bazel(t *testing.T) {
+	dir, err := ioutil.TempDir("", "binaries")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	defer setEnv(t, "PRYSM_E2E_BEACON_BINARY", "")()
+	defer setEnv(t, "PATH", dir)()
+	defer stubBazelFindBinary("/bazel/beacon-chain", true)()
+
+	found, err := findBinary("beacon-chain")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if found != "/bazel/beacon-chain" {
+		t.Errorf("Expected binary built by bazel, received %s", found)
+	}
+}
+
+func TestFindBinary_NotFound(t *testing.T) {
+	dir, err := ioutil.TempDir("", "binaries")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	defer setEnv(t, "PRYSM_E2E_BEACON_BINARY", "")()
+	defer setEnv(t, "PATH", dir)()
+	defer stubBazelFindBinary("/bazel/beacon-chain", false)()
+
+	_, err = findBinary("beacon-chain")
+	expected := "beacon-chain binary not found, looked in PRYSM_E2E_BEACON_BINARY (not set), PATH, bazel runfiles (/bazel/beacon-chain)"
+	if err == nil || err.Error() != expected {
+		t.Errorf("Expected error %q, received %v", expected, err)
+	}
+}
+
+func TestParseBinaryVersion(t *testing.T) {
+	tests := []struct {
+		output   string
+		expected semver
+		errorMsg string
+	}{
+		{
+			output:   "beacon-chain version Prysm/v0.3.1/7a6e4d2b. Built at: 2020-03-02 10:00:00+00:00\n",
+			expected: semver{major: 0, minor: 3, patch: 1},
+		},
+		{
+			output:   "Prysm/v1.12.104",
+			expected: semver{major: 1, minor: 12, patch: 104},
+		},
+		{
+			output:   "beacon-chain version Prysm/Git commit: 7a6e4d2b. Built at: 2020-03-02 10:00:00+00:00\n",
+			errorMsg: "no release version",
+		},
+		{
+			output:   "0.3.1",
+			errorMsg: "no release version",
+		},
+		{
+			output:   "v99999999999999999999.0.0",
+			errorMsg: "invalid version v99999999999999999999.0.0",
+		},
+	}
+	for _, tt := range tests {
+		version, err := parseBinaryVersion(tt.output)
+		if tt.errorMsg != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.errorMsg) {
+				t.Errorf("Expected error containing %q parsing %q, received %v", tt.errorMsg, tt.output, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Could not parse %q: %v", tt.output, err)
+			continue
+		}
+		if version != tt.expected {
+			t.Errorf("Expected version %s parsing %q, received %s", tt.expected, tt.output, version)
+		}
+	}
+}
+
+func TestSemver_Less(t *testing.T) {
+	versions := []semver{{0, 2, 9}, {0, 3, 0}, {0, 3, 1}, {1, 0, 0}}
+	for i, v := range versions {
+		for j, other := range versions {
+			if v.less(other) != (i < j) {
+				t.Errorf("Expected %s less than %s to be %v", v, other, i < j)
+			}
+		}
+	}
+}
+
+func TestCheckBinaryVersion(t *testing.T) {
+	dir, err := ioutil.TempDir("", "binaries")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	minVersion := semver{major: 0, minor: 3, patch: 0}
+	tests := []struct {
+		name     string
+		script   string
+		errorMsg string
+		logged   string
+	}{
+		{
+			name:   "newer",
+			script: "#!/bin/sh\necho 'beacon-chain version Prysm/v0.3.2/7a6e4d2b. Built at: 2020-03-02'\n",
+		},
+		{
+			name:   "same",
+			script: "#!/bin/sh\necho 'beacon-chain version Prysm/v0.3.0/7a6e4d2b. Built at: 2020-03-02'\n",
+		},
+		{
+			name:     "older",
+			script:   "#!/bin/sh\necho 'beacon-chain version Prysm/v0.2.7/7a6e4d2b. Built at: 2020-01-20'\n",
+			errorMsg: "is v0.2.7, older than the v0.3.0 required by the E2E, rebuild it or point PRYSM_E2E_BEACON_BINARY to a newer binary",
+		},
+		{
+			name:   "local build",
+			script: "#!/bin/sh\necho 'beacon-chain version Prysm/Git commit: 7a6e4d2b. Built at: 2020-03-02'\n",
+			logged: "reports no release: beacon-chain version Prysm/Git commit: 7a6e4d2b",
+		},
+		{
+			name:     "failing",
+			script:   "#!/bin/sh\nexit 1\n",
+			errorMsg: "could not get the version of",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			binaryPath := writeScript(t, dir, strings.Replace(tt.name, " ", "-", -1), tt.script)
+			logger := &recordingLogger{}
+			err := checkBinaryVersion(logger, binaryPath, minVersion)
+			if tt.errorMsg == "" && err != nil {
+				t.Errorf("Unexpected error: %v", err)
+			}
+			if tt.errorMsg != "" && (err == nil || !strings.Contains(err.Error(), tt.errorMsg)) {
+				t.Errorf("Expected error containing %q, received %v", tt.errorMsg, err)
+			}
+			if tt.logged != "" && (len(logger.lines) != 1 || !strings.Contains(logger.lines[0], tt.logged)) {
+				t.Errorf("Expected log containing %q, received %v", tt.logged, logger.lines)
+			}
+		})
+	}
+}
@@ -0,0 +1,114 @@
+package endtoend
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bazelbuild/rules_go/go/tools/bazel"
+)
+
+// bootNodeShutdownTimeout is how long the boot node is given to exit after SIGTERM before it's killed.
+const bootNodeShutdownTimeout = 5 * time.Second
+
+type bootNodeInfo struct {
+	enr       string
+	processID int
+	cmd       *exec.Cmd
+	logFile   *os.File
+}
+
+var bootNodeLogFileName = "bootnode.log"
+
+// startBootNode starts a discv5 boot node and waits for it to print its ENR, which the beacon nodes
+// are given to discover each other.
+func startBootNode(t testing.TB, config *end2EndConfig) *bootNodeInfo {
+	binaryPath, found := bazel.FindBinary("tools/bootnode", "bootnode")
+	if !found {
+		t.Fatal("boot node binary not found")
+	}
+
+	file, err := os.Create(path.Join(config.tmpPath, bootNodeLogFileName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	discv5Port, err := freePorts.udpPort()
+	if err != nil {
+		t.Fatal(err)
+	}
+	kademliaPort, err := findFreePort()
+	if err != nil {
+		t.Fatal(err)
+	}
+	metricsPort, err := findFreePort()
+	if err != nil {
+		t.Fatal(err)
+	}
+	args := []string{
+		fmt.Sprintf("--discv5-port=%d", discv5Port),
+		fmt.Sprintf("--kad-port=%d", kademliaPort),
+		fmt.Sprintf("--metrics-port=%d", metricsPort),
+		"--debug",
+	}
+	t.Logf("Starting boot node with flags: %s", strings.Join(args, " "))
+	cmd := exec.Command(binaryPath, args...)
+	cmd.Stdout = file
+	cmd.Stderr = file
+	if err := cmd.Start(); err != nil {
+		t.Fatalf("Failed to start boot node: %v", err)
+	}
+	node := &bootNodeInfo{
+		processID: cmd.Process.Pid,
+		cmd:       cmd,
+		logFile:   file,
+	}
+
+	if err := waitForTextInFile(context.Background(), file, "Running bootnode", config.startupTimeout()); err != nil {
+		_ = node.Stop(bootNodeShutdownTimeout)
+		t.Fatalf("Boot node did not start: %v", err)
+	}
+	node.enr, err = getENRFromLogFile(file.Name())
+	if err != nil {
+		_ = node.Stop(bootNodeShutdownTimeout)
+		t.Fatalf("Could not get boot node ENR: %v", err)
+	}
+	t.Logf("Started boot node with ENR: %s", node.enr)
+	return node
+}
+
+// Stop stops the boot node, see beaconNodeInfo.Stop.
+func (b *bootNodeInfo) Stop(timeout time.Duration) error {
+	if err := stopProcess(b.cmd, timeout); err != nil {
+		return err
+	}
+	return b.logFile.Close()
+}
+
+// stopBootNode stops the boot node, it's meant to be deferred by the test that started it.
+func stopBootNode(t testing.TB, node *bootNodeInfo) {
+	if err := node.Stop(bootNodeShutdownTimeout); err != nil {
+		t.Errorf("Could not stop boot node: %v", err)
+	}
+}
+
+// enrRegex matches the record printed by the boot node once its discv5 listener is running.
+var enrRegex = regexp.MustCompile(`Running bootnode: ((?:enr:|enode://)[^\s"]+)`)
+
+func getENRFromLogFile(name string) (string, error) {
+	byteContent, err := ioutil.ReadFile(name)
+	if err != nil {
+		return "", err
+	}
+	match := enrRegex.FindStringSubmatch(string(byteContent))
+	if match == nil {
+		return "", fmt.Errorf("did not find ENR in %s", string(byteContent))
+	}
+	return match[1], nil
+}
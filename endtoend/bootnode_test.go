@@ -0,0 +1,95 @@
+package endtoend
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/bazelbuild/rules_go/go/tools/bazel"
+)
+
+func TestGetENRFromLogFile(t *testing.T) {
+	tests := []struct {
+		name    string
+		log     string
+		enr     string
+		wantErr bool
+	}{
+		{
+			name: "enr record",
+			log: `Starting bootnode. Version: Prysm/Git commit: Local build
+time="2020-01-20 10:11:12" level=info msg="Running bootnode: enr:-IS4QJ2d11eu6dC7E7LoXeLMgMP3kom1u3SE8esFSWvaHoo0dP1jg8O3-nx9ht-EO3CmG7L6OkHcMmoIh00IYWB92QABgmlkgnY0gmlwhH8AAAGJc2VjcDI1NmsxoQIB_c-jQMOXsbjWkbN-Oj99H57gfId5pfb4wa1qxwV4CIN1ZHCCIyk"
+`,
+			enr: "enr:-IS4QJ2d11eu6dC7E7LoXeLMgMP3kom1u3SE8esFSWvaHoo0dP1jg8O3-nx9ht-EO3CmG7L6OkHcMmoIh00IYWB92QABgmlkgnY0gmlwhH8AAAGJc2VjcDI1NmsxoQIB_c-jQMOXsbjWkbN-Oj99H57gfId5pfb4wa1qxwV4CIN1ZHCCIyk",
+		},
+		{
+			name: "enode url",
+			log:  `level=info msg="Running bootnode: enode://a979fb575495b8d6db44f750317d0f4622bf4c2aa3365d6af7c284339968eef29b69ad0dce72a4d8db5ebb4968de0e3bec910127f134779fbcb0cb6d3331163c@127.0.0.1:0?discport=4000"` + "\n",
+			enr:  "enode://a979fb575495b8d6db44f750317d0f4622bf4c2aa3365d6af7c284339968eef29b69ad0dce72a4d8db5ebb4968de0e3bec910127f134779fbcb0cb6d3331163c@127.0.0.1:0?discport=4000",
+		},
+		{
+			name:    "missing log line",
+			log:     "Starting bootnode. Version: Prysm/Git commit: Local build\n",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file, err := ioutil.TempFile("", "bootnode-*.log")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.Remove(file.Name())
+			if _, err := file.WriteString(tt.log); err != nil {
+				t.Fatal(err)
+			}
+
+			enr, err := getENRFromLogFile(file.Name())
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("Expected error, received ENR %s", enr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if enr != tt.enr {
+				t.Errorf("Expected ENR %s, received %s", tt.enr, enr)
+			}
+		})
+	}
+}
+
+func TestStartBeaconNodes_Discovery(t *testing.T) {
+	config := &end2EndConfig{
+		tmpPath:        bazel.TestTmpDir(),
+		numBeaconNodes: 3,
+		minimalConfig:  true,
+	}
+	bootNode := startBootNode(t, config)
+	defer stopBootNode(t, bootNode)
+	config.bootNodeENR = bootNode.enr
+	nodes := startBeaconNodes(context.Background(), t, config)
+	defer stopBeaconNodes(t, nodes)
+
+	// Without static peers, the nodes can only have found each other through the boot node.
+	for _, node := range nodes {
+		if len(node.peers) != 0 {
+			t.Fatalf("Node %d was given static peers %v", node.index, node.peers)
+		}
+	}
+	deadline := time.Now().Add(time.Minute)
+	for _, node := range nodes {
+		err := peersConnect(node.monitorPort, config.numBeaconNodes-1)
+		for err != nil && time.Now().Before(deadline) {
+			time.Sleep(time.Second)
+			err = peersConnect(node.monitorPort, config.numBeaconNodes-1)
+		}
+		if err != nil {
+			t.Errorf("Node %d did not discover its peers: %v", node.index, err)
+		}
+	}
+}
@@ -0,0 +1,161 @@
+package endtoend
+
+import (
+	"context"
+	"time"
+
+	ptypes "github.com/gogo/protobuf/types"
+	"github.com/pkg/errors"
+	eth "github.com/prysmaticlabs/ethereumapis/eth/v1alpha1"
+	ev "github.com/prysmaticlabs/prysm/endtoend/evaluators"
+	"google.golang.org/grpc"
+)
+
+// maxDialAttempts is how many times a beacon node is dialed before giving up.
+const maxDialAttempts = 5
+
+// connHealthCheckTimeout is how long a beacon node is given to answer a health check.
+const connHealthCheckTimeout = 5 * time.Second
+
+// initialDialBackoff is the wait after the first failed dial, it doubles after every attempt.
+var initialDialBackoff = 500 * time.Millisecond
+
+// maxReadyBackoff caps the wait between two readiness checks of a starting beacon node.
+var maxReadyBackoff = 5 * time.Second
+
+// beaconConns keeps a gRPC connection to every running beacon node, keyed by node index, so the
+// evaluators share them instead of dialing the nodes every epoch.
+type beaconConns struct {
+	conns map[int]*grpc.ClientConn
+	nodes map[int]*beaconNodeInfo
+	// restartCounts records how many times each node had been restarted when it was dialed.
+	restartCounts map[int]int
+}
+
+func newBeaconConns() *beaconConns {
+	return &beaconConns{
+		conns:         make(map[int]*grpc.ClientConn),
+		nodes:         make(map[int]*beaconNodeInfo),
+		restartCounts: make(map[int]int),
+	}
+}
+
+// refresh makes sure there's a healthy connection to every alive node. Connections to killed nodes
+// are closed, nodes restarted since they were dialed or failing their health check are dialed again.
+func (c *beaconConns) refresh(ctx context.Context, nodes []*beaconNodeInfo) error {
+	for _, node := range nodes {
+		conn, ok := c.conns[node.index]
+		if !node.alive {
+			if ok {
+				c.drop(node.index)
+			}
+			continue
+		}
+		if ok && c.restartCounts[node.index] == node.restartCount && checkHealth(ctx, conn) == nil {
+			continue
+		}
+		if ok {
+			c.drop(node.index)
+		}
+		conn, err := dialWithBackoff(ctx, node)
+		if err != nil {
+			return errors.Wrapf(err, "could not dial beacon node %d", node.index)
+		}
+		c.conns[node.index] = conn
+		c.nodes[node.index] = node
+		c.restartCounts[node.index] = node.restartCount
+	}
+	return nil
+}
+
+// nodeConns returns the connections for the evaluators, which are made against the given node.
+func (c *beaconConns) nodeConns(evaluated int) *ev.NodeConns {
+	monitorPorts := make(map[int]uint64, len(c.nodes))
+	for index, node := range c.nodes {
+		monitorPorts[index] = node.monitorPort
+	}
+	return &ev.NodeConns{Evaluated: evaluated, Conns: c.conns, MonitorPorts: monitorPorts}
+}
+
+func (c *beaconConns) drop(index int) {
+	_ = c.nodes[index].Close()
+	delete(c.conns, index)
+	delete(c.nodes, index)
+	delete(c.restartCounts, index)
+}
+
+// close closes all the connections, it's meant to be deferred once the evaluations are over.
+func (c *beaconConns) close() {
+	for index := range c.conns {
+		c.drop(index)
+	}
+}
+
+// dialWithBackoff dials the beacon node RPC server until it answers a health check, doubling the
+// wait between attempts.
+func dialWithBackoff(ctx context.Context, node *beaconNodeInfo) (*grpc.ClientConn, error) {
+	backoff := initialDialBackoff
+	var err error
+	for attempt := 1; ; attempt++ {
+		var conn *grpc.ClientConn
+		conn, err = node.GRPCConn()
+		if err == nil {
+			if err = checkHealth(ctx, conn); err == nil {
+				return conn, nil
+			}
+			_ = node.Close()
+		}
+		if attempt == maxDialAttempts {
+			return nil, errors.Wrapf(err, "failed after %d attempts", maxDialAttempts)
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
+// waitForNodeReady polls the sync status of the beacon node until its RPC server answers, so
+// validators never dial it while it's refusing connections. The wait between checks doubles up to
+// maxReadyBackoff, and it gives up after maxWait. The chain head isn't checked as it's only known
+// once the chain started, which may be after the node is.
+func waitForNodeReady(ctx context.Context, node *beaconNodeInfo, maxWait time.Duration) error {
+	ctx, cancel := context.WithTimeout(ctx, maxWait)
+	defer cancel()
+	backoff := initialDialBackoff
+	for {
+		conn, err := node.GRPCConn()
+		if err == nil {
+			if err = checkSyncStatus(ctx, conn); err == nil {
+				return nil
+			}
+			// The cached connection backs off on its own after failing, a new one is dialed instead.
+			_ = node.Close()
+		}
+		select {
+		case <-ctx.Done():
+			return errors.Wrapf(err, "RPC server of beacon node %d not ready after %v", node.index, maxWait)
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+		if backoff > maxReadyBackoff {
+			backoff = maxReadyBackoff
+		}
+	}
+}
+
+func checkSyncStatus(ctx context.Context, conn *grpc.ClientConn) error {
+	ctx, cancel := context.WithTimeout(ctx, connHealthCheckTimeout)
+	defer cancel()
+	_, err := eth.NewNodeClient(conn).GetSyncStatus(ctx, &ptypes.Empty{})
+	return err
+}
+
+func checkHealth(ctx context.Context, conn *grpc.ClientConn) error {
+	ctx, cancel := context.WithTimeout(ctx, connHealthCheckTimeout)
+	defer cancel()
+	_, err := eth.NewNodeClient(conn).GetVersion(ctx, &ptypes.Empty{})
+	return err
+}
@@ -0,0 +1,177 @@
+package endtoend
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	ptypes "github.com/gogo/protobuf/types"
+	eth "github.com/prysmaticlabs/ethereumapis/eth/v1alpha1"
+	"google.golang.org/grpc"
+)
+
+type versionServer struct {
+	eth.NodeServer
+}
+
+func (s *versionServer) GetVersion(_ context.Context, _ *ptypes.Empty) (*eth.Version, error) {
+	return &eth.Version{Version: "test"}, nil
+}
+
+func (s *versionServer) GetSyncStatus(_ context.Context, _ *ptypes.Empty) (*eth.SyncStatus, error) {
+	return &eth.SyncStatus{Syncing: true}, nil
+}
+
+func (s *versionServer) GetGenesis(_ context.Context, _ *ptypes.Empty) (*eth.Genesis, error) {
+	return &eth.Genesis{GenesisTime: &ptypes.Timestamp{Seconds: testGenesisTime}}, nil
+}
+
+// testGenesisTime is the genesis time served by the version server, in seconds.
+const testGenesisTime = 1580000000
+
+// startServer serves the gRPC services registered by register on a free port, standing in for a
+// beacon node RPC server, and returns the port along with a function stopping the server.
+func startServer(t *testing.T, register func(server *grpc.Server)) (uint64, func()) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := grpc.NewServer()
+	register(server)
+	go func() {
+		if err := server.Serve(listener); err != nil {
+			t.Error(err)
+		}
+	}()
+	return uint64(listener.Addr().(*net.TCPAddr).Port), server.Stop
+}
+
+// startVersionServer serves the node API on a free port, standing in for a beacon node RPC server.
+func startVersionServer(t *testing.T) (uint64, func()) {
+	return startServer(t, func(server *grpc.Server) {
+		eth.RegisterNodeServer(server, &versionServer{})
+	})
+}
+
+func TestBeaconConns_Refresh(t *testing.T) {
+	ctx := context.Background()
+	port0, stop0 := startVersionServer(t)
+	defer stop0()
+	port1, stop1 := startVersionServer(t)
+	defer stop1()
+	nodes := []*beaconNodeInfo{
+		{index: 0, rpcPort: port0, monitorPort: 8080, alive: true},
+		{index: 1, rpcPort: port1, alive: true},
+	}
+
+	conns := newBeaconConns()
+	defer conns.close()
+	if err := conns.refresh(ctx, nodes); err != nil {
+		t.Fatal(err)
+	}
+	if len(conns.conns) != 2 {
+		t.Fatalf("Expected 2 connections, received %d", len(conns.conns))
+	}
+	first := conns.conns[0]
+
+	if err := conns.refresh(ctx, nodes); err != nil {
+		t.Fatal(err)
+	}
+	if conns.conns[0] != first {
+		t.Error("Expected healthy connection to be kept")
+	}
+
+	nodes[0].restartCount++
+	if err := conns.refresh(ctx, nodes); err != nil {
+		t.Fatal(err)
+	}
+	if conns.conns[0] == first {
+		t.Error("Expected restarted node to be dialed again")
+	}
+
+	nodes[1].alive = false
+	if err := conns.refresh(ctx, nodes); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := conns.conns[1]; ok {
+		t.Error("Expected connection to killed node to be dropped")
+	}
+	evaluated := conns.nodeConns(0)
+	if evaluated.Conns[0] == nil {
+		t.Error("Expected connection to the evaluated node")
+	}
+	if evaluated.MonitorPorts[0] != 8080 {
+		t.Errorf("Expected monitoring port of the evaluated node, received %d", evaluated.MonitorPorts[0])
+	}
+}
+
+func TestDialWithBackoff_Unreachable(t *testing.T) {
+	defer func(backoff time.Duration) { initialDialBackoff = backoff }(initialDialBackoff)
+	initialDialBackoff = time.Millisecond
+
+	port, err := findFreePort()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	if _, err := dialWithBackoff(ctx, &beaconNodeInfo{rpcPort: port}); err == nil || !strings.Contains(err.Error(), "failed after 5 attempts") {
+		t.Errorf("Expected dial to fail after 5 attempts, received %v", err)
+	}
+}
+
+func TestWaitForNodeReady(t *testing.T) {
+	defer func(backoff time.Duration) { initialDialBackoff = backoff }(initialDialBackoff)
+	initialDialBackoff = 10 * time.Millisecond
+
+	port, err := findFreePort()
+	if err != nil {
+		t.Fatal(err)
+	}
+	node := &beaconNodeInfo{index: 3, rpcPort: port}
+	defer func() {
+		if err := node.Close(); err != nil {
+			t.Error(err)
+		}
+	}()
+	// The RPC server only starts serving after the node was first dialed, like a node still starting.
+	server := grpc.NewServer()
+	eth.RegisterNodeServer(server, &versionServer{})
+	defer server.Stop()
+	go func() {
+		time.Sleep(300 * time.Millisecond)
+		listener, err := net.Listen("tcp", fmt.Sprintf("127.0.0.1:%d", port))
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		if err := server.Serve(listener); err != nil {
+			t.Error(err)
+		}
+	}()
+
+	if err := waitForNodeReady(context.Background(), node, 10*time.Second); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestWaitForNodeReady_Timeout(t *testing.T) {
+	defer func(backoff time.Duration) { initialDialBackoff = backoff }(initialDialBackoff)
+	initialDialBackoff = 10 * time.Millisecond
+
+	port, err := findFreePort()
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := time.Now()
+	err = waitForNodeReady(context.Background(), &beaconNodeInfo{index: 3, rpcPort: port}, 500*time.Millisecond)
+	if err == nil || !strings.Contains(err.Error(), "RPC server of beacon node 3 not ready") {
+		t.Errorf("Expected readiness check to time out, received %v", err)
+	}
+	if time.Since(start) > 5*time.Second {
+		t.Errorf("Expected to stop waiting at the deadline, waited %v", time.Since(start))
+	}
+}
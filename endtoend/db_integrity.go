@@ -0,0 +1,93 @@
+package endtoend
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"os/exec"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	ev "github.com/prysmaticlabs/prysm/endtoend/evaluators"
+)
+
+// dbBackupTimeout is how long a beacon node is given to write a backup of its database.
+const dbBackupTimeout = 30 * time.Second
+
+// DBIntegrityEvaluator returns an evaluator that checks the database of every running beacon node
+// at every epoch, with the db-check command of the beacon-chain binary, and fails when it exits
+// with an error. A running node holds a lock on its database, so the node is asked for a backup
+// through its monitoring port, which is checked instead. Backups that pass the check are removed,
+// corrupted ones are kept for the artifacts. datadirs are the data directories, by node index.
+func DBIntegrityEvaluator(datadirs []string) ev.Evaluator {
+	return ev.Evaluator{
+		Name:   "db_integrity_epoch_%d",
+		Policy: ev.AllEpochs,
+		Evaluation: func(conns *ev.NodeConns) error {
+			indices := make([]int, 0, len(conns.Conns))
+			for index := range conns.Conns {
+				indices = append(indices, index)
+			}
+			sort.Ints(indices)
+			for _, index := range indices {
+				if index >= len(datadirs) {
+					return fmt.Errorf("no datadir for beacon node %d", index)
+				}
+				if err := checkNodeDB(conns.MonitorPorts[index], datadirs[index]); err != nil {
+					return errors.Wrapf(err, "database of beacon node %d", index)
+				}
+			}
+			return nil
+		},
+	}
+}
+
+// checkNodeDB has the beacon node back up its database to datadir, then runs db-check on the backup.
+func checkNodeDB(monitorPort uint64, datadir string) error {
+	backupPath, err := backupNodeDB(monitorPort, datadir)
+	if err != nil {
+		return err
+	}
+
+	binaryPath, err := findBinary("beacon-chain")
+	if err != nil {
+		return err
+	}
+	output, err := exec.Command(binaryPath, "db-check", backupPath).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("db-check of %s failed: %v, output:\n%s", backupPath, err, strings.TrimSpace(string(output)))
+	}
+	return os.Remove(backupPath)
+}
+
+// backupNodeDB has the beacon node back up its database to datadir, returning the path to the backup.
+func backupNodeDB(monitorPort uint64, datadir string) (string, error) {
+	client := &http.Client{Timeout: dbBackupTimeout}
+	resp, err := client.Get(fmt.Sprintf("http://127.0.0.1:%d/db/backup", monitorPort))
+	if err != nil {
+		return "", errors.Wrap(err, "failed to request backup")
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("backup request failed with status %s", resp.Status)
+	}
+	return latestDBBackup(datadir)
+}
+
+// latestDBBackup returns the path to the latest backup of the database in datadir. Backups are
+// named after the slot of the head block, zero padded, so the latest one comes last.
+func latestDBBackup(datadir string) (string, error) {
+	backupsDir := path.Join(datadir, "beaconchaindata", "backups")
+	files, err := ioutil.ReadDir(backupsDir)
+	if err != nil {
+		return "", errors.Wrap(err, "could not list database backups")
+	}
+	if len(files) == 0 {
+		return "", fmt.Errorf("no database backup in %s", backupsDir)
+	}
+	return path.Join(backupsDir, files[len(files)-1].Name()), nil
+}
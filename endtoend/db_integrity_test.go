@@ -0,0 +1,138 @@
+package endtoend
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"testing"
+
+	ev "github.com/prysmaticlabs/prysm/endtoend/evaluators"
+	"google.golang.org/grpc"
+)
+
+// startBackupServer serves the backup endpoint of a beacon node, writing a backup with the given
+// content to the datadir, and returns its port.
+func startBackupServer(t *testing.T, datadir string, content string) (uint64, func()) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/db/backup" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		backupsDir := path.Join(datadir, "beaconchaindata", "backups")
+		if err := os.MkdirAll(backupsDir, os.ModePerm); err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		backupPath := path.Join(backupsDir, "prysm_beacondb_at_slot_0000042.backup")
+		if err := ioutil.WriteFile(backupPath, []byte(content), 0644); err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		fmt.Fprint(w, "OK")
+	}))
+	serverURL, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	port, err := strconv.ParseUint(serverURL.Port(), 10, 64)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return port, server.Close
+}
+
+func TestDBIntegrityEvaluator(t *testing.T) {
+	dir, err := ioutil.TempDir("", "db-integrity")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	// The beacon-chain binary stands in for db-check, failing on backups it finds corrupted.
+	binary := writeScript(t, dir, "beacon-chain", `#!/bin/sh
+if grep -q corrupted "$2"; then
+  echo "database is corrupted: page 3: invalid type"
+  exit 1
+fi
+`)
+	defer setEnv(t, "PRYSM_E2E_BEACON_BINARY", binary)()
+
+	tests := []struct {
+		name     string
+		backups  []string
+		errorMsg string
+	}{
+		{
+			name:    "valid databases",
+			backups: []string{"valid", "valid"},
+		},
+		{
+			name:     "corrupted database",
+			backups:  []string{"valid", "corrupted"},
+			errorMsg: "database of beacon node 1: db-check of",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conns := &ev.NodeConns{Conns: make(map[int]*grpc.ClientConn), MonitorPorts: make(map[int]uint64)}
+			var datadirs []string
+			for i, content := range tt.backups {
+				datadir := path.Join(dir, tt.name, fmt.Sprintf("eth2-beacon-node-%d", i))
+				port, stop := startBackupServer(t, datadir, content)
+				defer stop()
+				conns.Conns[i] = nil
+				conns.MonitorPorts[i] = port
+				datadirs = append(datadirs, datadir)
+			}
+
+			err := DBIntegrityEvaluator(datadirs).Evaluation(conns)
+			if tt.errorMsg == "" {
+				if err != nil {
+					t.Fatalf("Unexpected error: %v", err)
+				}
+				for _, datadir := range datadirs {
+					files, err := ioutil.ReadDir(path.Join(datadir, "beaconchaindata", "backups"))
+					if err != nil {
+						t.Fatal(err)
+					}
+					if len(files) != 0 {
+						t.Errorf("Expected checked backups to be removed, found %d in %s", len(files), datadir)
+					}
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.errorMsg) {
+				t.Fatalf("Expected error containing %q, received %v", tt.errorMsg, err)
+			}
+			if !strings.Contains(err.Error(), "database is corrupted: page 3: invalid type") {
+				t.Errorf("Expected the output of db-check in the error, received %v", err)
+			}
+		})
+	}
+}
+
+func TestDBIntegrityEvaluator_BackupFails(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+	serverURL, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	port, err := strconv.ParseUint(serverURL.Port(), 10, 64)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	conns := &ev.NodeConns{Conns: map[int]*grpc.ClientConn{0: nil}, MonitorPorts: map[int]uint64{0: port}}
+	err = DBIntegrityEvaluator([]string{"/tmp/datadir"}).Evaluation(conns)
+	if err == nil || !strings.Contains(err.Error(), "backup request failed with status 500") {
+		t.Errorf("Expected backup error, received %v", err)
+	}
+}
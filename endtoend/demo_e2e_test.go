@@ -13,15 +13,22 @@ func TestEndToEnd_DemoConfig(t *testing.T) {
 	testutil.ResetCache()
 	params.UseDemoBeaconConfig()
 
+	numValidators := params.BeaconConfig().MinGenesisActiveValidatorCount
 	demoConfig := &end2EndConfig{
 		minimalConfig:  false,
 		epochsToRun:    5,
 		numBeaconNodes: 4,
-		numValidators:  params.BeaconConfig().MinGenesisActiveValidatorCount,
+		numValidators:  numValidators,
+		portOffset:     100,
 		evaluators: []ev.Evaluator{
-			ev.ValidatorsAreActive,
+			ev.ValidatorsAreActive(numValidators),
+			ev.PeersConnect(4),
 			ev.ValidatorsParticipating,
-			ev.FinalizationOccurs,
+			ev.FinalizationOccurs(),
+			ev.NodesAgreeOnHead,
+			ev.ValidatorsGainBalance(false /*slashingEnabled*/),
+			ev.RewardAccountingEvaluator(2),
+			ev.AttestationInclusionEvaluator(ev.DefaultAttestationInclusionRate),
 		},
 	}
 	runEndToEndTest(t, demoConfig)
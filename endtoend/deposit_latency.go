@@ -0,0 +1,102 @@
+package endtoend
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	ptypes "github.com/gogo/protobuf/types"
+	"github.com/pkg/errors"
+	eth "github.com/prysmaticlabs/ethereumapis/eth/v1alpha1"
+	ev "github.com/prysmaticlabs/prysm/endtoend/evaluators"
+)
+
+// depositLog records the eth1 block number of every deposit the E2E submitted to the deposit
+// contract, in the order the contract counted them. See end2EndConfig.submittedDeposits.
+type depositLog struct {
+	lock         sync.Mutex
+	blockNumbers []uint64
+}
+
+// record adds deposits included in the given blocks. Nothing is recorded by a nil log.
+func (d *depositLog) record(blockNumbers []uint64) {
+	if d == nil {
+		return
+	}
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	d.blockNumbers = append(d.blockNumbers, blockNumbers...)
+}
+
+// submitted returns the block numbers of the deposits submitted so far.
+func (d *depositLog) submitted() []uint64 {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	return append([]uint64{}, d.blockNumbers...)
+}
+
+// DepositProcessingLatencyEvaluator returns an evaluator that ensures the deposits recorded in the
+// log are counted by the beacon chain within maxEpochs of their submission. The API has no way to
+// get the eth1 data of the state, so the deposit count voted by the proposer of the head block is
+// followed. A deposit counts as submitted at the first epoch it's evaluated at, which is the one it
+// was sent in as the deposits are sent before the evaluators run.
+func DepositProcessingLatencyEvaluator(deposits *depositLog, maxEpochs uint64) ev.Evaluator {
+	var submittedAt []uint64
+	return ev.Evaluator{
+		Name:             "deposit_processing_latency_epoch_%d",
+		Policy:           ev.AllEpochs,
+		RequiresDeposits: true,
+		Evaluation: func(conns *ev.NodeConns) error {
+			client := conns.BeaconChainClient()
+			chainHead, err := client.GetChainHead(context.Background(), &ptypes.Empty{})
+			if err != nil {
+				return errors.Wrap(err, "failed to get chain head")
+			}
+			blockNumbers := deposits.submitted()
+			for len(submittedAt) < len(blockNumbers) {
+				submittedAt = append(submittedAt, chainHead.HeadEpoch)
+			}
+			depositCount, err := headDepositCount(client, chainHead.HeadBlockRoot)
+			if err != nil {
+				return err
+			}
+			if depositCount >= uint64(len(blockNumbers)) {
+				return nil
+			}
+			// Deposits are counted in order, so the first one missing is the one waiting the longest.
+			oldest := depositCount
+			if chainHead.HeadEpoch > submittedAt[oldest]+maxEpochs {
+				return fmt.Errorf(
+					"deposit %d, included in eth1 block %d and submitted at epoch %d, is not counted after %d epochs, "+
+						"the beacon chain counts %d of the %d submitted deposits at epoch %d",
+					oldest,
+					blockNumbers[oldest],
+					submittedAt[oldest],
+					maxEpochs,
+					depositCount,
+					len(blockNumbers),
+					chainHead.HeadEpoch,
+				)
+			}
+			return nil
+		},
+	}
+}
+
+// headDepositCount returns the deposit count of the eth1 data voted in the head block.
+func headDepositCount(client eth.BeaconChainClient, headRoot []byte) (uint64, error) {
+	res, err := client.ListBlocks(context.Background(), &eth.ListBlocksRequest{
+		QueryFilter: &eth.ListBlocksRequest_Root{Root: headRoot},
+	})
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to get head block")
+	}
+	if len(res.BlockContainers) == 0 {
+		return 0, fmt.Errorf("head block %#x not found", headRoot)
+	}
+	block := res.BlockContainers[0].Block
+	if block == nil || block.Block == nil || block.Block.Body == nil || block.Block.Body.Eth1Data == nil {
+		return 0, fmt.Errorf("head block %#x has no eth1 data", headRoot)
+	}
+	return block.Block.Body.Eth1Data.DepositCount, nil
+}
@@ -0,0 +1,127 @@
+package endtoend
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+
+	ptypes "github.com/gogo/protobuf/types"
+	eth "github.com/prysmaticlabs/ethereumapis/eth/v1alpha1"
+	ev "github.com/prysmaticlabs/prysm/endtoend/evaluators"
+	"google.golang.org/grpc"
+)
+
+// eth1DataServer serves a head block voting the given deposit count at the given epoch.
+type eth1DataServer struct {
+	eth.BeaconChainServer
+	lock         sync.Mutex
+	epoch        uint64
+	depositCount uint64
+	missingBlock bool
+}
+
+func (s *eth1DataServer) set(epoch uint64, depositCount uint64) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.epoch = epoch
+	s.depositCount = depositCount
+}
+
+func (s *eth1DataServer) GetChainHead(_ context.Context, _ *ptypes.Empty) (*eth.ChainHead, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return &eth.ChainHead{HeadEpoch: s.epoch, HeadSlot: s.epoch * 8, HeadBlockRoot: []byte{0x0a}}, nil
+}
+
+func (s *eth1DataServer) ListBlocks(_ context.Context, req *eth.ListBlocksRequest) (*eth.ListBlocksResponse, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.missingBlock {
+		return &eth.ListBlocksResponse{}, nil
+	}
+	block := &eth.SignedBeaconBlock{Block: &eth.BeaconBlock{
+		Body: &eth.BeaconBlockBody{Eth1Data: &eth.Eth1Data{DepositCount: s.depositCount}},
+	}}
+	return &eth.ListBlocksResponse{BlockContainers: []*eth.BeaconBlockContainer{{Block: block, BlockRoot: req.GetRoot()}}}, nil
+}
+
+func startEth1DataServer(t *testing.T, server *eth1DataServer) (*ev.NodeConns, func()) {
+	port, stopServer := startServer(t, func(grpcServer *grpc.Server) {
+		eth.RegisterBeaconChainServer(grpcServer, server)
+	})
+	node := &beaconNodeInfo{rpcPort: port}
+	conn, err := node.GRPCConn()
+	if err != nil {
+		stopServer()
+		t.Fatal(err)
+	}
+	return &ev.NodeConns{Conns: map[int]*grpc.ClientConn{0: conn}}, func() {
+		if err := node.Close(); err != nil {
+			t.Error(err)
+		}
+		stopServer()
+	}
+}
+
+func TestDepositProcessingLatencyEvaluator(t *testing.T) {
+	server := &eth1DataServer{}
+	conns, stop := startEth1DataServer(t, server)
+	defer stop()
+	deposits := &depositLog{}
+	genesisBlocks := make([]uint64, 64)
+	for i := range genesisBlocks {
+		genesisBlocks[i] = 10
+	}
+	deposits.record(genesisBlocks)
+	evaluator := DepositProcessingLatencyEvaluator(deposits, 2)
+
+	steps := []struct {
+		epoch        uint64
+		depositCount uint64
+		submit       []uint64
+		errorMsg     string
+	}{
+		{epoch: 0, depositCount: 64},
+		// 8 deposits are submitted at epoch 1, the last 2 in a later eth1 block.
+		{epoch: 1, depositCount: 64, submit: []uint64{120, 120, 120, 120, 120, 120, 121, 121}},
+		{epoch: 2, depositCount: 66},
+		{epoch: 3, depositCount: 66},
+		{
+			epoch:        4,
+			depositCount: 66,
+			errorMsg: "deposit 66, included in eth1 block 120 and submitted at epoch 1, is not counted after 2 epochs, " +
+				"the beacon chain counts 66 of the 72 submitted deposits at epoch 4",
+		},
+		{epoch: 4, depositCount: 72},
+	}
+	for _, step := range steps {
+		deposits.record(step.submit)
+		server.set(step.epoch, step.depositCount)
+		err := evaluator.Evaluation(conns)
+		if step.errorMsg == "" {
+			if err != nil {
+				t.Errorf("Unexpected error at epoch %d with %d deposits counted: %v", step.epoch, step.depositCount, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), step.errorMsg) {
+			t.Errorf("Expected error containing %q at epoch %d, received %v", step.errorMsg, step.epoch, err)
+		}
+	}
+}
+
+func TestDepositProcessingLatencyEvaluator_HeadBlockMissing(t *testing.T) {
+	conns, stop := startEth1DataServer(t, &eth1DataServer{missingBlock: true})
+	defer stop()
+	err := DepositProcessingLatencyEvaluator(&depositLog{}, 2).Evaluation(conns)
+	if err == nil || !strings.Contains(err.Error(), "head block 0x0a not found") {
+		t.Errorf("Expected error for missing head block, received %v", err)
+	}
+}
+
+func TestDepositLog_Nil(t *testing.T) {
+	var deposits *depositLog
+	// Deposits sent outside of runEndToEndTest have no log to be recorded in.
+	deposits.record([]uint64{12})
+}
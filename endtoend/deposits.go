@@ -0,0 +1,168 @@
+package endtoend
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"sync"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/pkg/errors"
+	ethpb "github.com/prysmaticlabs/ethereumapis/eth/v1alpha1"
+	"github.com/prysmaticlabs/go-ssz"
+	contracts "github.com/prysmaticlabs/prysm/contracts/deposit-contract"
+	"github.com/prysmaticlabs/prysm/shared/bls"
+	"github.com/prysmaticlabs/prysm/shared/hashutil"
+	"github.com/prysmaticlabs/prysm/shared/params"
+	"github.com/prysmaticlabs/prysm/shared/testutil"
+)
+
+// maxPendingDeposits bounds how many deposit transactions wait to be mined at the same time.
+const maxPendingDeposits = 16
+
+// sendDeposits deposits amountGwei for each of the keys to the deposit contract from the account of
+// the keystore, and returns the number of the block each deposit was included in. Amounts below
+// MaxEffectiveBalance are partial deposits, or top-ups when the key was already deposited.
+func sendDeposits(
+	ctx context.Context,
+	web3Endpoint string,
+	contractAddr common.Address,
+	keystorePath string,
+	keys []*bls.SecretKey,
+	amountGwei uint64,
+) ([]uint64, error) {
+	if amountGwei < params.BeaconConfig().MinDepositAmount {
+		return nil, fmt.Errorf(
+			"deposit amount %d is below the minimum deposit amount of %d gwei",
+			amountGwei,
+			params.BeaconConfig().MinDepositAmount,
+		)
+	}
+
+	client, err := rpc.DialHTTP(web3Endpoint)
+	if err != nil {
+		return nil, err
+	}
+	web3 := ethclient.NewClient(client)
+
+	jsonBytes, err := ioutil.ReadFile(keystorePath)
+	if err != nil {
+		return nil, err
+	}
+	txOps, err := bind.NewTransactor(bytes.NewReader(jsonBytes), "" /*password*/)
+	if err != nil {
+		return nil, err
+	}
+	amountInWei := new(big.Int).Mul(
+		new(big.Int).SetUint64(amountGwei),
+		new(big.Int).SetUint64(params.BeaconConfig().GweiPerEth),
+	)
+	txOps.Value = amountInWei
+	txOps.GasLimit = 4000000
+	nonce, err := web3.PendingNonceAt(ctx, txOps.From)
+	if err != nil {
+		return nil, err
+	}
+
+	contract, err := contracts.NewDepositContract(contractAddr, web3)
+	if err != nil {
+		return nil, err
+	}
+
+	blockNumbers := make([]uint64, len(keys))
+	errs := make(chan error, len(keys))
+	pending := make(chan struct{}, maxPendingDeposits)
+	var wg sync.WaitGroup
+	for i, key := range keys {
+		data, dataRoot, err := signedDepositData(key, amountGwei)
+		if err != nil {
+			return nil, err
+		}
+		opts := *txOps
+		opts.Nonce = new(big.Int).SetUint64(nonce + uint64(i))
+		// Transactions are sent in nonce order, so the pending ones are always the next to be mined.
+		pending <- struct{}{}
+		tx, err := contract.Deposit(&opts, data.PublicKey, data.WithdrawalCredentials, data.Signature, dataRoot)
+		if err != nil {
+			<-pending
+			wg.Wait()
+			return nil, errors.Wrapf(err, "unable to send deposit %d to contract", i)
+		}
+		wg.Add(1)
+		go func(i int, txHash common.Hash) {
+			defer wg.Done()
+			defer func() { <-pending }()
+			receipt, err := waitForReceipt(ctx, web3, txHash, defaultNodeStartupTimeout)
+			if err != nil {
+				errs <- errors.Wrapf(err, "deposit %d was not included", i)
+				return
+			}
+			blockNumbers[i] = receipt.BlockNumber.Uint64()
+		}(i, tx.Hash())
+	}
+	wg.Wait()
+	close(errs)
+	if err := <-errs; err != nil {
+		return nil, err
+	}
+
+	keystore, err := keystore.DecryptKey(jsonBytes, "" /*password*/)
+	if err != nil {
+		return nil, err
+	}
+	// "Safe" amount of blocks to mine to make sure the deposits are seen.
+	if err := mineBlocks(web3, keystore, 20); err != nil {
+		return nil, errors.Wrap(err, "failed to mine blocks")
+	}
+	return blockNumbers, nil
+}
+
+// sendMidRunDeposits deposits the validators following the genesis ones, so they go through the
+// activation queue while the chain is running.
+func sendMidRunDeposits(ctx context.Context, t testing.TB, config *end2EndConfig, eth1Node *eth1NodeInfo) {
+	_, keys, err := testutil.DeterministicDepositsAndKeys(config.numValidators + config.numMidRunDeposits)
+	if err != nil {
+		t.Fatal(err)
+	}
+	amount := params.BeaconConfig().MaxEffectiveBalance
+	newKeys := keys[config.numValidators:]
+	blockNumbers, err := sendDeposits(ctx, eth1Node.httpEndpoint, eth1Node.contractAddr, eth1Node.keystorePath, newKeys, amount)
+	if err != nil {
+		t.Fatalf("Could not send mid-run deposits: %v", err)
+	}
+	config.submittedDeposits.record(blockNumbers)
+	t.Logf("Deposited %d new validators", len(newKeys))
+}
+
+// signedDepositData returns the deposit data of amountGwei for the key, signed by the key, along
+// with the root the deposit contract expects for it.
+func signedDepositData(key *bls.SecretKey, amountGwei uint64) (*ethpb.Deposit_Data, [32]byte, error) {
+	pubKey := key.PublicKey().Marshal()
+	withdrawalCreds := hashutil.Hash(pubKey)
+	withdrawalCreds[0] = params.BeaconConfig().BLSWithdrawalPrefixByte
+	data := &ethpb.Deposit_Data{
+		PublicKey:             pubKey,
+		WithdrawalCredentials: withdrawalCreds[:],
+		Amount:                amountGwei,
+	}
+
+	domain := bls.ComputeDomain(params.BeaconConfig().DomainDeposit)
+	signingRoot, err := ssz.SigningRoot(data)
+	if err != nil {
+		return nil, [32]byte{}, errors.Wrap(err, "could not get signing root of deposit data")
+	}
+	data.Signature = key.Sign(signingRoot[:], domain).Marshal()
+
+	dataRoot, err := ssz.HashTreeRoot(data)
+	if err != nil {
+		return nil, [32]byte{}, errors.Wrap(err, "could not tree hash deposit data")
+	}
+	return data, dataRoot, nil
+}
@@ -0,0 +1,44 @@
+package endtoend
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/prysmaticlabs/prysm/shared/params"
+	"github.com/prysmaticlabs/prysm/shared/testutil"
+)
+
+func TestSendDeposits_AmountBelowMinimum(t *testing.T) {
+	amount := params.BeaconConfig().MinDepositAmount - 1
+	_, err := sendDeposits(context.Background(), "http://127.0.0.1:0", common.Address{}, "", nil, amount)
+	if err == nil || !strings.Contains(err.Error(), "below the minimum deposit amount") {
+		t.Errorf("Expected minimum deposit amount error, received %v", err)
+	}
+}
+
+func TestSignedDepositData_PartialDeposit(t *testing.T) {
+	_, keys, err := testutil.DeterministicDepositsAndKeys(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	amount := params.BeaconConfig().MaxEffectiveBalance / 2
+	data, dataRoot, err := signedDepositData(keys[0], amount)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data.Amount != amount {
+		t.Errorf("Expected deposit amount %d, received %d", amount, data.Amount)
+	}
+	if !bytes.Equal(data.PublicKey, keys[0].PublicKey().Marshal()) {
+		t.Error("Deposit data is not for the given key")
+	}
+	if data.WithdrawalCredentials[0] != params.BeaconConfig().BLSWithdrawalPrefixByte {
+		t.Errorf("Expected BLS withdrawal prefix, received %#x", data.WithdrawalCredentials[0])
+	}
+	if dataRoot == [32]byte{} {
+		t.Error("Expected deposit data root to be set")
+	}
+}
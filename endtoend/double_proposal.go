@@ -0,0 +1,111 @@
+package endtoend
+
+import (
+	"context"
+	"fmt"
+
+	ptypes "github.com/gogo/protobuf/types"
+	"github.com/pkg/errors"
+	eth "github.com/prysmaticlabs/ethereumapis/eth/v1alpha1"
+	"github.com/prysmaticlabs/go-ssz"
+	slashpb "github.com/prysmaticlabs/prysm/proto/slashing"
+	"github.com/prysmaticlabs/prysm/shared/bls"
+	"github.com/prysmaticlabs/prysm/shared/interop"
+	"github.com/prysmaticlabs/prysm/shared/params"
+	"google.golang.org/grpc"
+)
+
+// interopKey returns the secret key of the interop validator at the given index, which is the key
+// its validator client signs with.
+func interopKey(index uint64) (*bls.SecretKey, error) {
+	keys, _, err := interop.DeterministicallyGenerateKeys(index, 1)
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not generate the key of validator %d", index)
+	}
+	return keys[0], nil
+}
+
+// signBlockHeader signs the block header with the key in the given proposer domain.
+func signBlockHeader(key *bls.SecretKey, header *eth.BeaconBlockHeader, domain uint64) (*eth.SignedBeaconBlockHeader, error) {
+	root, err := ssz.HashTreeRoot(header)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not compute block header root")
+	}
+	return &eth.SignedBeaconBlockHeader{
+		Header:    header,
+		Signature: key.Sign(root[:], domain).Marshal(),
+	}, nil
+}
+
+// conflictingHeaders signs two block headers for the same slot and parent, which only differ by
+// their body root, as a validator proposing two blocks at once would.
+func conflictingHeaders(key *bls.SecretKey, slot uint64, parentRoot []byte, domain uint64) (*eth.SignedBeaconBlockHeader, *eth.SignedBeaconBlockHeader, error) {
+	var signed [2]*eth.SignedBeaconBlockHeader
+	for i := range signed {
+		bodyRoot := [32]byte{byte(i + 1)}
+		header := &eth.BeaconBlockHeader{
+			Slot:       slot,
+			ParentRoot: parentRoot,
+			StateRoot:  make([]byte, 32),
+			BodyRoot:   bodyRoot[:],
+		}
+		var err error
+		if signed[i], err = signBlockHeader(key, header, domain); err != nil {
+			return nil, nil, err
+		}
+	}
+	return signed[0], signed[1], nil
+}
+
+// submitDoubleProposal signs two conflicting block headers of the validator at the head slot of the
+// beacon node and submits them to the slasher listening on slasherRPCPort, which must report the
+// second one as a double proposal.
+func submitDoubleProposal(ctx context.Context, beaconConn *grpc.ClientConn, slasherRPCPort uint64, validatorIndex uint64) error {
+	key, err := interopKey(validatorIndex)
+	if err != nil {
+		return err
+	}
+	head, err := eth.NewBeaconChainClient(beaconConn).GetChainHead(ctx, &ptypes.Empty{})
+	if err != nil {
+		return errors.Wrap(err, "failed to get chain head")
+	}
+	domain, err := eth.NewBeaconNodeValidatorClient(beaconConn).DomainData(ctx, &eth.DomainRequest{
+		Epoch:  head.HeadEpoch,
+		Domain: params.BeaconConfig().DomainBeaconProposer,
+	})
+	if err != nil {
+		return errors.Wrap(err, "failed to get proposer domain")
+	}
+	header1, header2, err := conflictingHeaders(key, head.HeadSlot, head.HeadBlockRoot, domain.SignatureDomain)
+	if err != nil {
+		return err
+	}
+
+	conn, err := grpc.Dial(fmt.Sprintf("127.0.0.1:%d", slasherRPCPort), grpc.WithInsecure())
+	if err != nil {
+		return errors.Wrap(err, "failed to dial slasher")
+	}
+	defer conn.Close()
+	slasherClient := slashpb.NewSlasherClient(conn)
+	res, err := slasherClient.IsSlashableBlock(ctx, &slashpb.ProposerSlashingRequest{
+		BlockHeader:    header1,
+		ValidatorIndex: validatorIndex,
+	})
+	if err != nil {
+		return errors.Wrap(err, "failed to submit block header to slasher")
+	}
+	if len(res.ProposerSlashing) != 0 {
+		return fmt.Errorf("expected first block header to not be slashable, received %d slashings", len(res.ProposerSlashing))
+	}
+	res, err = slasherClient.IsSlashableBlock(ctx, &slashpb.ProposerSlashingRequest{
+		BlockHeader:    header2,
+		ValidatorIndex: validatorIndex,
+	})
+	if err != nil {
+		return errors.Wrap(err, "failed to submit conflicting block header to slasher")
+	}
+	if len(res.ProposerSlashing) != 1 {
+		return fmt.Errorf("expected conflicting block header to be detected as a double proposal, received %d slashings", len(res.ProposerSlashing))
+	}
+	return nil
+}
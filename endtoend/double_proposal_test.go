@@ -0,0 +1,47 @@
+package endtoend
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/gogo/protobuf/proto"
+	eth "github.com/prysmaticlabs/ethereumapis/eth/v1alpha1"
+	"github.com/prysmaticlabs/go-ssz"
+	"github.com/prysmaticlabs/prysm/shared/bls"
+)
+
+func TestConflictingHeaders(t *testing.T) {
+	key, err := interopKey(3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	parentRoot := bytes.Repeat([]byte{0xaa}, 32)
+	domain := uint64(42)
+	header1, header2, err := conflictingHeaders(key, 12, parentRoot, domain)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if header1.Header.Slot != 12 || header2.Header.Slot != 12 {
+		t.Errorf("Expected both headers at slot 12, received %d and %d", header1.Header.Slot, header2.Header.Slot)
+	}
+	if !bytes.Equal(header1.Header.ParentRoot, parentRoot) || !bytes.Equal(header2.Header.ParentRoot, parentRoot) {
+		t.Error("Expected both headers to have the given parent root")
+	}
+	if proto.Equal(header1.Header, header2.Header) {
+		t.Error("Expected the headers to conflict")
+	}
+	for i, header := range []*eth.SignedBeaconBlockHeader{header1, header2} {
+		root, err := ssz.HashTreeRoot(header.Header)
+		if err != nil {
+			t.Fatal(err)
+		}
+		sig, err := bls.SignatureFromBytes(header.Signature)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !sig.Verify(root[:], key.PublicKey(), domain) {
+			t.Errorf("Signature of header %d does not verify", i+1)
+		}
+	}
+}
@@ -3,6 +3,7 @@ package endtoend
 import (
 	"bufio"
 	"context"
+	"flag"
 	"fmt"
 	"io/ioutil"
 	"net/http"
@@ -14,37 +15,137 @@ import (
 	"time"
 
 	"github.com/bazelbuild/rules_go/go/tools/bazel"
-	ptypes "github.com/gogo/protobuf/types"
 	"github.com/pkg/errors"
-	eth "github.com/prysmaticlabs/ethereumapis/eth/v1alpha1"
+	ev "github.com/prysmaticlabs/prysm/endtoend/evaluators"
 	"github.com/prysmaticlabs/prysm/shared/params"
-	"google.golang.org/grpc"
 )
 
-func runEndToEndTest(t *testing.T, config *end2EndConfig) {
-	tmpPath := bazel.TestTmpDir()
+func runEndToEndTest(t testing.TB, config *end2EndConfig) {
+	if applyMinimalEnv(config) {
+		t.Logf("%s=1 is set, running with the minimal config for %d epochs with %d validators", minimalEnvVar, config.epochsToRun, config.numValidators)
+	}
+	if config.slotDurationSeconds > 0 {
+		// The epoch ticker and the evaluators time the run with the beacon config of the test.
+		c := params.BeaconConfig()
+		c.SecondsPerSlot = config.slotDurationSeconds
+		params.OverrideBeaconConfig(c)
+	}
+	tmpPath := suiteTmpPath(t)
+	if err := os.MkdirAll(tmpPath, os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
 	config.tmpPath = tmpPath
+	defer saveArtifacts(t, config)
+	results := newResultsCollector(t.Name(), config, beaconChainVersion())
+	defer writeResults(t, results, config)
+	ctx, cancel := testContext()
+	defer cancel()
 	t.Logf("Starting time: %s\n", time.Now().String())
 	t.Logf("Test Path: %s\n\n", tmpPath)
 
-	contractAddr, keystorePath, eth1PID := startEth1(t, tmpPath)
-	config.contractAddr = contractAddr
-	beaconNodes := startBeaconNodes(t, config)
-	valClients := initializeValidators(t, config, keystorePath)
-	processIDs := []int{eth1PID}
+	var eth1Node *eth1NodeInfo
+	var keystorePath string
+	if config.eth1Endpoint == "" {
+		eth1Node = startEth1(ctx, t, config)
+		defer stopEth1Node(t, eth1Node)
+		config.contractAddr = eth1Node.contractAddr
+		config.contractDeploymentBlock = eth1Node.deploymentBlock
+		keystorePath = eth1Node.keystorePath
+	}
+	bootNode := startBootNode(t, config)
+	defer stopBootNode(t, bootNode)
+	config.bootNodeENR = bootNode.enr
+	validateConfig(t, config)
+	config.submittedDeposits = &depositLog{}
+	if config.tlsRPC {
+		if err := generateTLSCertificate(config.tlsCertFile(), config.tlsKeyFile()); err != nil {
+			t.Fatalf("Could not generate TLS certificate: %v", err)
+		}
+	}
+	if config.useInteropGenesis {
+		config.genesisStateFile = generateGenesisState(t, config)
+	}
+	if config.eth1Endpoint != "" {
+		if err := checkEth1Endpoints(ctx, config.eth1HTTPProvider(), config.eth1WSProvider(), config.contractAddr); err != nil {
+			t.Fatalf("External eth1 node is not usable: %v", err)
+		}
+	}
+	beaconNodes := startBeaconNodes(ctx, t, config)
+	defer stopBeaconNodes(t, beaconNodes)
+	stopHealthMonitor := MonitorNodeHealth(t, beaconNodes, nodeHealthInterval)
+	defer stopHealthMonitor()
+	if os.Getenv(streamLogsEnvVar) == "1" {
+		stopStreaming, err := StreamNodeLogs(t, beaconNodes)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer stopStreaming()
+	}
+	if config.nodeLatencyMs > 0 {
+		for _, node := range beaconNodes {
+			restore, err := SetNodeLatency(t, node, config.nodeLatencyMs, config.nodeLatencyJitterMs)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer restore()
+		}
+	}
+	results.addNodes(beaconNodes)
+	prysmNodes := make([]BeaconNodeController, len(beaconNodes))
+	for i, node := range beaconNodes {
+		prysmNodes[i] = node
+	}
+	lighthouseNodes := startLighthouseNodes(ctx, t, config, prysmNodes)
+	defer stopLighthouseNodes(t, lighthouseNodes)
+	// The gateway of a beacon node dials its gRPC server in plaintext, so it fails with TLS.
+	if config.tlsRPC {
+		t.Log("Skipping the JSON gateway evaluator, the gateway can't reach a gRPC server using TLS")
+	} else {
+		gatewayEndpoints := make([]string, len(beaconNodes))
+		for i, node := range beaconNodes {
+			gatewayEndpoints[i] = fmt.Sprintf("http://127.0.0.1:%d", node.grpcPort)
+		}
+		config.evaluators = append(config.evaluators, ev.HTTPGatewayEvaluator(gatewayEndpoints))
+	}
+	config.evaluators = append(
+		config.evaluators,
+		ev.GenesisAgreement(),
+		ev.DepositContractEchoed(config.contractAddr.Bytes()),
+		ev.ChainConfigLoaded(),
+	)
+	datadirs := make([]string, len(beaconNodes))
+	for i, node := range beaconNodes {
+		datadirs[i] = node.datadir
+	}
+	// The databases are checked by the built beacon-chain binary, which isn't used with an image.
+	if config.beaconNodeImage == "" {
+		config.evaluators = append(config.evaluators, DBIntegrityEvaluator(datadirs))
+	}
+	config.evaluators = append(config.evaluators, StateDiffEvaluator(t, datadirs))
+	var slasher *slasherInfo
+	if config.testSlasher {
+		slasher = startSlasher(ctx, t, config, beaconNodes[0])
+		defer stopSlasher(t, slasher)
+		config.evaluators = append(config.evaluators, ev.SlasherDetectsDoubleVote(slasher.rpcPort))
+		// The double proposal is the only slashing the slasher is expected to report.
+		var proposerSlashings uint64
+		if config.doubleProposalAtEpoch > 0 {
+			proposerSlashings = 1
+		}
+		config.evaluators = append(config.evaluators, SlasherEvaluator(slasher, proposerSlashings, config.doubleProposalAtEpoch))
+	}
+	valClients, depositErrs := initializeValidators(ctx, t, config, keystorePath, beaconNodes)
+	var processIDs []int
 	for _, vv := range valClients {
 		processIDs = append(processIDs, vv.processID)
 	}
-	for _, bb := range beaconNodes {
-		processIDs = append(processIDs, bb.processID)
-	}
 	defer logOutput(t, tmpPath, config)
 	defer killProcesses(t, processIDs)
 
 	if config.numBeaconNodes > 1 {
-		t.Run("all_peers_connect", func(t *testing.T) {
-			for _, bNode := range beaconNodes {
-				if err := peersConnect(bNode.monitorPort, config.numBeaconNodes-1); err != nil {
+		runSubtest(t, "all_peers_connect", func(t testing.TB) {
+			for i, bNode := range beaconNodes {
+				if err := peersConnect(bNode.monitorPort, config.topology.expectedPeers(i, int(config.numBeaconNodes))); err != nil {
 					t.Fatalf("Failed to connect to peers: %v", err)
 				}
 			}
@@ -55,7 +156,7 @@ func runEndToEndTest(t *testing.T, config *end2EndConfig) {
 	if err != nil {
 		t.Fatal(err)
 	}
-	if err := waitForTextInFile(beaconLogFile, "Sending genesis time notification"); err != nil {
+	if err := waitForTextInFile(ctx, beaconLogFile, "Sending genesis time notification", config.startupTimeout()); err != nil {
 		t.Fatalf("failed to find genesis in logs, this means the chain did not start: %v", err)
 	}
 
@@ -64,39 +165,237 @@ func runEndToEndTest(t *testing.T, config *end2EndConfig) {
 		return
 	}
 
-	conn, err := grpc.Dial("127.0.0.1:4000", grpc.WithInsecure())
+	var restartedNode *beaconNodeInfo
+	if config.restartNodeAtEpoch > 0 {
+		restartedNode = beaconNodes[config.restartNodeIndex]
+		// Allow the restarted node to lag a quarter of an epoch behind the node being evaluated.
+		tolerance := params.BeaconConfig().SlotsPerEpoch / 4
+		config.evaluators = append(config.evaluators, ev.RestartedNodeSynced(restartedNode.index, config.restartNodeAtEpoch, tolerance))
+	}
+
+	if config.archive {
+		config.evaluators = append(config.evaluators, ev.ArchivedCommitteesEvaluator(0))
+	}
+
+	late := &lateNode{}
+	defer func() {
+		if node, _ := late.get(); node != nil {
+			stopBeaconNodes(t, []*beaconNodeInfo{node})
+		}
+	}()
+	if config.lateNodeAtEpoch > 0 {
+		config.evaluators = append(config.evaluators, LateNodeSyncedEvaluator(late, config.lateNodeAtEpoch, config.maxLateNodeSyncSlots()))
+	}
+
+	if config.maxNodeRSSMB > 0 {
+		config.evaluators = append(config.evaluators, NodeMemoryEvaluator(beaconNodes, config.maxNodeRSSMB))
+	}
+
+	if config.depositBatchSize > 0 && config.depositBatchSize < config.numValidators {
+		config.evaluators = append(config.evaluators, ev.ActiveValidatorsGrow())
+	}
+
+	if config.maxDepositLatencyEpochs > 0 {
+		config.evaluators = append(config.evaluators, DepositProcessingLatencyEvaluator(config.submittedDeposits, config.maxDepositLatencyEpochs))
+	}
+
+	if config.depositsAtEpoch > 0 {
+		config.evaluators = append(config.evaluators, ev.DepositsProcessed(
+			config.depositsAtEpoch,
+			config.numValidators,
+			config.numMidRunDeposits,
+		))
+	}
+
+	if config.genesisStateFile != "" {
+		var skipped []string
+		config.evaluators, skipped = withoutDepositEvaluators(config.evaluators)
+		if len(skipped) > 0 {
+			t.Logf("Skipping evaluators requiring deposits, the chain starts from a genesis state: %s", strings.Join(skipped, ", "))
+		}
+	}
+
+	var killCandidates []*beaconNodeInfo
+	for _, node := range beaconNodes {
+		// Keep the restarted node out of the kill candidates so its evaluator can reach it.
+		if config.restartNodeAtEpoch > 0 && node == restartedNode {
+			continue
+		}
+		// Likewise for the archive node, the only one serving past epochs.
+		if config.archive && node.index == 0 {
+			continue
+		}
+		killCandidates = append(killCandidates, node)
+	}
+
+	metricsOutputDir := config.metricsOutputDir
+	if metricsOutputDir != "" && !path.IsAbs(metricsOutputDir) {
+		metricsOutputDir = path.Join(tmpPath, metricsOutputDir)
+	}
+	if metricsOutputDir != "" {
+		if err := os.MkdirAll(metricsOutputDir, os.ModePerm); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	csvMetrics, err := newMetricsCSVWriter(path.Join(tmpPath, metricsCSVFileName))
 	if err != nil {
-		t.Fatalf("Failed to dial: %v", err)
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := csvMetrics.Close(); err != nil {
+			t.Error(err)
+		}
+	}()
+	if err := csvMetrics.WriteHeader(); err != nil {
+		t.Fatal(err)
 	}
-	beaconClient := eth.NewBeaconChainClient(conn)
-	nodeClient := eth.NewNodeClient(conn)
 
-	genesis, err := nodeClient.GetGenesis(context.Background(), &ptypes.Empty{})
+	var previousMetrics map[int]metrics
+	var restorePartition func()
+	defer func() {
+		if restorePartition != nil {
+			restorePartition()
+		}
+	}()
+
+	conns := newBeaconConns()
+	defer conns.close()
+	if err := conns.refresh(ctx, beaconNodes); err != nil {
+		t.Fatal(err)
+	}
+	startupEvaluators, epochEvaluators := splitRunOnceEvaluators(config.evaluators)
+	config.evaluators = epochEvaluators
+	runStartupEvaluators(t, startupEvaluators, conns.nodeConns(0), results)
+	if t.Failed() {
+		return
+	}
+	clock, err := newEpochClock(conns.conns[0], params.BeaconConfig())
 	if err != nil {
 		t.Fatal(err)
 	}
 	// Small offset so evaluators perform in the middle of an epoch.
-	epochSeconds := params.BeaconConfig().SecondsPerSlot * params.BeaconConfig().SlotsPerEpoch
-	genesisTime := time.Unix(genesis.GenesisTime.Seconds+int64(epochSeconds/2), 0)
+	offset := clock.epochDuration() / 2
+	stopMonitor := monitorResources(beaconNodes, clock, offset)
+	defer stopMonitor()
+	if config.continueOnFailure {
+		defer reportFailures(t, results)
+	}
 	currentEpoch := uint64(0)
-	ticker := GetEpochTicker(genesisTime, epochSeconds)
+	ticker := clock.ticker(offset)
 	for c := range ticker.C() {
-		if c >= config.epochsToRun || t.Failed() {
+		if c >= config.epochsToRun || (t.Failed() && !config.continueOnFailure) || len(crashedNodes(beaconNodes)) > 0 {
 			ticker.Done()
 			break
 		}
 
-		for _, evaluator := range config.evaluators {
-			// Only run if the policy says so.
-			if !evaluator.Policy(currentEpoch) {
+		if config.restartNodeAtEpoch > 0 && currentEpoch == config.restartNodeAtEpoch {
+			if err := restartedNode.Restart(ctx, t, config); err != nil {
+				logNodeStartFailure(t, tmpPath, err)
+				t.Fatal(err)
+			}
+		}
+		if config.lateNodeAtEpoch > 0 && currentEpoch == config.lateNodeAtEpoch {
+			if _, err := late.start(ctx, t, config, aliveBeaconNodes(beaconNodes)); err != nil {
+				logNodeStartFailure(t, tmpPath, err)
+				t.Fatal(err)
+			}
+		}
+		if config.killNodeAtEpoch > 0 && currentEpoch == config.killNodeAtEpoch {
+			killBeaconNodes(t, killCandidates, config.nodesToKill)
+		}
+		if config.partitionAtEpoch > 0 && currentEpoch == config.partitionAtEpoch {
+			partitioned, others := config.partitionGroups(beaconNodes)
+			restorePartition = PartitionNodes(t, partitioned, others)
+		}
+		if restorePartition != nil && currentEpoch == config.partitionAtEpoch+config.partitionEpochs {
+			restorePartition()
+			restorePartition = nil
+		}
+		if config.depositsAtEpoch > 0 && currentEpoch == config.depositsAtEpoch {
+			sendMidRunDeposits(ctx, t, config, eth1Node)
+		}
+		select {
+		case err := <-depositErrs:
+			if err != nil {
+				t.Fatal(err)
+			}
+			t.Log("Sent all the deposit batches")
+		default:
+		}
+
+		// Evaluate against the first beacon node still alive, killed nodes can't be dialed.
+		// The restarted node is only used as reference if it's the last one alive.
+		evaluatedNode := aliveBeaconNodes(beaconNodes)[0]
+		for _, node := range aliveBeaconNodes(beaconNodes) {
+			if config.restartNodeAtEpoch == 0 || node != restartedNode {
+				evaluatedNode = node
+				break
+			}
+		}
+		// Restarted nodes are dialed again and the connections to killed nodes are closed.
+		if err := conns.refresh(ctx, beaconNodes); err != nil {
+			t.Fatal(err)
+		}
+		if config.doubleProposalAtEpoch > 0 && currentEpoch == config.doubleProposalAtEpoch {
+			if err := submitDoubleProposal(ctx, conns.conns[evaluatedNode.index], slasher.rpcPort, config.slashedValidatorIndex); err != nil {
+				t.Fatalf("Failed to submit double proposal: %v", err)
+			}
+			t.Logf("Submitted a double proposal of validator %d", config.slashedValidatorIndex)
+		}
+		runEvaluators(t, config.evaluators, conns.nodeConns(evaluatedNode.index), currentEpoch, results)
+		for _, evaluator := range config.logEvaluators {
+			if !evaluator.policy(currentEpoch) {
 				continue
 			}
-			t.Run(fmt.Sprintf(evaluator.Name, currentEpoch), func(t *testing.T) {
-				if err := evaluator.Evaluation(beaconClient); err != nil {
-					t.Fatalf("evaluation failed for epoch %d: %v", currentEpoch, err)
+			name := fmt.Sprintf(evaluator.name, currentEpoch)
+			runSubtest(t, name, func(t testing.TB) {
+				start := time.Now()
+				var err error
+				for _, node := range aliveBeaconNodes(beaconNodes) {
+					if err = evaluator.evaluation(node); err != nil {
+						break
+					}
+				}
+				results.record(currentEpoch, name, time.Since(start), err)
+				if err != nil {
+					t.Fatalf("log evaluation failed for epoch %d: %v", currentEpoch, err)
 				}
 			})
 		}
+		if metricsOutputDir != "" || len(config.metricsEvaluators) > 0 {
+			currentMetrics, err := captureMetrics(metricsOutputDir, currentEpoch, aliveBeaconNodes(beaconNodes))
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, evaluator := range config.metricsEvaluators {
+				if !evaluator.policy(currentEpoch) {
+					continue
+				}
+				name := fmt.Sprintf(evaluator.name, currentEpoch)
+				runSubtest(t, name, func(t testing.TB) {
+					start := time.Now()
+					var err error
+					for _, node := range aliveBeaconNodes(beaconNodes) {
+						// The restarted node starts over from its database, its metrics restart too.
+						if currentEpoch == config.restartNodeAtEpoch && node == restartedNode {
+							continue
+						}
+						if err = evaluator.evaluation(node, previousMetrics[node.index], currentMetrics[node.index]); err != nil {
+							break
+						}
+					}
+					results.record(currentEpoch, name, time.Since(start), err)
+					if err != nil {
+						t.Fatalf("metrics evaluation failed for epoch %d: %v", currentEpoch, err)
+					}
+				})
+			}
+			previousMetrics = currentMetrics
+		}
+		if err := csvMetrics.WriteEpoch(currentEpoch, aliveBeaconNodes(beaconNodes)); err != nil {
+			t.Fatal(err)
+		}
 		currentEpoch++
 	}
 
@@ -105,6 +404,140 @@ func runEndToEndTest(t *testing.T, config *end2EndConfig) {
 	}
 }
 
+// suiteTmpPath returns the directory the files of the suite are written to. Each suite gets its
+// own directory so suites can run side by side.
+func suiteTmpPath(t testing.TB) string {
+	return path.Join(bazel.TestTmpDir(), t.Name())
+}
+
+// runSubtest runs fn as a subtest with the given name when the harness runs in a test. A benchmark
+// can't run subtests in the middle of its timing, so there fn runs in the benchmark itself.
+func runSubtest(t testing.TB, name string, fn func(t testing.TB)) {
+	if tt, ok := t.(*testing.T); ok {
+		tt.Run(name, func(t *testing.T) {
+			fn(t)
+		})
+		return
+	}
+	fn(t)
+}
+
+// runEvaluators runs the evaluators whose policy applies to the epoch against the given nodes,
+// each as its own subtest, and records their outcome. It returns the names of the evaluators that
+// were skipped.
+func runEvaluators(t testing.TB, evaluators []ev.Evaluator, conns *ev.NodeConns, currentEpoch uint64, results *resultsCollector) []string {
+	var skipped []string
+	for _, evaluator := range evaluators {
+		name := fmt.Sprintf(evaluator.Name, currentEpoch)
+		// Only run if the policy says so.
+		if !evaluator.Policy(currentEpoch) {
+			skipped = append(skipped, name)
+			continue
+		}
+		runSubtest(t, name, func(t testing.TB) {
+			start := time.Now()
+			err := evaluateWithRetries(t, evaluator, name, conns)
+			if err != nil {
+				err = evaluationError(name, currentEpoch, conns.Evaluated, err)
+			}
+			results.record(currentEpoch, name, time.Since(start), err)
+			if err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+	if len(skipped) > 0 {
+		t.Logf("Skipped evaluators at epoch %d: %s", currentEpoch, strings.Join(skipped, ", "))
+	}
+	return skipped
+}
+
+// runStartupEvaluators runs the evaluators meant to run once after startup against the given nodes,
+// each as its own subtest, and records their outcome apart from the ones run at every epoch.
+func runStartupEvaluators(t testing.TB, evaluators []ev.Evaluator, conns *ev.NodeConns, results *resultsCollector) {
+	for _, evaluator := range evaluators {
+		name := evaluator.Name
+		runSubtest(t, name, func(t testing.TB) {
+			start := time.Now()
+			err := evaluateWithRetries(t, evaluator, name, conns)
+			if err != nil {
+				err = evaluationError(name, 0, conns.Evaluated, err)
+			}
+			results.recordStartup(name, time.Since(start), err)
+			if err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+// evaluateWithRetries runs the evaluation, running it again up to evaluator.Retries times while it
+// fails. Each failed attempt followed by a retry is logged with its error.
+func evaluateWithRetries(logger Logger, evaluator ev.Evaluator, name string, conns *ev.NodeConns) error {
+	delay := evaluator.RetryDelay
+	if delay == 0 {
+		delay = time.Duration(params.BeaconConfig().SecondsPerSlot) * time.Second
+	}
+	err := evaluator.Evaluation(conns)
+	for attempt := 1; err != nil && attempt <= evaluator.Retries; attempt++ {
+		logger.Logf("Retrying %s in %v, attempt %d of %d failed: %v", name, delay, attempt, evaluator.Retries+1, err)
+		time.Sleep(delay)
+		err = evaluator.Evaluation(conns)
+	}
+	return err
+}
+
+// splitRunOnceEvaluators separates the evaluators run once after startup from the ones run at the
+// epochs their policy applies to.
+func splitRunOnceEvaluators(evaluators []ev.Evaluator) ([]ev.Evaluator, []ev.Evaluator) {
+	var once, perEpoch []ev.Evaluator
+	for _, evaluator := range evaluators {
+		if evaluator.RunOnce {
+			once = append(once, evaluator)
+		} else {
+			perEpoch = append(perEpoch, evaluator)
+		}
+	}
+	return once, perEpoch
+}
+
+// withoutDepositEvaluators returns the evaluators that don't require deposits, along with the
+// names of the ones left out.
+func withoutDepositEvaluators(evaluators []ev.Evaluator) ([]ev.Evaluator, []string) {
+	var kept []ev.Evaluator
+	var skipped []string
+	for _, evaluator := range evaluators {
+		if evaluator.RequiresDeposits {
+			skipped = append(skipped, strings.Replace(evaluator.Name, "_epoch_%d", "", 1))
+			continue
+		}
+		kept = append(kept, evaluator)
+	}
+	return kept, skipped
+}
+
+// testBinaryStart approximates when the go test timeout started counting down.
+var testBinaryStart = time.Now()
+
+// testContext returns a context that is cancelled shortly before the go test timeout fires,
+// so the started processes get killed instead of being orphaned when the test binary panics.
+func testContext() (context.Context, context.CancelFunc) {
+	timeoutFlag := flag.Lookup("test.timeout")
+	if timeoutFlag == nil {
+		return context.WithCancel(context.Background())
+	}
+	timeout, ok := timeoutFlag.Value.(flag.Getter).Get().(time.Duration)
+	if !ok || timeout == 0 {
+		return context.WithCancel(context.Background())
+	}
+	// Leave some time for the teardown and log output before the test binary is killed.
+	teardownTime := 30 * time.Second
+	if timeout > 2*teardownTime {
+		timeout -= teardownTime
+	}
+	return context.WithDeadline(context.Background(), testBinaryStart.Add(timeout))
+}
+
 func peersConnect(port uint64, expectedPeers uint64) error {
 	response, err := http.Get(fmt.Sprintf("http://127.0.0.1:%d/p2p", port))
 	if err != nil {
@@ -135,7 +568,7 @@ func peersConnect(port uint64, expectedPeers uint64) error {
 	return nil
 }
 
-func killProcesses(t *testing.T, pIDs []int) {
+func killProcesses(t testing.TB, pIDs []int) {
 	for _, id := range pIDs {
 		process, err := os.FindProcess(id)
 		if err != nil {
@@ -150,10 +583,10 @@ func killProcesses(t *testing.T, pIDs []int) {
 	}
 }
 
-func logOutput(t *testing.T, tmpPath string, config *end2EndConfig) {
+func logOutput(t testing.TB, tmpPath string, config *end2EndConfig) {
 	if t.Failed() {
 		// Log out errors from beacon chain nodes.
-		for i := uint64(0); i < config.numBeaconNodes; i++ {
+		for i := uint64(0); i < config.numPrysmNodes(); i++ {
 			beaconLogFile, err := os.Open(path.Join(tmpPath, fmt.Sprintf(beaconNodeLogFileName, i)))
 			if err != nil {
 				t.Fatal(err)
@@ -169,13 +602,13 @@ func logOutput(t *testing.T, tmpPath string, config *end2EndConfig) {
 	}
 }
 
-func logErrorOutput(t *testing.T, file *os.File, title string, index uint64) {
+func logErrorOutput(t testing.TB, file *os.File, title string, index uint64) {
 	var errorLines []string
 
 	scanner := bufio.NewScanner(file)
 	for scanner.Scan() {
 		currentLine := scanner.Text()
-		if strings.Contains(currentLine, "level=error") {
+		if lineLevel(currentLine) == "error" {
 			errorLines = append(errorLines, currentLine)
 		}
 	}
@@ -194,3 +627,133 @@ func logErrorOutput(t *testing.T, file *os.File, title string, index uint64) {
 	t.Logf("\nEnd of %s %d error output:", title, index)
 	t.Log("===================================================================")
 }
+
+func TestRunEvaluators_Policy(t *testing.T) {
+	var runs int
+	evaluator := ev.Evaluator{
+		Name:   "on_epoch_2_epoch_%d",
+		Policy: ev.OnEpoch(2),
+		Evaluation: func(_ *ev.NodeConns) error {
+			runs++
+			return nil
+		},
+	}
+	results := newResultsCollector(t.Name(), &end2EndConfig{}, "")
+	var skipped int
+	for epoch := uint64(0); epoch < 6; epoch++ {
+		skipped += len(runEvaluators(t, []ev.Evaluator{evaluator}, &ev.NodeConns{}, epoch, results))
+	}
+	if len(results.results) != 1 || results.results[0].Evaluator != "on_epoch_2_epoch_2" || !results.results[0].Passed {
+		t.Errorf("Expected the run at epoch 2 to be recorded as passed, received %+v", results.results)
+	}
+	if runs != 1 {
+		t.Errorf("Expected evaluator to run once, ran %d times", runs)
+	}
+	if skipped != 5 {
+		t.Errorf("Expected evaluator to be skipped at 5 epochs, skipped %d times", skipped)
+	}
+}
+
+func TestRunStartupEvaluators(t *testing.T) {
+	var runs int
+	evaluators := []ev.Evaluator{
+		{
+			Name:   "every_epoch_epoch_%d",
+			Policy: ev.AllEpochs,
+			Evaluation: func(_ *ev.NodeConns) error {
+				return nil
+			},
+		},
+		{
+			Name:    "once",
+			RunOnce: true,
+			Evaluation: func(_ *ev.NodeConns) error {
+				runs++
+				return nil
+			},
+		},
+	}
+	once, perEpoch := splitRunOnceEvaluators(evaluators)
+	if len(once) != 1 || once[0].Name != "once" || len(perEpoch) != 1 || perEpoch[0].Name != "every_epoch_epoch_%d" {
+		t.Fatalf("Unexpected split of the evaluators, once: %+v, every epoch: %+v", once, perEpoch)
+	}
+	results := newResultsCollector(t.Name(), &end2EndConfig{}, "")
+	runStartupEvaluators(t, once, &ev.NodeConns{}, results)
+	for epoch := uint64(0); epoch < 3; epoch++ {
+		runEvaluators(t, perEpoch, &ev.NodeConns{}, epoch, results)
+	}
+	if runs != 1 {
+		t.Errorf("Expected the startup evaluator to run once, ran %d times", runs)
+	}
+	if len(results.startupResults) != 1 || results.startupResults[0].Evaluator != "once" || !results.startupResults[0].Passed {
+		t.Errorf("Expected the startup run to be recorded as passed, received %+v", results.startupResults)
+	}
+	if len(results.results) != 3 {
+		t.Errorf("Expected the 3 epoch runs to be recorded apart, received %+v", results.results)
+	}
+}
+
+func TestEvaluateWithRetries(t *testing.T) {
+	tests := []struct {
+		name       string
+		retries    int
+		failures   int
+		errorMsg   string
+		runs       int
+		loggedRuns int
+	}{
+		{name: "passes first", retries: 2, failures: 0, runs: 1},
+		{name: "passes on retry", retries: 2, failures: 2, runs: 3, loggedRuns: 2},
+		{name: "fails every retry", retries: 2, failures: 5, errorMsg: "attempt 3 failed", runs: 3, loggedRuns: 2},
+		{name: "strict", retries: 0, failures: 1, errorMsg: "attempt 1 failed", runs: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var runs int
+			evaluator := ev.Evaluator{
+				Retries:    tt.retries,
+				RetryDelay: time.Millisecond,
+				Evaluation: func(_ *ev.NodeConns) error {
+					runs++
+					if runs <= tt.failures {
+						return fmt.Errorf("attempt %d failed", runs)
+					}
+					return nil
+				},
+			}
+			logger := &recordingLogger{}
+			err := evaluateWithRetries(logger, evaluator, "flaky_epoch_1", &ev.NodeConns{})
+			if tt.errorMsg == "" && err != nil {
+				t.Errorf("Unexpected error: %v", err)
+			}
+			if tt.errorMsg != "" && (err == nil || err.Error() != tt.errorMsg) {
+				t.Errorf("Expected error %q, received %v", tt.errorMsg, err)
+			}
+			if runs != tt.runs {
+				t.Errorf("Expected %d runs, received %d", tt.runs, runs)
+			}
+			if len(logger.lines) != tt.loggedRuns {
+				t.Fatalf("Expected %d retries logged, received %q", tt.loggedRuns, logger.lines)
+			}
+			if tt.loggedRuns > 0 && logger.lines[0] != "Retrying flaky_epoch_1 in 1ms, attempt 1 of 3 failed: attempt 1 failed" {
+				t.Errorf("Unexpected retry log %q", logger.lines[0])
+			}
+		})
+	}
+}
+
+func TestWithoutDepositEvaluators(t *testing.T) {
+	evaluators := []ev.Evaluator{
+		ev.ValidatorsParticipating,
+		ev.ActiveValidatorsGrow(),
+		ev.FinalizationOccurs(),
+		ev.DepositsProcessed(1, 64, 8),
+	}
+	kept, skipped := withoutDepositEvaluators(evaluators)
+	if len(kept) != 2 || kept[0].Name != ev.ValidatorsParticipating.Name || kept[1].Name != ev.FinalizationOccurs().Name {
+		t.Errorf("Expected the evaluators not requiring deposits to be kept, received %v", kept)
+	}
+	if strings.Join(skipped, ",") != "active_validators_grow,deposits_processed" {
+		t.Errorf("Expected the deposit evaluators to be skipped, received %v", skipped)
+	}
+}
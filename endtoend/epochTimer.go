@@ -1,11 +1,55 @@
 package endtoend
 
 import (
+	"context"
 	"time"
 
+	ptypes "github.com/gogo/protobuf/types"
+	"github.com/pkg/errors"
+	eth "github.com/prysmaticlabs/ethereumapis/eth/v1alpha1"
+	"github.com/prysmaticlabs/prysm/shared/params"
 	"github.com/prysmaticlabs/prysm/shared/roughtime"
+	"google.golang.org/grpc"
 )
 
+// epochClock times the epochs of the running chain from its genesis time, read once from a beacon
+// node, so the E2E follows the epoch boundaries of the nodes instead of its own start.
+type epochClock struct {
+	genesisTime  time.Time
+	epochSeconds uint64
+}
+
+// newEpochClock reads the genesis time from the beacon node behind conn and times the epochs with
+// the given config, which is the minimal one when the E2E runs with it.
+func newEpochClock(conn *grpc.ClientConn, config *params.BeaconChainConfig) (*epochClock, error) {
+	genesis, err := eth.NewNodeClient(conn).GetGenesis(context.Background(), &ptypes.Empty{})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get genesis")
+	}
+	if genesis.GenesisTime == nil {
+		return nil, errors.New("no genesis time")
+	}
+	return &epochClock{
+		genesisTime:  time.Unix(genesis.GenesisTime.Seconds, int64(genesis.GenesisTime.Nanos)),
+		epochSeconds: config.SecondsPerSlot * config.SlotsPerEpoch,
+	}, nil
+}
+
+// epochDuration returns how long an epoch lasts.
+func (c *epochClock) epochDuration() time.Duration {
+	return time.Duration(c.epochSeconds) * time.Second
+}
+
+// boundary returns the time the given epoch starts at.
+func (c *epochClock) boundary(epoch uint64) time.Time {
+	return c.genesisTime.Add(time.Duration(epoch) * c.epochDuration())
+}
+
+// ticker returns a ticker firing at the given offset into every epoch.
+func (c *epochClock) ticker(offset time.Duration) *EpochTicker {
+	return GetEpochTicker(c.genesisTime.Add(offset), c.epochSeconds)
+}
+
 // EpochTicker is a special ticker for timing epoch changes.
 // The channel emits over the epoch interval, and ensures that
 // the ticks are in line with the genesis time. This means that
@@ -50,19 +94,7 @@ func (s *EpochTicker) start(
 	d := time.Duration(secondsPerEpoch) * time.Second
 
 	go func() {
-		sinceGenesis := since(genesisTime)
-
-		var nextTickTime time.Time
-		var epoch uint64
-		if sinceGenesis < 0 {
-			// Handle when the current time is before the genesis time.
-			nextTickTime = genesisTime
-			epoch = 0
-		} else {
-			nextTick := sinceGenesis.Truncate(d) + d
-			nextTickTime = genesisTime.Add(nextTick)
-			epoch = uint64(nextTick / d)
-		}
+		nextTickTime, epoch := nextEpochBoundary(genesisTime, since(genesisTime), d)
 
 		for {
 			waitTime := until(nextTickTime)
@@ -77,3 +109,13 @@ func (s *EpochTicker) start(
 		}
 	}()
 }
+
+// nextEpochBoundary returns the first epoch boundary after sinceGenesis has elapsed since genesis,
+// along with the epoch starting then. Before genesis, the first boundary is genesis itself.
+func nextEpochBoundary(genesisTime time.Time, sinceGenesis time.Duration, epochDuration time.Duration) (time.Time, uint64) {
+	if sinceGenesis < 0 {
+		return genesisTime, 0
+	}
+	nextTick := sinceGenesis.Truncate(epochDuration) + epochDuration
+	return genesisTime.Add(nextTick), uint64(nextTick / epochDuration)
+}
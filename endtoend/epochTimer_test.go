@@ -0,0 +1,144 @@
+package endtoend
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/prysmaticlabs/prysm/shared/params"
+	"google.golang.org/grpc"
+)
+
+func TestNewEpochClock(t *testing.T) {
+	port, stop := startVersionServer(t)
+	defer stop()
+	conn, err := grpc.Dial(fmt.Sprintf("127.0.0.1:%d", port), grpc.WithInsecure())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	genesisTime := time.Unix(testGenesisTime, 0)
+
+	tests := []struct {
+		name          string
+		config        *params.BeaconChainConfig
+		epochDuration time.Duration
+	}{
+		{
+			name:          "mainnet config",
+			config:        params.MainnetConfig(),
+			epochDuration: 384 * time.Second,
+		},
+		{
+			name:          "minimal config",
+			config:        params.MinimalSpecConfig(),
+			epochDuration: 48 * time.Second,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clock, err := newEpochClock(conn, tt.config)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if clock.epochDuration() != tt.epochDuration {
+				t.Errorf("Expected epochs of %v, received %v", tt.epochDuration, clock.epochDuration())
+			}
+			if !clock.boundary(0).Equal(genesisTime) {
+				t.Errorf("Expected epoch 0 to start at genesis %v, received %v", genesisTime, clock.boundary(0))
+			}
+			if expected := genesisTime.Add(5 * tt.epochDuration); !clock.boundary(5).Equal(expected) {
+				t.Errorf("Expected epoch 5 to start at %v, received %v", expected, clock.boundary(5))
+			}
+		})
+	}
+}
+
+func TestNextEpochBoundary(t *testing.T) {
+	genesisTime := time.Unix(testGenesisTime, 0)
+	for _, config := range []*params.BeaconChainConfig{params.MainnetConfig(), params.MinimalSpecConfig()} {
+		epoch := time.Duration(config.SecondsPerSlot*config.SlotsPerEpoch) * time.Second
+		tests := []struct {
+			name         string
+			sinceGenesis time.Duration
+			boundary     time.Time
+			epoch        uint64
+		}{
+			{
+				name:         "before genesis",
+				sinceGenesis: -time.Minute,
+				boundary:     genesisTime,
+			},
+			{
+				name:     "at genesis",
+				boundary: genesisTime.Add(epoch),
+				epoch:    1,
+			},
+			{
+				name:         "mid epoch",
+				sinceGenesis: 2*epoch + epoch/2,
+				boundary:     genesisTime.Add(3 * epoch),
+				epoch:        3,
+			},
+			{
+				name:         "on a boundary",
+				sinceGenesis: 3 * epoch,
+				boundary:     genesisTime.Add(4 * epoch),
+				epoch:        4,
+			},
+			{
+				name:         "just before a boundary",
+				sinceGenesis: 4*epoch - time.Millisecond,
+				boundary:     genesisTime.Add(4 * epoch),
+				epoch:        4,
+			},
+		}
+		for _, tt := range tests {
+			t.Run(fmt.Sprintf("%v epochs %s", epoch, tt.name), func(t *testing.T) {
+				boundary, next := nextEpochBoundary(genesisTime, tt.sinceGenesis, epoch)
+				if !boundary.Equal(tt.boundary) || next != tt.epoch {
+					t.Errorf("Expected epoch %d at %v, received epoch %d at %v", tt.epoch, tt.boundary, next, boundary)
+				}
+			})
+		}
+	}
+}
+
+func TestEpochTicker_Start(t *testing.T) {
+	genesisTime := time.Unix(testGenesisTime, 0)
+	now := genesisTime.Add(90 * time.Second)
+	waits := make(chan time.Duration, 4)
+	calls := 0
+	ticker := &EpochTicker{c: make(chan uint64), done: make(chan struct{})}
+	ticker.start(
+		genesisTime,
+		48,
+		func(t time.Time) time.Duration { return now.Sub(t) },
+		func(t time.Time) time.Duration { return t.Sub(now) },
+		func(d time.Duration) <-chan time.Time {
+			waits <- d
+			calls++
+			if calls > 3 {
+				// Never fires, leaving the ticker to stop.
+				return nil
+			}
+			// Time moves on to the tick right away.
+			now = now.Add(d)
+			c := make(chan time.Time, 1)
+			c <- now
+			return c
+		},
+	)
+	for _, expected := range []uint64{2, 3, 4} {
+		if epoch := <-ticker.C(); epoch != expected {
+			t.Fatalf("Expected epoch %d, received %d", expected, epoch)
+		}
+	}
+	ticker.Done()
+	// The first tick waits for the boundary of epoch 2, the following ones for a full epoch.
+	for i, expected := range []time.Duration{6 * time.Second, 48 * time.Second, 48 * time.Second} {
+		if wait := <-waits; wait != expected {
+			t.Errorf("Expected wait %d to be %v, received %v", i, expected, wait)
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package endtoend
+
+import (
+	"errors"
+	"fmt"
+	"path"
+
+	ev "github.com/prysmaticlabs/prysm/endtoend/evaluators"
+)
+
+// NodeStartError is returned when a beacon node can't be started, telling which node failed and at
+// which stage, e.g. "launch" when the process didn't start its p2p server in time.
+type NodeStartError struct {
+	NodeIndex int
+	Stage     string
+	Cause     error
+}
+
+func (e *NodeStartError) Error() string {
+	return fmt.Sprintf("beacon node %d failed to start during %s: %v", e.NodeIndex, e.Stage, e.Cause)
+}
+
+// Unwrap returns the cause, so errors.Is and errors.As see through the error.
+func (e *NodeStartError) Unwrap() error {
+	return e.Cause
+}
+
+// nodeStartLogLines is how many of the last lines logged by a beacon node are shown when it can't be started.
+const nodeStartLogLines = 20
+
+// logNodeStartFailure logs the last lines logged by the beacon node that failed to start, when the
+// error tells which node it is.
+func logNodeStartFailure(logger Logger, tmpPath string, err error) {
+	var startErr *NodeStartError
+	if !errors.As(err, &startErr) {
+		return
+	}
+	name := path.Join(tmpPath, fmt.Sprintf(beaconNodeLogFileName, startErr.NodeIndex))
+	lines, readErr := lastLinesOfFile(name, nodeStartLogLines)
+	if readErr != nil {
+		logger.Logf("Could not read the log of beacon node %d: %v", startErr.NodeIndex, readErr)
+		return
+	}
+	logger.Logf("Last lines logged by beacon node %d before failing during %s:\n%s", startErr.NodeIndex, startErr.Stage, lines)
+}
+
+// evaluationError attributes the error of an evaluation to the evaluator and the epoch. It's
+// attributed to the evaluated beacon node, unless the evaluator already named the failing node.
+func evaluationError(name string, epoch uint64, evaluated int, err error) error {
+	var evalErr *ev.EvaluatorError
+	if !errors.As(err, &evalErr) {
+		evalErr = &ev.EvaluatorError{NodeIndex: evaluated, Cause: err}
+		err = evalErr
+	}
+	evalErr.EvaluatorName = name
+	evalErr.EpochNumber = epoch
+	return err
+}
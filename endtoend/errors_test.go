@@ -0,0 +1,106 @@
+package endtoend
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
+	"testing"
+
+	ev "github.com/prysmaticlabs/prysm/endtoend/evaluators"
+)
+
+// recordingLogger keeps what was logged through it.
+type recordingLogger struct {
+	lines []string
+}
+
+func (l *recordingLogger) Logf(format string, args ...interface{}) {
+	l.lines = append(l.lines, fmt.Sprintf(format, args...))
+}
+
+func TestNodeStartError(t *testing.T) {
+	cause := errors.New("could not find multiaddr")
+	err := fmt.Errorf("failed to start 1 of 4 beacon nodes: %w", &NodeStartError{NodeIndex: 2, Stage: "launch", Cause: cause})
+	for _, field := range []string{"beacon node 2", "during launch", "could not find multiaddr"} {
+		if !strings.Contains(err.Error(), field) {
+			t.Errorf("Expected %q to contain %q", err.Error(), field)
+		}
+	}
+	var startErr *NodeStartError
+	if !errors.As(err, &startErr) || startErr.NodeIndex != 2 {
+		t.Errorf("Expected to unwrap the error of node 2, received %v", startErr)
+	}
+	if !errors.Is(err, cause) {
+		t.Error("Expected the cause to be unwrapped")
+	}
+}
+
+func TestLogNodeStartFailure(t *testing.T) {
+	dir, err := ioutil.TempDir("", "node-start")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	content := "level=info msg=\"Starting beacon node\"\nlevel=fatal msg=\"Could not open database\"\n"
+	if err := ioutil.WriteFile(path.Join(dir, fmt.Sprintf(beaconNodeLogFileName, 1)), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	logger := &recordingLogger{}
+	logNodeStartFailure(logger, dir, errors.New("no node index"))
+	if len(logger.lines) != 0 {
+		t.Errorf("Expected nothing logged for an error without node, received %v", logger.lines)
+	}
+	startErr := fmt.Errorf("failed to start 1 of 2 beacon nodes: %w", &NodeStartError{NodeIndex: 1, Stage: "launch", Cause: errors.New("timeout")})
+	logNodeStartFailure(logger, dir, startErr)
+	if len(logger.lines) != 1 {
+		t.Fatalf("Expected the log of the node to be logged once, received %v", logger.lines)
+	}
+	for _, want := range []string{"beacon node 1", "during launch", "Could not open database"} {
+		if !strings.Contains(logger.lines[0], want) {
+			t.Errorf("Expected %q to contain %q", logger.lines[0], want)
+		}
+	}
+}
+
+func TestEvaluationError(t *testing.T) {
+	tests := []struct {
+		name      string
+		err       error
+		nodeIndex int
+		contains  []string
+	}{
+		{
+			name:      "attributed to the evaluated node",
+			err:       errors.New("expected finalized epoch to be 2, received: 1"),
+			nodeIndex: 0,
+			contains:  []string{"finalized_epoch_4", "epoch 4", "beacon node 0", "expected finalized epoch to be 2"},
+		},
+		{
+			name:      "failing node named by the evaluator",
+			err:       &ev.EvaluatorError{NodeIndex: 3, Cause: errors.New("validators not active since genesis: [4]")},
+			nodeIndex: 3,
+			contains:  []string{"finalized_epoch_4", "epoch 4", "beacon node 3", "validators not active since genesis"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := evaluationError("finalized_epoch_4", 4, 0, tt.err)
+			for _, field := range tt.contains {
+				if !strings.Contains(err.Error(), field) {
+					t.Errorf("Expected %q to contain %q", err.Error(), field)
+				}
+			}
+			var evalErr *ev.EvaluatorError
+			if !errors.As(err, &evalErr) {
+				t.Fatalf("Expected an evaluator error, received %T", err)
+			}
+			if evalErr.NodeIndex != tt.nodeIndex || evalErr.EpochNumber != 4 || evalErr.EvaluatorName != "finalized_epoch_4" {
+				t.Errorf("Unexpected evaluator error %+v", evalErr)
+			}
+		})
+	}
+}
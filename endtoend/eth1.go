@@ -14,23 +14,47 @@ import (
 	"time"
 
 	"github.com/bazelbuild/rules_go/go/tools/bazel"
+	"github.com/ethereum/go-ethereum"
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/accounts/keystore"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/ethclient"
 	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/pkg/errors"
 	contracts "github.com/prysmaticlabs/prysm/contracts/deposit-contract"
 	"github.com/prysmaticlabs/prysm/shared/params"
 )
 
+// eth1BlockPeriod is how often, in seconds, the eth1 dev chain mines a block when no transactions are sent.
+const eth1BlockPeriod = 2
+
+// eth1ShutdownTimeout is how long the eth1 node is given to exit after SIGTERM before it's killed.
+const eth1ShutdownTimeout = 5 * time.Second
+
+// eth1DialTimeout is how long an external eth1 node is given to answer the queries checking it's usable.
+const eth1DialTimeout = 10 * time.Second
+
+type eth1NodeInfo struct {
+	processID       int
+	cmd             *exec.Cmd
+	logFile         *os.File
+	datadir         string
+	httpEndpoint    string
+	wsEndpoint      string
+	contractAddr    common.Address
+	deploymentBlock uint64
+	keystorePath    string
+}
+
 // startEth1 starts an eth1 local dev chain and deploys a deposit contract.
-func startEth1(t *testing.T, tmpPath string) (common.Address, string, int) {
+func startEth1(ctx context.Context, t testing.TB, config *end2EndConfig) *eth1NodeInfo {
 	binaryPath, found := bazel.FindBinary("cmd/geth", "geth")
 	if !found {
 		t.Fatal("go-ethereum binary not found")
 	}
 
+	tmpPath := config.tmpPath
 	eth1Path := path.Join(tmpPath, "eth1data/")
 	// Clear out ETH1 to prevent issues.
 	if _, err := os.Stat(eth1Path); !os.IsNotExist(err) {
@@ -43,16 +67,18 @@ func startEth1(t *testing.T, tmpPath string) (common.Address, string, int) {
 		fmt.Sprintf("--datadir=%s", eth1Path),
 		"--rpc",
 		"--rpcaddr=0.0.0.0",
+		fmt.Sprintf("--rpcport=%d", config.eth1HTTPPort()),
 		"--rpccorsdomain=\"*\"",
 		"--rpcvhosts=\"*\"",
 		"--ws",
 		"--wsaddr=0.0.0.0",
+		fmt.Sprintf("--wsport=%d", config.eth1WSPort()),
 		"--wsorigins=\"*\"",
 		"--dev",
-		"--dev.period=0",
+		fmt.Sprintf("--dev.period=%d", eth1BlockPeriod),
 		"--ipcdisable",
 	}
-	cmd := exec.Command(binaryPath, args...)
+	cmd := exec.CommandContext(ctx, binaryPath, args...)
 	file, err := os.Create(path.Join(tmpPath, "eth1.log"))
 	if err != nil {
 		t.Fatal(err)
@@ -62,13 +88,27 @@ func startEth1(t *testing.T, tmpPath string) (common.Address, string, int) {
 	if err := cmd.Start(); err != nil {
 		t.Fatalf("Failed to start eth1 chain: %v", err)
 	}
+	node := &eth1NodeInfo{
+		processID:    cmd.Process.Pid,
+		cmd:          cmd,
+		logFile:      file,
+		datadir:      eth1Path,
+		httpEndpoint: config.eth1HTTPProvider(),
+		wsEndpoint:   config.eth1WSProvider(),
+	}
+	// Don't leave the eth1 node running if it can't be set up.
+	defer func() {
+		if t.Failed() {
+			_ = node.Stop(eth1ShutdownTimeout)
+		}
+	}()
 
-	if err = waitForTextInFile(file, "Commit new mining work"); err != nil {
+	if err = waitForTextInFile(ctx, file, "Commit new mining work", defaultNodeStartupTimeout); err != nil {
 		t.Fatalf("mining log not found, this means the eth1 chain had issues starting: %v", err)
 	}
 
 	// Connect to the started geth dev chain.
-	client, err := rpc.DialHTTP("http://127.0.0.1:8545")
+	client, err := rpc.DialHTTP(node.httpEndpoint)
 	if err != nil {
 		t.Fatalf("Failed to connect to ipc: %v", err)
 	}
@@ -94,29 +134,119 @@ func startEth1(t *testing.T, tmpPath string) (common.Address, string, int) {
 		t.Fatalf("Unable to advance chain: %v", err)
 	}
 
-	txOpts, err := bind.NewTransactor(bytes.NewReader(jsonBytes), "" /*password*/)
+	contractAddr, deploymentBlock, err := deployDepositContract(ctx, web3, jsonBytes)
 	if err != nil {
-		t.Fatal(err)
+		t.Fatalf("Failed to deploy deposit contract: %v", err)
 	}
-	nonce, err := web3.PendingNonceAt(context.Background(), keystore.Address)
+
+	node.contractAddr = contractAddr
+	node.deploymentBlock = deploymentBlock
+	node.keystorePath = keystorePath
+	return node
+}
+
+// Stop stops the eth1 node, see beaconNodeInfo.Stop.
+func (e *eth1NodeInfo) Stop(timeout time.Duration) error {
+	if err := stopProcess(e.cmd, timeout); err != nil {
+		return err
+	}
+	if err := e.logFile.Sync(); err != nil {
+		return errors.Wrap(err, "could not flush log file")
+	}
+	return e.logFile.Close()
+}
+
+// stopEth1Node stops the eth1 node, it's meant to be deferred by the test that started it.
+func stopEth1Node(t testing.TB, node *eth1NodeInfo) {
+	if err := node.Stop(eth1ShutdownTimeout); err != nil {
+		t.Errorf("Could not stop eth1 node: %v", err)
+	}
+}
+
+// checkEth1Endpoints ensures an external eth1 node answers on both its HTTP and websocket endpoints
+// and that the deposit contract can be queried through it, before beacon nodes are pointed at it.
+func checkEth1Endpoints(ctx context.Context, httpEndpoint string, wsEndpoint string, contractAddr common.Address) error {
+	ctx, cancel := context.WithTimeout(ctx, eth1DialTimeout)
+	defer cancel()
+	for _, endpoint := range []string{httpEndpoint, wsEndpoint} {
+		web3, err := ethclient.DialContext(ctx, endpoint)
+		if err != nil {
+			return errors.Wrapf(err, "could not dial eth1 node at %s", endpoint)
+		}
+		err = queryDepositContract(ctx, web3, contractAddr)
+		web3.Close()
+		if err != nil {
+			return errors.Wrapf(err, "eth1 node at %s", endpoint)
+		}
+	}
+	return nil
+}
+
+// queryDepositContract reads the head block and the deposit root of the contract, which the beacon
+// nodes need to follow deposits.
+func queryDepositContract(ctx context.Context, web3 *ethclient.Client, contractAddr common.Address) error {
+	if _, err := web3.HeaderByNumber(ctx, nil); err != nil {
+		return errors.Wrap(err, "could not get head block")
+	}
+	caller, err := contracts.NewDepositContractCaller(contractAddr, web3)
 	if err != nil {
-		t.Fatal(err)
+		return err
+	}
+	if _, err := caller.GetDepositRoot(&bind.CallOpts{Context: ctx}); err != nil {
+		return errors.Wrapf(err, "could not query deposit contract %s", contractAddr.Hex())
+	}
+	return nil
+}
+
+// deployDepositContract deploys the deposit contract from the account of the given keystore and
+// returns its address along with the number of the block it was included in.
+func deployDepositContract(ctx context.Context, web3 *ethclient.Client, keystoreJSON []byte) (common.Address, uint64, error) {
+	txOpts, err := bind.NewTransactor(bytes.NewReader(keystoreJSON), "" /*password*/)
+	if err != nil {
+		return common.Address{}, 0, err
+	}
+	nonce, err := web3.PendingNonceAt(ctx, txOpts.From)
+	if err != nil {
+		return common.Address{}, 0, err
 	}
 	txOpts.Nonce = big.NewInt(int64(nonce))
 	contractAddr, tx, _, err := contracts.DeployDepositContract(txOpts, web3, txOpts.From)
 	if err != nil {
-		t.Fatalf("Failed to deploy deposit contract: %v", err)
+		return common.Address{}, 0, err
+	}
+	receipt, err := waitForReceipt(ctx, web3, tx.Hash(), defaultNodeStartupTimeout)
+	if err != nil {
+		return common.Address{}, 0, err
+	}
+	if receipt.Status != types.ReceiptStatusSuccessful {
+		return common.Address{}, 0, fmt.Errorf("deployment transaction %s failed", tx.Hash().Hex())
 	}
+	return contractAddr, receipt.BlockNumber.Uint64(), nil
+}
 
-	// Wait for contract to mine.
-	for pending := true; pending; _, pending, err = web3.TransactionByHash(context.Background(), tx.Hash()) {
-		if err != nil {
-			t.Fatal(err)
+// waitForReceipt polls the eth1 node for the receipt of the transaction until it's mined.
+// RPC errors are retried, as the node may be busy, until the timeout is reached.
+func waitForReceipt(ctx context.Context, backend bind.DeployBackend, txHash common.Hash, timeout time.Duration) (*types.Receipt, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	var lastErr error
+	for {
+		receipt, err := backend.TransactionReceipt(ctx, txHash)
+		if err == nil {
+			return receipt, nil
+		}
+		if err != ethereum.NotFound {
+			lastErr = err
+		}
+		select {
+		case <-ctx.Done():
+			if lastErr != nil {
+				return nil, errors.Wrapf(lastErr, "no receipt for transaction %s", txHash.Hex())
+			}
+			return nil, fmt.Errorf("transaction %s was not mined within %v", txHash.Hex(), timeout)
+		case <-time.After(100 * time.Millisecond):
 		}
-		time.Sleep(100 * time.Millisecond)
 	}
-
-	return contractAddr, keystorePath, cmd.Process.Pid
 }
 
 func mineBlocks(web3 *ethclient.Client, keystore *keystore.Key, blocksToMake uint64) error {
@@ -0,0 +1,103 @@
+package endtoend
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// receiptBackend returns the queued errors from TransactionReceipt before returning the receipt.
+type receiptBackend struct {
+	errs    []error
+	receipt *types.Receipt
+}
+
+func (b *receiptBackend) TransactionReceipt(_ context.Context, _ common.Hash) (*types.Receipt, error) {
+	if len(b.errs) > 0 {
+		err := b.errs[0]
+		b.errs = b.errs[1:]
+		return nil, err
+	}
+	if b.receipt == nil {
+		return nil, ethereum.NotFound
+	}
+	return b.receipt, nil
+}
+
+func (b *receiptBackend) CodeAt(_ context.Context, _ common.Address, _ *big.Int) ([]byte, error) {
+	return nil, nil
+}
+
+func TestWaitForReceipt(t *testing.T) {
+	txHash := common.HexToHash("0x1234")
+	tests := []struct {
+		name     string
+		backend  *receiptBackend
+		errorMsg string
+	}{
+		{
+			name: "mined after transient errors",
+			backend: &receiptBackend{
+				errs:    []error{ethereum.NotFound, errors.New("connection refused"), ethereum.NotFound},
+				receipt: &types.Receipt{BlockNumber: big.NewInt(42)},
+			},
+		},
+		{
+			name:     "never mined",
+			backend:  &receiptBackend{},
+			errorMsg: "transaction " + txHash.Hex() + " was not mined",
+		},
+		{
+			name:     "node unreachable",
+			backend:  &receiptBackend{errs: []error{errors.New("connection refused")}},
+			errorMsg: "no receipt for transaction " + txHash.Hex() + ": connection refused",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			receipt, err := waitForReceipt(context.Background(), tt.backend, txHash, time.Second)
+			if tt.errorMsg != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.errorMsg) {
+					t.Errorf("Expected error containing %q, received %v", tt.errorMsg, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if receipt.BlockNumber.Uint64() != 42 {
+				t.Errorf("Expected receipt of block 42, received block %d", receipt.BlockNumber.Uint64())
+			}
+		})
+	}
+}
+
+func TestCheckEth1Endpoints_Unreachable(t *testing.T) {
+	err := checkEth1Endpoints(context.Background(), "http://127.0.0.1:1", "ws://127.0.0.1:1", common.Address{})
+	if err == nil || !strings.Contains(err.Error(), "eth1 node at http://127.0.0.1:1") {
+		t.Errorf("Expected error about the HTTP endpoint, received %v", err)
+	}
+}
+
+// TestCheckEth1Endpoints_External queries the deposit contract through a real eth1 node, such as an
+// Infura project. It only runs when E2E_ETH1_ENDPOINT, E2E_ETH1_WS_ENDPOINT and E2E_DEPOSIT_CONTRACT
+// are set.
+func TestCheckEth1Endpoints_External(t *testing.T) {
+	httpEndpoint := os.Getenv("E2E_ETH1_ENDPOINT")
+	wsEndpoint := os.Getenv("E2E_ETH1_WS_ENDPOINT")
+	contractAddr := os.Getenv("E2E_DEPOSIT_CONTRACT")
+	if httpEndpoint == "" || wsEndpoint == "" || contractAddr == "" {
+		t.Skip("E2E_ETH1_ENDPOINT, E2E_ETH1_WS_ENDPOINT and E2E_DEPOSIT_CONTRACT must be set to query an external eth1 node")
+	}
+	if err := checkEth1Endpoints(context.Background(), httpEndpoint, wsEndpoint, common.HexToAddress(contractAddr)); err != nil {
+		t.Fatal(err)
+	}
+}
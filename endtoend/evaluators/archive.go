@@ -0,0 +1,107 @@
+package evaluators
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/pkg/errors"
+	eth "github.com/prysmaticlabs/ethereumapis/eth/v1alpha1"
+	"github.com/prysmaticlabs/prysm/shared/params"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ArchivedCommitteesEvaluator returns an evaluator that ensures, from epoch 2 on, the beacon node
+// with the given index, started with --archive, serves the committees of the genesis epoch, and that
+// they are consistent with the genesis validators. The other nodes are expected to answer that they
+// don't hold the data. Epoch 0 is archived once the first block of epoch 1 is processed.
+func ArchivedCommitteesEvaluator(archiveNode int) Evaluator {
+	return Evaluator{
+		Name:   "archived_committees_epoch_%d",
+		Policy: AfterNthEpoch(1),
+		Evaluation: func(conns *NodeConns) error {
+			return committeesArchived(conns, archiveNode)
+		},
+	}
+}
+
+// committeesArchived checks the committees of epoch 0 served by every connected node.
+func committeesArchived(conns *NodeConns, archiveNode int) error {
+	genesisRequest := &eth.ListCommitteesRequest{QueryFilter: &eth.ListCommitteesRequest_Epoch{Epoch: 0}}
+	for _, index := range conns.sortedIndices() {
+		if index == archiveNode {
+			continue
+		}
+		client := eth.NewBeaconChainClient(conns.Conns[index])
+		_, err := client.ListBeaconCommittees(context.Background(), genesisRequest)
+		if err == nil {
+			return fmt.Errorf("beacon node %d served the committees of epoch 0 without --archive", index)
+		}
+		if status.Code(err) != codes.NotFound {
+			return errors.Wrapf(err, "failed to get committees of epoch 0 from beacon node %d", index)
+		}
+	}
+
+	conn, ok := conns.Conns[archiveNode]
+	if !ok {
+		return fmt.Errorf("no connection to archive node %d", archiveNode)
+	}
+	client := eth.NewBeaconChainClient(conn)
+	committees, err := client.ListBeaconCommittees(context.Background(), genesisRequest)
+	if err != nil {
+		return errors.Wrapf(err, "failed to get committees of epoch 0 from archive node %d", archiveNode)
+	}
+	var genesisValidators uint64
+	err = listValidators(client, func(_ uint64, item *eth.Validators_ValidatorContainer) {
+		if item.Validator.ActivationEpoch == 0 {
+			genesisValidators++
+		}
+	})
+	if err != nil {
+		return err
+	}
+	if err := committeesConsistent(committees, genesisValidators); err != nil {
+		return errors.Wrapf(err, "inconsistent committees of epoch 0 on archive node %d", archiveNode)
+	}
+	return nil
+}
+
+// committeesConsistent ensures the committees of epoch 0 cover each of its slots and assign every
+// active validator exactly once.
+func committeesConsistent(committees *eth.BeaconCommittees, activeValidators uint64) error {
+	if committees.Epoch != 0 {
+		return fmt.Errorf("received the committees of epoch %d", committees.Epoch)
+	}
+	if committees.ActiveValidatorCount != activeValidators {
+		return fmt.Errorf(
+			"%d active validators reported, expected the %d genesis validators",
+			committees.ActiveValidatorCount,
+			activeValidators,
+		)
+	}
+	slotsPerEpoch := params.BeaconConfig().SlotsPerEpoch
+	if uint64(len(committees.Committees)) != slotsPerEpoch {
+		return fmt.Errorf("received committees for %d slots, expected %d", len(committees.Committees), slotsPerEpoch)
+	}
+	assigned := make(map[uint64]uint64)
+	var total uint64
+	for slot := uint64(0); slot < slotsPerEpoch; slot++ {
+		slotCommittees, ok := committees.Committees[slot]
+		if !ok {
+			return fmt.Errorf("no committees for slot %d", slot)
+		}
+		for _, committee := range slotCommittees.Committees {
+			for _, index := range committee.ValidatorIndices {
+				if previous, ok := assigned[index]; ok {
+					return fmt.Errorf("validator %d is assigned to slots %d and %d", index, previous, slot)
+				}
+				assigned[index] = slot
+				total++
+			}
+		}
+	}
+	if total != activeValidators {
+		return fmt.Errorf("committees sum up to %d validators, expected %d", total, activeValidators)
+	}
+	return nil
+}
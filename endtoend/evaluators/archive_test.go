@@ -0,0 +1,165 @@
+package evaluators
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	eth "github.com/prysmaticlabs/ethereumapis/eth/v1alpha1"
+	"github.com/prysmaticlabs/prysm/shared/params"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// archiveServer serves the committees of epoch 0, or an error, along with validators activated at
+// the given epochs.
+type archiveServer struct {
+	eth.BeaconChainServer
+	committees       *eth.BeaconCommittees
+	err              error
+	activationEpochs []uint64
+}
+
+func (s *archiveServer) ListBeaconCommittees(_ context.Context, _ *eth.ListCommitteesRequest) (*eth.BeaconCommittees, error) {
+	return s.committees, s.err
+}
+
+func (s *archiveServer) ListValidators(_ context.Context, _ *eth.ListValidatorsRequest) (*eth.Validators, error) {
+	var list []*eth.Validators_ValidatorContainer
+	for i, epoch := range s.activationEpochs {
+		list = append(list, &eth.Validators_ValidatorContainer{
+			Index:     uint64(i),
+			Validator: &eth.Validator{ActivationEpoch: epoch, ExitEpoch: ^uint64(0)},
+		})
+	}
+	return &eth.Validators{Epoch: 3, ValidatorList: list}, nil
+}
+
+// genesisCommittees assigns the validators to the slots of epoch 0 in turn, one committee per slot.
+func genesisCommittees(validators uint64) *eth.BeaconCommittees {
+	slotsPerEpoch := params.BeaconConfig().SlotsPerEpoch
+	committees := make(map[uint64]*eth.BeaconCommittees_CommitteesList)
+	for slot := uint64(0); slot < slotsPerEpoch; slot++ {
+		committees[slot] = &eth.BeaconCommittees_CommitteesList{
+			Committees: []*eth.BeaconCommittees_CommitteeItem{{}},
+		}
+	}
+	for index := uint64(0); index < validators; index++ {
+		committee := committees[index%slotsPerEpoch].Committees[0]
+		committee.ValidatorIndices = append(committee.ValidatorIndices, index)
+	}
+	return &eth.BeaconCommittees{Committees: committees, ActiveValidatorCount: validators}
+}
+
+func TestArchivedCommitteesEvaluator(t *testing.T) {
+	notArchived := status.Error(codes.NotFound, "Could not retrieve data for epoch 0, perhaps --archive in the running beacon node is disabled")
+	// 64 genesis validators and 8 deposited later.
+	activationEpochs := make([]uint64, 72)
+	for i := 64; i < len(activationEpochs); i++ {
+		activationEpochs[i] = 2
+	}
+	// The committees of slot 3 are served as those of the first slot of epoch 1.
+	missingSlot := genesisCommittees(64)
+	missingSlot.Committees[params.BeaconConfig().SlotsPerEpoch] = missingSlot.Committees[3]
+	delete(missingSlot.Committees, 3)
+	duplicated := genesisCommittees(64)
+	duplicated.Committees[1].Committees[0].ValidatorIndices[0] = 0
+	missingValidator := genesisCommittees(64)
+	missingValidator.Committees[2].Committees[0].ValidatorIndices = missingValidator.Committees[2].Committees[0].ValidatorIndices[1:]
+	wrongEpoch := genesisCommittees(64)
+	wrongEpoch.Epoch = 2
+
+	tests := []struct {
+		name     string
+		archive  *archiveServer
+		other    *archiveServer
+		errorMsg string
+	}{
+		{
+			name:    "committees archived",
+			archive: &archiveServer{committees: genesisCommittees(64)},
+			other:   &archiveServer{err: notArchived},
+		},
+		{
+			name:     "not archived",
+			archive:  &archiveServer{err: notArchived},
+			other:    &archiveServer{err: notArchived},
+			errorMsg: "failed to get committees of epoch 0 from archive node 0",
+		},
+		{
+			name:     "served without archive",
+			archive:  &archiveServer{committees: genesisCommittees(64)},
+			other:    &archiveServer{committees: genesisCommittees(64)},
+			errorMsg: "beacon node 1 served the committees of epoch 0 without --archive",
+		},
+		{
+			name:     "unexpected error from other node",
+			archive:  &archiveServer{committees: genesisCommittees(64)},
+			other:    &archiveServer{err: status.Error(codes.Internal, "Could not request archival data for epoch 0")},
+			errorMsg: "failed to get committees of epoch 0 from beacon node 1",
+		},
+		{
+			name:     "wrong epoch",
+			archive:  &archiveServer{committees: wrongEpoch},
+			other:    &archiveServer{err: notArchived},
+			errorMsg: "received the committees of epoch 2",
+		},
+		{
+			name:     "active validator count differs",
+			archive:  &archiveServer{committees: genesisCommittees(72)},
+			other:    &archiveServer{err: notArchived},
+			errorMsg: "72 active validators reported, expected the 64 genesis validators",
+		},
+		{
+			name:     "slot missing",
+			archive:  &archiveServer{committees: missingSlot},
+			other:    &archiveServer{err: notArchived},
+			errorMsg: "no committees for slot 3",
+		},
+		{
+			name:     "validator assigned twice",
+			archive:  &archiveServer{committees: duplicated},
+			other:    &archiveServer{err: notArchived},
+			errorMsg: "validator 0 is assigned to slots 0 and 1",
+		},
+		{
+			name:     "validator missing",
+			archive:  &archiveServer{committees: missingValidator},
+			other:    &archiveServer{err: notArchived},
+			errorMsg: "inconsistent committees of epoch 0 on archive node 0: committees sum up to 63 validators, expected 64",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conns := &NodeConns{Evaluated: 1, Conns: make(map[int]*grpc.ClientConn)}
+			for i, server := range []*archiveServer{tt.archive, tt.other} {
+				server.activationEpochs = activationEpochs
+				node, stop := startBeaconChainServer(t, server)
+				defer stop()
+				conns.Conns[i] = node.Conns[0]
+			}
+
+			err := ArchivedCommitteesEvaluator(0).Evaluation(conns)
+			if tt.errorMsg == "" {
+				if err != nil {
+					t.Errorf("Unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.errorMsg) {
+				t.Errorf("Expected error containing %q, received %v", tt.errorMsg, err)
+			}
+		})
+	}
+}
+
+func TestArchivedCommitteesEvaluator_ArchiveNodeNotConnected(t *testing.T) {
+	node, stop := startBeaconChainServer(t, &archiveServer{err: status.Error(codes.NotFound, "not archived")})
+	defer stop()
+	conns := &NodeConns{Evaluated: 1, Conns: map[int]*grpc.ClientConn{1: node.Conns[0]}}
+	err := ArchivedCommitteesEvaluator(0).Evaluation(conns)
+	if err == nil || !strings.Contains(err.Error(), "no connection to archive node 0") {
+		t.Errorf("Expected archive node to be missing, received %v", err)
+	}
+}
@@ -0,0 +1,100 @@
+package evaluators
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/pkg/errors"
+	eth "github.com/prysmaticlabs/ethereumapis/eth/v1alpha1"
+)
+
+// DefaultAttestationInclusionRate is the share of the expected attestations of an epoch a healthy
+// local network includes in blocks by the middle of the following epoch.
+const DefaultAttestationInclusionRate = 0.9
+
+// AttestationInclusionEvaluator returns an evaluator that ensures, at every epoch, that at least
+// minRate of the attestations expected for the previous epoch were included in blocks. Every
+// member of a committee of the epoch is expected to attest once, except for the validators slashed
+// since, whose attestations may be missing or rejected.
+//
+// As for RewardAccountingEvaluator, the committees of an epoch are read while it's the current
+// epoch and kept for the next evaluation, older committees being only served by archive nodes. The
+// genesis epoch is not checked, as validators can't attest before the first block.
+func AttestationInclusionEvaluator(minRate float64) Evaluator {
+	committees := make(map[uint64]*eth.BeaconCommittees)
+	return Evaluator{
+		Name:   "attestation_inclusion_epoch_%d",
+		Policy: AllEpochs,
+		Evaluation: func(conns *NodeConns) error {
+			client := conns.BeaconChainClient()
+			current, err := client.ListBeaconCommittees(context.Background(), &eth.ListCommitteesRequest{})
+			if err != nil {
+				return errors.Wrap(err, "failed to get committees")
+			}
+			committees[current.Epoch] = current
+			if current.Epoch == 0 {
+				return nil
+			}
+			epoch := current.Epoch - 1
+			previous, ok := committees[epoch]
+			delete(committees, epoch)
+			if !ok || epoch == 0 {
+				return nil
+			}
+			attesters, err := listAttesters(client, epoch, previous)
+			if err != nil {
+				return err
+			}
+			slashed := make(map[uint64]bool)
+			err = listValidators(client, func(_ uint64, item *eth.Validators_ValidatorContainer) {
+				if item.Validator.Slashed {
+					slashed[item.Index] = true
+				}
+			})
+			if err != nil {
+				return err
+			}
+			return attestationsIncluded(epoch, previous, attesters, slashed, minRate)
+		},
+	}
+}
+
+// attestationsIncluded ensures the attesters make up at least minRate of the committee members of
+// the epoch that are not slashed.
+func attestationsIncluded(
+	epoch uint64,
+	committees *eth.BeaconCommittees,
+	attesters map[uint64]bool,
+	slashed map[uint64]bool,
+	minRate float64,
+) error {
+	var expected, included uint64
+	for _, slotCommittees := range committees.Committees {
+		for _, committee := range slotCommittees.Committees {
+			for _, index := range committee.ValidatorIndices {
+				if slashed[index] {
+					continue
+				}
+				expected++
+				if attesters[index] {
+					included++
+				}
+			}
+		}
+	}
+	if expected == 0 {
+		return fmt.Errorf("no attestations expected for epoch %d, every committee member is slashed", epoch)
+	}
+	rate := float64(included) / float64(expected)
+	if rate < minRate {
+		return fmt.Errorf(
+			"attestation inclusion rate of epoch %d is %.2f%%, below %.2f%%: %d of %d expected attestations included",
+			epoch,
+			rate*100,
+			minRate*100,
+			included,
+			expected,
+		)
+	}
+	return nil
+}
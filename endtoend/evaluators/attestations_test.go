@@ -0,0 +1,130 @@
+package evaluators
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	eth "github.com/prysmaticlabs/ethereumapis/eth/v1alpha1"
+)
+
+// attestationsServer serves 8 validators in two committees, at slots 0 and 1 of the current epoch,
+// along with the attestations included for the previous epoch.
+type attestationsServer struct {
+	eth.BeaconChainServer
+	epoch        uint64
+	attestations []*eth.Attestation
+	slashed      map[uint64]bool
+}
+
+func (s *attestationsServer) ListBeaconCommittees(_ context.Context, _ *eth.ListCommitteesRequest) (*eth.BeaconCommittees, error) {
+	return &eth.BeaconCommittees{
+		Epoch: s.epoch,
+		Committees: map[uint64]*eth.BeaconCommittees_CommitteesList{
+			0: {Committees: []*eth.BeaconCommittees_CommitteeItem{{ValidatorIndices: []uint64{0, 1, 2, 3}}}},
+			1: {Committees: []*eth.BeaconCommittees_CommitteeItem{{ValidatorIndices: []uint64{4, 5, 6, 7}}}},
+		},
+	}, nil
+}
+
+func (s *attestationsServer) ListAttestations(_ context.Context, _ *eth.ListAttestationsRequest) (*eth.ListAttestationsResponse, error) {
+	return &eth.ListAttestationsResponse{Attestations: s.attestations}, nil
+}
+
+func (s *attestationsServer) ListValidators(_ context.Context, _ *eth.ListValidatorsRequest) (*eth.Validators, error) {
+	var list []*eth.Validators_ValidatorContainer
+	for i := uint64(0); i < 8; i++ {
+		list = append(list, &eth.Validators_ValidatorContainer{
+			Index:     i,
+			Validator: &eth.Validator{Slashed: s.slashed[i], ExitEpoch: ^uint64(0)},
+		})
+	}
+	return &eth.Validators{Epoch: s.epoch, ValidatorList: list}, nil
+}
+
+func TestAttestationInclusionEvaluator(t *testing.T) {
+	// The aggregation bits are bitlists of the 4 committee members, the fifth bit marking the length.
+	attestation := func(slot uint64, bits byte) *eth.Attestation {
+		return &eth.Attestation{AggregationBits: []byte{bits}, Data: &eth.AttestationData{Slot: slot}}
+	}
+	tests := []struct {
+		name         string
+		epoch        uint64
+		attestations []*eth.Attestation
+		slashed      map[uint64]bool
+		errorMsg     string
+	}{
+		{
+			name:         "all attestations included",
+			epoch:        2,
+			attestations: []*eth.Attestation{attestation(0, 0x1f), attestation(1, 0x1f)},
+		},
+		{
+			name:         "attestation missing",
+			epoch:        2,
+			attestations: []*eth.Attestation{attestation(0, 0x17), attestation(1, 0x1f)},
+			errorMsg:     "attestation inclusion rate of epoch 2 is 87.50%, below 90.00%: 7 of 8 expected attestations included",
+		},
+		{
+			name:         "attestations split across aggregates",
+			epoch:        2,
+			attestations: []*eth.Attestation{attestation(0, 0x13), attestation(0, 0x1c), attestation(1, 0x1f)},
+		},
+		{
+			name:         "validator slashed mid-epoch without attesting",
+			epoch:        2,
+			attestations: []*eth.Attestation{attestation(0, 0x17), attestation(1, 0x1f)},
+			slashed:      map[uint64]bool{3: true},
+		},
+		{
+			name:         "slashed validator attestation not counted",
+			epoch:        2,
+			attestations: []*eth.Attestation{attestation(0, 0x1b), attestation(1, 0x1f)},
+			slashed:      map[uint64]bool{3: true},
+			errorMsg:     "attestation inclusion rate of epoch 2 is 85.71%, below 90.00%: 6 of 7 expected attestations included",
+		},
+		{
+			name:     "every validator slashed",
+			epoch:    2,
+			slashed:  map[uint64]bool{0: true, 1: true, 2: true, 3: true, 4: true, 5: true, 6: true, 7: true},
+			errorMsg: "no attestations expected for epoch 2, every committee member is slashed",
+		},
+		{
+			name:  "genesis epoch skipped",
+			epoch: 0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := &attestationsServer{epoch: tt.epoch, attestations: tt.attestations, slashed: tt.slashed}
+			conns, stop := startBeaconChainServer(t, server)
+			defer stop()
+
+			evaluator := AttestationInclusionEvaluator(DefaultAttestationInclusionRate)
+			// The committees of the epoch are read during the epoch, its attestations during the next one.
+			if err := evaluator.Evaluation(conns); err != nil {
+				t.Fatalf("Unexpected error reading the committees: %v", err)
+			}
+			server.epoch++
+			err := evaluator.Evaluation(conns)
+			if tt.errorMsg == "" {
+				if err != nil {
+					t.Errorf("Unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.errorMsg) {
+				t.Errorf("Expected error containing %q, received %v", tt.errorMsg, err)
+			}
+		})
+	}
+}
+
+func TestAttestationInclusionEvaluator_NoPreviousCommittees(t *testing.T) {
+	// Evaluations starting mid-run have no committees for the previous epoch, which is not checked.
+	conns, stop := startBeaconChainServer(t, &attestationsServer{epoch: 3})
+	defer stop()
+	if err := AttestationInclusionEvaluator(DefaultAttestationInclusionRate).Evaluation(conns); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}
@@ -0,0 +1,111 @@
+package evaluators
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	eth "github.com/prysmaticlabs/ethereumapis/eth/v1alpha1"
+)
+
+// worstBalancesReported is how many of the validators that lost the most are listed on failure.
+const worstBalancesReported = 5
+
+// ValidatorsGainBalance returns an evaluator that snapshots the balance of every validator the
+// first time it runs, then ensures the average balance of these validators has increased since.
+// Unless slashings are expected in the run, no validator may fall below its starting balance.
+func ValidatorsGainBalance(slashingEnabled bool) Evaluator {
+	var startBalances map[uint64]uint64
+	return Evaluator{
+		Name:   "validators_gain_balance_epoch_%d",
+		Policy: AfterNthEpoch(0),
+		Evaluation: func(conns *NodeConns) error {
+			balances, err := listBalances(conns.BeaconChainClient())
+			if err != nil {
+				return err
+			}
+			if startBalances == nil {
+				startBalances = balances
+				return nil
+			}
+			return balancesIncreased(startBalances, balances, slashingEnabled)
+		},
+	}
+}
+
+type balanceDelta struct {
+	index uint64
+	delta int64
+}
+
+func balancesIncreased(start map[uint64]uint64, current map[uint64]uint64, slashingEnabled bool) error {
+	var startTotal, currentTotal uint64
+	deltas := make([]balanceDelta, 0, len(start))
+	for index, startBalance := range start {
+		balance, ok := current[index]
+		if !ok {
+			return fmt.Errorf("no balance for validator %d", index)
+		}
+		startTotal += startBalance
+		currentTotal += balance
+		deltas = append(deltas, balanceDelta{index: index, delta: int64(balance) - int64(startBalance)})
+	}
+	sort.Slice(deltas, func(i, j int) bool {
+		if deltas[i].delta == deltas[j].delta {
+			return deltas[i].index < deltas[j].index
+		}
+		return deltas[i].delta < deltas[j].delta
+	})
+	worst := deltas
+	if len(worst) > worstBalancesReported {
+		worst = worst[:worstBalancesReported]
+	}
+	var worstViews []string
+	for _, d := range worst {
+		worstViews = append(worstViews, fmt.Sprintf("validator %d: %+d gwei", d.index, d.delta))
+	}
+
+	if currentTotal <= startTotal {
+		return fmt.Errorf(
+			"average balance did not increase, was %d gwei and is %d gwei, worst validators: %s",
+			startTotal/uint64(len(start)),
+			currentTotal/uint64(len(start)),
+			strings.Join(worstViews, ", "),
+		)
+	}
+	if !slashingEnabled && len(deltas) > 0 && deltas[0].delta < 0 {
+		var penalized int
+		for _, d := range deltas {
+			if d.delta < 0 {
+				penalized++
+			}
+		}
+		return fmt.Errorf(
+			"%d validators fell below their starting balance, worst validators: %s",
+			penalized,
+			strings.Join(worstViews, ", "),
+		)
+	}
+	return nil
+}
+
+// listBalances pages through the balances of all the validators, keyed by validator index.
+func listBalances(client eth.BeaconChainClient) (map[uint64]uint64, error) {
+	balances := make(map[uint64]uint64)
+	req := &eth.ListValidatorBalancesRequest{}
+	for {
+		res, err := client.ListValidatorBalances(context.Background(), req)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to get validator balances")
+		}
+		for _, balance := range res.Balances {
+			balances[balance.Index] = balance.Balance
+		}
+		if res.NextPageToken == "" {
+			return balances, nil
+		}
+		req.PageToken = res.NextPageToken
+	}
+}
@@ -0,0 +1,119 @@
+package evaluators
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"testing"
+
+	eth "github.com/prysmaticlabs/ethereumapis/eth/v1alpha1"
+)
+
+// balancesServer serves the balances of the validators, split into pages of pageSize.
+type balancesServer struct {
+	eth.BeaconChainServer
+	balances []uint64
+	pageSize int
+}
+
+func (s *balancesServer) ListValidatorBalances(
+	_ context.Context, req *eth.ListValidatorBalancesRequest,
+) (*eth.ValidatorBalances, error) {
+	start := 0
+	if req.PageToken != "" {
+		page, err := strconv.Atoi(req.PageToken)
+		if err != nil {
+			return nil, err
+		}
+		start = page * s.pageSize
+	}
+	end := start + s.pageSize
+	nextPageToken := strconv.Itoa(start/s.pageSize + 1)
+	if end >= len(s.balances) {
+		end = len(s.balances)
+		nextPageToken = ""
+	}
+	res := &eth.ValidatorBalances{NextPageToken: nextPageToken}
+	for i := start; i < end; i++ {
+		res.Balances = append(res.Balances, &eth.ValidatorBalances_Balance{Index: uint64(i), Balance: s.balances[i]})
+	}
+	return res, nil
+}
+
+func uniformBalances(n int, balance uint64) []uint64 {
+	balances := make([]uint64, n)
+	for i := range balances {
+		balances[i] = balance
+	}
+	return balances
+}
+
+func TestValidatorsGainBalance(t *testing.T) {
+	const start = 32000000000
+	tests := []struct {
+		name            string
+		modify          func(balances []uint64) []uint64
+		slashingEnabled bool
+		errorMsg        string
+	}{
+		{
+			name:   "all validators rewarded",
+			modify: func(b []uint64) []uint64 { return uniformBalances(len(b), start+1000) },
+		},
+		{
+			name: "new validators are ignored",
+			modify: func(b []uint64) []uint64 {
+				return append(uniformBalances(len(b), start+1000), start-1000)
+			},
+		},
+		{
+			name:     "no rewards",
+			modify:   func(b []uint64) []uint64 { return b },
+			errorMsg: "average balance did not increase, was 32000000000 gwei and is 32000000000 gwei",
+		},
+		{
+			name: "validators penalized",
+			modify: func(b []uint64) []uint64 {
+				b = uniformBalances(len(b), start+1000)
+				for i, penalty := range []uint64{500, 3000, 2000, 100, 4000, 1000} {
+					b[10*i] = start - penalty
+				}
+				return b
+			},
+			errorMsg: "6 validators fell below their starting balance, worst validators: validator 40: -4000 gwei, " +
+				"validator 10: -3000 gwei, validator 20: -2000 gwei, validator 50: -1000 gwei, validator 0: -500 gwei",
+		},
+		{
+			name: "validators penalized with slashing enabled",
+			modify: func(b []uint64) []uint64 {
+				b = uniformBalances(len(b), start+1000)
+				b[3] = start - 10000
+				return b
+			},
+			slashingEnabled: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := &balancesServer{balances: uniformBalances(64, start), pageSize: 30}
+			conns, stop := startBeaconChainServer(t, server)
+			defer stop()
+
+			evaluator := ValidatorsGainBalance(tt.slashingEnabled)
+			if err := evaluator.Evaluation(conns); err != nil {
+				t.Fatalf("Unexpected error taking the snapshot: %v", err)
+			}
+			server.balances = tt.modify(server.balances)
+			err := evaluator.Evaluation(conns)
+			if tt.errorMsg == "" {
+				if err != nil {
+					t.Errorf("Unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.errorMsg) {
+				t.Errorf("Expected error containing %q, received %v", tt.errorMsg, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,165 @@
+package evaluators
+
+import (
+	"context"
+	"fmt"
+
+	ptypes "github.com/gogo/protobuf/types"
+	"github.com/pkg/errors"
+	eth "github.com/prysmaticlabs/ethereumapis/eth/v1alpha1"
+	"github.com/prysmaticlabs/prysm/shared/interop"
+	"github.com/prysmaticlabs/prysm/shared/params"
+)
+
+// depositFinalityDelay is how many epochs the finalized epoch is behind the head in a healthy chain.
+const depositFinalityDelay = 2
+
+// DepositsProcessed returns an evaluator that follows numDeposits validators deposited at
+// depositEpoch through the activation queue. The active validator count must never drop, and the
+// deposited validators must all be in the registry, pending or active, once the eth1 data
+// including their deposits has been voted in. Once the queue had time to activate them, every
+// deposited key must have an activation epoch at or before the head epoch and be reported active.
+func DepositsProcessed(depositEpoch uint64, numGenesisValidators uint64, numDeposits uint64) Evaluator {
+	cfg := params.BeaconConfig()
+	votingPeriodEpochs := cfg.SlotsPerEth1VotingPeriod / cfg.SlotsPerEpoch
+	// Deposits are included once a voting period following the deposit has completed.
+	inclusionEpoch := depositEpoch + votingPeriodEpochs + 1
+	// They are activated once their inclusion is finalized, after the seed lookahead, at most
+	// MinPerEpochChurnLimit per epoch.
+	churnEpochs := (numDeposits + cfg.MinPerEpochChurnLimit - 1) / cfg.MinPerEpochChurnLimit
+	activationEpoch := inclusionEpoch + depositFinalityDelay + 1 + cfg.MaxSeedLookahead + churnEpochs
+	var lastActive uint64
+	var depositedKeys [][]byte
+	return Evaluator{
+		Name:             "deposits_processed_epoch_%d",
+		Policy:           AfterNthEpoch(depositEpoch),
+		RequiresDeposits: true,
+		Evaluation: func(conns *NodeConns) error {
+			client := conns.BeaconChainClient()
+			chainHead, err := client.GetChainHead(context.Background(), &ptypes.Empty{})
+			if err != nil {
+				return errors.Wrap(err, "failed to get chain head")
+			}
+			var active, pending uint64
+			registry := make(map[string]*eth.Validators_ValidatorContainer)
+			err = listValidators(client, func(_ uint64, item *eth.Validators_ValidatorContainer) {
+				if item.Validator.ActivationEpoch <= chainHead.HeadEpoch {
+					active++
+				} else {
+					pending++
+				}
+				registry[string(item.Validator.PublicKey)] = item
+			})
+			if err != nil {
+				return err
+			}
+			expected := numGenesisValidators + numDeposits
+			if active+pending > expected {
+				return fmt.Errorf("expected at most %d validators, received %d active and %d pending", expected, active, pending)
+			}
+			if active < lastActive {
+				return fmt.Errorf("active validator count dropped from %d to %d", lastActive, active)
+			}
+			lastActive = active
+			if chainHead.HeadEpoch < inclusionEpoch {
+				return nil
+			}
+			if active+pending != expected {
+				return fmt.Errorf(
+					"expected the %d deposited validators to be included by epoch %d, received %d active and %d pending",
+					numDeposits,
+					inclusionEpoch,
+					active,
+					pending,
+				)
+			}
+			if depositedKeys == nil {
+				if depositedKeys, err = interopPublicKeys(numGenesisValidators, numDeposits); err != nil {
+					return err
+				}
+			}
+			for _, key := range depositedKeys {
+				if _, ok := registry[string(key)]; !ok {
+					return fmt.Errorf("deposited validator %#x is not in the registry", key)
+				}
+			}
+			if chainHead.HeadEpoch < activationEpoch {
+				return nil
+			}
+			validatorClient := eth.NewBeaconNodeValidatorClient(conns.Conns[conns.Evaluated])
+			for _, key := range depositedKeys {
+				item := registry[string(key)]
+				if item.Validator.ActivationEpoch > chainHead.HeadEpoch {
+					return fmt.Errorf(
+						"expected deposited validator %d to be activated by epoch %d, activation epoch is %d",
+						item.Index,
+						activationEpoch,
+						item.Validator.ActivationEpoch,
+					)
+				}
+				status, err := validatorClient.ValidatorStatus(context.Background(), &eth.ValidatorStatusRequest{PublicKey: key})
+				if err != nil {
+					return errors.Wrapf(err, "failed to get status of validator %d", item.Index)
+				}
+				if status.Status != eth.ValidatorStatus_ACTIVE {
+					return fmt.Errorf("expected deposited validator %d to be reported active, received %s", item.Index, status.Status)
+				}
+			}
+			return nil
+		},
+	}
+}
+
+// interopPublicKeys returns the public keys of numKeys interop validators, from startIndex.
+func interopPublicKeys(startIndex uint64, numKeys uint64) ([][]byte, error) {
+	_, pubKeys, err := interop.DeterministicallyGenerateKeys(startIndex, numKeys)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not generate the keys of the deposited validators")
+	}
+	keys := make([][]byte, len(pubKeys))
+	for i, pubKey := range pubKeys {
+		keys[i] = pubKey.Marshal()
+	}
+	return keys, nil
+}
+
+// ActiveValidatorsGrow returns an evaluator that ensures the active validator count never drops
+// from one epoch to the next, for validators deposited gradually while the chain runs.
+func ActiveValidatorsGrow() Evaluator {
+	var lastActive uint64
+	return Evaluator{
+		Name:             "active_validators_grow_epoch_%d",
+		Policy:           AfterNthEpoch(0),
+		RequiresDeposits: true,
+		Evaluation: func(conns *NodeConns) error {
+			client := conns.BeaconChainClient()
+			chainHead, err := client.GetChainHead(context.Background(), &ptypes.Empty{})
+			if err != nil {
+				return errors.Wrap(err, "failed to get chain head")
+			}
+			active, pending, err := countValidators(client, chainHead.HeadEpoch)
+			if err != nil {
+				return err
+			}
+			if active < lastActive {
+				return fmt.Errorf("active validator count dropped from %d to %d with %d pending", lastActive, active, pending)
+			}
+			lastActive = active
+			return nil
+		},
+	}
+}
+
+// countValidators returns how many validators of the registry are active at the epoch,
+// and how many are still waiting to be activated.
+func countValidators(client eth.BeaconChainClient, epoch uint64) (uint64, uint64, error) {
+	var active, pending uint64
+	err := listValidators(client, func(_ uint64, item *eth.Validators_ValidatorContainer) {
+		if item.Validator.ActivationEpoch <= epoch {
+			active++
+		} else {
+			pending++
+		}
+	})
+	return active, pending, err
+}
@@ -0,0 +1,250 @@
+package evaluators
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"testing"
+
+	ptypes "github.com/gogo/protobuf/types"
+	eth "github.com/prysmaticlabs/ethereumapis/eth/v1alpha1"
+	"github.com/prysmaticlabs/prysm/shared/params"
+	"google.golang.org/grpc"
+)
+
+// registryServer serves a fixed chain head and validator registry, split into pages of pageSize.
+// The validators activated by the head epoch are reported active, unless notActive is set.
+type registryServer struct {
+	eth.BeaconChainServer
+	eth.BeaconNodeValidatorServer
+	headEpoch  uint64
+	validators []*eth.Validators_ValidatorContainer
+	pageSize   int
+	notActive  bool
+}
+
+func (c *registryServer) GetChainHead(_ context.Context, _ *ptypes.Empty) (*eth.ChainHead, error) {
+	return &eth.ChainHead{HeadEpoch: c.headEpoch}, nil
+}
+
+func (c *registryServer) ListValidators(_ context.Context, req *eth.ListValidatorsRequest) (*eth.Validators, error) {
+	start := 0
+	if req.PageToken != "" {
+		page, err := strconv.Atoi(req.PageToken)
+		if err != nil {
+			return nil, err
+		}
+		start = page * c.pageSize
+	}
+	end := start + c.pageSize
+	nextPageToken := strconv.Itoa(start/c.pageSize + 1)
+	if end >= len(c.validators) {
+		end = len(c.validators)
+		nextPageToken = ""
+	}
+	return &eth.Validators{ValidatorList: c.validators[start:end], NextPageToken: nextPageToken}, nil
+}
+
+func (c *registryServer) GetValidator(_ context.Context, req *eth.GetValidatorRequest) (*eth.Validator, error) {
+	index := req.GetIndex()
+	if index >= uint64(len(c.validators)) {
+		return nil, fmt.Errorf("no validator at index %d", index)
+	}
+	return c.validators[index].Validator, nil
+}
+
+func (c *registryServer) ValidatorStatus(_ context.Context, req *eth.ValidatorStatusRequest) (*eth.ValidatorStatusResponse, error) {
+	for _, item := range c.validators {
+		if !bytes.Equal(item.Validator.PublicKey, req.PublicKey) {
+			continue
+		}
+		if c.notActive || item.Validator.ActivationEpoch > c.headEpoch {
+			return &eth.ValidatorStatusResponse{Status: eth.ValidatorStatus_PENDING_ACTIVE}, nil
+		}
+		return &eth.ValidatorStatusResponse{Status: eth.ValidatorStatus_ACTIVE}, nil
+	}
+	return &eth.ValidatorStatusResponse{Status: eth.ValidatorStatus_UNKNOWN_STATUS}, nil
+}
+
+// startRegistryServer serves the beacon chain and validator APIs of the registry server.
+func startRegistryServer(t *testing.T, registryServer *registryServer) (*NodeConns, func()) {
+	conn, stop := startServer(t, func(server *grpc.Server) {
+		eth.RegisterBeaconChainServer(server, registryServer)
+		eth.RegisterBeaconNodeValidatorServer(server, registryServer)
+	})
+	return &NodeConns{Conns: map[int]*grpc.ClientConn{0: conn}}, stop
+}
+
+func registry(active uint64, pending uint64) []*eth.Validators_ValidatorContainer {
+	var validators []*eth.Validators_ValidatorContainer
+	for i := uint64(0); i < active+pending; i++ {
+		activationEpoch := uint64(0)
+		if i >= active {
+			activationEpoch = params.BeaconConfig().FarFutureEpoch
+		}
+		validators = append(validators, &eth.Validators_ValidatorContainer{
+			Index:     i,
+			Validator: &eth.Validator{ActivationEpoch: activationEpoch},
+		})
+	}
+	return validators
+}
+
+func TestDepositsProcessed(t *testing.T) {
+	// Deposits at epoch 1 are expected to be included by epoch 4 and activated by epoch 13 with the
+	// minimal config.
+	params.UseMinimalConfig()
+	defer params.UseMainnetConfig()
+	keys, err := interopPublicKeys(0, 72)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		epochs    []uint64
+		active    []uint64
+		pending   []uint64
+		otherKey  bool
+		notActive bool
+		errorMsg  string
+	}{
+		{
+			name:    "deposits enter the queue",
+			epochs:  []uint64{2, 3, 4},
+			active:  []uint64{64, 64, 64},
+			pending: []uint64{0, 0, 8},
+		},
+		{
+			name:     "deposits not included",
+			epochs:   []uint64{2, 3, 4},
+			active:   []uint64{64, 64, 64},
+			pending:  []uint64{0, 0, 0},
+			errorMsg: "expected the 8 deposited validators to be included by epoch 4",
+		},
+		{
+			name:    "deposits activated",
+			epochs:  []uint64{4, 12, 13},
+			active:  []uint64{64, 68, 72},
+			pending: []uint64{8, 4, 0},
+		},
+		{
+			name:     "deposits not activated",
+			epochs:   []uint64{4, 13},
+			active:   []uint64{64, 68},
+			pending:  []uint64{8, 4},
+			errorMsg: "expected deposited validator 68 to be activated by epoch 13",
+		},
+		{
+			name:      "deposits not reported active",
+			epochs:    []uint64{4, 13},
+			active:    []uint64{64, 72},
+			pending:   []uint64{8, 0},
+			notActive: true,
+			errorMsg:  "expected deposited validator 64 to be reported active, received PENDING_ACTIVE",
+		},
+		{
+			name:     "deposit of another key",
+			epochs:   []uint64{4},
+			active:   []uint64{64},
+			pending:  []uint64{8},
+			otherKey: true,
+			errorMsg: "is not in the registry",
+		},
+		{
+			name:     "too many validators",
+			epochs:   []uint64{2},
+			active:   []uint64{64},
+			pending:  []uint64{9},
+			errorMsg: "expected at most 72 validators",
+		},
+		{
+			name:     "active validators drop",
+			epochs:   []uint64{2, 3},
+			active:   []uint64{64, 63},
+			pending:  []uint64{0, 0},
+			errorMsg: "active validator count dropped from 64 to 63",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := &registryServer{pageSize: 30, notActive: tt.notActive}
+			conns, stop := startRegistryServer(t, server)
+			defer stop()
+
+			evaluator := DepositsProcessed(1, 64, 8)
+			var err error
+			for i, epoch := range tt.epochs {
+				server.headEpoch = epoch
+				server.validators = registry(tt.active[i], tt.pending[i])
+				for j, item := range server.validators {
+					item.Validator.PublicKey = keys[j%len(keys)]
+				}
+				if tt.otherKey {
+					server.validators[70].Validator.PublicKey = keys[0]
+				}
+				if err = evaluator.Evaluation(conns); err != nil {
+					break
+				}
+			}
+			if tt.errorMsg == "" {
+				if err != nil {
+					t.Errorf("Unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.errorMsg) {
+				t.Errorf("Expected error containing %q, received %v", tt.errorMsg, err)
+			}
+		})
+	}
+}
+
+func TestActiveValidatorsGrow(t *testing.T) {
+	tests := []struct {
+		name     string
+		active   []uint64
+		pending  []uint64
+		errorMsg string
+	}{
+		{
+			name:    "validators activated gradually",
+			active:  []uint64{64, 64, 72, 80},
+			pending: []uint64{16, 16, 8, 0},
+		},
+		{
+			name:     "active validators drop",
+			active:   []uint64{64, 72, 70},
+			pending:  []uint64{16, 8, 8},
+			errorMsg: "active validator count dropped from 72 to 70 with 8 pending",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := &registryServer{pageSize: 30}
+			conns, stop := startBeaconChainServer(t, server)
+			defer stop()
+
+			evaluator := ActiveValidatorsGrow()
+			var err error
+			for i := range tt.active {
+				server.headEpoch = uint64(i)
+				server.validators = registry(tt.active[i], tt.pending[i])
+				if err = evaluator.Evaluation(conns); err != nil {
+					break
+				}
+			}
+			if tt.errorMsg == "" {
+				if err != nil {
+					t.Errorf("Unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.errorMsg) {
+				t.Errorf("Expected error containing %q, received %v", tt.errorMsg, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,30 @@
+package evaluators
+
+import "fmt"
+
+// EvaluatorError is the error of a failed evaluation, telling which evaluator failed, at which epoch
+// and on which beacon node. Evaluators checking every node return one naming the failing node
+// through nodeError, the E2E fills in the evaluator and the epoch.
+type EvaluatorError struct {
+	EpochNumber   uint64
+	EvaluatorName string
+	NodeIndex     int
+	Cause         error
+}
+
+func (e *EvaluatorError) Error() string {
+	if e.EvaluatorName == "" {
+		return fmt.Sprintf("beacon node %d: %v", e.NodeIndex, e.Cause)
+	}
+	return fmt.Sprintf("%s failed at epoch %d on beacon node %d: %v", e.EvaluatorName, e.EpochNumber, e.NodeIndex, e.Cause)
+}
+
+// Unwrap returns the cause, so errors.Is and errors.As see through the error.
+func (e *EvaluatorError) Unwrap() error {
+	return e.Cause
+}
+
+// nodeError attributes the error to the beacon node with the given index.
+func nodeError(index int, err error) error {
+	return &EvaluatorError{NodeIndex: index, Cause: err}
+}
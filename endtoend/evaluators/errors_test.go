@@ -0,0 +1,48 @@
+package evaluators
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestEvaluatorError(t *testing.T) {
+	cause := errors.New("head slot did not reach 12")
+	tests := []struct {
+		name string
+		err  *EvaluatorError
+		want string
+	}{
+		{
+			name: "returned by an evaluator",
+			err:  &EvaluatorError{NodeIndex: 1, Cause: cause},
+			want: "beacon node 1: head slot did not reach 12",
+		},
+		{
+			name: "attributed by the E2E",
+			err:  &EvaluatorError{EpochNumber: 3, EvaluatorName: "nodes_agree_on_head_epoch_3", NodeIndex: 1, Cause: cause},
+			want: "nodes_agree_on_head_epoch_3 failed at epoch 3 on beacon node 1: head slot did not reach 12",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.err.Error() != tt.want {
+				t.Errorf("Expected %q, received %q", tt.want, tt.err.Error())
+			}
+			if !errors.Is(tt.err, cause) {
+				t.Error("Expected the cause to be unwrapped")
+			}
+		})
+	}
+}
+
+func TestNodeError(t *testing.T) {
+	err := nodeError(2, errors.New("validators with an unexpected public key: [5]"))
+	var evalErr *EvaluatorError
+	if !errors.As(err, &evalErr) || evalErr.NodeIndex != 2 {
+		t.Fatalf("Expected an evaluator error of node 2, received %v", err)
+	}
+	if !strings.Contains(err.Error(), "beacon node 2: validators with an unexpected public key") {
+		t.Errorf("Unexpected error message %q", err.Error())
+	}
+}
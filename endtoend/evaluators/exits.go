@@ -0,0 +1,143 @@
+package evaluators
+
+import (
+	"context"
+	"fmt"
+
+	ptypes "github.com/gogo/protobuf/types"
+	"github.com/pkg/errors"
+	eth "github.com/prysmaticlabs/ethereumapis/eth/v1alpha1"
+	"github.com/prysmaticlabs/go-ssz"
+	"github.com/prysmaticlabs/prysm/shared/interop"
+	"github.com/prysmaticlabs/prysm/shared/params"
+)
+
+// exitFinalizationEpochs is how many epochs a voluntary exit is given to be processed and finalized
+// once it's submitted.
+const exitFinalizationEpochs = 3
+
+// VoluntaryExitEvaluator returns an evaluator that submits a voluntary exit of the interop validator
+// at the given index at epoch, through the ProposeExit RPC validator clients use, then ensures the
+// exit is finalized within 3 epochs and that the balance of the validator stops increasing once it
+// exited.
+func VoluntaryExitEvaluator(validatorIndex uint64, epoch uint64) Evaluator {
+	exit := &voluntaryExit{validatorIndex: validatorIndex}
+	return Evaluator{
+		Name: "voluntary_exit_epoch_%d",
+		Policy: func(currentEpoch uint64) bool {
+			return currentEpoch >= epoch
+		},
+		Evaluation: func(conns *NodeConns) error {
+			return exit.evaluate(conns)
+		},
+	}
+}
+
+// voluntaryExit follows the exit of a validator across the epochs of the E2E.
+type voluntaryExit struct {
+	validatorIndex uint64
+	submitted      bool
+	submittedAt    uint64
+	processed      bool
+	// processedAt is the first head epoch the exit epoch of the validator was set at.
+	processedAt     uint64
+	previousBalance uint64
+}
+
+func (e *voluntaryExit) evaluate(conns *NodeConns) error {
+	ctx := context.Background()
+	client := conns.BeaconChainClient()
+	head, err := client.GetChainHead(ctx, &ptypes.Empty{})
+	if err != nil {
+		return errors.Wrap(err, "failed to get chain head")
+	}
+	if !e.submitted {
+		if err := proposeExit(ctx, eth.NewBeaconNodeValidatorClient(conns.Conns[conns.Evaluated]), e.validatorIndex, head.HeadEpoch); err != nil {
+			return err
+		}
+		e.submitted = true
+		e.submittedAt = head.HeadEpoch
+		return nil
+	}
+
+	validator, err := client.GetValidator(ctx, &eth.GetValidatorRequest{
+		QueryFilter: &eth.GetValidatorRequest_Index{Index: e.validatorIndex},
+	})
+	if err != nil {
+		return errors.Wrapf(err, "failed to get validator %d", e.validatorIndex)
+	}
+	deadline := e.submittedAt + exitFinalizationEpochs
+	if validator.ExitEpoch == params.BeaconConfig().FarFutureEpoch {
+		if head.HeadEpoch >= deadline {
+			return fmt.Errorf("exit of validator %d submitted at epoch %d was not processed by epoch %d", e.validatorIndex, e.submittedAt, head.HeadEpoch)
+		}
+		return nil
+	}
+	if !e.processed {
+		e.processed = true
+		e.processedAt = head.HeadEpoch
+	}
+	if head.FinalizedEpoch < e.processedAt && head.HeadEpoch >= deadline {
+		return fmt.Errorf(
+			"exit of validator %d processed at epoch %d was not finalized by epoch %d, finalized epoch is %d",
+			e.validatorIndex,
+			e.processedAt,
+			head.HeadEpoch,
+			head.FinalizedEpoch,
+		)
+	}
+	if head.HeadEpoch < validator.ExitEpoch {
+		return nil
+	}
+
+	res, err := client.ListValidatorBalances(ctx, &eth.ListValidatorBalancesRequest{
+		Indices: []uint64{e.validatorIndex},
+	})
+	if err != nil {
+		return errors.Wrapf(err, "failed to get balance of validator %d", e.validatorIndex)
+	}
+	if len(res.Balances) != 1 {
+		return fmt.Errorf("expected 1 balance for validator %d, received %d", e.validatorIndex, len(res.Balances))
+	}
+	balance := res.Balances[0].Balance
+	if e.previousBalance != 0 && balance > e.previousBalance {
+		return fmt.Errorf(
+			"balance of validator %d increased after it exited at epoch %d, was %d gwei and is %d gwei",
+			e.validatorIndex,
+			validator.ExitEpoch,
+			e.previousBalance,
+			balance,
+		)
+	}
+	e.previousBalance = balance
+	return nil
+}
+
+// proposeExit signs a voluntary exit of the interop validator at the given index for the epoch and
+// submits it to the beacon node.
+func proposeExit(ctx context.Context, client eth.BeaconNodeValidatorClient, validatorIndex uint64, epoch uint64) error {
+	keys, _, err := interop.DeterministicallyGenerateKeys(validatorIndex, 1)
+	if err != nil {
+		return errors.Wrapf(err, "could not generate the key of validator %d", validatorIndex)
+	}
+	domain, err := client.DomainData(ctx, &eth.DomainRequest{
+		Epoch:  epoch,
+		Domain: params.BeaconConfig().DomainVoluntaryExit,
+	})
+	if err != nil {
+		return errors.Wrap(err, "failed to get voluntary exit domain")
+	}
+	exit := &eth.VoluntaryExit{Epoch: epoch, ValidatorIndex: validatorIndex}
+	root, err := ssz.HashTreeRoot(exit)
+	if err != nil {
+		return errors.Wrap(err, "could not compute voluntary exit root")
+	}
+	signed := &eth.SignedVoluntaryExit{
+		Exit:      exit,
+		Signature: keys[0].Sign(root[:], domain.SignatureDomain).Marshal(),
+	}
+	if _, err := client.ProposeExit(ctx, signed); err != nil {
+		return errors.Wrapf(err, "failed to propose exit of validator %d", validatorIndex)
+	}
+	return nil
+}
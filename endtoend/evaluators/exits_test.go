@@ -0,0 +1,190 @@
+package evaluators
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	ptypes "github.com/gogo/protobuf/types"
+	eth "github.com/prysmaticlabs/ethereumapis/eth/v1alpha1"
+	"github.com/prysmaticlabs/go-ssz"
+	"github.com/prysmaticlabs/prysm/shared/bls"
+	"github.com/prysmaticlabs/prysm/shared/interop"
+	"github.com/prysmaticlabs/prysm/shared/params"
+	"google.golang.org/grpc"
+)
+
+const testExitDomain = 7
+
+// exitEpochState is what the node serves during an epoch of the E2E.
+type exitEpochState struct {
+	headEpoch      uint64
+	finalizedEpoch uint64
+	exitEpoch      uint64
+	balance        uint64
+}
+
+// exitServer serves the validator through the given epochs, moving on to the next one every time
+// the chain head is requested, and records the exits proposed to it.
+type exitServer struct {
+	eth.BeaconChainServer
+	eth.BeaconNodeValidatorServer
+	epochs   []exitEpochState
+	current  exitEpochState
+	exits    []*eth.SignedVoluntaryExit
+	rejected bool
+}
+
+func (s *exitServer) GetChainHead(_ context.Context, _ *ptypes.Empty) (*eth.ChainHead, error) {
+	s.current = s.epochs[0]
+	s.epochs = s.epochs[1:]
+	return &eth.ChainHead{HeadEpoch: s.current.headEpoch, FinalizedEpoch: s.current.finalizedEpoch}, nil
+}
+
+func (s *exitServer) GetValidator(_ context.Context, _ *eth.GetValidatorRequest) (*eth.Validator, error) {
+	return &eth.Validator{ExitEpoch: s.current.exitEpoch}, nil
+}
+
+func (s *exitServer) ListValidatorBalances(_ context.Context, req *eth.ListValidatorBalancesRequest) (*eth.ValidatorBalances, error) {
+	return &eth.ValidatorBalances{
+		Balances: []*eth.ValidatorBalances_Balance{{Index: req.Indices[0], Balance: s.current.balance}},
+	}, nil
+}
+
+func (s *exitServer) DomainData(_ context.Context, _ *eth.DomainRequest) (*eth.DomainResponse, error) {
+	return &eth.DomainResponse{SignatureDomain: testExitDomain}, nil
+}
+
+func (s *exitServer) ProposeExit(_ context.Context, exit *eth.SignedVoluntaryExit) (*ptypes.Empty, error) {
+	if s.rejected {
+		return nil, errors.New("validator 5 cannot exit before epoch 2048")
+	}
+	s.exits = append(s.exits, exit)
+	return &ptypes.Empty{}, nil
+}
+
+// startExitServer serves the beacon chain and validator APIs, standing in for a beacon node.
+func startExitServer(t *testing.T, exitServer *exitServer) (*NodeConns, func()) {
+	conn, stop := startServer(t, func(server *grpc.Server) {
+		eth.RegisterBeaconChainServer(server, exitServer)
+		eth.RegisterBeaconNodeValidatorServer(server, exitServer)
+	})
+	return &NodeConns{Conns: map[int]*grpc.ClientConn{0: conn}}, stop
+}
+
+func TestVoluntaryExitEvaluator(t *testing.T) {
+	farFuture := params.BeaconConfig().FarFutureEpoch
+	tests := []struct {
+		name     string
+		epochs   []exitEpochState
+		rejected bool
+		errorMsg string
+	}{
+		{
+			name: "exit finalized and balance not increasing",
+			epochs: []exitEpochState{
+				{headEpoch: 4, finalizedEpoch: 2, exitEpoch: farFuture},
+				{headEpoch: 5, finalizedEpoch: 3, exitEpoch: 10},
+				{headEpoch: 6, finalizedEpoch: 4, exitEpoch: 10},
+				{headEpoch: 7, finalizedEpoch: 5, exitEpoch: 10},
+				{headEpoch: 10, finalizedEpoch: 8, exitEpoch: 10, balance: 32e9},
+				{headEpoch: 11, finalizedEpoch: 9, exitEpoch: 10, balance: 32e9},
+			},
+		},
+		{
+			name:     "exit rejected",
+			epochs:   []exitEpochState{{headEpoch: 4, finalizedEpoch: 2, exitEpoch: farFuture}},
+			rejected: true,
+			errorMsg: "failed to propose exit of validator 5",
+		},
+		{
+			name: "exit not processed",
+			epochs: []exitEpochState{
+				{headEpoch: 4, finalizedEpoch: 2, exitEpoch: farFuture},
+				{headEpoch: 5, finalizedEpoch: 3, exitEpoch: farFuture},
+				{headEpoch: 6, finalizedEpoch: 4, exitEpoch: farFuture},
+				{headEpoch: 7, finalizedEpoch: 5, exitEpoch: farFuture},
+			},
+			errorMsg: "exit of validator 5 submitted at epoch 4 was not processed by epoch 7",
+		},
+		{
+			name: "exit not finalized",
+			epochs: []exitEpochState{
+				{headEpoch: 4, finalizedEpoch: 2, exitEpoch: farFuture},
+				{headEpoch: 5, finalizedEpoch: 2, exitEpoch: 10},
+				{headEpoch: 6, finalizedEpoch: 2, exitEpoch: 10},
+				{headEpoch: 7, finalizedEpoch: 4, exitEpoch: 10},
+			},
+			errorMsg: "exit of validator 5 processed at epoch 5 was not finalized by epoch 7, finalized epoch is 4",
+		},
+		{
+			name: "balance increasing after exit",
+			epochs: []exitEpochState{
+				{headEpoch: 4, finalizedEpoch: 2, exitEpoch: farFuture},
+				{headEpoch: 5, finalizedEpoch: 3, exitEpoch: 6},
+				{headEpoch: 6, finalizedEpoch: 4, exitEpoch: 6, balance: 31e9},
+				{headEpoch: 7, finalizedEpoch: 5, exitEpoch: 6, balance: 32e9},
+			},
+			errorMsg: "balance of validator 5 increased after it exited at epoch 6, was 31000000000 gwei and is 32000000000 gwei",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := &exitServer{epochs: tt.epochs, rejected: tt.rejected}
+			conns, stop := startExitServer(t, server)
+			defer stop()
+
+			evaluator := VoluntaryExitEvaluator(5, 4)
+			var err error
+			for len(server.epochs) > 0 && err == nil {
+				err = evaluator.Evaluation(conns)
+			}
+			if tt.errorMsg != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.errorMsg) {
+					t.Errorf("Expected error containing %q, received %v", tt.errorMsg, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if len(server.exits) != 1 {
+				t.Fatalf("Expected 1 proposed exit, received %d", len(server.exits))
+			}
+			verifyExitSignature(t, server.exits[0], 5, 4)
+		})
+	}
+}
+
+// verifyExitSignature checks the exit of the validator at the epoch is signed with its interop key.
+func verifyExitSignature(t *testing.T, signed *eth.SignedVoluntaryExit, validatorIndex uint64, epoch uint64) {
+	if signed.Exit.ValidatorIndex != validatorIndex || signed.Exit.Epoch != epoch {
+		t.Fatalf("Expected exit of validator %d at epoch %d, received %v", validatorIndex, epoch, signed.Exit)
+	}
+	_, pubKeys, err := interop.DeterministicallyGenerateKeys(validatorIndex, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	root, err := ssz.HashTreeRoot(signed.Exit)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sig, err := bls.SignatureFromBytes(signed.Signature)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !sig.Verify(root[:], pubKeys[0], testExitDomain) {
+		t.Error("Expected exit to be signed by the validator in the exit domain")
+	}
+}
+
+func TestVoluntaryExitEvaluator_Policy(t *testing.T) {
+	evaluator := VoluntaryExitEvaluator(5, 4)
+	if evaluator.Policy(3) {
+		t.Error("Expected evaluator to not run before the exit epoch")
+	}
+	if !evaluator.Policy(4) || !evaluator.Policy(8) {
+		t.Error("Expected evaluator to run from the exit epoch on")
+	}
+}
@@ -9,30 +9,35 @@ import (
 	eth "github.com/prysmaticlabs/ethereumapis/eth/v1alpha1"
 )
 
-// FinalizationOccurs is an evaluator to make sure finalization is performing as it should.
+// maxFinalizationLag is how many epochs a node's finalized epoch may be behind the expected one
+// before FinalizationOccurs fails, so a slightly slow node doesn't fail the run.
+const maxFinalizationLag = 1
+
+// FinalizationOccurs returns an evaluator to make sure finalization is performing as it should on
+// every node. A node may be behind by maxFinalizationLag epochs at one evaluation, but not at two
+// in a row. The justified epochs are only checked on the evaluated node.
 // Requires to be run after at least 4 epochs have passed.
-var FinalizationOccurs = Evaluator{
-	Name:       "finalizes_at_epoch_%d",
-	Policy:     afterNthEpoch(3),
-	Evaluation: finalizationOccurs,
+func FinalizationOccurs() Evaluator {
+	lagging := make(map[int]bool)
+	return Evaluator{
+		Name:   "finalizes_at_epoch_%d",
+		Policy: AfterNthEpoch(3),
+		Evaluation: func(conns *NodeConns) error {
+			return finalizationOccurs(conns, lagging)
+		},
+	}
 }
 
-func finalizationOccurs(client eth.BeaconChainClient) error {
-	chainHead, err := client.GetChainHead(context.Background(), &ptypes.Empty{})
+func finalizationOccurs(conns *NodeConns, lagging map[int]bool) error {
+	chainHead, err := conns.BeaconChainClient().GetChainHead(context.Background(), &ptypes.Empty{})
 	if err != nil {
 		return errors.Wrap(err, "failed to get chain head")
 	}
 	currentEpoch := chainHead.HeadEpoch
-	finalizedEpoch := chainHead.FinalizedEpoch
-
-	expectedFinalizedEpoch := currentEpoch - 2
-	if expectedFinalizedEpoch != finalizedEpoch {
-		return fmt.Errorf(
-			"expected finalized epoch to be %d, received: %d",
-			expectedFinalizedEpoch,
-			finalizedEpoch,
-		)
+	if err := nodesFinalized(conns, currentEpoch-2, lagging); err != nil {
+		return err
 	}
+
 	previousJustifiedEpoch := chainHead.PreviousJustifiedEpoch
 	currentJustifiedEpoch := chainHead.JustifiedEpoch
 	if previousJustifiedEpoch+1 != currentJustifiedEpoch {
@@ -51,3 +56,90 @@ func finalizationOccurs(client eth.BeaconChainClient) error {
 	}
 	return nil
 }
+
+// nodesFinalized ensures the finalized epoch of every node is at least the expected one,
+// allowing for maxFinalizationLag epochs of delay unless the node was already behind at the
+// previous evaluation. lagging holds the nodes that were behind, by node index.
+func nodesFinalized(conns *NodeConns, expectedFinalizedEpoch uint64, lagging map[int]bool) error {
+	for _, index := range conns.sortedIndices() {
+		client := eth.NewBeaconChainClient(conns.Conns[index])
+		chainHead, err := client.GetChainHead(context.Background(), &ptypes.Empty{})
+		if err != nil {
+			return errors.Wrapf(err, "failed to get chain head of node %d", index)
+		}
+		if chainHead.FinalizedEpoch >= expectedFinalizedEpoch {
+			lagging[index] = false
+			continue
+		}
+		if chainHead.FinalizedEpoch+maxFinalizationLag < expectedFinalizedEpoch || lagging[index] {
+			return fmt.Errorf(
+				"node %d is lagging %d epochs behind, expected finalized epoch to be %d, received: %d",
+				index,
+				expectedFinalizedEpoch-chainHead.FinalizedEpoch,
+				expectedFinalizedEpoch,
+				chainHead.FinalizedEpoch,
+			)
+		}
+		lagging[index] = true
+	}
+	return nil
+}
+
+// FinalityResumes returns an evaluator that ensures every beacon node has finalized healEpoch
+// within maxEpochs of it, when the nodes partitioned from each other were reconnected. Neither
+// side of an even partition has enough votes to finalize on its own.
+func FinalityResumes(healEpoch uint64, maxEpochs uint64) Evaluator {
+	return Evaluator{
+		Name:   "finality_resumes_epoch_%d",
+		Policy: OnEpoch(healEpoch + maxEpochs),
+		Evaluation: func(conns *NodeConns) error {
+			for _, index := range conns.sortedIndices() {
+				chainHead, err := eth.NewBeaconChainClient(conns.Conns[index]).GetChainHead(context.Background(), &ptypes.Empty{})
+				if err != nil {
+					return errors.Wrapf(err, "failed to get chain head of node %d", index)
+				}
+				if chainHead.FinalizedEpoch < healEpoch {
+					return fmt.Errorf(
+						"node %d finalized epoch %d, expected finality to resume with epoch %d within %d epochs of reconnecting the nodes",
+						index,
+						chainHead.FinalizedEpoch,
+						healEpoch,
+						maxEpochs,
+					)
+				}
+			}
+			return nil
+		},
+	}
+}
+
+// FinalizationEvaluator returns an evaluator that fails when the finalized epoch has not
+// advanced for more than maxEpochs epochs, whether since genesis or since it last advanced.
+func FinalizationEvaluator(maxEpochs uint64) Evaluator {
+	var lastFinalizedEpoch, lastAdvancedAt uint64
+	return Evaluator{
+		Name:   "finalization_advances_epoch_%d",
+		Policy: AfterNthEpoch(0),
+		Evaluation: func(conns *NodeConns) error {
+			chainHead, err := conns.BeaconChainClient().GetChainHead(context.Background(), &ptypes.Empty{})
+			if err != nil {
+				return errors.Wrap(err, "failed to get chain head")
+			}
+			if chainHead.FinalizedEpoch > lastFinalizedEpoch {
+				lastFinalizedEpoch = chainHead.FinalizedEpoch
+				lastAdvancedAt = chainHead.HeadEpoch
+				return nil
+			}
+			if chainHead.HeadEpoch > lastAdvancedAt+maxEpochs {
+				return fmt.Errorf(
+					"finalized epoch is stuck at %d since epoch %d, expected it to advance within %d epochs, head epoch is %d",
+					lastFinalizedEpoch,
+					lastAdvancedAt,
+					maxEpochs,
+					chainHead.HeadEpoch,
+				)
+			}
+			return nil
+		},
+	}
+}
@@ -0,0 +1,235 @@
+package evaluators
+
+import (
+	"context"
+	"net"
+	"strings"
+	"testing"
+
+	ptypes "github.com/gogo/protobuf/types"
+	eth "github.com/prysmaticlabs/ethereumapis/eth/v1alpha1"
+	"google.golang.org/grpc"
+)
+
+// chainHeadServer serves the given chain heads one after the other, repeating the last one.
+type chainHeadServer struct {
+	eth.BeaconChainServer
+	heads []*eth.ChainHead
+}
+
+func (s *chainHeadServer) GetChainHead(_ context.Context, _ *ptypes.Empty) (*eth.ChainHead, error) {
+	head := s.heads[0]
+	if len(s.heads) > 1 {
+		s.heads = s.heads[1:]
+	}
+	return head, nil
+}
+
+// startServer serves the gRPC services registered by register on a free port, standing in for a
+// beacon node, and returns a connection to it along with a function stopping both.
+func startServer(t *testing.T, register func(server *grpc.Server)) (*grpc.ClientConn, func()) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := grpc.NewServer()
+	register(server)
+	go func() {
+		if err := server.Serve(listener); err != nil {
+			t.Error(err)
+		}
+	}()
+	conn, err := grpc.Dial(listener.Addr().String(), grpc.WithInsecure())
+	if err != nil {
+		server.Stop()
+		t.Fatal(err)
+	}
+	return conn, func() {
+		conn.Close()
+		server.Stop()
+	}
+}
+
+// startBeaconChainServer serves the beacon chain API with the given server, as beacon node 0.
+func startBeaconChainServer(t *testing.T, beaconChainServer eth.BeaconChainServer) (*NodeConns, func()) {
+	conn, stop := startServer(t, func(server *grpc.Server) {
+		eth.RegisterBeaconChainServer(server, beaconChainServer)
+	})
+	return &NodeConns{Conns: map[int]*grpc.ClientConn{0: conn}}, stop
+}
+
+func TestFinalizationEvaluator(t *testing.T) {
+	tests := []struct {
+		name         string
+		heads        []*eth.ChainHead
+		failingEpoch uint64 // When non-zero, the head epoch the evaluator is expected to fail at.
+	}{
+		{
+			name: "finalization advances",
+			heads: []*eth.ChainHead{
+				{HeadEpoch: 1},
+				{HeadEpoch: 2},
+				{HeadEpoch: 3, FinalizedEpoch: 1},
+				{HeadEpoch: 4, FinalizedEpoch: 2},
+				{HeadEpoch: 5, FinalizedEpoch: 3},
+			},
+		},
+		{
+			name: "no finalization since genesis",
+			heads: []*eth.ChainHead{
+				{HeadEpoch: 1},
+				{HeadEpoch: 2},
+				{HeadEpoch: 3},
+				{HeadEpoch: 4},
+			},
+			failingEpoch: 4,
+		},
+		{
+			name: "finalization stalls",
+			heads: []*eth.ChainHead{
+				{HeadEpoch: 3, FinalizedEpoch: 1},
+				{HeadEpoch: 4, FinalizedEpoch: 2},
+				{HeadEpoch: 5, FinalizedEpoch: 2},
+				{HeadEpoch: 6, FinalizedEpoch: 2},
+				{HeadEpoch: 7, FinalizedEpoch: 2},
+				{HeadEpoch: 8, FinalizedEpoch: 2},
+			},
+			failingEpoch: 8,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conns, stop := startBeaconChainServer(t, &chainHeadServer{heads: tt.heads})
+			defer stop()
+
+			evaluator := FinalizationEvaluator(3)
+			for _, head := range tt.heads {
+				err := evaluator.Evaluation(conns)
+				if head.HeadEpoch == tt.failingEpoch {
+					if err == nil || !strings.Contains(err.Error(), "expected it to advance within 3 epochs") {
+						t.Errorf("Expected stalled finalization error at epoch %d, received %v", head.HeadEpoch, err)
+					}
+					return
+				}
+				if err != nil {
+					t.Fatalf("Unexpected error at epoch %d: %v", head.HeadEpoch, err)
+				}
+			}
+			if tt.failingEpoch != 0 {
+				t.Errorf("Expected evaluator to fail at epoch %d", tt.failingEpoch)
+			}
+		})
+	}
+}
+
+func TestFinalizationOccurs(t *testing.T) {
+	// The evaluated node finalizes the expected epoch, 4 at epoch 6 and 5 at epoch 7.
+	evaluatedHeads := []*eth.ChainHead{
+		{HeadEpoch: 6, FinalizedEpoch: 4, JustifiedEpoch: 5, PreviousJustifiedEpoch: 4},
+		{HeadEpoch: 7, FinalizedEpoch: 5, JustifiedEpoch: 6, PreviousJustifiedEpoch: 5},
+	}
+	tests := []struct {
+		name string
+		// finalizedEpochs is the finalized epoch of the other node at each evaluation, only the
+		// last evaluation may fail.
+		finalizedEpochs []uint64
+		errorMsg        string
+	}{
+		{
+			name:            "all nodes finalized",
+			finalizedEpochs: []uint64{4, 5},
+		},
+		{
+			name:            "node one epoch behind once",
+			finalizedEpochs: []uint64{3, 5},
+		},
+		{
+			name:            "node one epoch behind after catching up",
+			finalizedEpochs: []uint64{4, 4},
+		},
+		{
+			name:            "node one epoch behind twice in a row",
+			finalizedEpochs: []uint64{3, 4},
+			errorMsg:        "node 1 is lagging 1 epochs behind, expected finalized epoch to be 5, received: 4",
+		},
+		{
+			name:            "node two epochs behind",
+			finalizedEpochs: []uint64{2},
+			errorMsg:        "node 1 is lagging 2 epochs behind, expected finalized epoch to be 4, received: 2",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conns, stop := startBeaconChainServer(t, &chainHeadServer{heads: evaluatedHeads})
+			defer stop()
+			heads := make([]*eth.ChainHead, len(tt.finalizedEpochs))
+			for i, epoch := range tt.finalizedEpochs {
+				heads[i] = &eth.ChainHead{HeadEpoch: 6 + uint64(i), FinalizedEpoch: epoch}
+			}
+			lagging, stopLagging := startBeaconChainServer(t, &chainHeadServer{heads: heads})
+			defer stopLagging()
+			conns.Conns[1] = lagging.Conns[0]
+
+			evaluator := FinalizationOccurs()
+			var err error
+			for i := range tt.finalizedEpochs {
+				if err = evaluator.Evaluation(conns); err != nil && i < len(tt.finalizedEpochs)-1 {
+					t.Fatalf("Unexpected error at evaluation %d: %v", i, err)
+				}
+			}
+			if tt.errorMsg == "" {
+				if err != nil {
+					t.Errorf("Unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.errorMsg) {
+				t.Errorf("Expected error containing %q, received %v", tt.errorMsg, err)
+			}
+		})
+	}
+}
+
+func TestFinalityResumes(t *testing.T) {
+	evaluator := FinalityResumes(4, 3)
+	if evaluator.Policy(6) || !evaluator.Policy(7) || evaluator.Policy(8) {
+		t.Error("Expected finality to be checked maxEpochs after the heal epoch")
+	}
+	tests := []struct {
+		name           string
+		finalizedEpoch uint64
+		errorMsg       string
+	}{
+		{
+			name:           "heal epoch finalized",
+			finalizedEpoch: 4,
+		},
+		{
+			name:           "still stuck before the partition",
+			finalizedEpoch: 1,
+			errorMsg:       "node 1 finalized epoch 1, expected finality to resume with epoch 4 within 3 epochs of reconnecting the nodes",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conns, stop := startBeaconChainServer(t, &chainHeadServer{heads: []*eth.ChainHead{{HeadEpoch: 7, FinalizedEpoch: 5}}})
+			defer stop()
+			other, stopOther := startBeaconChainServer(t, &chainHeadServer{heads: []*eth.ChainHead{
+				{HeadEpoch: 7, FinalizedEpoch: tt.finalizedEpoch},
+			}})
+			defer stopOther()
+			conns.Conns[1] = other.Conns[0]
+
+			err := evaluator.Evaluation(conns)
+			if tt.errorMsg == "" {
+				if err != nil {
+					t.Errorf("Unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.errorMsg) {
+				t.Errorf("Expected error containing %q, received %v", tt.errorMsg, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,258 @@
+package evaluators
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	ptypes "github.com/gogo/protobuf/types"
+	"github.com/pkg/errors"
+	eth "github.com/prysmaticlabs/ethereumapis/eth/v1alpha1"
+)
+
+// Gateway routes of BeaconChain.GetChainHead, BeaconChain.ListValidators and Node.GetVersion.
+const (
+	chainHeadPath  = "/eth/v1alpha1/beacon/chainhead"
+	validatorsPath = "/eth/v1alpha1/validators"
+	versionPath    = "/eth/v1alpha1/node/version"
+)
+
+// gatewayPageSize is the size of the validator pages requested from the gateway, small enough for
+// the genesis validators to span several pages.
+const gatewayPageSize = 2
+
+// gatewayTimeout is how long a gateway is given to answer a request.
+const gatewayTimeout = 5 * time.Second
+
+// maxGatewayErrorBody is how much of the body of a failed gateway request is reported.
+const maxGatewayErrorBody = 1024
+
+// HTTPGatewayEvaluator returns an evaluator that requests the chain head, the first pages of the
+// validators and the version from the JSON gateway of each beacon node, given as base URLs such as
+// http://127.0.0.1:3200 in the order of the node indices. The responses are validated and compared
+// to the same data requested from the node over gRPC, to catch marshaling regressions.
+func HTTPGatewayEvaluator(endpoints []string) Evaluator {
+	return Evaluator{
+		Name:   "http_gateway_epoch_%d",
+		Policy: OnEpoch(1),
+		Evaluation: func(conns *NodeConns) error {
+			client := &http.Client{Timeout: gatewayTimeout}
+			for index, endpoint := range endpoints {
+				conn, ok := conns.Conns[index]
+				if !ok {
+					return fmt.Errorf("no connection to beacon node %d to compare gateway %s with", index, endpoint)
+				}
+				gateway := &gatewayClient{client: client, endpoint: strings.TrimRight(endpoint, "/")}
+				if err := gatewayChainHeadValid(gateway, eth.NewBeaconChainClient(conn)); err != nil {
+					return errors.Wrapf(err, "gateway %s", endpoint)
+				}
+				if err := gatewayValidatorsValid(gateway, eth.NewBeaconChainClient(conn)); err != nil {
+					return errors.Wrapf(err, "gateway %s", endpoint)
+				}
+				if err := gatewayVersionValid(gateway, eth.NewNodeClient(conn)); err != nil {
+					return errors.Wrapf(err, "gateway %s", endpoint)
+				}
+			}
+			return nil
+		},
+	}
+}
+
+// gatewayClient requests the routes of the JSON gateway at endpoint.
+type gatewayClient struct {
+	client   *http.Client
+	endpoint string
+}
+
+// get requests the route and decodes its JSON response into v. Failed requests are reported with
+// the start of their body, which holds the error returned by the gRPC server.
+func (g *gatewayClient) get(route string, v interface{}) error {
+	res, err := g.client.Get(g.endpoint + route)
+	if err != nil {
+		return errors.Wrapf(err, "failed to request %s", route)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		body, _ := ioutil.ReadAll(io.LimitReader(res.Body, maxGatewayErrorBody))
+		return fmt.Errorf("expected status 200 from %s, received %s: %s", route, res.Status, bytes.TrimSpace(body))
+	}
+	mediaType, _, err := mime.ParseMediaType(res.Header.Get("Content-Type"))
+	if err != nil || mediaType != "application/json" {
+		return fmt.Errorf("expected content type application/json from %s, received %q", route, res.Header.Get("Content-Type"))
+	}
+	if err := json.NewDecoder(res.Body).Decode(v); err != nil {
+		return errors.Wrapf(err, "failed to decode %s", route)
+	}
+	return nil
+}
+
+// gatewayChainHead is the JSON encoding of the chain head fields that are checked. The gateway
+// encodes 64 bits integers as strings.
+type gatewayChainHead struct {
+	HeadSlot           string `json:"headSlot"`
+	HeadBlockRoot      string `json:"headBlockRoot"`
+	FinalizedEpoch     string `json:"finalizedEpoch"`
+	FinalizedBlockRoot string `json:"finalizedBlockRoot"`
+}
+
+// gatewayChainHeadValid checks the chain head served by the gateway. The head may advance between
+// the requests, so it's only expected to be at or past the one served over gRPC just before, while
+// the finalized checkpoint, which only changes at epoch boundaries, must be the same.
+func gatewayChainHeadValid(gateway *gatewayClient, client eth.BeaconChainClient) error {
+	expected, err := client.GetChainHead(context.Background(), &ptypes.Empty{})
+	if err != nil {
+		return errors.Wrap(err, "failed to get chain head over gRPC")
+	}
+	var head gatewayChainHead
+	if err := gateway.get(chainHeadPath, &head); err != nil {
+		return err
+	}
+	slot, err := strconv.ParseUint(head.HeadSlot, 10, 64)
+	if err != nil {
+		return errors.Wrapf(err, "invalid head slot %q", head.HeadSlot)
+	}
+	if slot == 0 {
+		return errors.New("expected head slot to be above 0")
+	}
+	if slot < expected.HeadSlot {
+		return fmt.Errorf("expected head slot to be at least %d as served over gRPC, received %d", expected.HeadSlot, slot)
+	}
+	root, err := decodeGatewayBytes(head.HeadBlockRoot)
+	if err != nil {
+		return errors.Wrapf(err, "invalid head block root %q", head.HeadBlockRoot)
+	}
+	if len(root) != 32 {
+		return fmt.Errorf("expected head block root to be 32 bytes, received %d bytes", len(root))
+	}
+	finalizedEpoch, err := strconv.ParseUint(head.FinalizedEpoch, 10, 64)
+	if err != nil {
+		return errors.Wrapf(err, "invalid finalized epoch %q", head.FinalizedEpoch)
+	}
+	finalizedRoot, err := decodeGatewayBytes(head.FinalizedBlockRoot)
+	if err != nil {
+		return errors.Wrapf(err, "invalid finalized block root %q", head.FinalizedBlockRoot)
+	}
+	if finalizedEpoch != expected.FinalizedEpoch || !bytes.Equal(finalizedRoot, expected.FinalizedBlockRoot) {
+		return fmt.Errorf(
+			"expected finalized checkpoint %d %#x as served over gRPC, received %d %#x",
+			expected.FinalizedEpoch,
+			expected.FinalizedBlockRoot,
+			finalizedEpoch,
+			finalizedRoot,
+		)
+	}
+	return nil
+}
+
+// gatewayValidators is the JSON encoding of the validator page fields that are checked.
+type gatewayValidators struct {
+	ValidatorList []struct {
+		Index     string `json:"index"`
+		Validator struct {
+			PublicKey string `json:"publicKey"`
+		} `json:"validator"`
+	} `json:"validatorList"`
+	NextPageToken string `json:"nextPageToken"`
+}
+
+// gatewayValidatorsValid checks that the first 2 pages of validators served by the gateway, requested
+// with the pagination query parameters, hold the same validators as served over gRPC.
+func gatewayValidatorsValid(gateway *gatewayClient, client eth.BeaconChainClient) error {
+	req := &eth.ListValidatorsRequest{PageSize: gatewayPageSize}
+	for page := 0; page < 2; page++ {
+		expected, err := client.ListValidators(context.Background(), req)
+		if err != nil {
+			return errors.Wrap(err, "failed to get validators over gRPC")
+		}
+		query := url.Values{"page_size": {strconv.Itoa(int(req.PageSize))}}
+		if req.PageToken != "" {
+			query.Set("page_token", req.PageToken)
+		}
+		route := validatorsPath + "?" + query.Encode()
+		var validators gatewayValidators
+		if err := gateway.get(route, &validators); err != nil {
+			return err
+		}
+		if len(validators.ValidatorList) != len(expected.ValidatorList) {
+			return fmt.Errorf(
+				"expected %d validators from %s as served over gRPC, received %d",
+				len(expected.ValidatorList),
+				route,
+				len(validators.ValidatorList),
+			)
+		}
+		for i, item := range validators.ValidatorList {
+			want := expected.ValidatorList[i]
+			index, err := strconv.ParseUint(item.Index, 10, 64)
+			if err != nil {
+				return errors.Wrapf(err, "invalid validator index %q", item.Index)
+			}
+			publicKey, err := decodeGatewayBytes(item.Validator.PublicKey)
+			if err != nil {
+				return errors.Wrapf(err, "invalid public key %q of validator %d", item.Validator.PublicKey, index)
+			}
+			if index != want.Index || !bytes.Equal(publicKey, want.Validator.PublicKey) {
+				return fmt.Errorf(
+					"expected validator %d %#x from %s as served over gRPC, received validator %d %#x",
+					want.Index,
+					want.Validator.PublicKey,
+					route,
+					index,
+					publicKey,
+				)
+			}
+		}
+		if validators.NextPageToken != expected.NextPageToken {
+			return fmt.Errorf("expected next page token %q from %s as served over gRPC, received %q", expected.NextPageToken, route, validators.NextPageToken)
+		}
+		if expected.NextPageToken == "" {
+			return nil
+		}
+		req.PageToken = expected.NextPageToken
+	}
+	return nil
+}
+
+// gatewayVersion is the JSON encoding of the node version.
+type gatewayVersion struct {
+	Version string `json:"version"`
+}
+
+// gatewayVersionValid checks the gateway serves the version the node serves over gRPC.
+func gatewayVersionValid(gateway *gatewayClient, client eth.NodeClient) error {
+	expected, err := client.GetVersion(context.Background(), &ptypes.Empty{})
+	if err != nil {
+		return errors.Wrap(err, "failed to get version over gRPC")
+	}
+	var version gatewayVersion
+	if err := gateway.get(versionPath, &version); err != nil {
+		return err
+	}
+	if version.Version == "" {
+		return errors.New("expected a version")
+	}
+	if version.Version != expected.Version {
+		return fmt.Errorf("expected version %q as served over gRPC, received %q", expected.Version, version.Version)
+	}
+	return nil
+}
+
+// decodeGatewayBytes decodes a bytes field, which the gateway encodes in base64 like jsonpb does,
+// accepting 0x prefixed hex as well.
+func decodeGatewayBytes(value string) ([]byte, error) {
+	if strings.HasPrefix(value, "0x") {
+		return hex.DecodeString(value[2:])
+	}
+	return base64.StdEncoding.DecodeString(value)
+}
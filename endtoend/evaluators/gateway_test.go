@@ -0,0 +1,291 @@
+package evaluators
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	ptypes "github.com/gogo/protobuf/types"
+	eth "github.com/prysmaticlabs/ethereumapis/eth/v1alpha1"
+	"google.golang.org/grpc"
+)
+
+// gatewayBackend serves over gRPC the chain head, the validators and the version its gateway is
+// expected to serve over HTTP.
+type gatewayBackend struct {
+	eth.BeaconChainServer
+	eth.NodeServer
+	head       *eth.ChainHead
+	validators []*eth.Validators_ValidatorContainer
+	version    string
+}
+
+func newGatewayBackend() *gatewayBackend {
+	backend := &gatewayBackend{
+		head: &eth.ChainHead{
+			HeadSlot:           12,
+			HeadBlockRoot:      make([]byte, 32),
+			FinalizedEpoch:     1,
+			FinalizedBlockRoot: []byte{0x0f},
+		},
+		version: "Prysm/v0.9.0/e2e",
+	}
+	for i := uint64(0); i < 5; i++ {
+		backend.validators = append(backend.validators, &eth.Validators_ValidatorContainer{
+			Index:     i,
+			Validator: &eth.Validator{PublicKey: []byte{byte(i), 0xaa}},
+		})
+	}
+	return backend
+}
+
+func (s *gatewayBackend) GetChainHead(_ context.Context, _ *ptypes.Empty) (*eth.ChainHead, error) {
+	return s.head, nil
+}
+
+// ListValidators serves the validators by pages, the page token being the index of the first
+// validator of the page.
+func (s *gatewayBackend) ListValidators(_ context.Context, req *eth.ListValidatorsRequest) (*eth.Validators, error) {
+	start := 0
+	if req.PageToken != "" {
+		var err error
+		if start, err = strconv.Atoi(req.PageToken); err != nil {
+			return nil, err
+		}
+	}
+	end := start + int(req.PageSize)
+	res := &eth.Validators{TotalSize: int32(len(s.validators))}
+	if end < len(s.validators) {
+		res.NextPageToken = strconv.Itoa(end)
+	} else {
+		end = len(s.validators)
+	}
+	res.ValidatorList = s.validators[start:end]
+	return res, nil
+}
+
+func (s *gatewayBackend) GetVersion(_ context.Context, _ *ptypes.Empty) (*eth.Version, error) {
+	return &eth.Version{Version: s.version}, nil
+}
+
+// ServeHTTP serves the gRPC responses as JSON, as the gateway encodes them.
+func (s *gatewayBackend) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var res interface{}
+	switch r.URL.Path {
+	case chainHeadPath:
+		res = map[string]string{
+			"headSlot":           strconv.FormatUint(s.head.HeadSlot, 10),
+			"headBlockRoot":      base64.StdEncoding.EncodeToString(s.head.HeadBlockRoot),
+			"finalizedEpoch":     strconv.FormatUint(s.head.FinalizedEpoch, 10),
+			"finalizedBlockRoot": base64.StdEncoding.EncodeToString(s.head.FinalizedBlockRoot),
+		}
+	case validatorsPath:
+		pageSize, _ := strconv.Atoi(r.URL.Query().Get("page_size"))
+		page, err := s.ListValidators(context.Background(), &eth.ListValidatorsRequest{
+			PageSize:  int32(pageSize),
+			PageToken: r.URL.Query().Get("page_token"),
+		})
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		var list []map[string]interface{}
+		for _, item := range page.ValidatorList {
+			list = append(list, map[string]interface{}{
+				"index":     strconv.FormatUint(item.Index, 10),
+				"validator": map[string]string{"publicKey": base64.StdEncoding.EncodeToString(item.Validator.PublicKey)},
+			})
+		}
+		res = map[string]interface{}{"validatorList": list, "nextPageToken": page.NextPageToken, "totalSize": page.TotalSize}
+	case versionPath:
+		res = map[string]string{"version": s.version, "metadata": ""}
+	default:
+		http.NotFound(w, r)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(res); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// startGatewayBackend serves the gRPC API of the backend, returning a connection to it.
+func startGatewayBackend(t *testing.T, backend *gatewayBackend) (*grpc.ClientConn, func()) {
+	return startServer(t, func(server *grpc.Server) {
+		eth.RegisterBeaconChainServer(server, backend)
+		eth.RegisterNodeServer(server, backend)
+	})
+}
+
+func TestHTTPGatewayEvaluator(t *testing.T) {
+	root := "Gs2pXJ5Q8W/GT4tYGcrbFcDdWLbJvSCkzkNMSVcdhmU="
+	finalizedRoot := base64.StdEncoding.EncodeToString([]byte{0x0f})
+	tests := []struct {
+		name        string
+		path        string
+		status      int
+		contentType string
+		body        string
+		errorMsg    string
+	}{
+		{
+			name: "valid responses",
+		},
+		{
+			name:        "hex roots",
+			path:        chainHeadPath,
+			contentType: "application/json; charset=utf-8",
+			body: `{"headSlot": "12", "headBlockRoot": "0x1acda95c9e50f16fc64f8b5819cadb15c0dd58b6c9bd20a4ce434c49571d8665", ` +
+				`"finalizedEpoch": "1", "finalizedBlockRoot": "0x0f"}`,
+		},
+		{
+			name:        "head past the one served over gRPC",
+			path:        chainHeadPath,
+			contentType: "application/json",
+			body:        fmt.Sprintf(`{"headSlot": "13", "headBlockRoot": %q, "finalizedEpoch": "1", "finalizedBlockRoot": %q}`, root, finalizedRoot),
+		},
+		{
+			name:        "wrong content type",
+			path:        chainHeadPath,
+			contentType: "text/plain",
+			body:        fmt.Sprintf(`{"headSlot": "12", "headBlockRoot": %q}`, root),
+			errorMsg:    `expected content type application/json from /eth/v1alpha1/beacon/chainhead, received "text/plain"`,
+		},
+		{
+			name:        "genesis slot",
+			path:        chainHeadPath,
+			contentType: "application/json",
+			body:        fmt.Sprintf(`{"headSlot": "0", "headBlockRoot": %q}`, root),
+			errorMsg:    "expected head slot to be above 0",
+		},
+		{
+			name:        "head behind the one served over gRPC",
+			path:        chainHeadPath,
+			contentType: "application/json",
+			body:        fmt.Sprintf(`{"headSlot": "11", "headBlockRoot": %q, "finalizedEpoch": "1", "finalizedBlockRoot": %q}`, root, finalizedRoot),
+			errorMsg:    "expected head slot to be at least 12 as served over gRPC, received 11",
+		},
+		{
+			name:        "short root",
+			path:        chainHeadPath,
+			contentType: "application/json",
+			body:        `{"headSlot": "12", "headBlockRoot": "AAEC"}`,
+			errorMsg:    "expected head block root to be 32 bytes, received 3 bytes",
+		},
+		{
+			name:        "malformed root",
+			path:        chainHeadPath,
+			contentType: "application/json",
+			body:        `{"headSlot": "12", "headBlockRoot": "0xzz"}`,
+			errorMsg:    "invalid head block root",
+		},
+		{
+			name:        "malformed JSON",
+			path:        chainHeadPath,
+			contentType: "application/json",
+			body:        `{"headSlot": `,
+			errorMsg:    "failed to decode /eth/v1alpha1/beacon/chainhead",
+		},
+		{
+			name:        "different finalized checkpoint",
+			path:        chainHeadPath,
+			contentType: "application/json",
+			body:        fmt.Sprintf(`{"headSlot": "12", "headBlockRoot": %q, "finalizedEpoch": "0", "finalizedBlockRoot": %q}`, root, finalizedRoot),
+			errorMsg:    "expected finalized checkpoint 1 0x0f as served over gRPC, received 0 0x0f",
+		},
+		{
+			name:        "pagination ignored",
+			path:        validatorsPath,
+			contentType: "application/json",
+			body:        `{"validatorList": [{"index": "0", "validator": {"publicKey": "AKo="}}], "nextPageToken": "", "totalSize": 5}`,
+			errorMsg:    "expected 2 validators from /eth/v1alpha1/validators?page_size=2 as served over gRPC, received 1",
+		},
+		{
+			name:        "different public key",
+			path:        validatorsPath,
+			contentType: "application/json",
+			body: `{"validatorList": [{"index": "0", "validator": {"publicKey": "AKo="}}, ` +
+				`{"index": "1", "validator": {"publicKey": "AAA="}}], "nextPageToken": "2", "totalSize": 5}`,
+			errorMsg: "expected validator 1 0x01aa from /eth/v1alpha1/validators?page_size=2 as served over gRPC, received validator 1 0x0000",
+		},
+		{
+			name:        "request failed",
+			path:        validatorsPath,
+			status:      http.StatusInternalServerError,
+			contentType: "application/json",
+			body:        `{"error": "Could not get validators", "code": 13}`,
+			errorMsg: "expected status 200 from /eth/v1alpha1/validators?page_size=2, received 500 Internal Server Error: " +
+				`{"error": "Could not get validators", "code": 13}`,
+		},
+		{
+			name:        "different version",
+			path:        versionPath,
+			contentType: "application/json",
+			body:        `{"version": "Prysm/v0.8.0/e2e", "metadata": ""}`,
+			errorMsg:    `expected version "Prysm/v0.9.0/e2e" as served over gRPC, received "Prysm/v0.8.0/e2e"`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conns := &NodeConns{Conns: make(map[int]*grpc.ClientConn)}
+			var endpoints []string
+			for i := 0; i < 2; i++ {
+				backend := newGatewayBackend()
+				conn, stop := startGatewayBackend(t, backend)
+				defer stop()
+				conns.Conns[i] = conn
+				// The first node serves valid responses, the second the response under test.
+				underTest := i == 1
+				server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					if !underTest || r.URL.Path != tt.path {
+						backend.ServeHTTP(w, r)
+						return
+					}
+					w.Header().Set("Content-Type", tt.contentType)
+					if tt.status != 0 {
+						w.WriteHeader(tt.status)
+					}
+					fmt.Fprint(w, tt.body)
+				}))
+				defer server.Close()
+				endpoints = append(endpoints, server.URL)
+			}
+
+			err := HTTPGatewayEvaluator(endpoints).Evaluation(conns)
+			if tt.errorMsg == "" {
+				if err != nil {
+					t.Errorf("Unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.errorMsg) || !strings.Contains(err.Error(), endpoints[1]) {
+				t.Errorf("Expected error for %s containing %q, received %v", endpoints[1], tt.errorMsg, err)
+			}
+		})
+	}
+}
+
+func TestHTTPGatewayEvaluator_NotFound(t *testing.T) {
+	conn, stop := startGatewayBackend(t, newGatewayBackend())
+	defer stop()
+	server := httptest.NewServer(http.NotFoundHandler())
+	defer server.Close()
+	conns := &NodeConns{Conns: map[int]*grpc.ClientConn{0: conn}}
+	err := HTTPGatewayEvaluator([]string{server.URL}).Evaluation(conns)
+	if err == nil || !strings.Contains(err.Error(), "expected status 200 from /eth/v1alpha1/beacon/chainhead, received 404 Not Found: 404 page not found") {
+		t.Errorf("Expected error for missing route, received %v", err)
+	}
+}
+
+func TestHTTPGatewayEvaluator_NodeNotConnected(t *testing.T) {
+	err := HTTPGatewayEvaluator([]string{"http://127.0.0.1:3200"}).Evaluation(&NodeConns{})
+	if err == nil || !strings.Contains(err.Error(), "no connection to beacon node 0") {
+		t.Errorf("Expected error for missing connection, received %v", err)
+	}
+}
@@ -0,0 +1,52 @@
+package evaluators
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// healthzRequestTimeout is how long a beacon node is given to answer a health request.
+const healthzRequestTimeout = 5 * time.Second
+
+// healthzPollInterval is the wait between two requests to the health endpoint of a beacon node.
+var healthzPollInterval = 500 * time.Millisecond
+
+// PollHealthz requests the /healthz endpoint served on the monitoring port of a beacon node until it
+// answers 200, which it does once every service reports a healthy status. It gives up after timeout,
+// returning the last status received, which lists the failing services.
+func PollHealthz(monitoringPort uint64, timeout time.Duration) error {
+	client := &http.Client{Timeout: healthzRequestTimeout}
+	url := fmt.Sprintf("http://127.0.0.1:%d/healthz", monitoringPort)
+	deadline := time.Now().Add(timeout)
+	for {
+		err := checkHealthz(client, url)
+		if err == nil {
+			return nil
+		}
+		if !time.Now().Before(deadline) {
+			return errors.Wrapf(err, "not healthy after %v", timeout)
+		}
+		time.Sleep(healthzPollInterval)
+	}
+}
+
+func checkHealthz(client *http.Client, url string) error {
+	response, err := client.Get(url)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	if response.StatusCode == http.StatusOK {
+		return nil
+	}
+	body, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return errors.Wrapf(err, "could not read response with status %d", response.StatusCode)
+	}
+	return fmt.Errorf("%s returned status %d: %s", url, response.StatusCode, strings.TrimSpace(string(body)))
+}
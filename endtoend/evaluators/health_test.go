@@ -0,0 +1,58 @@
+package evaluators
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// startHealthzServer serves /healthz with the given statuses in turn, the last one being repeated.
+func startHealthzServer(t *testing.T, statuses ...int) (uint64, func()) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/healthz" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		status := statuses[0]
+		if len(statuses) > 1 {
+			statuses = statuses[1:]
+		}
+		w.WriteHeader(status)
+		if status != http.StatusOK {
+			fmt.Fprintln(w, "*powchain.Service: ERROR could not connect to eth1")
+		}
+	}))
+	return uint64(server.Listener.Addr().(*net.TCPAddr).Port), server.Close
+}
+
+func TestPollHealthz(t *testing.T) {
+	defer func(interval time.Duration) { healthzPollInterval = interval }(healthzPollInterval)
+	healthzPollInterval = 10 * time.Millisecond
+
+	port, stop := startHealthzServer(t, http.StatusInternalServerError, http.StatusInternalServerError, http.StatusOK)
+	defer stop()
+	if err := PollHealthz(port, 10*time.Second); err != nil {
+		t.Errorf("Expected node to become healthy: %v", err)
+	}
+}
+
+func TestPollHealthz_Unhealthy(t *testing.T) {
+	defer func(interval time.Duration) { healthzPollInterval = interval }(healthzPollInterval)
+	healthzPollInterval = 10 * time.Millisecond
+
+	port, stop := startHealthzServer(t, http.StatusInternalServerError)
+	defer stop()
+	err := PollHealthz(port, 100*time.Millisecond)
+	if err == nil {
+		t.Fatal("Expected error for unhealthy node")
+	}
+	for _, want := range []string{"not healthy after 100ms", "returned status 500", "could not connect to eth1"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Expected error to contain %q, received: %v", want, err)
+		}
+	}
+}
@@ -0,0 +1,314 @@
+package evaluators
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	ptypes "github.com/gogo/protobuf/types"
+	"github.com/pkg/errors"
+	eth "github.com/prysmaticlabs/ethereumapis/eth/v1alpha1"
+	"github.com/prysmaticlabs/prysm/shared/params"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// RestartedNodeSynced returns an evaluator that ensures the beacon node with the given index,
+// which was restarted at restartEpoch, has caught up to the evaluated node's head slot
+// within the given amount of slots.
+func RestartedNodeSynced(nodeIndex int, restartEpoch uint64, slotTolerance uint64) Evaluator {
+	return Evaluator{
+		Name: "restarted_node_synced_epoch_%d",
+		// Give the node a full epoch after being restarted to catch up.
+		Policy: AfterNthEpoch(restartEpoch + 1),
+		Evaluation: func(conns *NodeConns) error {
+			return nodeIsSynced(conns, nodeIndex, slotTolerance)
+		},
+	}
+}
+
+// nodeIsSynced gives the restarted node up to a slot to reach the evaluated node's head slot, within
+// the tolerance, so a block that was just proposed doesn't fail the evaluation.
+func nodeIsSynced(conns *NodeConns, nodeIndex int, slotTolerance uint64) error {
+	conn, ok := conns.Conns[nodeIndex]
+	if !ok {
+		return fmt.Errorf("no connection to restarted node %d", nodeIndex)
+	}
+	nodeClient := eth.NewBeaconChainClient(conn)
+
+	expectedHead, err := conns.BeaconChainClient().GetChainHead(context.Background(), &ptypes.Empty{})
+	if err != nil {
+		return errors.Wrap(err, "failed to get chain head")
+	}
+	if expectedHead.HeadSlot <= slotTolerance {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), slotDuration())
+	defer cancel()
+	err = WaitForSlot(ctx, conn, expectedHead.HeadSlot-slotTolerance)
+	if err == nil {
+		return nil
+	}
+	if ctx.Err() == nil {
+		return errors.Wrap(err, "failed to wait for restarted node")
+	}
+	restartedHead, err := nodeClient.GetChainHead(context.Background(), &ptypes.Empty{})
+	if err != nil {
+		return errors.Wrap(err, "failed to get chain head of restarted node")
+	}
+	return fmt.Errorf(
+		"restarted node is behind, expected head slot of at least %d, received %d",
+		expectedHead.HeadSlot-slotTolerance,
+		restartedHead.HeadSlot,
+	)
+}
+
+// slotPollInterval is how often the chain head is polled while waiting for a slot.
+var slotPollInterval = 250 * time.Millisecond
+
+// WaitForSlot polls the chain head of the beacon node until its head slot reaches targetSlot, or
+// until ctx is done. It rides out the node being briefly unavailable, e.g. while it restarts or is
+// partitioned, and only fails early on other errors. The last observed slot is reported when the
+// target isn't reached.
+func WaitForSlot(ctx context.Context, conn *grpc.ClientConn, targetSlot uint64) error {
+	client := eth.NewBeaconChainClient(conn)
+	var headSlot uint64
+	observed := false
+	var lastErr error
+	for {
+		head, err := client.GetChainHead(ctx, &ptypes.Empty{})
+		switch {
+		case err == nil && head.HeadSlot >= targetSlot:
+			return nil
+		case err == nil:
+			headSlot, observed, lastErr = head.HeadSlot, true, nil
+		case ctx.Err() != nil:
+		case !isTransient(err):
+			return errors.Wrap(err, "failed to get chain head")
+		default:
+			lastErr = err
+		}
+		select {
+		case <-ctx.Done():
+			cause := lastErr
+			if cause == nil {
+				cause = ctx.Err()
+			}
+			if !observed {
+				return errors.Wrapf(cause, "head slot did not reach %d, no head observed", targetSlot)
+			}
+			return errors.Wrapf(cause, "head slot did not reach %d, last observed slot %d", targetSlot, headSlot)
+		case <-time.After(slotPollInterval):
+		}
+	}
+}
+
+// isTransient reports whether the gRPC error is expected to go away on retry, as when the node
+// isn't listening yet or didn't answer in time.
+func isTransient(err error) bool {
+	switch status.Code(err) {
+	case codes.Unavailable, codes.DeadlineExceeded, codes.ResourceExhausted, codes.Aborted:
+		return true
+	default:
+		return false
+	}
+}
+
+// slotDuration is the time between two slots.
+func slotDuration() time.Duration {
+	return time.Duration(params.BeaconConfig().SecondsPerSlot) * time.Second
+}
+
+// NodesAgreeOnHead returns an evaluator that ensures every beacon node has the same head block,
+// head slot and justified checkpoint at every epoch, so a fork between the nodes is caught as soon
+// as it happens.
+var NodesAgreeOnHead = Evaluator{
+	Name:       "nodes_agree_on_head_epoch_%d",
+	Policy:     AfterNthEpoch(0),
+	Evaluation: headsConverge,
+}
+
+// HeadsConverge returns an evaluator that ensures every beacon node has the same head at the
+// given epoch, e.g. once nodes that were partitioned from each other had time to reorg.
+func HeadsConverge(epoch uint64) Evaluator {
+	return Evaluator{
+		Name:       "heads_converge_epoch_%d",
+		Policy:     OnEpoch(epoch),
+		Evaluation: headsConverge,
+	}
+}
+
+// PartitionHealed returns an evaluator that ensures every beacon node agrees on the same head
+// within maxSlots of healEpoch, when the nodes partitioned from each other are reconnected.
+// Connectivity is restored right before the evaluators run, so the nodes are waited for.
+func PartitionHealed(healEpoch uint64, maxSlots uint64) Evaluator {
+	return Evaluator{
+		Name:   "partition_healed_epoch_%d",
+		Policy: OnEpoch(healEpoch),
+		Evaluation: func(conns *NodeConns) error {
+			if err := headsConvergeWithin(conns, time.Duration(maxSlots)*slotDuration()); err != nil {
+				return errors.Wrapf(err, "heads did not converge within %d slots of reconnecting the nodes", maxSlots)
+			}
+			return nil
+		},
+	}
+}
+
+// headsConverge gives the nodes up to a slot to agree on the head, since the nodes are queried one
+// after the other and a new block may not have reached every node yet.
+func headsConverge(conns *NodeConns) error {
+	return headsConvergeWithin(conns, slotDuration())
+}
+
+// headsConvergeWithin waits up to timeout for the nodes to agree on the head.
+func headsConvergeWithin(conns *NodeConns, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	// The heads of the last round the nodes were all queried in, reported if time runs out.
+	var lastHeads map[int]*eth.ChainHead
+	differentHeads := func() error {
+		return fmt.Errorf("beacon nodes have different heads:\n%s", headsTable(conns.sortedIndices(), lastHeads))
+	}
+	for {
+		heads, err := chainHeads(ctx, conns)
+		if err != nil {
+			if ctx.Err() != nil && lastHeads != nil {
+				return differentHeads()
+			}
+			return err
+		}
+		var highestSlot uint64
+		for _, head := range heads {
+			if head.HeadSlot > highestSlot {
+				highestSlot = head.HeadSlot
+			}
+		}
+		converged := true
+		lagging := false
+		for _, head := range heads {
+			if !sameHead(head, heads[conns.Evaluated]) {
+				converged = false
+			}
+			if head.HeadSlot < highestSlot {
+				lagging = true
+			}
+		}
+		if converged {
+			return nil
+		}
+		lastHeads = heads
+		// Nodes behind wait for the highest block, nodes forked at the same slot for the next one.
+		targetSlot := highestSlot
+		if !lagging {
+			targetSlot++
+		}
+		for _, index := range conns.sortedIndices() {
+			if heads[index].HeadSlot >= targetSlot {
+				continue
+			}
+			if err := WaitForSlot(ctx, conns.Conns[index], targetSlot); err != nil {
+				if ctx.Err() != nil {
+					return differentHeads()
+				}
+				return nodeError(index, err)
+			}
+		}
+	}
+}
+
+// maxHeadSlotLag is how many slots the head of a beacon node can be behind the others for the
+// nodes to still be considered on the same chain.
+const maxHeadSlotLag = 2
+
+// HeadConsistencyEvaluator returns an evaluator that ensures the heads of the beacon nodes are at
+// most 2 slots apart at every epoch, and that nodes with their head at the same slot have the same
+// head block. Unlike NodesAgreeOnHead, nodes are not waited for, a block that has not reached every
+// node yet is tolerated.
+func HeadConsistencyEvaluator() Evaluator {
+	return Evaluator{
+		Name:       "head_consistency_epoch_%d",
+		Policy:     AllEpochs,
+		Evaluation: headsConsistent,
+	}
+}
+
+func headsConsistent(conns *NodeConns) error {
+	heads, err := chainHeads(context.Background(), conns)
+	if err != nil {
+		return err
+	}
+	return checkHeadsConsistent(conns.sortedIndices(), heads)
+}
+
+// chainHeads returns the chain head of every node, by node index.
+func chainHeads(ctx context.Context, conns *NodeConns) (map[int]*eth.ChainHead, error) {
+	heads := make(map[int]*eth.ChainHead, len(conns.Conns))
+	for _, index := range conns.sortedIndices() {
+		head, err := eth.NewBeaconChainClient(conns.Conns[index]).GetChainHead(ctx, &ptypes.Empty{})
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to get chain head of beacon node %d", index)
+		}
+		heads[index] = head
+	}
+	return heads, nil
+}
+
+// checkHeadsConsistent fails when the heads are more than maxHeadSlotLag slots apart, or when two
+// heads at the same slot have different block roots.
+func checkHeadsConsistent(indices []int, heads map[int]*eth.ChainHead) error {
+	if len(indices) == 0 {
+		return nil
+	}
+	lowest, highest := heads[indices[0]].HeadSlot, heads[indices[0]].HeadSlot
+	rootAtSlot := make(map[uint64][]byte, len(indices))
+	for _, index := range indices {
+		head := heads[index]
+		if head.HeadSlot < lowest {
+			lowest = head.HeadSlot
+		}
+		if head.HeadSlot > highest {
+			highest = head.HeadSlot
+		}
+		root, ok := rootAtSlot[head.HeadSlot]
+		if !ok {
+			rootAtSlot[head.HeadSlot] = head.HeadBlockRoot
+			continue
+		}
+		if !bytes.Equal(root, head.HeadBlockRoot) {
+			return fmt.Errorf("beacon nodes have divergent head blocks at slot %d:\n%s", head.HeadSlot, headsTable(indices, heads))
+		}
+	}
+	if highest-lowest > maxHeadSlotLag {
+		return fmt.Errorf(
+			"beacon node heads are %d slots apart, more than %d:\n%s",
+			highest-lowest,
+			maxHeadSlotLag,
+			headsTable(indices, heads),
+		)
+	}
+	return nil
+}
+
+func sameHead(a *eth.ChainHead, b *eth.ChainHead) bool {
+	return a.HeadSlot == b.HeadSlot &&
+		bytes.Equal(a.HeadBlockRoot, b.HeadBlockRoot) &&
+		a.JustifiedEpoch == b.JustifiedEpoch &&
+		bytes.Equal(a.JustifiedBlockRoot, b.JustifiedBlockRoot)
+}
+
+// headsTable lays out the head of every node, one node per row.
+func headsTable(indices []int, heads map[int]*eth.ChainHead) string {
+	var buf bytes.Buffer
+	w := tabwriter.NewWriter(&buf, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "node\thead slot\thead block root\tjustified epoch\tjustified block root")
+	for _, index := range indices {
+		head := heads[index]
+		fmt.Fprintf(w, "%d\t%d\t%#x\t%d\t%#x\n", index, head.HeadSlot, head.HeadBlockRoot, head.JustifiedEpoch, head.JustifiedBlockRoot)
+	}
+	_ = w.Flush()
+	return strings.TrimRight(buf.String(), "\n")
+}
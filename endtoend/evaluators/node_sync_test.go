@@ -0,0 +1,339 @@
+package evaluators
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	ptypes "github.com/gogo/protobuf/types"
+	eth "github.com/prysmaticlabs/ethereumapis/eth/v1alpha1"
+	"github.com/prysmaticlabs/prysm/shared/params"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// headServer serves its head, which moves to the next of the blocks every time it's requested
+// again, after failing the first unavailable requests with err. A server that hangs only answers
+// the first request, later ones wait until they are cancelled.
+type headServer struct {
+	eth.BeaconChainServer
+	lock        sync.Mutex
+	head        *eth.ChainHead
+	blocks      []*eth.ChainHead
+	served      bool
+	unavailable int
+	err         error
+	hangs       bool
+}
+
+func (s *headServer) GetChainHead(ctx context.Context, _ *ptypes.Empty) (*eth.ChainHead, error) {
+	s.lock.Lock()
+	if s.hangs && s.served {
+		s.lock.Unlock()
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+	defer s.lock.Unlock()
+	if s.unavailable > 0 {
+		s.unavailable--
+		return nil, s.err
+	}
+	if s.served && len(s.blocks) > 0 {
+		s.head, s.blocks = s.blocks[0], s.blocks[1:]
+	}
+	s.served = true
+	return s.head, nil
+}
+
+func TestWaitForSlot(t *testing.T) {
+	defaultInterval := slotPollInterval
+	slotPollInterval = 10 * time.Millisecond
+	defer func() {
+		slotPollInterval = defaultInterval
+	}()
+
+	tests := []struct {
+		name       string
+		server     *headServer
+		targetSlot uint64
+		errorMsg   string
+	}{
+		{
+			name:       "slot reached",
+			server:     &headServer{head: &eth.ChainHead{HeadSlot: 1}, blocks: []*eth.ChainHead{{HeadSlot: 3}, {HeadSlot: 5}}},
+			targetSlot: 5,
+		},
+		{
+			name: "node briefly unavailable",
+			server: &headServer{
+				head:        &eth.ChainHead{HeadSlot: 2},
+				unavailable: 3,
+				err:         status.Error(codes.Unavailable, "connection refused"),
+			},
+			targetSlot: 2,
+		},
+		{
+			name:       "slot not reached",
+			server:     &headServer{head: &eth.ChainHead{HeadSlot: 1}, blocks: []*eth.ChainHead{{HeadSlot: 3}}},
+			targetSlot: 1000,
+			errorMsg:   "head slot did not reach 1000, last observed slot 3: context deadline exceeded",
+		},
+		{
+			name:       "node unavailable",
+			server:     &headServer{unavailable: 1000, err: status.Error(codes.Unavailable, "connection refused")},
+			targetSlot: 1,
+			errorMsg:   "head slot did not reach 1, no head observed: rpc error: code = Unavailable",
+		},
+		{
+			name:       "other error",
+			server:     &headServer{unavailable: 1, err: status.Error(codes.Internal, "could not get head state")},
+			targetSlot: 1,
+			errorMsg:   "failed to get chain head: rpc error: code = Internal",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conns, stop := startBeaconChainServer(t, tt.server)
+			defer stop()
+
+			ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+			defer cancel()
+			err := WaitForSlot(ctx, conns.Conns[0], tt.targetSlot)
+			if tt.errorMsg == "" {
+				if err != nil {
+					t.Errorf("Unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.errorMsg) {
+				t.Errorf("Expected error containing %q, received %v", tt.errorMsg, err)
+			}
+		})
+	}
+}
+
+func TestHeadsConverge(t *testing.T) {
+	config := params.BeaconConfig()
+	defer params.OverrideBeaconConfig(config)
+	testConfig := *config
+	testConfig.SecondsPerSlot = 1
+	params.OverrideBeaconConfig(&testConfig)
+
+	headA := &eth.ChainHead{HeadSlot: 40, HeadBlockRoot: []byte{0xaa}, JustifiedEpoch: 3, JustifiedBlockRoot: []byte{0x0a}}
+	headB := &eth.ChainHead{HeadSlot: 39, HeadBlockRoot: []byte{0xbb}, JustifiedEpoch: 3, JustifiedBlockRoot: []byte{0x0a}}
+	headAJustifiedElsewhere := &eth.ChainHead{HeadSlot: 40, HeadBlockRoot: []byte{0xaa}, JustifiedEpoch: 3, JustifiedBlockRoot: []byte{0x0b}}
+	headC := &eth.ChainHead{HeadSlot: 41, HeadBlockRoot: []byte{0xcc}, JustifiedEpoch: 3, JustifiedBlockRoot: []byte{0x0a}}
+	tests := []struct {
+		name     string
+		nodes    []*headServer
+		errorMsg string
+	}{
+		{
+			name:  "same head",
+			nodes: []*headServer{{head: headA}, {head: headA}, {head: headA}},
+		},
+		{
+			name:  "block reaches a node late",
+			nodes: []*headServer{{head: headA}, {head: headB, blocks: []*eth.ChainHead{headA}}, {head: headA}},
+		},
+		{
+			name: "fork resolved by the next block",
+			nodes: []*headServer{
+				{head: headA, blocks: []*eth.ChainHead{headC}},
+				{head: headAJustifiedElsewhere, blocks: []*eth.ChainHead{headC}},
+			},
+		},
+		{
+			name:  "diverging heads",
+			nodes: []*headServer{{head: headA}, {head: headA}, {head: headB}},
+			errorMsg: "beacon nodes have different heads:\n" +
+				"node  head slot  head block root  justified epoch  justified block root\n" +
+				"0     40         0xaa             3                0x0a\n" +
+				"1     40         0xaa             3                0x0a\n" +
+				"2     39         0xbb             3                0x0a",
+		},
+		{
+			name:     "diverging justified checkpoints",
+			nodes:    []*headServer{{head: headA}, {head: headAJustifiedElsewhere}},
+			errorMsg: "1     40         0xaa             3                0x0b",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conns := &NodeConns{Conns: make(map[int]*grpc.ClientConn)}
+			for i, server := range tt.nodes {
+				node, stop := startBeaconChainServer(t, server)
+				defer stop()
+				conns.Conns[i] = node.Conns[0]
+			}
+
+			err := HeadsConverge(4).Evaluation(conns)
+			if tt.errorMsg == "" {
+				if err != nil {
+					t.Errorf("Unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.errorMsg) {
+				t.Errorf("Expected error containing %q, received %v", tt.errorMsg, err)
+			}
+		})
+	}
+}
+
+func TestPartitionHealed(t *testing.T) {
+	config := params.BeaconConfig()
+	defer params.OverrideBeaconConfig(config)
+	testConfig := *config
+	testConfig.SecondsPerSlot = 1
+	params.OverrideBeaconConfig(&testConfig)
+
+	// The minority side reorgs to the head of the majority after a few blocks.
+	majority := &eth.ChainHead{HeadSlot: 42, HeadBlockRoot: []byte{0xaa}, JustifiedEpoch: 3, JustifiedBlockRoot: []byte{0x0a}}
+	minority := &eth.ChainHead{HeadSlot: 41, HeadBlockRoot: []byte{0xbb}, JustifiedEpoch: 2, JustifiedBlockRoot: []byte{0x0b}}
+	conns := &NodeConns{Conns: make(map[int]*grpc.ClientConn)}
+	for i, server := range []*headServer{
+		{head: majority},
+		{head: minority, blocks: []*eth.ChainHead{minority, minority, majority}},
+	} {
+		node, stop := startBeaconChainServer(t, server)
+		defer stop()
+		conns.Conns[i] = node.Conns[0]
+	}
+	evaluator := PartitionHealed(4, 2)
+	if !evaluator.Policy(4) || evaluator.Policy(5) {
+		t.Error("Expected the heads to be checked at the heal epoch only")
+	}
+	if err := evaluator.Evaluation(conns); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	// A node that stays on its own fork fails after the given slots.
+	node, stop := startBeaconChainServer(t, &headServer{head: minority})
+	defer stop()
+	conns.Conns[1] = node.Conns[0]
+	err := PartitionHealed(4, 1).Evaluation(conns)
+	if err == nil || !strings.Contains(err.Error(), "heads did not converge within 1 slots of reconnecting the nodes: beacon nodes have different heads") {
+		t.Errorf("Expected heads not to converge, received %v", err)
+	}
+
+	// A node that stops answering doesn't hold the evaluator past the given slots.
+	node, stop = startBeaconChainServer(t, &headServer{head: majority, hangs: true})
+	defer stop()
+	conns.Conns[0] = node.Conns[0]
+	node, stop = startBeaconChainServer(t, &headServer{head: minority, blocks: []*eth.ChainHead{{HeadSlot: 43, HeadBlockRoot: []byte{0xcc}}}})
+	defer stop()
+	conns.Conns[1] = node.Conns[0]
+	err = PartitionHealed(4, 1).Evaluation(conns)
+	if err == nil || !strings.Contains(err.Error(), "heads did not converge within 1 slots of reconnecting the nodes: beacon nodes have different heads") {
+		t.Errorf("Expected heads not to converge, received %v", err)
+	}
+}
+
+func TestHeadConsistencyEvaluator(t *testing.T) {
+	justified := []byte{0x0a}
+	headA := &eth.ChainHead{HeadSlot: 40, HeadBlockRoot: []byte{0xaa}, JustifiedEpoch: 3, JustifiedBlockRoot: justified}
+	headB := &eth.ChainHead{HeadSlot: 40, HeadBlockRoot: []byte{0xbb}, JustifiedEpoch: 3, JustifiedBlockRoot: justified}
+	headSlot38 := &eth.ChainHead{HeadSlot: 38, HeadBlockRoot: []byte{0x38}, JustifiedEpoch: 3, JustifiedBlockRoot: justified}
+	headSlot37 := &eth.ChainHead{HeadSlot: 37, HeadBlockRoot: []byte{0x37}, JustifiedEpoch: 3, JustifiedBlockRoot: justified}
+	tests := []struct {
+		name     string
+		nodes    []*headServer
+		errorMsg string
+	}{
+		{
+			name:  "same head",
+			nodes: []*headServer{{head: headA}, {head: headA}},
+		},
+		{
+			name:  "node within 2 slots",
+			nodes: []*headServer{{head: headA}, {head: headSlot38}, {head: headA}},
+		},
+		{
+			name:  "lagging node",
+			nodes: []*headServer{{head: headA}, {head: headA}, {head: headSlot37}},
+			errorMsg: "beacon node heads are 3 slots apart, more than 2:\n" +
+				"node  head slot  head block root  justified epoch  justified block root\n" +
+				"0     40         0xaa             3                0x0a\n" +
+				"1     40         0xaa             3                0x0a\n" +
+				"2     37         0x37             3                0x0a",
+		},
+		{
+			name:     "divergent heads at the same slot",
+			nodes:    []*headServer{{head: headA}, {head: headB}},
+			errorMsg: "beacon nodes have divergent head blocks at slot 40",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conns := &NodeConns{Conns: make(map[int]*grpc.ClientConn)}
+			for i, server := range tt.nodes {
+				node, stop := startBeaconChainServer(t, server)
+				defer stop()
+				conns.Conns[i] = node.Conns[0]
+			}
+
+			err := HeadConsistencyEvaluator().Evaluation(conns)
+			if tt.errorMsg == "" {
+				if err != nil {
+					t.Errorf("Unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.errorMsg) {
+				t.Errorf("Expected error containing %q, received %v", tt.errorMsg, err)
+			}
+		})
+	}
+}
+
+func TestRestartedNodeSynced(t *testing.T) {
+	config := params.BeaconConfig()
+	defer params.OverrideBeaconConfig(config)
+	testConfig := *config
+	testConfig.SecondsPerSlot = 1
+	params.OverrideBeaconConfig(&testConfig)
+
+	tests := []struct {
+		name      string
+		restarted *headServer
+		errorMsg  string
+	}{
+		{
+			name:      "within tolerance",
+			restarted: &headServer{head: &eth.ChainHead{HeadSlot: 38}},
+		},
+		{
+			name:      "catches up",
+			restarted: &headServer{head: &eth.ChainHead{HeadSlot: 20}, blocks: []*eth.ChainHead{{HeadSlot: 30}, {HeadSlot: 39}}},
+		},
+		{
+			name:      "behind",
+			restarted: &headServer{head: &eth.ChainHead{HeadSlot: 20}, blocks: []*eth.ChainHead{{HeadSlot: 30}}},
+			errorMsg:  "restarted node is behind, expected head slot of at least 38, received 30",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conns, stop := startBeaconChainServer(t, &headServer{head: &eth.ChainHead{HeadSlot: 40}})
+			defer stop()
+			restarted, stopRestarted := startBeaconChainServer(t, tt.restarted)
+			defer stopRestarted()
+			conns.Conns[1] = restarted.Conns[0]
+
+			err := RestartedNodeSynced(1, 2, 2).Evaluation(conns)
+			if tt.errorMsg == "" {
+				if err != nil {
+					t.Errorf("Unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.errorMsg) {
+				t.Errorf("Expected error containing %q, received %v", tt.errorMsg, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,60 @@
+package evaluators
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+	eth "github.com/prysmaticlabs/ethereumapis/eth/v1alpha1"
+)
+
+// DefaultParticipationThreshold is the participation rate expected from a healthy local network.
+const DefaultParticipationThreshold = 0.95
+
+// ParticipationAtEpoch returns an evaluator that ensures the participation rate of the previous
+// epoch is at least the threshold on every beacon node. The rates of all the nodes are reported
+// on failure, so nodes with a diverging view can be spotted.
+func ParticipationAtEpoch(threshold float32) Evaluator {
+	return Evaluator{
+		Name:   "participation_above_threshold_epoch_%d",
+		Policy: AfterNthEpoch(0),
+		Evaluation: func(conns *NodeConns) error {
+			return participationAboveThreshold(conns, threshold)
+		},
+		Retries: transientRetries,
+	}
+}
+
+func participationAboveThreshold(conns *NodeConns, threshold float32) error {
+	var views []string
+	var belowThreshold bool
+	for _, index := range conns.sortedIndices() {
+		client := eth.NewBeaconChainClient(conns.Conns[index])
+		// Without an epoch, the participation of the previous epoch is returned since the current one is in progress.
+		res, err := client.GetValidatorParticipation(context.Background(), &eth.GetValidatorParticipationRequest{})
+		if err != nil {
+			return errors.Wrapf(err, "failed to get validator participation of beacon node %d", index)
+		}
+		// Participation of the genesis epoch is incomplete, as the first blocks can't include attestations yet.
+		if res.Epoch == 0 {
+			continue
+		}
+		participation := res.Participation
+		views = append(views, fmt.Sprintf(
+			"node %d: %.2f%% at epoch %d (%d/%d gwei voted)",
+			index,
+			participation.GlobalParticipationRate*100,
+			res.Epoch,
+			participation.VotedEther,
+			participation.EligibleEther,
+		))
+		if participation.GlobalParticipationRate < threshold {
+			belowThreshold = true
+		}
+	}
+	if belowThreshold {
+		return fmt.Errorf("participation below %.2f%%, %s", threshold*100, strings.Join(views, ", "))
+	}
+	return nil
+}
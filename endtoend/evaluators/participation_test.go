@@ -0,0 +1,82 @@
+package evaluators
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	eth "github.com/prysmaticlabs/ethereumapis/eth/v1alpha1"
+	"google.golang.org/grpc"
+)
+
+// participationServer serves a fixed participation of the previous epoch.
+type participationServer struct {
+	eth.BeaconChainServer
+	epoch uint64
+	rate  float32
+}
+
+func (s *participationServer) GetValidatorParticipation(
+	_ context.Context, _ *eth.GetValidatorParticipationRequest,
+) (*eth.ValidatorParticipationResponse, error) {
+	return &eth.ValidatorParticipationResponse{
+		Epoch: s.epoch,
+		Participation: &eth.ValidatorParticipation{
+			GlobalParticipationRate: s.rate,
+			VotedEther:              uint64(s.rate * 2048e9),
+			EligibleEther:           2048e9,
+		},
+	}, nil
+}
+
+func TestParticipationAtEpoch(t *testing.T) {
+	tests := []struct {
+		name     string
+		epoch    uint64
+		rates    []float32
+		errorMsg string
+	}{
+		{
+			name:  "full participation",
+			epoch: 2,
+			rates: []float32{1, 1},
+		},
+		{
+			name:  "participation at threshold",
+			epoch: 2,
+			rates: []float32{1, 0.95},
+		},
+		{
+			name:     "node below threshold",
+			epoch:    2,
+			rates:    []float32{1, 0.5},
+			errorMsg: "participation below 95.00%, node 0: 100.00% at epoch 2 (2048000000000/2048000000000 gwei voted), node 1: 50.00% at epoch 2",
+		},
+		{
+			name:  "genesis epoch is ignored",
+			epoch: 0,
+			rates: []float32{0.5, 0.5},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conns := &NodeConns{Conns: make(map[int]*grpc.ClientConn)}
+			for i, rate := range tt.rates {
+				node, stop := startBeaconChainServer(t, &participationServer{epoch: tt.epoch, rate: rate})
+				defer stop()
+				conns.Conns[i] = node.Conns[0]
+			}
+
+			err := ParticipationAtEpoch(DefaultParticipationThreshold).Evaluation(conns)
+			if tt.errorMsg == "" {
+				if err != nil {
+					t.Errorf("Unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.errorMsg) {
+				t.Errorf("Expected error containing %q, received %v", tt.errorMsg, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,71 @@
+package evaluators
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	ptypes "github.com/gogo/protobuf/types"
+	"github.com/pkg/errors"
+	eth "github.com/prysmaticlabs/ethereumapis/eth/v1alpha1"
+)
+
+// peersConnectTimeout is how long the beacon nodes are given to connect to each other, as peer
+// connections can take a few seconds after startup.
+var peersConnectTimeout = 10 * time.Second
+
+// peersPollInterval is how often the peers of the beacon nodes are listed while waiting for them to connect.
+var peersPollInterval = 500 * time.Millisecond
+
+// PeersConnect returns an evaluator that ensures each of the beacon nodes is connected to all the
+// others. The peer addresses of every node are reported on failure, so a missing link can be spotted.
+func PeersConnect(numBeaconNodes uint64) Evaluator {
+	return Evaluator{
+		Name:   "peers_connect_epoch_%d",
+		Policy: onGenesisEpoch,
+		Evaluation: func(conns *NodeConns) error {
+			return peersConnect(conns, numBeaconNodes-1)
+		},
+		Retries: transientRetries,
+	}
+}
+
+func peersConnect(conns *NodeConns, expectedPeers uint64) error {
+	deadline := time.Now().Add(peersConnectTimeout)
+	for {
+		views, connected, err := listPeers(conns, expectedPeers)
+		if err != nil {
+			return err
+		}
+		if connected {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("expected every node to have %d peers after %v, %s", expectedPeers, peersConnectTimeout, strings.Join(views, "; "))
+		}
+		time.Sleep(peersPollInterval)
+	}
+}
+
+// listPeers describes the peers of every node, and reports whether all the nodes have the expected amount of peers.
+func listPeers(conns *NodeConns, expectedPeers uint64) ([]string, bool, error) {
+	var views []string
+	connected := true
+	for _, index := range conns.sortedIndices() {
+		client := eth.NewNodeClient(conns.Conns[index])
+		peers, err := client.ListPeers(context.Background(), &ptypes.Empty{})
+		if err != nil {
+			return nil, false, errors.Wrapf(err, "failed to list peers of beacon node %d", index)
+		}
+		addresses := make([]string, len(peers.Peers))
+		for i, peer := range peers.Peers {
+			addresses[i] = peer.Address
+		}
+		views = append(views, fmt.Sprintf("node %d has %d peers: [%s]", index, len(addresses), strings.Join(addresses, ", ")))
+		if uint64(len(addresses)) != expectedPeers {
+			connected = false
+		}
+	}
+	return views, connected, nil
+}
@@ -0,0 +1,96 @@
+package evaluators
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	ptypes "github.com/gogo/protobuf/types"
+	eth "github.com/prysmaticlabs/ethereumapis/eth/v1alpha1"
+	"google.golang.org/grpc"
+)
+
+// peersServer serves the given peer addresses, only once ListPeers was called connectAfter times.
+type peersServer struct {
+	eth.NodeServer
+	addresses    []string
+	connectAfter int
+	calls        int
+}
+
+func (s *peersServer) ListPeers(_ context.Context, _ *ptypes.Empty) (*eth.Peers, error) {
+	s.calls++
+	peers := &eth.Peers{}
+	if s.calls <= s.connectAfter {
+		return peers, nil
+	}
+	for _, address := range s.addresses {
+		peers.Peers = append(peers.Peers, &eth.Peer{Address: address})
+	}
+	return peers, nil
+}
+
+func startNodeServer(t *testing.T, nodeServer eth.NodeServer) (*grpc.ClientConn, func()) {
+	return startServer(t, func(server *grpc.Server) {
+		eth.RegisterNodeServer(server, nodeServer)
+	})
+}
+
+func TestPeersConnect(t *testing.T) {
+	defaultTimeout, defaultInterval := peersConnectTimeout, peersPollInterval
+	peersConnectTimeout, peersPollInterval = 200*time.Millisecond, 10*time.Millisecond
+	defer func() {
+		peersConnectTimeout, peersPollInterval = defaultTimeout, defaultInterval
+	}()
+
+	tests := []struct {
+		name     string
+		servers  []*peersServer
+		errorMsg string
+	}{
+		{
+			name: "all nodes connected",
+			servers: []*peersServer{
+				{addresses: []string{"/ip4/127.0.0.1/tcp/2"}},
+				{addresses: []string{"/ip4/127.0.0.1/tcp/1"}},
+			},
+		},
+		{
+			name: "nodes connect after a few attempts",
+			servers: []*peersServer{
+				{addresses: []string{"/ip4/127.0.0.1/tcp/2"}, connectAfter: 3},
+				{addresses: []string{"/ip4/127.0.0.1/tcp/1"}, connectAfter: 5},
+			},
+		},
+		{
+			name: "node never connects",
+			servers: []*peersServer{
+				{addresses: []string{"/ip4/127.0.0.1/tcp/2"}},
+				{addresses: []string{"/ip4/127.0.0.1/tcp/1"}, connectAfter: 1000},
+			},
+			errorMsg: "node 0 has 1 peers: [/ip4/127.0.0.1/tcp/2]; node 1 has 0 peers: []",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conns := &NodeConns{Conns: make(map[int]*grpc.ClientConn)}
+			for i, server := range tt.servers {
+				conn, stop := startNodeServer(t, server)
+				defer stop()
+				conns.Conns[i] = conn
+			}
+
+			err := PeersConnect(uint64(len(tt.servers))).Evaluation(conns)
+			if tt.errorMsg == "" {
+				if err != nil {
+					t.Errorf("Unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.errorMsg) {
+				t.Errorf("Expected error containing %q, received %v", tt.errorMsg, err)
+			}
+		})
+	}
+}
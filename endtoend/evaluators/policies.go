@@ -0,0 +1,28 @@
+package evaluators
+
+// Policies decide at which epochs of the E2E an evaluator runs, evaluators are skipped at
+// every other epoch.
+
+func onGenesisEpoch(currentEpoch uint64) bool {
+	return currentEpoch < 2
+}
+
+// AllEpochs runs the evaluator at every epoch.
+func AllEpochs(_ uint64) bool {
+	return true
+}
+
+// AfterNthEpoch runs the evaluator at every epoch after the given one. Not including the
+// first epoch, with AfterNthEpoch(0), avoids issues with genesis.
+func AfterNthEpoch(afterEpoch uint64) func(uint64) bool {
+	return func(currentEpoch uint64) bool {
+		return currentEpoch > afterEpoch
+	}
+}
+
+// OnEpoch runs the evaluator only at the given epoch.
+func OnEpoch(epoch uint64) func(uint64) bool {
+	return func(currentEpoch uint64) bool {
+		return currentEpoch == epoch
+	}
+}
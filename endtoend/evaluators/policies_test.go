@@ -0,0 +1,36 @@
+package evaluators
+
+import (
+	"testing"
+)
+
+func TestPolicies(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy func(uint64) bool
+		epochs []uint64
+	}{
+		{name: "on genesis epoch", policy: onGenesisEpoch, epochs: []uint64{0, 1}},
+		{name: "all epochs", policy: AllEpochs, epochs: []uint64{0, 1, 2, 3, 4, 5}},
+		{name: "after epoch 3", policy: AfterNthEpoch(3), epochs: []uint64{4, 5}},
+		{name: "on epoch 2", policy: OnEpoch(2), epochs: []uint64{2}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var ran []uint64
+			for epoch := uint64(0); epoch < 6; epoch++ {
+				if tt.policy(epoch) {
+					ran = append(ran, epoch)
+				}
+			}
+			if len(ran) != len(tt.epochs) {
+				t.Fatalf("Expected to run at epochs %v, ran at %v", tt.epochs, ran)
+			}
+			for i := range ran {
+				if ran[i] != tt.epochs[i] {
+					t.Errorf("Expected to run at epochs %v, ran at %v", tt.epochs, ran)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,213 @@
+package evaluators
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	ptypes "github.com/gogo/protobuf/types"
+	"github.com/pkg/errors"
+	eth "github.com/prysmaticlabs/ethereumapis/eth/v1alpha1"
+	"github.com/prysmaticlabs/go-bitfield"
+	"github.com/prysmaticlabs/prysm/shared/mathutil"
+	"github.com/prysmaticlabs/prysm/shared/params"
+)
+
+// RewardAccountingEvaluator returns an evaluator that checks the rewards of the attestations made
+// at the given epoch, which are processed at the end of the following epoch. Every validator that
+// attested at the epoch and isn't slashed must have gained at least its base reward, as defined by
+// the spec with the running config.
+//
+// The committees of the epoch are read while it's the current epoch, as older committees are only
+// served by archive nodes. The attestations included for the epoch and the balances are read during
+// the following epoch, then compared to the balances an epoch later.
+func RewardAccountingEvaluator(epoch uint64) Evaluator {
+	var committees *eth.BeaconCommittees
+	var before *rewardSnapshot
+	return Evaluator{
+		Name: "reward_accounting_epoch_%d",
+		Policy: func(currentEpoch uint64) bool {
+			return currentEpoch >= epoch && currentEpoch <= epoch+2
+		},
+		Evaluation: func(conns *NodeConns) error {
+			client := conns.BeaconChainClient()
+			var err error
+			switch {
+			case committees == nil:
+				committees, err = listCommittees(client, epoch)
+				return err
+			case before == nil:
+				before, err = takeRewardSnapshot(client, epoch, committees)
+				return err
+			default:
+				balances, err := listBalances(client)
+				if err != nil {
+					return err
+				}
+				return rewardsMatchSpec(before, balances)
+			}
+		},
+	}
+}
+
+// rewardSnapshot holds what the rewards of the attestations made at an epoch are computed from,
+// read before they are processed.
+type rewardSnapshot struct {
+	epoch              uint64
+	finalizedEpoch     uint64
+	attesters          map[uint64]bool
+	slashed            map[uint64]bool
+	effectiveBalances  map[uint64]uint64
+	totalActiveBalance uint64
+	balances           map[uint64]uint64
+}
+
+// listCommittees returns the committees of the current epoch, which must be the given one.
+func listCommittees(client eth.BeaconChainClient, epoch uint64) (*eth.BeaconCommittees, error) {
+	committees, err := client.ListBeaconCommittees(context.Background(), &eth.ListCommitteesRequest{})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get committees")
+	}
+	if committees.Epoch != epoch {
+		return nil, fmt.Errorf("expected the committees of epoch %d, received the ones of epoch %d", epoch, committees.Epoch)
+	}
+	return committees, nil
+}
+
+func takeRewardSnapshot(client eth.BeaconChainClient, epoch uint64, committees *eth.BeaconCommittees) (*rewardSnapshot, error) {
+	attesters, err := listAttesters(client, epoch, committees)
+	if err != nil {
+		return nil, err
+	}
+	head, err := client.GetChainHead(context.Background(), &ptypes.Empty{})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get chain head")
+	}
+	snapshot := &rewardSnapshot{
+		epoch:             epoch,
+		finalizedEpoch:    head.FinalizedEpoch,
+		attesters:         attesters,
+		slashed:           make(map[uint64]bool),
+		effectiveBalances: make(map[uint64]uint64),
+	}
+	err = listValidators(client, func(registryEpoch uint64, item *eth.Validators_ValidatorContainer) {
+		validator := item.Validator
+		snapshot.slashed[item.Index] = validator.Slashed
+		snapshot.effectiveBalances[item.Index] = validator.EffectiveBalance
+		if validator.ActivationEpoch <= registryEpoch && registryEpoch < validator.ExitEpoch {
+			snapshot.totalActiveBalance += validator.EffectiveBalance
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	if snapshot.totalActiveBalance == 0 {
+		return nil, errors.New("no active balance")
+	}
+	snapshot.balances, err = listBalances(client)
+	if err != nil {
+		return nil, err
+	}
+	return snapshot, nil
+}
+
+// listAttesters returns the validators whose attestations for the epoch were included in blocks.
+func listAttesters(client eth.BeaconChainClient, epoch uint64, committees *eth.BeaconCommittees) (map[uint64]bool, error) {
+	attesters := make(map[uint64]bool)
+	req := &eth.ListAttestationsRequest{
+		QueryFilter: &eth.ListAttestationsRequest_TargetEpoch{TargetEpoch: epoch},
+	}
+	for {
+		res, err := client.ListAttestations(context.Background(), req)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to get attestations of epoch %d", epoch)
+		}
+		for _, att := range res.Attestations {
+			slotCommittees, ok := committees.Committees[att.Data.Slot]
+			if !ok || att.Data.CommitteeIndex >= uint64(len(slotCommittees.Committees)) {
+				return nil, fmt.Errorf("no committee %d at slot %d", att.Data.CommitteeIndex, att.Data.Slot)
+			}
+			committee := slotCommittees.Committees[att.Data.CommitteeIndex].ValidatorIndices
+			for i, index := range committee {
+				if bitfield.Bitlist(att.AggregationBits).BitAt(uint64(i)) {
+					attesters[index] = true
+				}
+			}
+		}
+		if res.NextPageToken == "" {
+			return attesters, nil
+		}
+		req.PageToken = res.NextPageToken
+	}
+}
+
+// baseReward is the reward of a validator for each part of its attestation done right, see
+// get_base_reward in the spec.
+func baseReward(effectiveBalance uint64, totalActiveBalance uint64) uint64 {
+	return effectiveBalance * params.BeaconConfig().BaseRewardFactor /
+		mathutil.IntegerSquareRoot(totalActiveBalance) / params.BeaconConfig().BaseRewardsPerEpoch
+}
+
+type rewardFailure struct {
+	index    uint64
+	delta    int64
+	expected int64
+}
+
+// rewardsMatchSpec ensures every validator that attested and isn't slashed gained at least its base
+// reward. When the chain isn't finalizing, the inactivity penalty all the validators get is taken off.
+func rewardsMatchSpec(before *rewardSnapshot, balances map[uint64]uint64) error {
+	if len(before.attesters) == 0 {
+		return fmt.Errorf("no attestations were included for epoch %d", before.epoch)
+	}
+	var finalityDelay uint64
+	if before.epoch > before.finalizedEpoch {
+		finalityDelay = before.epoch - before.finalizedEpoch
+	}
+	leaking := finalityDelay > params.BeaconConfig().MinEpochsToInactivityPenalty
+
+	var failures []rewardFailure
+	for index := range before.attesters {
+		if before.slashed[index] {
+			continue
+		}
+		balance, ok := balances[index]
+		if !ok {
+			return fmt.Errorf("no balance for validator %d", index)
+		}
+		reward := baseReward(before.effectiveBalances[index], before.totalActiveBalance)
+		expected := int64(reward)
+		if leaking {
+			expected -= int64(params.BeaconConfig().BaseRewardsPerEpoch * reward)
+		}
+		delta := int64(balance) - int64(before.balances[index])
+		if delta < expected {
+			failures = append(failures, rewardFailure{index: index, delta: delta, expected: expected})
+		}
+	}
+	if len(failures) == 0 {
+		return nil
+	}
+	sort.Slice(failures, func(i, j int) bool {
+		shortfallI := failures[i].expected - failures[i].delta
+		shortfallJ := failures[j].expected - failures[j].delta
+		if shortfallI == shortfallJ {
+			return failures[i].index < failures[j].index
+		}
+		return shortfallI > shortfallJ
+	})
+	var views []string
+	for _, f := range failures {
+		if len(views) == worstBalancesReported {
+			break
+		}
+		views = append(views, fmt.Sprintf("validator %d: %+d gwei, expected at least %+d gwei", f.index, f.delta, f.expected))
+	}
+	return fmt.Errorf(
+		"%d validators that attested at epoch %d were not rewarded as expected: %s",
+		len(failures),
+		before.epoch,
+		strings.Join(views, ", "),
+	)
+}
@@ -0,0 +1,146 @@
+package evaluators
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	ptypes "github.com/gogo/protobuf/types"
+	eth "github.com/prysmaticlabs/ethereumapis/eth/v1alpha1"
+)
+
+// rewardsServer serves a registry of 4 validators with a single committee at slot 0 of the epoch,
+// and the balances in turn.
+type rewardsServer struct {
+	eth.BeaconChainServer
+	epoch          uint64
+	finalizedEpoch uint64
+	attestations   []*eth.Attestation
+	slashed        map[uint64]bool
+	balances       [][]uint64
+}
+
+func (s *rewardsServer) ListBeaconCommittees(_ context.Context, _ *eth.ListCommitteesRequest) (*eth.BeaconCommittees, error) {
+	return &eth.BeaconCommittees{
+		Epoch: s.epoch,
+		Committees: map[uint64]*eth.BeaconCommittees_CommitteesList{
+			0: {Committees: []*eth.BeaconCommittees_CommitteeItem{{ValidatorIndices: []uint64{0, 1, 2, 3}}}},
+		},
+	}, nil
+}
+
+func (s *rewardsServer) ListAttestations(_ context.Context, _ *eth.ListAttestationsRequest) (*eth.ListAttestationsResponse, error) {
+	return &eth.ListAttestationsResponse{Attestations: s.attestations}, nil
+}
+
+func (s *rewardsServer) GetChainHead(_ context.Context, _ *ptypes.Empty) (*eth.ChainHead, error) {
+	return &eth.ChainHead{HeadEpoch: s.epoch + 1, FinalizedEpoch: s.finalizedEpoch}, nil
+}
+
+func (s *rewardsServer) ListValidators(_ context.Context, _ *eth.ListValidatorsRequest) (*eth.Validators, error) {
+	var list []*eth.Validators_ValidatorContainer
+	for i := uint64(0); i < 4; i++ {
+		list = append(list, &eth.Validators_ValidatorContainer{
+			Index: i,
+			Validator: &eth.Validator{
+				EffectiveBalance: 32e9,
+				Slashed:          s.slashed[i],
+				ExitEpoch:        ^uint64(0),
+			},
+		})
+	}
+	return &eth.Validators{Epoch: s.epoch + 1, ValidatorList: list}, nil
+}
+
+func (s *rewardsServer) ListValidatorBalances(_ context.Context, _ *eth.ListValidatorBalancesRequest) (*eth.ValidatorBalances, error) {
+	balances := s.balances[0]
+	s.balances = s.balances[1:]
+	res := &eth.ValidatorBalances{}
+	for i, balance := range balances {
+		res.Balances = append(res.Balances, &eth.ValidatorBalances_Balance{Index: uint64(i), Balance: balance})
+	}
+	return res, nil
+}
+
+func TestRewardAccountingEvaluator(t *testing.T) {
+	// Validators 0, 1 and 2 of the committee attested, each with a base reward of 1431087 gwei
+	// out of the 128 ETH at stake.
+	attestations := []*eth.Attestation{
+		{AggregationBits: []byte{0x17}, Data: &eth.AttestationData{Slot: 0, CommitteeIndex: 0}},
+	}
+	start := []uint64{32e9, 32e9, 32e9, 32e9}
+	tests := []struct {
+		name           string
+		epoch          uint64
+		finalizedEpoch uint64
+		attestations   []*eth.Attestation
+		slashed        map[uint64]bool
+		end            []uint64
+		errorMsg       string
+	}{
+		{
+			name:         "attesters rewarded",
+			epoch:        2,
+			attestations: attestations,
+			end:          []uint64{32e9 + 1500000, 32e9 + 1500000, 32e9 + 1500000, 32e9 - 500000},
+		},
+		{
+			name:         "attester not rewarded enough",
+			epoch:        2,
+			attestations: attestations,
+			end:          []uint64{32e9 + 1500000, 32e9 + 1000000, 32e9 - 10, 32e9 - 500000},
+			errorMsg: "2 validators that attested at epoch 2 were not rewarded as expected: " +
+				"validator 2: -10 gwei, expected at least +1431087 gwei, validator 1: +1000000 gwei, expected at least +1431087 gwei",
+		},
+		{
+			name:         "slashed attester",
+			epoch:        2,
+			attestations: attestations,
+			slashed:      map[uint64]bool{2: true},
+			end:          []uint64{32e9 + 1500000, 32e9 + 1500000, 31e9, 32e9 - 500000},
+		},
+		{
+			name:           "inactivity leak",
+			epoch:          6,
+			finalizedEpoch: 1,
+			attestations:   attestations,
+			end:            []uint64{32e9 - 1000000, 32e9 - 1000000, 32e9 - 1000000, 32e9 - 5000000},
+		},
+		{
+			name:     "no attestations",
+			epoch:    2,
+			end:      start,
+			errorMsg: "no attestations were included for epoch 2",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conns, stop := startBeaconChainServer(t, &rewardsServer{
+				epoch:          tt.epoch,
+				finalizedEpoch: tt.finalizedEpoch,
+				attestations:   tt.attestations,
+				slashed:        tt.slashed,
+				balances:       [][]uint64{start, tt.end},
+			})
+			defer stop()
+
+			evaluator := RewardAccountingEvaluator(tt.epoch)
+			if evaluator.Policy(tt.epoch-1) || !evaluator.Policy(tt.epoch+2) || evaluator.Policy(tt.epoch+3) {
+				t.Fatal("Expected the evaluator to run from the epoch for 3 epochs")
+			}
+			var err error
+			for i := 0; i < 3 && err == nil; i++ {
+				err = evaluator.Evaluation(conns)
+			}
+			if tt.errorMsg == "" {
+				if err != nil {
+					t.Errorf("Unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.errorMsg) {
+				t.Errorf("Expected error containing %q, received %v", tt.errorMsg, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,79 @@
+package evaluators
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/gogo/protobuf/proto"
+	ptypes "github.com/gogo/protobuf/types"
+	"github.com/pkg/errors"
+	eth "github.com/prysmaticlabs/ethereumapis/eth/v1alpha1"
+	slashpb "github.com/prysmaticlabs/prysm/proto/slashing"
+	"google.golang.org/grpc"
+)
+
+// doubleVoteEpochDistance puts the double vote far enough ahead of the chain head that it can't
+// conflict with, or surround, any vote the validator really made during the run.
+const doubleVoteEpochDistance = 100
+
+// SlasherDetectsDoubleVote returns an evaluator that submits two conflicting votes of the
+// same validator to the slasher listening on slasherRPCPort, and ensures the second one is
+// reported as a double vote. Only the detection is checked.
+// TODO(3259): Check the slashing is included in a block and the validator penalised once
+// beacon nodes include slashings.
+func SlasherDetectsDoubleVote(slasherRPCPort uint64) Evaluator {
+	return Evaluator{
+		Name:   "slasher_detects_double_vote_epoch_%d",
+		Policy: OnEpoch(2),
+		Evaluation: func(conns *NodeConns) error {
+			return doubleVoteDetected(conns.BeaconChainClient(), slasherRPCPort)
+		},
+	}
+}
+
+func doubleVoteDetected(client eth.BeaconChainClient, slasherRPCPort uint64) error {
+	conn, err := grpc.Dial(fmt.Sprintf("127.0.0.1:%d", slasherRPCPort), grpc.WithInsecure())
+	if err != nil {
+		return errors.Wrap(err, "failed to dial slasher")
+	}
+	defer conn.Close()
+	slasherClient := slashpb.NewSlasherClient(conn)
+
+	chainHead, err := client.GetChainHead(context.Background(), &ptypes.Empty{})
+	if err != nil {
+		return errors.Wrap(err, "failed to get chain head")
+	}
+	targetEpoch := chainHead.HeadEpoch + doubleVoteEpochDistance
+	vote := &eth.IndexedAttestation{
+		AttestingIndices: []uint64{0},
+		Data: &eth.AttestationData{
+			BeaconBlockRoot: chainHead.HeadBlockRoot,
+			Source:          &eth.Checkpoint{Epoch: targetEpoch - 1, Root: make([]byte, 32)},
+			Target:          &eth.Checkpoint{Epoch: targetEpoch, Root: make([]byte, 32)},
+		},
+		Signature: make([]byte, 96),
+	}
+	conflictingVote := proto.Clone(vote).(*eth.IndexedAttestation)
+	conflictingRoot := [32]byte{1}
+	conflictingVote.Data.Target.Root = conflictingRoot[:]
+
+	res, err := slasherClient.IsSlashableAttestation(context.Background(), vote)
+	if err != nil {
+		return errors.Wrap(err, "failed to submit vote to slasher")
+	}
+	if len(res.AttesterSlashing) != 0 {
+		return fmt.Errorf("expected first vote to not be slashable, received %d slashings", len(res.AttesterSlashing))
+	}
+	res, err = slasherClient.IsSlashableAttestation(context.Background(), conflictingVote)
+	if err != nil {
+		return errors.Wrap(err, "failed to submit conflicting vote to slasher")
+	}
+	if len(res.AttesterSlashing) != 1 {
+		return fmt.Errorf("expected conflicting vote to be detected as a double vote, received %d slashings", len(res.AttesterSlashing))
+	}
+	slashing := res.AttesterSlashing[0]
+	if !proto.Equal(slashing.Attestation_1, conflictingVote) || !proto.Equal(slashing.Attestation_2, vote) {
+		return errors.New("attester slashing does not contain the conflicting votes")
+	}
+	return nil
+}
@@ -0,0 +1,138 @@
+package evaluators
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"time"
+
+	ptypes "github.com/gogo/protobuf/types"
+	"github.com/pkg/errors"
+	eth "github.com/prysmaticlabs/ethereumapis/eth/v1alpha1"
+	"github.com/prysmaticlabs/prysm/shared/params"
+	"google.golang.org/grpc"
+)
+
+// genesisTimeout is how long the beacon nodes are given to process the chain start, as the E2E
+// only waits for the first node.
+var genesisTimeout = 30 * time.Second
+
+// genesisPollInterval is how often a beacon node is asked for the genesis while waiting for it.
+var genesisPollInterval = 500 * time.Millisecond
+
+// GenesisAgreement returns an evaluator that ensures, once after startup, the beacon nodes have the
+// same genesis time and genesis block. The genesis block commits to the genesis state, so nodes
+// agreeing on it started with the same genesis validators.
+func GenesisAgreement() Evaluator {
+	return Evaluator{
+		Name:       "genesis_agreement",
+		RunOnce:    true,
+		Evaluation: genesisAgreement,
+	}
+}
+
+func genesisAgreement(conns *NodeConns) error {
+	var reference int
+	var referenceTime time.Time
+	var referenceRoot []byte
+	for i, index := range conns.sortedIndices() {
+		genesis, err := waitForGenesis(conns.Conns[index])
+		if err != nil {
+			return nodeError(index, err)
+		}
+		genesisTime := time.Unix(genesis.GenesisTime.Seconds, int64(genesis.GenesisTime.Nanos))
+		blocks, err := eth.NewBeaconChainClient(conns.Conns[index]).ListBlocks(context.Background(), &eth.ListBlocksRequest{
+			QueryFilter: &eth.ListBlocksRequest_Genesis{Genesis: true},
+		})
+		if err != nil {
+			return nodeError(index, errors.Wrap(err, "failed to get genesis block"))
+		}
+		if len(blocks.BlockContainers) != 1 {
+			return nodeError(index, fmt.Errorf("expected 1 genesis block, received %d", len(blocks.BlockContainers)))
+		}
+		root := blocks.BlockContainers[0].BlockRoot
+		if i == 0 {
+			reference, referenceTime, referenceRoot = index, genesisTime, root
+			continue
+		}
+		if !genesisTime.Equal(referenceTime) {
+			return nodeError(index, fmt.Errorf("genesis time %v differs from %v on beacon node %d", genesisTime, referenceTime, reference))
+		}
+		if !bytes.Equal(root, referenceRoot) {
+			return nodeError(index, fmt.Errorf("genesis block %#x differs from %#x on beacon node %d", root, referenceRoot, reference))
+		}
+	}
+	return nil
+}
+
+// DepositContractEchoed returns an evaluator that ensures, once after startup, every beacon node
+// reports the deposit contract at the given address, the one the E2E deployed.
+func DepositContractEchoed(address []byte) Evaluator {
+	return Evaluator{
+		Name:    "deposit_contract_echoed",
+		RunOnce: true,
+		Evaluation: func(conns *NodeConns) error {
+			for _, index := range conns.sortedIndices() {
+				genesis, err := eth.NewNodeClient(conns.Conns[index]).GetGenesis(context.Background(), &ptypes.Empty{})
+				if err != nil {
+					return nodeError(index, errors.Wrap(err, "failed to get genesis"))
+				}
+				if !bytes.Equal(genesis.DepositContractAddress, address) {
+					return nodeError(index, fmt.Errorf("expected deposit contract %#x, received %#x", address, genesis.DepositContractAddress))
+				}
+			}
+			return nil
+		},
+	}
+}
+
+// ChainConfigLoaded returns an evaluator that ensures, once after startup, every beacon node runs
+// with the slots per epoch of the E2E config. The API doesn't serve the config, so it's read from
+// the committees of the current epoch, which cover every slot of the epoch.
+func ChainConfigLoaded() Evaluator {
+	return Evaluator{
+		Name:    "chain_config_loaded",
+		RunOnce: true,
+		Evaluation: func(conns *NodeConns) error {
+			expected := params.BeaconConfig().SlotsPerEpoch
+			for _, index := range conns.sortedIndices() {
+				if _, err := waitForGenesis(conns.Conns[index]); err != nil {
+					return nodeError(index, err)
+				}
+				client := eth.NewBeaconChainClient(conns.Conns[index])
+				committees, err := client.ListBeaconCommittees(context.Background(), &eth.ListCommitteesRequest{})
+				if err != nil {
+					return nodeError(index, errors.Wrap(err, "failed to get committees"))
+				}
+				if uint64(len(committees.Committees)) != expected {
+					return nodeError(index, fmt.Errorf(
+						"expected committees for %d slots per epoch, received %d, the node doesn't run the config of the E2E",
+						expected,
+						len(committees.Committees),
+					))
+				}
+			}
+			return nil
+		},
+	}
+}
+
+// waitForGenesis polls the beacon node until it serves the genesis, which it only does once it
+// processed the chain start, giving up after genesisTimeout.
+func waitForGenesis(conn *grpc.ClientConn) (*eth.Genesis, error) {
+	client := eth.NewNodeClient(conn)
+	deadline := time.Now().Add(genesisTimeout)
+	for {
+		genesis, err := client.GetGenesis(context.Background(), &ptypes.Empty{})
+		if err == nil && genesis.GenesisTime != nil && genesis.GenesisTime.Seconds > 0 {
+			return genesis, nil
+		}
+		if time.Now().After(deadline) {
+			if err == nil {
+				err = errors.New("no genesis time")
+			}
+			return nil, errors.Wrapf(err, "chain did not start after %v", genesisTimeout)
+		}
+		time.Sleep(genesisPollInterval)
+	}
+}
@@ -0,0 +1,164 @@
+package evaluators
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	ptypes "github.com/gogo/protobuf/types"
+	eth "github.com/prysmaticlabs/ethereumapis/eth/v1alpha1"
+	"github.com/prysmaticlabs/prysm/shared/params"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// genesisServer serves the genesis, the genesis block and the committees of a beacon node. The
+// genesis is only served once GetGenesis was called startAfter times, as before the chain start.
+type genesisServer struct {
+	eth.BeaconChainServer
+	eth.NodeServer
+	genesisTime   int64
+	genesisRoot   []byte
+	contract      []byte
+	slotsPerEpoch uint64
+	startAfter    int
+	calls         int
+}
+
+func newGenesisServer() *genesisServer {
+	return &genesisServer{
+		genesisTime:   1580000000,
+		genesisRoot:   []byte{0x01},
+		contract:      []byte{0xde, 0xad},
+		slotsPerEpoch: params.BeaconConfig().SlotsPerEpoch,
+	}
+}
+
+func (s *genesisServer) GetGenesis(_ context.Context, _ *ptypes.Empty) (*eth.Genesis, error) {
+	s.calls++
+	if s.calls <= s.startAfter {
+		return nil, status.Error(codes.Internal, "Could not convert genesis time to proto")
+	}
+	genesis := &eth.Genesis{DepositContractAddress: s.contract}
+	genesis.GenesisTime = &ptypes.Timestamp{Seconds: s.genesisTime}
+	return genesis, nil
+}
+
+func (s *genesisServer) ListBlocks(_ context.Context, _ *eth.ListBlocksRequest) (*eth.ListBlocksResponse, error) {
+	return &eth.ListBlocksResponse{
+		BlockContainers: []*eth.BeaconBlockContainer{{BlockRoot: s.genesisRoot}},
+		TotalSize:       1,
+	}, nil
+}
+
+func (s *genesisServer) ListBeaconCommittees(_ context.Context, _ *eth.ListCommitteesRequest) (*eth.BeaconCommittees, error) {
+	committees := &eth.BeaconCommittees{Committees: make(map[uint64]*eth.BeaconCommittees_CommitteesList)}
+	for slot := uint64(0); slot < s.slotsPerEpoch; slot++ {
+		committees.Committees[slot] = &eth.BeaconCommittees_CommitteesList{}
+	}
+	return committees, nil
+}
+
+func startGenesisServer(t *testing.T, genesisServer *genesisServer) (*grpc.ClientConn, func()) {
+	return startServer(t, func(server *grpc.Server) {
+		eth.RegisterBeaconChainServer(server, genesisServer)
+		eth.RegisterNodeServer(server, genesisServer)
+	})
+}
+
+func TestStartupEvaluators(t *testing.T) {
+	defaultTimeout, defaultInterval := genesisTimeout, genesisPollInterval
+	genesisTimeout, genesisPollInterval = 200*time.Millisecond, 10*time.Millisecond
+	defer func() {
+		genesisTimeout, genesisPollInterval = defaultTimeout, defaultInterval
+	}()
+
+	tests := []struct {
+		name      string
+		evaluator Evaluator
+		modify    func(s *genesisServer)
+		errorMsg  string
+	}{
+		{
+			name:      "same genesis",
+			evaluator: GenesisAgreement(),
+			modify:    func(s *genesisServer) {},
+		},
+		{
+			name:      "genesis processed late",
+			evaluator: GenesisAgreement(),
+			modify:    func(s *genesisServer) { s.startAfter = 3 },
+		},
+		{
+			name:      "chain not started",
+			evaluator: GenesisAgreement(),
+			modify:    func(s *genesisServer) { s.startAfter = 1000 },
+			errorMsg:  "beacon node 1: chain did not start after 200ms: rpc error",
+		},
+		{
+			name:      "different genesis time",
+			evaluator: GenesisAgreement(),
+			modify:    func(s *genesisServer) { s.genesisTime++ },
+			errorMsg:  "beacon node 1: genesis time 2020-01-26",
+		},
+		{
+			name:      "different genesis block",
+			evaluator: GenesisAgreement(),
+			modify:    func(s *genesisServer) { s.genesisRoot = []byte{0x02} },
+			errorMsg:  "beacon node 1: genesis block 0x02 differs from 0x01 on beacon node 0",
+		},
+		{
+			name:      "deposit contract echoed",
+			evaluator: DepositContractEchoed([]byte{0xde, 0xad}),
+			modify:    func(s *genesisServer) {},
+		},
+		{
+			name:      "different deposit contract",
+			evaluator: DepositContractEchoed([]byte{0xde, 0xad}),
+			modify:    func(s *genesisServer) { s.contract = []byte{0xbe, 0xef} },
+			errorMsg:  "beacon node 1: expected deposit contract 0xdead, received 0xbeef",
+		},
+		{
+			name:      "config loaded",
+			evaluator: ChainConfigLoaded(),
+			modify:    func(s *genesisServer) {},
+		},
+		{
+			name:      "other config loaded",
+			evaluator: ChainConfigLoaded(),
+			modify:    func(s *genesisServer) { s.slotsPerEpoch++ },
+			errorMsg:  "doesn't run the config of the E2E",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if !tt.evaluator.RunOnce {
+				t.Errorf("Expected %s to run once", tt.evaluator.Name)
+			}
+			conns := &NodeConns{Conns: make(map[int]*grpc.ClientConn)}
+			for i := 0; i < 2; i++ {
+				server := newGenesisServer()
+				// The first node serves the expected genesis, the second the one under test.
+				if i == 1 {
+					tt.modify(server)
+				}
+				conn, stop := startGenesisServer(t, server)
+				defer stop()
+				conns.Conns[i] = conn
+			}
+
+			err := tt.evaluator.Evaluation(conns)
+			if tt.errorMsg == "" {
+				if err != nil {
+					t.Errorf("Unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.errorMsg) {
+				t.Errorf("Expected error containing %q, received %v", tt.errorMsg, err)
+			}
+		})
+	}
+}
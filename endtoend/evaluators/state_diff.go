@@ -0,0 +1,157 @@
+package evaluators
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/gogo/protobuf/proto"
+	pb "github.com/prysmaticlabs/prysm/proto/beacon/p2p/v1"
+)
+
+// BeaconStateDiffer summarizes the changes between two beacon states, to follow how the state of a
+// node evolves over a run.
+type BeaconStateDiffer struct{}
+
+// Diff returns the changes of every top-level field of the state from prev to next, such as
+// "Validators: 64→65, Balances: changed 3 entries", or "no changes". Lists report their length
+// when it changed and how many of the entries they both hold changed. Either state may be nil.
+func (d BeaconStateDiffer) Diff(prev, next *pb.BeaconState) string {
+	var changes []string
+	add := func(field string, change string) {
+		if change != "" {
+			changes = append(changes, field+": "+change)
+		}
+	}
+	add("GenesisTime", uintChange(prev.GetGenesisTime(), next.GetGenesisTime()))
+	add("Slot", uintChange(prev.GetSlot(), next.GetSlot()))
+	add("Fork", messageChange(prev.GetFork(), next.GetFork(), "epoch", prev.GetFork().GetEpoch(), next.GetFork().GetEpoch()))
+	add("LatestBlockHeader", messageChange(
+		prev.GetLatestBlockHeader(),
+		next.GetLatestBlockHeader(),
+		"slot",
+		prev.GetLatestBlockHeader().GetSlot(),
+		next.GetLatestBlockHeader().GetSlot(),
+	))
+	add("BlockRoots", rootsChange(prev.GetBlockRoots(), next.GetBlockRoots()))
+	add("StateRoots", rootsChange(prev.GetStateRoots(), next.GetStateRoots()))
+	add("HistoricalRoots", rootsChange(prev.GetHistoricalRoots(), next.GetHistoricalRoots()))
+	add("Eth1Data", messageChange(
+		prev.GetEth1Data(),
+		next.GetEth1Data(),
+		"deposit count",
+		prev.GetEth1Data().GetDepositCount(),
+		next.GetEth1Data().GetDepositCount(),
+	))
+	prevVotes, nextVotes := prev.GetEth1DataVotes(), next.GetEth1DataVotes()
+	add("Eth1DataVotes", listChange(len(prevVotes), len(nextVotes), func(i int) bool {
+		return !proto.Equal(prevVotes[i], nextVotes[i])
+	}))
+	add("Eth1DepositIndex", uintChange(prev.GetEth1DepositIndex(), next.GetEth1DepositIndex()))
+	prevValidators, nextValidators := prev.GetValidators(), next.GetValidators()
+	add("Validators", listChange(len(prevValidators), len(nextValidators), func(i int) bool {
+		return !proto.Equal(prevValidators[i], nextValidators[i])
+	}))
+	add("Balances", uintsChange(prev.GetBalances(), next.GetBalances()))
+	add("RandaoMixes", rootsChange(prev.GetRandaoMixes(), next.GetRandaoMixes()))
+	add("Slashings", uintsChange(prev.GetSlashings(), next.GetSlashings()))
+	prevAtts, nextAtts := prev.GetPreviousEpochAttestations(), next.GetPreviousEpochAttestations()
+	add("PreviousEpochAttestations", listChange(len(prevAtts), len(nextAtts), func(i int) bool {
+		return !proto.Equal(prevAtts[i], nextAtts[i])
+	}))
+	prevAtts, nextAtts = prev.GetCurrentEpochAttestations(), next.GetCurrentEpochAttestations()
+	add("CurrentEpochAttestations", listChange(len(prevAtts), len(nextAtts), func(i int) bool {
+		return !proto.Equal(prevAtts[i], nextAtts[i])
+	}))
+	if !bytes.Equal(prev.GetJustificationBits(), next.GetJustificationBits()) {
+		add("JustificationBits", fmt.Sprintf("%#x→%#x", []byte(prev.GetJustificationBits()), []byte(next.GetJustificationBits())))
+	}
+	add("PreviousJustifiedCheckpoint", messageChange(
+		prev.GetPreviousJustifiedCheckpoint(),
+		next.GetPreviousJustifiedCheckpoint(),
+		"epoch",
+		prev.GetPreviousJustifiedCheckpoint().GetEpoch(),
+		next.GetPreviousJustifiedCheckpoint().GetEpoch(),
+	))
+	add("CurrentJustifiedCheckpoint", messageChange(
+		prev.GetCurrentJustifiedCheckpoint(),
+		next.GetCurrentJustifiedCheckpoint(),
+		"epoch",
+		prev.GetCurrentJustifiedCheckpoint().GetEpoch(),
+		next.GetCurrentJustifiedCheckpoint().GetEpoch(),
+	))
+	add("FinalizedCheckpoint", messageChange(
+		prev.GetFinalizedCheckpoint(),
+		next.GetFinalizedCheckpoint(),
+		"epoch",
+		prev.GetFinalizedCheckpoint().GetEpoch(),
+		next.GetFinalizedCheckpoint().GetEpoch(),
+	))
+	if len(changes) == 0 {
+		return "no changes"
+	}
+	return strings.Join(changes, ", ")
+}
+
+func uintChange(prev, next uint64) string {
+	if prev == next {
+		return ""
+	}
+	return fmt.Sprintf("%d→%d", prev, next)
+}
+
+// messageChange describes the change of a message by the change of its key field, named key, or
+// as changed when only its other fields changed.
+func messageChange(prev, next proto.Message, key string, prevKey, nextKey uint64) string {
+	if proto.Equal(prev, next) {
+		return ""
+	}
+	if prevKey != nextKey {
+		return fmt.Sprintf("%s %d→%d", key, prevKey, nextKey)
+	}
+	return "changed"
+}
+
+// listChange describes the change of a list from prevLen to nextLen entries, entryChanged
+// reporting whether the entry at an index held by both lists changed.
+func listChange(prevLen, nextLen int, entryChanged func(i int) bool) string {
+	common := prevLen
+	if nextLen < common {
+		common = nextLen
+	}
+	changed := 0
+	for i := 0; i < common; i++ {
+		if entryChanged(i) {
+			changed++
+		}
+	}
+	switch {
+	case prevLen != nextLen && changed > 0:
+		return fmt.Sprintf("%d→%d with %s changed", prevLen, nextLen, entries(changed))
+	case prevLen != nextLen:
+		return fmt.Sprintf("%d→%d", prevLen, nextLen)
+	case changed > 0:
+		return fmt.Sprintf("changed %s", entries(changed))
+	default:
+		return ""
+	}
+}
+
+func rootsChange(prev, next [][]byte) string {
+	return listChange(len(prev), len(next), func(i int) bool {
+		return !bytes.Equal(prev[i], next[i])
+	})
+}
+
+func uintsChange(prev, next []uint64) string {
+	return listChange(len(prev), len(next), func(i int) bool {
+		return prev[i] != next[i]
+	})
+}
+
+func entries(n int) string {
+	if n == 1 {
+		return "1 entry"
+	}
+	return fmt.Sprintf("%d entries", n)
+}
@@ -0,0 +1,150 @@
+package evaluators
+
+import (
+	"testing"
+
+	eth "github.com/prysmaticlabs/ethereumapis/eth/v1alpha1"
+	pb "github.com/prysmaticlabs/prysm/proto/beacon/p2p/v1"
+)
+
+// diffState returns a state at slot 8 with 4 validators, the first epoch justified.
+func diffState() *pb.BeaconState {
+	state := &pb.BeaconState{
+		GenesisTime:       1000,
+		Slot:              8,
+		Fork:              &pb.Fork{PreviousVersion: []byte{0, 0, 0, 0}, CurrentVersion: []byte{0, 0, 0, 0}},
+		LatestBlockHeader: &eth.BeaconBlockHeader{Slot: 8, ParentRoot: []byte{0x08}},
+		Eth1Data:          &eth.Eth1Data{DepositCount: 4, BlockHash: []byte{0xee}},
+		Eth1DataVotes:     []*eth.Eth1Data{{DepositCount: 4}},
+		Eth1DepositIndex:  4,
+		JustificationBits: []byte{0x01},
+		CurrentJustifiedCheckpoint: &eth.Checkpoint{
+			Epoch: 1,
+			Root:  []byte{0x01},
+		},
+		FinalizedCheckpoint: &eth.Checkpoint{Root: []byte{0x00}},
+	}
+	for i := 0; i < 8; i++ {
+		state.BlockRoots = append(state.BlockRoots, []byte{byte(i)})
+		state.StateRoots = append(state.StateRoots, []byte{byte(i)})
+		state.RandaoMixes = append(state.RandaoMixes, []byte{byte(i)})
+		state.Slashings = append(state.Slashings, 0)
+	}
+	for i := 0; i < 4; i++ {
+		state.Validators = append(state.Validators, &eth.Validator{
+			PublicKey:        []byte{byte(i)},
+			EffectiveBalance: 32,
+			ExitEpoch:        ^uint64(0),
+		})
+		state.Balances = append(state.Balances, 32)
+		state.CurrentEpochAttestations = append(state.CurrentEpochAttestations, &pb.PendingAttestation{ProposerIndex: uint64(i)})
+	}
+	return state
+}
+
+func TestBeaconStateDiffer_Diff(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(state *pb.BeaconState)
+		diff   string
+	}{
+		{
+			name:   "same state",
+			modify: func(state *pb.BeaconState) {},
+			diff:   "no changes",
+		},
+		{
+			name: "new validator",
+			modify: func(state *pb.BeaconState) {
+				state.Validators = append(state.Validators, &eth.Validator{PublicKey: []byte{0x04}})
+				state.Balances = append(state.Balances, 32)
+				state.Balances[0] = 31
+				state.Balances[1] = 33
+				state.Balances[3] = 33
+				state.Eth1DepositIndex = 5
+			},
+			diff: "Eth1DepositIndex: 4→5, Validators: 4→5, Balances: 4→5 with 3 entries changed",
+		},
+		{
+			name: "balances changed",
+			modify: func(state *pb.BeaconState) {
+				state.Balances[0] = 31
+				state.Balances[2] = 33
+				state.Balances[3] = 33
+			},
+			diff: "Balances: changed 3 entries",
+		},
+		{
+			name: "validator exited",
+			modify: func(state *pb.BeaconState) {
+				state.Validators[2].ExitEpoch = 4
+				state.Slashings[1] = 32
+			},
+			diff: "Validators: changed 1 entry, Slashings: changed 1 entry",
+		},
+		{
+			name: "next slot",
+			modify: func(state *pb.BeaconState) {
+				state.Slot = 9
+				state.LatestBlockHeader.Slot = 9
+				state.BlockRoots[0] = []byte{0x09}
+				state.StateRoots[0] = []byte{0x09}
+				state.RandaoMixes[1] = []byte{0x09}
+				state.CurrentEpochAttestations = append(state.CurrentEpochAttestations, &pb.PendingAttestation{ProposerIndex: 1})
+			},
+			diff: "Slot: 8→9, LatestBlockHeader: slot 8→9, BlockRoots: changed 1 entry, StateRoots: changed 1 entry, " +
+				"RandaoMixes: changed 1 entry, CurrentEpochAttestations: 4→5",
+		},
+		{
+			name: "epoch transition",
+			modify: func(state *pb.BeaconState) {
+				state.PreviousEpochAttestations = state.CurrentEpochAttestations
+				state.CurrentEpochAttestations = nil
+				state.JustificationBits = []byte{0x03}
+				state.PreviousJustifiedCheckpoint = state.CurrentJustifiedCheckpoint
+				state.CurrentJustifiedCheckpoint = &eth.Checkpoint{Epoch: 2, Root: []byte{0x02}}
+				state.FinalizedCheckpoint = &eth.Checkpoint{Epoch: 0, Root: []byte{0x01}}
+				state.HistoricalRoots = [][]byte{{0x0a}}
+			},
+			diff: "HistoricalRoots: 0→1, PreviousEpochAttestations: 0→4, CurrentEpochAttestations: 4→0, " +
+				"JustificationBits: 0x01→0x03, PreviousJustifiedCheckpoint: epoch 0→1, CurrentJustifiedCheckpoint: epoch 1→2, " +
+				"FinalizedCheckpoint: changed",
+		},
+		{
+			name: "eth1 data",
+			modify: func(state *pb.BeaconState) {
+				state.Eth1Data = &eth.Eth1Data{DepositCount: 4, BlockHash: []byte{0xef}}
+				state.Eth1DataVotes = append(state.Eth1DataVotes, &eth.Eth1Data{DepositCount: 6})
+				state.Eth1DataVotes[0] = &eth.Eth1Data{DepositCount: 5}
+			},
+			diff: "Eth1Data: changed, Eth1DataVotes: 1→2 with 1 entry changed",
+		},
+		{
+			name: "fork",
+			modify: func(state *pb.BeaconState) {
+				state.Fork = &pb.Fork{PreviousVersion: []byte{0, 0, 0, 0}, CurrentVersion: []byte{1, 0, 0, 0}, Epoch: 3}
+				state.GenesisTime = 1001
+			},
+			diff: "GenesisTime: 1000→1001, Fork: epoch 0→3",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			next := diffState()
+			tt.modify(next)
+			if diff := (BeaconStateDiffer{}).Diff(diffState(), next); diff != tt.diff {
+				t.Errorf("Expected diff %q, received %q", tt.diff, diff)
+			}
+		})
+	}
+}
+
+func TestBeaconStateDiffer_Diff_NilState(t *testing.T) {
+	diff := (BeaconStateDiffer{}).Diff(nil, &pb.BeaconState{Slot: 1, Balances: []uint64{32, 32}})
+	if diff != "Slot: 0→1, Balances: 0→2" {
+		t.Errorf("Unexpected diff from a nil state: %q", diff)
+	}
+	if diff := (BeaconStateDiffer{}).Diff(nil, nil); diff != "no changes" {
+		t.Errorf("Unexpected diff between nil states: %q", diff)
+	}
+}
@@ -1,12 +1,17 @@
 package evaluators
 
 import (
+	"bytes"
 	"context"
 	"fmt"
+	"sort"
+	"strings"
+	"time"
 
 	"github.com/pkg/errors"
 	eth "github.com/prysmaticlabs/ethereumapis/eth/v1alpha1"
 	"github.com/prysmaticlabs/prysm/shared/params"
+	"google.golang.org/grpc"
 )
 
 // Evaluator defines the structure of the evaluators used to
@@ -14,71 +19,199 @@ import (
 type Evaluator struct {
 	Name       string
 	Policy     func(currentEpoch uint64) bool
-	Evaluation func(client eth.BeaconChainClient) error
+	Evaluation func(conns *NodeConns) error
+	// RequiresDeposits is set on evaluators following validators deposited on the eth1 chain, they
+	// are skipped when the chain starts from a genesis state.
+	RequiresDeposits bool
+	// RunOnce is set on evaluators checking what the beacon nodes loaded at startup, they run once
+	// before the first epoch is evaluated and their Policy is ignored.
+	RunOnce bool
+	// Retries is how many more times a failing evaluation is run within the epoch before the failure
+	// is recorded, for checks that can fail transiently at an epoch boundary. Strict evaluators
+	// leave it at zero.
+	Retries int
+	// RetryDelay is the wait before each retry, a slot when zero.
+	RetryDelay time.Duration
 }
 
-// ValidatorsAreActive ensures the expected amount of validators are active.
-var ValidatorsAreActive = Evaluator{
-	Name:       "validators_active_epoch_%d",
-	Policy:     onGenesisEpoch,
-	Evaluation: validatorsAreActive,
+// transientRetries is how many times the peer and participation checks are retried, as the nodes
+// may not have processed the epoch boundary yet when they first run.
+const transientRetries = 2
+
+// NodeConns holds the gRPC connection to every beacon node still running, keyed by node index.
+// The connections are dialed once by the E2E and shared by all the evaluators.
+type NodeConns struct {
+	// Evaluated is the index of the node the evaluations are made against.
+	Evaluated int
+	Conns     map[int]*grpc.ClientConn
+	// MonitorPorts are the monitoring ports of the connected nodes, which serve their metrics and
+	// health status, see PollHealthz.
+	MonitorPorts map[int]uint64
+}
+
+// BeaconChainClient returns a beacon chain client of the evaluated node.
+func (n *NodeConns) BeaconChainClient() eth.BeaconChainClient {
+	return eth.NewBeaconChainClient(n.Conns[n.Evaluated])
+}
+
+// sortedIndices returns the indices of the connected nodes in increasing order.
+func (n *NodeConns) sortedIndices() []int {
+	indices := make([]int, 0, len(n.Conns))
+	for index := range n.Conns {
+		indices = append(indices, index)
+	}
+	sort.Ints(indices)
+	return indices
+}
+
+// ValidatorsAreActive returns an evaluator that ensures the given amount of genesis validators are
+// active on every beacon node, with their full effective balance.
+func ValidatorsAreActive(numValidators uint64) Evaluator {
+	return Evaluator{
+		Name:   "validators_active_epoch_%d",
+		Policy: onGenesisEpoch,
+		Evaluation: func(conns *NodeConns) error {
+			for _, index := range conns.sortedIndices() {
+				client := eth.NewBeaconChainClient(conns.Conns[index])
+				if err := validatorsAreActive(client, numValidators); err != nil {
+					return nodeError(index, err)
+				}
+			}
+			return nil
+		},
+	}
+}
+
+// ValidatorKeysAtIndices returns an evaluator that ensures the validator at each index of pubKeys
+// is active with that public key on every beacon node, for chains started from a known genesis.
+func ValidatorKeysAtIndices(pubKeys [][]byte) Evaluator {
+	return Evaluator{
+		Name:   "validator_keys_at_indices_epoch_%d",
+		Policy: onGenesisEpoch,
+		Evaluation: func(conns *NodeConns) error {
+			for _, index := range conns.sortedIndices() {
+				client := eth.NewBeaconChainClient(conns.Conns[index])
+				if err := validatorKeysAtIndices(client, pubKeys); err != nil {
+					return nodeError(index, err)
+				}
+			}
+			return nil
+		},
+	}
 }
 
 // ValidatorsParticipating ensures the expected amount of validators are active.
 var ValidatorsParticipating = Evaluator{
 	Name:       "validators_participating_epoch_%d",
-	Policy:     afterNthEpoch(3),
+	Policy:     AfterNthEpoch(3),
 	Evaluation: validatorsParticipating,
+	Retries:    transientRetries,
 }
 
-func onGenesisEpoch(currentEpoch uint64) bool {
-	return currentEpoch < 2
-}
+// validatorsAreActive lists the indices of the genesis validators in an unexpected state, so a
+// deposit processing issue can be told apart from a validator that was slashed or exited.
+func validatorsAreActive(client eth.BeaconChainClient, numValidators uint64) error {
+	farFutureEpoch := params.BeaconConfig().FarFutureEpoch
+	var active uint64
+	var notActivated, exiting, wrongBalance []uint64
+	err := listValidators(client, func(epoch uint64, item *eth.Validators_ValidatorContainer) {
+		validator := item.Validator
+		if validator.ActivationEpoch <= epoch && epoch < validator.ExitEpoch {
+			active++
+		}
+		if item.Index >= numValidators {
+			return
+		}
+		if validator.ActivationEpoch != 0 {
+			notActivated = append(notActivated, item.Index)
+		}
+		if validator.ExitEpoch != farFutureEpoch || validator.WithdrawableEpoch != farFutureEpoch {
+			exiting = append(exiting, item.Index)
+		}
+		if validator.EffectiveBalance != params.BeaconConfig().MaxEffectiveBalance {
+			wrongBalance = append(wrongBalance, item.Index)
+		}
+	})
+	if err != nil {
+		return err
+	}
 
-// Not including first epoch because of issues with genesis.
-func afterNthEpoch(afterEpoch uint64) func(uint64) bool {
-	return func(currentEpoch uint64) bool {
-		return currentEpoch > afterEpoch
+	var problems []string
+	if active != numValidators {
+		problems = append(problems, fmt.Sprintf("expected validator count to be %d, received %d", numValidators, active))
+	}
+	if len(notActivated) > 0 {
+		problems = append(problems, fmt.Sprintf("genesis validators not activated at epoch 0: %v", notActivated))
+	}
+	if len(exiting) > 0 {
+		problems = append(problems, fmt.Sprintf("genesis validators with an exit or withdrawable epoch: %v", exiting))
+	}
+	if len(wrongBalance) > 0 {
+		problems = append(problems, fmt.Sprintf(
+			"genesis validators with an effective balance other than %d: %v",
+			params.BeaconConfig().MaxEffectiveBalance,
+			wrongBalance,
+		))
 	}
+	if len(problems) > 0 {
+		return errors.New(strings.Join(problems, "; "))
+	}
+	return nil
 }
 
-func validatorsAreActive(client eth.BeaconChainClient) error {
-	// Balances actually fluctuate but we just want to check initial balance.
-	validatorRequest := &eth.ListValidatorsRequest{}
-	validators, err := client.ListValidators(context.Background(), validatorRequest)
-	if err != nil {
-		return errors.Wrap(err, "failed to get validators")
+func validatorKeysAtIndices(client eth.BeaconChainClient, pubKeys [][]byte) error {
+	var mismatched, inactive []uint64
+	for i, pubKey := range pubKeys {
+		index := uint64(i)
+		validator, err := client.GetValidator(context.Background(), &eth.GetValidatorRequest{
+			QueryFilter: &eth.GetValidatorRequest_Index{Index: index},
+		})
+		if err != nil {
+			return errors.Wrapf(err, "failed to get validator %d", index)
+		}
+		if !bytes.Equal(validator.PublicKey, pubKey) {
+			mismatched = append(mismatched, index)
+		}
+		if validator.ActivationEpoch != 0 || validator.ExitEpoch != params.BeaconConfig().FarFutureEpoch {
+			inactive = append(inactive, index)
+		}
 	}
 
-	expectedCount := params.BeaconConfig().MinGenesisActiveValidatorCount
-	receivedCount := uint64(len(validators.ValidatorList))
-	if expectedCount != receivedCount {
-		return fmt.Errorf("expected validator count to be %d, recevied %d", expectedCount, receivedCount)
+	var problems []string
+	if len(mismatched) > 0 {
+		problems = append(problems, fmt.Sprintf("validators with an unexpected public key: %v", mismatched))
+	}
+	if len(inactive) > 0 {
+		problems = append(problems, fmt.Sprintf("validators not active since genesis: %v", inactive))
+	}
+	if len(problems) > 0 {
+		return errors.New(strings.Join(problems, "; "))
 	}
+	return nil
+}
 
-	for _, item := range validators.ValidatorList {
-		if item.Validator.ActivationEpoch != 0 {
-			return fmt.Errorf("expected genesis validator epoch to be 0, received %d", item.Validator.ActivationEpoch)
+// listValidators pages through the validator registry, calling fn with every validator along
+// with the epoch the registry was read at.
+func listValidators(client eth.BeaconChainClient, fn func(epoch uint64, item *eth.Validators_ValidatorContainer)) error {
+	req := &eth.ListValidatorsRequest{}
+	for {
+		validators, err := client.ListValidators(context.Background(), req)
+		if err != nil {
+			return errors.Wrap(err, "failed to get validators")
 		}
-		if item.Validator.ExitEpoch != params.BeaconConfig().FarFutureEpoch {
-			return fmt.Errorf("expected genesis validator exit epoch to be far future, received %d", item.Validator.ExitEpoch)
+		for _, item := range validators.ValidatorList {
+			fn(validators.Epoch, item)
 		}
-		if item.Validator.WithdrawableEpoch != params.BeaconConfig().FarFutureEpoch {
-			return fmt.Errorf("expected genesis validator withdrawable epoch to be far future, received %d", item.Validator.WithdrawableEpoch)
-		}
-		if item.Validator.EffectiveBalance != params.BeaconConfig().MaxEffectiveBalance {
-			return fmt.Errorf(
-				"expected genesis validator effective balance to be %d, received %d",
-				params.BeaconConfig().MaxEffectiveBalance,
-				item.Validator.EffectiveBalance,
-			)
+		if validators.NextPageToken == "" {
+			return nil
 		}
+		req.PageToken = validators.NextPageToken
 	}
-	return nil
 }
 
 // validatorsParticipating ensures the validators have an acceptable participation rate.
-func validatorsParticipating(client eth.BeaconChainClient) error {
+func validatorsParticipating(conns *NodeConns) error {
+	client := conns.BeaconChainClient()
 	validatorRequest := &eth.GetValidatorParticipationRequest{}
 	participation, err := client.GetValidatorParticipation(context.Background(), validatorRequest)
 	if err != nil {
@@ -0,0 +1,151 @@
+package evaluators
+
+import (
+	"strings"
+	"testing"
+
+	eth "github.com/prysmaticlabs/ethereumapis/eth/v1alpha1"
+	"github.com/prysmaticlabs/prysm/shared/params"
+)
+
+func genesisRegistry(numValidators uint64) []*eth.Validators_ValidatorContainer {
+	validators := make([]*eth.Validators_ValidatorContainer, numValidators)
+	for i := range validators {
+		validators[i] = &eth.Validators_ValidatorContainer{
+			Index: uint64(i),
+			Validator: &eth.Validator{
+				EffectiveBalance:  params.BeaconConfig().MaxEffectiveBalance,
+				ExitEpoch:         params.BeaconConfig().FarFutureEpoch,
+				WithdrawableEpoch: params.BeaconConfig().FarFutureEpoch,
+			},
+		}
+	}
+	return validators
+}
+
+func TestValidatorsAreActive(t *testing.T) {
+	tests := []struct {
+		name     string
+		modify   func(validators []*eth.Validators_ValidatorContainer) []*eth.Validators_ValidatorContainer
+		errorMsg string
+	}{
+		{
+			name:   "all genesis validators active",
+			modify: func(v []*eth.Validators_ValidatorContainer) []*eth.Validators_ValidatorContainer { return v },
+		},
+		{
+			name: "pending deposits are not genesis validators",
+			modify: func(v []*eth.Validators_ValidatorContainer) []*eth.Validators_ValidatorContainer {
+				pending := genesisRegistry(1)[0]
+				pending.Index = uint64(len(v))
+				pending.Validator.ActivationEpoch = params.BeaconConfig().FarFutureEpoch
+				return append(v, pending)
+			},
+		},
+		{
+			name: "validators not activated",
+			modify: func(v []*eth.Validators_ValidatorContainer) []*eth.Validators_ValidatorContainer {
+				v[3].Validator.ActivationEpoch = 5
+				v[40].Validator.ActivationEpoch = 5
+				return v
+			},
+			errorMsg: "beacon node 1: expected validator count to be 64, received 62; genesis validators not activated at epoch 0: [3 40]",
+		},
+		{
+			name: "validator exited",
+			modify: func(v []*eth.Validators_ValidatorContainer) []*eth.Validators_ValidatorContainer {
+				v[7].Validator.ExitEpoch = 1
+				return v
+			},
+			errorMsg: "genesis validators with an exit or withdrawable epoch: [7]",
+		},
+		{
+			name: "validator balance reduced",
+			modify: func(v []*eth.Validators_ValidatorContainer) []*eth.Validators_ValidatorContainer {
+				v[63].Validator.EffectiveBalance--
+				return v
+			},
+			errorMsg: "effective balance other than 32000000000: [63]",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conns, stop := startBeaconChainServer(t, &registryServer{validators: genesisRegistry(64), pageSize: 30})
+			defer stop()
+			node, stopNode := startBeaconChainServer(t, &registryServer{validators: tt.modify(genesisRegistry(64)), pageSize: 30})
+			defer stopNode()
+			conns.Conns[1] = node.Conns[0]
+
+			err := ValidatorsAreActive(64).Evaluation(conns)
+			if tt.errorMsg == "" {
+				if err != nil {
+					t.Errorf("Unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.errorMsg) {
+				t.Errorf("Expected error containing %q, received %v", tt.errorMsg, err)
+			}
+		})
+	}
+}
+
+func TestValidatorKeysAtIndices(t *testing.T) {
+	pubKeys := make([][]byte, 8)
+	for i := range pubKeys {
+		pubKeys[i] = []byte{byte(i)}
+	}
+	withKeys := func() []*eth.Validators_ValidatorContainer {
+		validators := genesisRegistry(8)
+		for i, item := range validators {
+			item.Validator.PublicKey = pubKeys[i]
+		}
+		return validators
+	}
+	tests := []struct {
+		name     string
+		modify   func(validators []*eth.Validators_ValidatorContainer)
+		errorMsg string
+	}{
+		{
+			name:   "expected keys",
+			modify: func(_ []*eth.Validators_ValidatorContainer) {},
+		},
+		{
+			name: "keys swapped",
+			modify: func(v []*eth.Validators_ValidatorContainer) {
+				v[2].Validator.PublicKey, v[5].Validator.PublicKey = v[5].Validator.PublicKey, v[2].Validator.PublicKey
+			},
+			errorMsg: "beacon node 1: validators with an unexpected public key: [2 5]",
+		},
+		{
+			name: "validator exited",
+			modify: func(v []*eth.Validators_ValidatorContainer) {
+				v[4].Validator.ExitEpoch = 1
+			},
+			errorMsg: "beacon node 1: validators not active since genesis: [4]",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conns, stop := startBeaconChainServer(t, &registryServer{validators: withKeys()})
+			defer stop()
+			validators := withKeys()
+			tt.modify(validators)
+			node, stopNode := startBeaconChainServer(t, &registryServer{validators: validators})
+			defer stopNode()
+			conns.Conns[1] = node.Conns[0]
+
+			err := ValidatorKeysAtIndices(pubKeys).Evaluation(conns)
+			if tt.errorMsg == "" {
+				if err != nil {
+					t.Errorf("Unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.errorMsg) {
+				t.Errorf("Expected error containing %q, received %v", tt.errorMsg, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,39 @@
+package endtoend
+
+import (
+	"testing"
+	"time"
+
+	ev "github.com/prysmaticlabs/prysm/endtoend/evaluators"
+	"github.com/prysmaticlabs/prysm/shared/params"
+	"github.com/prysmaticlabs/prysm/shared/testutil"
+)
+
+// fastSlotsMaxDuration is how long the accelerated suite may take, startup included.
+const fastSlotsMaxDuration = 5 * time.Minute
+
+func TestEndToEnd_FastSlots(t *testing.T) {
+	testutil.ResetCache()
+	params.UseMinimalConfig()
+
+	numValidators := params.BeaconConfig().MinGenesisActiveValidatorCount
+	fastSlotsConfig := &end2EndConfig{
+		minimalConfig:       true,
+		epochsToRun:         5,
+		numBeaconNodes:      2,
+		numValidators:       numValidators,
+		portOffset:          700,
+		slotDurationSeconds: 2,
+		evaluators: []ev.Evaluator{
+			ev.ValidatorsAreActive(numValidators),
+			ev.ValidatorsParticipating,
+			ev.FinalizationOccurs(),
+			ev.HeadConsistencyEvaluator(),
+		},
+	}
+	start := time.Now()
+	runEndToEndTest(t, fastSlotsConfig)
+	if elapsed := time.Since(start); elapsed > fastSlotsMaxDuration {
+		t.Errorf("Expected %d epochs of %d second slots to run within %v, took %v", fastSlotsConfig.epochsToRun, fastSlotsConfig.slotDurationSeconds, fastSlotsMaxDuration, elapsed)
+	}
+}
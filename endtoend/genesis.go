@@ -0,0 +1,45 @@
+package endtoend
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bazelbuild/rules_go/go/tools/bazel"
+)
+
+// genesisStateDelay is how far in the future the genesis time of a generated genesis state is
+// set, it leaves time for the eth1 chain and the beacon nodes to start before the chain does.
+const genesisStateDelay = 2 * time.Minute
+
+// generateGenesisState writes an SSZ genesis state with config.numValidators deterministic interop
+// validators to tmpPath and returns its path, so it can be used as config.genesisStateFile.
+func generateGenesisState(t testing.TB, config *end2EndConfig) string {
+	binaryPath, found := bazel.FindBinary("tools/genesis-state-gen", "genesis-state-gen")
+	if !found {
+		t.Fatal("genesis state generator binary not found")
+	}
+	if err := os.MkdirAll(config.tmpPath, os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+
+	genesisStatePath := path.Join(config.tmpPath, "genesis.ssz")
+	args := []string{
+		fmt.Sprintf("--num-validators=%d", config.numValidators),
+		fmt.Sprintf("--genesis-time=%d", time.Now().Add(genesisStateDelay).Unix()),
+		fmt.Sprintf("--output-ssz=%s", genesisStatePath),
+	}
+	if !config.minimalConfig {
+		args = append(args, "--mainnet-config")
+	}
+	t.Logf("Generating genesis state with flags: %s", strings.Join(args, " "))
+	output, err := exec.Command(binaryPath, args...).CombinedOutput()
+	if err != nil {
+		t.Fatalf("Failed to generate genesis state: %v, output: %s", err, output)
+	}
+	return genesisStatePath
+}
@@ -0,0 +1,43 @@
+package endtoend
+
+import (
+	"testing"
+
+	ev "github.com/prysmaticlabs/prysm/endtoend/evaluators"
+	"github.com/prysmaticlabs/prysm/shared/params"
+	"github.com/prysmaticlabs/prysm/shared/testutil"
+)
+
+func TestEndToEnd_GenesisStateFile(t *testing.T) {
+	testutil.ResetCache()
+	params.UseMinimalConfig()
+
+	numValidators := params.BeaconConfig().MinGenesisActiveValidatorCount
+	genesisConfig := &end2EndConfig{
+		minimalConfig:     true,
+		epochsToRun:       4,
+		numBeaconNodes:    2,
+		numValidators:     numValidators,
+		portOffset:        300,
+		useInteropGenesis: true,
+		// Covers the gRPC API served over TLS, which is otherwise only used manually.
+		tlsRPC: true,
+	}
+
+	// The generated state holds the interop validators, in the order of their keys.
+	_, keys, err := testutil.DeterministicDepositsAndKeys(numValidators)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pubKeys := make([][]byte, len(keys))
+	for i, key := range keys {
+		pubKeys[i] = key.PublicKey().Marshal()
+	}
+	genesisConfig.evaluators = []ev.Evaluator{
+		ev.ValidatorsAreActive(numValidators),
+		ev.ValidatorKeysAtIndices(pubKeys),
+		ev.FinalizationOccurs(),
+		ev.NodesAgreeOnHead,
+	}
+	runEndToEndTest(t, genesisConfig)
+}
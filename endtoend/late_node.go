@@ -0,0 +1,139 @@
+package endtoend
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	ptypes "github.com/gogo/protobuf/types"
+	"github.com/pkg/errors"
+	eth "github.com/prysmaticlabs/ethereumapis/eth/v1alpha1"
+	ev "github.com/prysmaticlabs/prysm/endtoend/evaluators"
+	"github.com/prysmaticlabs/prysm/shared/params"
+	"google.golang.org/grpc"
+)
+
+// lateNodePollInterval is how often the late node is asked whether it's synced.
+var lateNodePollInterval = time.Second
+
+// lateNode is the beacon node started after genesis, see end2EndConfig.lateNodeAtEpoch. It's only
+// set once the node is started, so the evaluator can be registered before.
+type lateNode struct {
+	lock    sync.Mutex
+	node    *beaconNodeInfo
+	started time.Time
+}
+
+func (l *lateNode) get() (*beaconNodeInfo, time.Time) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	return l.node, l.started
+}
+
+// start launches the late node with an empty database and no validators, statically peered with
+// the running beacon nodes, and waits for it to be ready. It takes the index following the Prysm
+// nodes started at genesis.
+func (l *lateNode) start(ctx context.Context, logger Logger, config *end2EndConfig, running []*beaconNodeInfo) (*beaconNodeInfo, error) {
+	index := int(config.numPrysmNodes())
+	ports, err := freePorts.nodePorts()
+	if err != nil {
+		return nil, &NodeStartError{NodeIndex: index, Stage: "port allocation", Cause: err}
+	}
+	if _, err := generateP2PKey(config.tmpPath, index, ports.p2pTCP); err != nil {
+		return nil, &NodeStartError{NodeIndex: index, Stage: "p2p key generation", Cause: err}
+	}
+	peers := make([]string, len(running))
+	for i, node := range running {
+		peers[i] = node.multiAddr
+	}
+	node, err := startNewBeaconNode(ctx, logger, config, index, ports, peers)
+	if err != nil {
+		return nil, err
+	}
+	l.lock.Lock()
+	l.node = node
+	l.started = time.Now()
+	l.lock.Unlock()
+	logger.Logf("Started late beacon node %d with multiaddr %s", index, node.multiAddr)
+	return node, nil
+}
+
+// maxLateNodeSyncSlots returns how many slots the late node is given to sync after starting.
+func (c *end2EndConfig) maxLateNodeSyncSlots() uint64 {
+	if c.lateNodeSyncSlots == 0 {
+		return 2 * params.BeaconConfig().SlotsPerEpoch
+	}
+	return c.lateNodeSyncSlots
+}
+
+// LateNodeSyncedEvaluator returns an evaluator that ensures the beacon node started at joinEpoch
+// with an empty database reports itself synced, with the head of the evaluated node, within
+// maxSlots of its start. It covers initial sync, which no node started at genesis goes through.
+func LateNodeSyncedEvaluator(late *lateNode, joinEpoch uint64, maxSlots uint64) ev.Evaluator {
+	return ev.Evaluator{
+		Name:   "late_node_synced_epoch_%d",
+		Policy: ev.AfterNthEpoch(joinEpoch),
+		Evaluation: func(conns *ev.NodeConns) error {
+			node, started := late.get()
+			if node == nil {
+				return errors.New("late beacon node was not started")
+			}
+			conn, err := node.GRPCConn()
+			if err != nil {
+				return errors.Wrapf(err, "could not dial late beacon node %d", node.index)
+			}
+			slot := time.Duration(params.BeaconConfig().SecondsPerSlot) * time.Second
+			deadline := started.Add(time.Duration(maxSlots) * slot)
+			if err := lateNodeSynced(conn, conns.BeaconChainClient(), deadline, slot); err != nil {
+				return errors.Wrapf(err, "late beacon node %d did not sync within %d slots", node.index, maxSlots)
+			}
+			return nil
+		},
+	}
+}
+
+// lateNodeSynced polls the late node until it's no longer syncing and has the same head block as
+// the reference node, giving up at the deadline. It's given at least a slot in case a block was
+// just proposed, which one of the nodes may not have processed yet.
+func lateNodeSynced(late *grpc.ClientConn, reference eth.BeaconChainClient, deadline time.Time, slot time.Duration) error {
+	if minDeadline := time.Now().Add(slot); deadline.Before(minDeadline) {
+		deadline = minDeadline
+	}
+	nodeClient := eth.NewNodeClient(late)
+	chainClient := eth.NewBeaconChainClient(late)
+	for {
+		status, err := nodeClient.GetSyncStatus(context.Background(), &ptypes.Empty{})
+		if err != nil {
+			return errors.Wrap(err, "failed to get sync status")
+		}
+		var state string
+		if status.Syncing {
+			state = "still syncing"
+		} else {
+			expected, err := reference.GetChainHead(context.Background(), &ptypes.Empty{})
+			if err != nil {
+				return errors.Wrap(err, "failed to get chain head of the evaluated node")
+			}
+			head, err := chainClient.GetChainHead(context.Background(), &ptypes.Empty{})
+			if err != nil {
+				return errors.Wrap(err, "failed to get chain head")
+			}
+			if bytes.Equal(head.HeadBlockRoot, expected.HeadBlockRoot) {
+				return nil
+			}
+			state = fmt.Sprintf(
+				"synced to head slot %d %#x, expected head slot %d %#x",
+				head.HeadSlot,
+				head.HeadBlockRoot,
+				expected.HeadSlot,
+				expected.HeadBlockRoot,
+			)
+		}
+		if time.Now().After(deadline) {
+			return errors.New(state)
+		}
+		time.Sleep(lateNodePollInterval)
+	}
+}
@@ -0,0 +1,126 @@
+package endtoend
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	ptypes "github.com/gogo/protobuf/types"
+	eth "github.com/prysmaticlabs/ethereumapis/eth/v1alpha1"
+	ev "github.com/prysmaticlabs/prysm/endtoend/evaluators"
+	"github.com/prysmaticlabs/prysm/shared/params"
+	"google.golang.org/grpc"
+)
+
+// syncServer reports itself syncing until it's polled syncPolls times, then serves its head.
+type syncServer struct {
+	eth.NodeServer
+	eth.BeaconChainServer
+	lock      sync.Mutex
+	syncPolls int
+	head      *eth.ChainHead
+}
+
+func (s *syncServer) GetSyncStatus(_ context.Context, _ *ptypes.Empty) (*eth.SyncStatus, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.syncPolls > 0 {
+		s.syncPolls--
+		return &eth.SyncStatus{Syncing: true}, nil
+	}
+	return &eth.SyncStatus{}, nil
+}
+
+func (s *syncServer) GetChainHead(_ context.Context, _ *ptypes.Empty) (*eth.ChainHead, error) {
+	return s.head, nil
+}
+
+func startSyncServer(t *testing.T, server *syncServer) (uint64, func()) {
+	return startServer(t, func(grpcServer *grpc.Server) {
+		eth.RegisterNodeServer(grpcServer, server)
+		eth.RegisterBeaconChainServer(grpcServer, server)
+	})
+}
+
+func TestLateNodeSyncedEvaluator(t *testing.T) {
+	defer func(interval time.Duration) { lateNodePollInterval = interval }(lateNodePollInterval)
+	lateNodePollInterval = 10 * time.Millisecond
+	config := params.BeaconConfig()
+	defer params.OverrideBeaconConfig(config)
+	testConfig := *config
+	testConfig.SecondsPerSlot = 1
+	params.OverrideBeaconConfig(&testConfig)
+	head := &eth.ChainHead{HeadSlot: 37, HeadBlockRoot: []byte{0x25}}
+	tests := []struct {
+		name     string
+		late     *syncServer
+		errorMsg string
+	}{
+		{
+			name: "synced",
+			late: &syncServer{head: head},
+		},
+		{
+			name: "synced while polled",
+			late: &syncServer{head: head, syncPolls: 3},
+		},
+		{
+			name:     "still syncing",
+			late:     &syncServer{head: head, syncPolls: 1000},
+			errorMsg: "late beacon node 4 did not sync within 16 slots: still syncing",
+		},
+		{
+			name:     "different head",
+			late:     &syncServer{head: &eth.ChainHead{HeadSlot: 36, HeadBlockRoot: []byte{0x24}}},
+			errorMsg: "late beacon node 4 did not sync within 16 slots: synced to head slot 36 0x24, expected head slot 37 0x25",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			referencePort, stopReference := startSyncServer(t, &syncServer{head: head})
+			defer stopReference()
+			latePort, stopLate := startSyncServer(t, tt.late)
+			defer stopLate()
+			reference := &beaconNodeInfo{index: 0, rpcPort: referencePort}
+			referenceConn, err := reference.GRPCConn()
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer referenceConn.Close()
+			node := &beaconNodeInfo{index: 4, rpcPort: latePort}
+			defer func() {
+				if conn, err := node.GRPCConn(); err == nil {
+					conn.Close()
+				}
+			}()
+			// The node was started long enough ago for the deadline to have passed, so it's only
+			// given a slot, which lasts a hundred polls here.
+			late := &lateNode{node: node, started: time.Now().Add(-time.Hour)}
+			evaluator := LateNodeSyncedEvaluator(late, 3, 16)
+			conns := &ev.NodeConns{Conns: map[int]*grpc.ClientConn{0: referenceConn}}
+			err = evaluator.Evaluation(conns)
+			if tt.errorMsg == "" {
+				if err != nil {
+					t.Errorf("Unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.errorMsg) {
+				t.Errorf("Expected error containing %q, received %v", tt.errorMsg, err)
+			}
+		})
+	}
+}
+
+func TestLateNodeSyncedEvaluator_NotStarted(t *testing.T) {
+	evaluator := LateNodeSyncedEvaluator(&lateNode{}, 3, 16)
+	if evaluator.Policy(3) || !evaluator.Policy(4) {
+		t.Error("Expected the late node to be evaluated from the epoch after it joined")
+	}
+	err := evaluator.Evaluation(&ev.NodeConns{})
+	if err == nil || err.Error() != "late beacon node was not started" {
+		t.Errorf("Expected error for the node not being started, received %v", err)
+	}
+}
@@ -0,0 +1,122 @@
+package endtoend
+
+import (
+	"fmt"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// latencyBands is the number of bands of the prio qdisc set on the loopback interface to delay
+// beacon nodes. The first 3 are the default bands every packet goes through, each delayed node
+// gets one of the others, the prio qdisc supporting 16 bands.
+const latencyBands = 16
+
+// latencyLock guards latencyNodes, the nodes with latency set: the root qdisc is shared by them,
+// added with the first one and removed with the last one.
+var (
+	latencyLock  sync.Mutex
+	latencyNodes = make(map[int]bool)
+)
+
+// SetNodeLatency delays every packet the beacon node sends over p2p TCP by latencyMs, varying by up
+// to jitterMs, until the returned restore function is called. The packets the node sends from its
+// p2p port are marked with iptables and queued to a netem qdisc with tc. As the node sends all its
+// p2p traffic from its listen port, as PartitionNodes relies on, the round trip between two delayed
+// nodes takes twice the latency. Changing the qdiscs needs root privileges, and tc is only available
+// on Linux, the test is skipped on other systems.
+func SetNodeLatency(t testing.TB, node *beaconNodeInfo, latencyMs, jitterMs uint64) (restore func(), err error) {
+	if runtime.GOOS != "linux" {
+		t.Skipf("Injecting network latency needs tc, which is not available on %s", runtime.GOOS)
+	}
+	latencyLock.Lock()
+	defer latencyLock.Unlock()
+	if latencyNodes[node.index] {
+		return nil, fmt.Errorf("latency is already set on beacon node %d", node.index)
+	}
+	apply, undo, err := nodeLatencyCommands(node, latencyMs, jitterMs)
+	if err != nil {
+		return nil, err
+	}
+	addRoot, removeRoot := latencyRootCommands()
+	if len(latencyNodes) == 0 {
+		apply = append([]firewallCommand{addRoot}, apply...)
+	}
+	for _, cmd := range apply {
+		if output, err := cmd.run(); err != nil {
+			// Don't leave the commands applied so far behind, the others fail to be undone.
+			for _, cmd := range undo {
+				_, _ = cmd.run()
+			}
+			if len(latencyNodes) == 0 {
+				_, _ = removeRoot.run()
+			}
+			return nil, fmt.Errorf(
+				"could not set latency on beacon node %d with %s: %v, output: %s",
+				node.index,
+				strings.Join(cmd.args, " "),
+				err,
+				output,
+			)
+		}
+	}
+	latencyNodes[node.index] = true
+	t.Logf("Delayed the p2p packets sent by beacon node %d by %dms, with %dms of jitter", node.index, latencyMs, jitterMs)
+	return func() {
+		latencyLock.Lock()
+		defer latencyLock.Unlock()
+		delete(latencyNodes, node.index)
+		if len(latencyNodes) == 0 {
+			undo = append(undo, removeRoot)
+		}
+		runFirewallCommands(t, undo)
+		t.Logf("Removed the latency of beacon node %d", node.index)
+	}, nil
+}
+
+// latencyRootCommands returns the commands adding and removing the prio qdisc the delayed nodes
+// share on the loopback interface. The default priority mapping only uses its first 3 bands, as the
+// default qdisc does, and removing it restores the default qdisc.
+func latencyRootCommands() (add firewallCommand, remove firewallCommand) {
+	add = firewallCommand{args: []string{"tc", "qdisc", "add", "dev", "lo", "root", "handle", "1:", "prio", "bands", fmt.Sprintf("%d", latencyBands)}}
+	remove = firewallCommand{args: []string{"tc", "qdisc", "del", "dev", "lo", "root"}}
+	return add, remove
+}
+
+// nodeLatencyCommands returns the commands delaying the p2p packets sent by the node, along with
+// the commands removing the delay, the iptables rule coming first so no more packets are marked. The
+// node's band, filter and mark are derived from its index.
+func nodeLatencyCommands(node *beaconNodeInfo, latencyMs, jitterMs uint64) (apply []firewallCommand, restore []firewallCommand, err error) {
+	if latencyMs == 0 {
+		return nil, nil, fmt.Errorf("latency of beacon node %d must be at least 1ms", node.index)
+	}
+	if node.index >= latencyBands-3 {
+		return nil, nil, fmt.Errorf("latency can only be set on the first %d beacon nodes, not on beacon node %d", latencyBands-3, node.index)
+	}
+	// Bands are numbered from 1 in class IDs, the 3 default ones being 1:1 to 1:3.
+	class := fmt.Sprintf("1:%x", node.index+4)
+	handle := fmt.Sprintf("%x:", node.index+10)
+	mark := fmt.Sprintf("%d", node.index+1)
+	filterPrio := fmt.Sprintf("%d", node.index+1)
+	rule := []string{
+		"OUTPUT", "-o", "lo", "-p", "tcp",
+		"--sport", fmt.Sprintf("%d", node.p2pTCPPort),
+		"-j", "MARK", "--set-mark", mark,
+	}
+	netem := []string{"netem", "delay", fmt.Sprintf("%dms", latencyMs)}
+	if jitterMs > 0 {
+		netem = append(netem, fmt.Sprintf("%dms", jitterMs))
+	}
+	apply = []firewallCommand{
+		{args: append([]string{"tc", "qdisc", "add", "dev", "lo", "parent", class, "handle", handle}, netem...)},
+		{args: []string{"tc", "filter", "add", "dev", "lo", "parent", "1:", "protocol", "ip", "prio", filterPrio, "handle", mark, "fw", "flowid", class}},
+		{args: append([]string{"iptables", "-t", "mangle", "-A"}, rule...)},
+	}
+	restore = []firewallCommand{
+		{args: append([]string{"iptables", "-t", "mangle", "-D"}, rule...)},
+		{args: []string{"tc", "filter", "del", "dev", "lo", "parent", "1:", "protocol", "ip", "prio", filterPrio}},
+		{args: []string{"tc", "qdisc", "del", "dev", "lo", "parent", class}},
+	}
+	return apply, restore, nil
+}
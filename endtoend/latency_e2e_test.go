@@ -0,0 +1,40 @@
+package endtoend
+
+import (
+	"os"
+	"runtime"
+	"testing"
+
+	ev "github.com/prysmaticlabs/prysm/endtoend/evaluators"
+	"github.com/prysmaticlabs/prysm/shared/params"
+	"github.com/prysmaticlabs/prysm/shared/testutil"
+)
+
+func TestEndToEnd_NetworkLatency(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skipf("Injecting network latency needs tc, which is not available on %s", runtime.GOOS)
+	}
+	if os.Geteuid() != 0 {
+		t.Skip("Injecting network latency needs root privileges to change the qdiscs")
+	}
+	testutil.ResetCache()
+	params.UseMinimalConfig()
+
+	// Every p2p packet is delayed by about 200ms, and the chain must still finalize within 6 epochs.
+	numValidators := params.BeaconConfig().MinGenesisActiveValidatorCount
+	latencyConfig := &end2EndConfig{
+		minimalConfig:       true,
+		epochsToRun:         6,
+		numBeaconNodes:      4,
+		numValidators:       numValidators,
+		portOffset:          900,
+		nodeLatencyMs:       200,
+		nodeLatencyJitterMs: 20,
+		evaluators: []ev.Evaluator{
+			ev.ValidatorsAreActive(numValidators),
+			ev.PeersConnect(4),
+			ev.FinalizationOccurs(),
+		},
+	}
+	runEndToEndTest(t, latencyConfig)
+}
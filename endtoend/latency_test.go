@@ -0,0 +1,62 @@
+package endtoend
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestNodeLatencyCommands(t *testing.T) {
+	node := &beaconNodeInfo{index: 2, p2pTCPPort: 13002}
+	apply, restore, err := nodeLatencyCommands(node, 200, 20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var applied, removed []string
+	for _, cmd := range apply {
+		applied = append(applied, strings.Join(cmd.args, " "))
+	}
+	for _, cmd := range restore {
+		removed = append(removed, strings.Join(cmd.args, " "))
+	}
+	wantApplied := []string{
+		"tc qdisc add dev lo parent 1:6 handle c: netem delay 200ms 20ms",
+		"tc filter add dev lo parent 1: protocol ip prio 3 handle 3 fw flowid 1:6",
+		"iptables -t mangle -A OUTPUT -o lo -p tcp --sport 13002 -j MARK --set-mark 3",
+	}
+	if !reflect.DeepEqual(applied, wantApplied) {
+		t.Errorf("Expected commands %v, received %v", wantApplied, applied)
+	}
+	wantRemoved := []string{
+		"iptables -t mangle -D OUTPUT -o lo -p tcp --sport 13002 -j MARK --set-mark 3",
+		"tc filter del dev lo parent 1: protocol ip prio 3",
+		"tc qdisc del dev lo parent 1:6",
+	}
+	if !reflect.DeepEqual(removed, wantRemoved) {
+		t.Errorf("Expected commands %v to remove the latency, received %v", wantRemoved, removed)
+	}
+}
+
+func TestNodeLatencyCommands_NoJitter(t *testing.T) {
+	apply, _, err := nodeLatencyCommands(&beaconNodeInfo{index: 0, p2pTCPPort: 13000}, 100, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "tc qdisc add dev lo parent 1:4 handle a: netem delay 100ms"
+	if received := strings.Join(apply[0].args, " "); received != want {
+		t.Errorf("Expected %q, received %q", want, received)
+	}
+}
+
+func TestNodeLatencyCommands_Invalid(t *testing.T) {
+	if _, _, err := nodeLatencyCommands(&beaconNodeInfo{index: 0}, 0, 20); err == nil || !strings.Contains(err.Error(), "must be at least 1ms") {
+		t.Errorf("Expected latency to be required, received %v", err)
+	}
+	// The 3 first bands of the root qdisc carry the packets that aren't delayed.
+	if _, _, err := nodeLatencyCommands(&beaconNodeInfo{index: 13}, 200, 0); err == nil || !strings.Contains(err.Error(), "first 13 beacon nodes") {
+		t.Errorf("Expected beacon node 13 to be out of bands, received %v", err)
+	}
+	if _, _, err := nodeLatencyCommands(&beaconNodeInfo{index: 12}, 200, 0); err != nil {
+		t.Errorf("Unexpected error for beacon node 12: %v", err)
+	}
+}
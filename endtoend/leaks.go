@@ -0,0 +1,75 @@
+package endtoend
+
+import (
+	"regexp"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// skipLeakCheckEnvVar disables the goroutine leak check of the E2E tests when set to 1.
+const skipLeakCheckEnvVar = "SKIP_LEAK_CHECK"
+
+// leakCheckTimeout is how long goroutines are given to exit once the tests are done, as closed
+// connections and stopped tickers take a moment to wind down.
+const leakCheckTimeout = 5 * time.Second
+
+var goroutineHeaderRegex = regexp.MustCompile(`^goroutine (\d+) \[`)
+
+// ignoredGoroutines are started by the runtime on demand, e.g. the first time signals are
+// watched, and live until the process exits.
+var ignoredGoroutines = []string{
+	"os/signal.signal_recv",
+	"os/signal.loop",
+	"runtime.ensureSigM",
+}
+
+// goroutineStacks returns the stack trace of every running goroutine, keyed by goroutine ID.
+func goroutineStacks() map[string]string {
+	buf := make([]byte, 1<<20)
+	for {
+		n := runtime.Stack(buf, true /*all*/)
+		if n < len(buf) {
+			buf = buf[:n]
+			break
+		}
+		buf = make([]byte, 2*len(buf))
+	}
+	stacks := make(map[string]string)
+	for _, stack := range strings.Split(string(buf), "\n\n") {
+		match := goroutineHeaderRegex.FindStringSubmatch(stack)
+		if match == nil {
+			continue
+		}
+		stacks[match[1]] = stack
+	}
+	return stacks
+}
+
+// leakedGoroutines returns the stack traces of the goroutines that were not running when the
+// before snapshot was taken, waiting up to timeout for them to exit.
+func leakedGoroutines(before map[string]string, timeout time.Duration) []string {
+	deadline := time.Now().Add(timeout)
+	for {
+		var leaked []string
+		for id, stack := range goroutineStacks() {
+			if _, ok := before[id]; ok || isIgnoredGoroutine(stack) {
+				continue
+			}
+			leaked = append(leaked, stack)
+		}
+		if len(leaked) == 0 || time.Now().After(deadline) {
+			return leaked
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+}
+
+func isIgnoredGoroutine(stack string) bool {
+	for _, function := range ignoredGoroutines {
+		if strings.Contains(stack, function) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,32 @@
+package endtoend
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLeakedGoroutines(t *testing.T) {
+	before := goroutineStacks()
+	stop := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		leakingGoroutine(stop)
+	}()
+
+	leaked := leakedGoroutines(before, 100*time.Millisecond)
+	if len(leaked) != 1 || !strings.Contains(leaked[0], "leakingGoroutine") {
+		t.Errorf("Expected the started goroutine to be reported, received %v", leaked)
+	}
+
+	close(stop)
+	<-stopped
+	if leaked := leakedGoroutines(before, time.Second); len(leaked) > 0 {
+		t.Errorf("Expected no goroutine to be reported once stopped, received %v", leaked)
+	}
+}
+
+func leakingGoroutine(stop chan struct{}) {
+	<-stop
+}
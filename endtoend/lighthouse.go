@@ -0,0 +1,323 @@
+package endtoend
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// clientType is the implementation a beacon node of the E2E runs.
+type clientType int
+
+const (
+	// PrysmClient nodes run the beacon-chain binary of this repository.
+	PrysmClient clientType = iota
+	// LighthouseClient nodes run the Lighthouse beacon_node binary given through LIGHTHOUSE_BINARY.
+	LighthouseClient
+)
+
+func (c clientType) String() string {
+	switch c {
+	case PrysmClient:
+		return "prysm"
+	case LighthouseClient:
+		return "lighthouse"
+	default:
+		return fmt.Sprintf("client(%d)", int(c))
+	}
+}
+
+// BeaconNodeController is implemented by the beacon nodes of every client, so they can be peered
+// with and stopped alike.
+type BeaconNodeController interface {
+	// MultiAddr returns the p2p address other nodes can peer with.
+	MultiAddr() string
+	// RPCPort returns the port of the API of the node, gRPC for Prysm and the HTTP API for Lighthouse.
+	RPCPort() uint64
+	// Stop stops the node, killing it if it's still alive after the timeout.
+	Stop(timeout time.Duration) error
+}
+
+// lighthouseBinaryEnvVar is the env var giving the path to the lighthouse binary, which isn't built
+// by this repository.
+const lighthouseBinaryEnvVar = "LIGHTHOUSE_BINARY"
+
+// lighthouseConfigEnvVar is the env var giving the spec config YAML Lighthouse nodes run with, it
+// must match the config of the Prysm nodes.
+const lighthouseConfigEnvVar = "LIGHTHOUSE_CONFIG"
+
+var lighthouseNodeLogFileName = "lighthouse-%d.log"
+
+// lighthouseStartupText is logged by Lighthouse once its node is started and serves its HTTP API.
+const lighthouseStartupText = "HTTP API started"
+
+// lighthouseNodeInfo is a Lighthouse beacon node. It's statically peered with the Prysm nodes and
+// runs no validators, the evaluators only check it through the peers of the Prysm nodes.
+type lighthouseNodeInfo struct {
+	index      int
+	cmd        *exec.Cmd
+	logFile    *os.File
+	datadir    string
+	httpPort   uint64
+	p2pTCPPort uint64
+	p2pUDPPort uint64
+	multiAddr  string
+}
+
+// MultiAddr returns the p2p address of the node.
+func (l *lighthouseNodeInfo) MultiAddr() string {
+	return l.multiAddr
+}
+
+// RPCPort returns the port of the HTTP API of the node, Lighthouse doesn't serve the Prysm gRPC API.
+func (l *lighthouseNodeInfo) RPCPort() uint64 {
+	return l.httpPort
+}
+
+// Stop stops the node, see beaconNodeInfo.Stop.
+func (l *lighthouseNodeInfo) Stop(timeout time.Duration) error {
+	if err := stopProcess(l.cmd, timeout); err != nil {
+		return err
+	}
+	if err := l.logFile.Sync(); err != nil {
+		return errors.Wrap(err, "could not flush log file")
+	}
+	return l.logFile.Close()
+}
+
+// MultiAddr returns the p2p address of the node.
+func (b *beaconNodeInfo) MultiAddr() string {
+	return b.multiAddr
+}
+
+// RPCPort returns the port of the gRPC server of the node.
+func (b *beaconNodeInfo) RPCPort() uint64 {
+	return b.rpcPort
+}
+
+// clientOf returns the client run by the beacon node with the given index, Prysm unless set in
+// perNodeClientType.
+func (c *end2EndConfig) clientOf(index int) clientType {
+	if index < len(c.perNodeClientType) {
+		return c.perNodeClientType[index]
+	}
+	return PrysmClient
+}
+
+// numPrysmNodes returns how many of the beacon nodes run Prysm, they come before the other clients.
+func (c *end2EndConfig) numPrysmNodes() uint64 {
+	var count uint64
+	for count < c.numBeaconNodes && c.clientOf(int(count)) == PrysmClient {
+		count++
+	}
+	return count
+}
+
+// checkClientTypes ensures the Prysm nodes come first, as the validators, the evaluators and the
+// other scenarios only run on them.
+func checkClientTypes(c *end2EndConfig) error {
+	if uint64(len(c.perNodeClientType)) > c.numBeaconNodes {
+		return fmt.Errorf("%d client types given for %d beacon nodes", len(c.perNodeClientType), c.numBeaconNodes)
+	}
+	if c.clientOf(0) != PrysmClient {
+		return errors.New("the first beacon node must run Prysm, evaluations are made against it")
+	}
+	prysmNodes := c.numPrysmNodes()
+	for i := int(prysmNodes); i < int(c.numBeaconNodes); i++ {
+		switch c.clientOf(i) {
+		case PrysmClient:
+			return fmt.Errorf("beacon node %d runs Prysm after a node running another client, Prysm nodes must come first", i)
+		case LighthouseClient:
+		default:
+			return fmt.Errorf("beacon node %d has unknown client %v", i, c.clientOf(i))
+		}
+	}
+	return nil
+}
+
+// startLighthouseNodes starts the Lighthouse beacon nodes of the config, peered with the given nodes,
+// failing the test if any of them can't be started. The nodes already started are stopped first, as
+// the caller only defers stopLighthouseNodes once they are returned.
+func startLighthouseNodes(ctx context.Context, t testing.TB, config *end2EndConfig, peers []BeaconNodeController) []*lighthouseNodeInfo {
+	var nodes []*lighthouseNodeInfo
+	if config.numPrysmNodes() == config.numBeaconNodes {
+		return nodes
+	}
+	testnetDir, err := writeLighthouseTestnetDir(config)
+	if err != nil {
+		t.Fatalf("Could not write Lighthouse testnet dir: %v", err)
+	}
+	peerAddrs := make([]string, len(peers))
+	for i, peer := range peers {
+		peerAddrs[i] = peer.MultiAddr()
+	}
+	for i := int(config.numPrysmNodes()); i < int(config.numBeaconNodes); i++ {
+		ports, err := freePorts.nodePorts()
+		if err != nil {
+			stopLighthouseNodes(t, nodes)
+			t.Fatal(&NodeStartError{NodeIndex: i, Stage: "port allocation", Cause: err})
+		}
+		node, err := startLighthouseNode(ctx, t, config, i, ports, testnetDir, peerAddrs)
+		if err != nil {
+			stopLighthouseNodes(t, nodes)
+			t.Fatal(err)
+		}
+		nodes = append(nodes, node)
+	}
+	return nodes
+}
+
+// stopLighthouseNodes stops the Lighthouse nodes, it's meant to be deferred once they are started.
+func stopLighthouseNodes(t testing.TB, nodes []*lighthouseNodeInfo) {
+	for _, node := range nodes {
+		if err := node.Stop(beaconNodeShutdownTimeout); err != nil {
+			t.Errorf("Could not stop Lighthouse node %d: %v", node.index, err)
+		}
+	}
+}
+
+// writeLighthouseTestnetDir writes the testnet dir Lighthouse nodes load the chain from: the spec
+// config, the deposit contract, the boot node and, if the run starts from one, the genesis state.
+func writeLighthouseTestnetDir(config *end2EndConfig) (string, error) {
+	specConfig := os.Getenv(lighthouseConfigEnvVar)
+	if specConfig == "" {
+		return "", fmt.Errorf("%s must point to the spec config YAML of the run", lighthouseConfigEnvVar)
+	}
+	dir := path.Join(config.tmpPath, "lighthouse-testnet")
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		return "", err
+	}
+	files := map[string]string{
+		"deposit_contract.txt": config.contractAddr.Hex(),
+		"deploy_block.txt":     strconv.FormatUint(config.contractDeploymentBlock, 10),
+		"boot_enr.yaml":        fmt.Sprintf("- %s\n", config.bootNodeENR),
+	}
+	copies := map[string]string{"config.yaml": specConfig}
+	if config.genesisStateFile != "" {
+		copies["genesis.ssz"] = config.genesisStateFile
+	}
+	for name, src := range copies {
+		content, err := ioutil.ReadFile(src)
+		if err != nil {
+			return "", err
+		}
+		files[name] = string(content)
+	}
+	for name, content := range files {
+		if err := ioutil.WriteFile(path.Join(dir, name), []byte(content), 0644); err != nil {
+			return "", err
+		}
+	}
+	return dir, nil
+}
+
+// lighthouseArgs returns the flags of the Lighthouse node, equivalent to the ones given to the
+// Prysm nodes.
+func lighthouseArgs(config *end2EndConfig, node *lighthouseNodeInfo, testnetDir string, peers []string) []string {
+	spec := "mainnet"
+	if config.minimalConfig {
+		spec = "minimal"
+	}
+	args := []string{
+		fmt.Sprintf("--spec=%s", spec),
+		"beacon_node",
+		fmt.Sprintf("--datadir=%s", node.datadir),
+		fmt.Sprintf("--testnet-dir=%s", testnetDir),
+		fmt.Sprintf("--port=%d", node.p2pTCPPort),
+		fmt.Sprintf("--discovery-port=%d", node.p2pUDPPort),
+		"--http",
+		fmt.Sprintf("--http-port=%d", node.httpPort),
+		"--eth1",
+		fmt.Sprintf("--eth1-endpoint=%s", config.eth1HTTPProvider()),
+		fmt.Sprintf("--boot-nodes=%s", config.bootNodeENR),
+	}
+	if len(peers) > 0 {
+		args = append(args, fmt.Sprintf("--libp2p-addresses=%s", strings.Join(peers, ",")))
+	}
+	return args
+}
+
+// startLighthouseNode starts the Lighthouse node with the given index and ports, peered with the
+// given multiaddrs. Its p2p key is generated up front, so its multiaddr is known without parsing
+// its logs.
+func startLighthouseNode(
+	ctx context.Context,
+	logger Logger,
+	config *end2EndConfig,
+	index int,
+	ports nodePorts,
+	testnetDir string,
+	peers []string,
+) (*lighthouseNodeInfo, error) {
+	binaryPath := os.Getenv(lighthouseBinaryEnvVar)
+	if binaryPath == "" {
+		return nil, &NodeStartError{
+			NodeIndex: index,
+			Stage:     "launch",
+			Cause:     fmt.Errorf("%s must point to the lighthouse binary", lighthouseBinaryEnvVar),
+		}
+	}
+	node := &lighthouseNodeInfo{
+		index:      index,
+		datadir:    fmt.Sprintf("%s/lighthouse-node-%d", config.tmpPath, index),
+		httpPort:   ports.rpc,
+		p2pTCPPort: ports.p2pTCP,
+		p2pUDPPort: ports.p2pUDP,
+	}
+	multiAddr, err := writeLighthouseKey(config.tmpPath, index, node.datadir, ports.p2pTCP)
+	if err != nil {
+		return nil, &NodeStartError{NodeIndex: index, Stage: "p2p key generation", Cause: err}
+	}
+	node.multiAddr = multiAddr
+	node.logFile, err = os.Create(path.Join(config.tmpPath, fmt.Sprintf(lighthouseNodeLogFileName, index)))
+	if err != nil {
+		return nil, &NodeStartError{NodeIndex: index, Stage: "log setup", Cause: err}
+	}
+
+	args := lighthouseArgs(config, node, testnetDir, peers)
+	logger.Logf("Starting Lighthouse node with flags: %s", strings.Join(args, " "))
+	node.cmd = exec.CommandContext(ctx, binaryPath, args...)
+	node.cmd.Stdout = node.logFile
+	node.cmd.Stderr = node.logFile
+	if err := node.cmd.Start(); err != nil {
+		_ = node.logFile.Close()
+		return nil, &NodeStartError{NodeIndex: index, Stage: "launch", Cause: err}
+	}
+	if err := waitForTextInFile(ctx, node.logFile, lighthouseStartupText, config.startupTimeout()); err != nil {
+		_ = node.Stop(beaconNodeShutdownTimeout)
+		return nil, &NodeStartError{NodeIndex: index, Stage: "launch", Cause: err}
+	}
+	return node, nil
+}
+
+// writeLighthouseKey generates the p2p key of the node and writes it where Lighthouse loads it from,
+// raw rather than hex encoded. It returns the multiaddr the node is reachable at.
+func writeLighthouseKey(tmpPath string, index int, datadir string, tcpPort uint64) (string, error) {
+	multiAddr, err := generateP2PKey(tmpPath, index, tcpPort)
+	if err != nil {
+		return "", err
+	}
+	encoded, err := ioutil.ReadFile(p2pKeyPath(tmpPath, index))
+	if err != nil {
+		return "", err
+	}
+	rawKey, err := hex.DecodeString(string(encoded))
+	if err != nil {
+		return "", err
+	}
+	networkDir := path.Join(datadir, "beacon", "network")
+	if err := os.MkdirAll(networkDir, os.ModePerm); err != nil {
+		return "", err
+	}
+	return multiAddr, ioutil.WriteFile(path.Join(networkDir, "key"), rawKey, 0600)
+}
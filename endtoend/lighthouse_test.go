@@ -0,0 +1,143 @@
+package endtoend
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func TestEnd2EndConfig_NumPrysmNodes(t *testing.T) {
+	config := &end2EndConfig{
+		numBeaconNodes:    4,
+		perNodeClientType: []clientType{PrysmClient, PrysmClient, LighthouseClient},
+	}
+	if n := config.numPrysmNodes(); n != 2 {
+		t.Errorf("Expected 2 Prysm nodes, received %d", n)
+	}
+	// Nodes past perNodeClientType run Prysm.
+	if client := config.clientOf(3); client != PrysmClient {
+		t.Errorf("Expected node 3 to run %v, received %v", PrysmClient, client)
+	}
+	if n := (&end2EndConfig{numBeaconNodes: 4}).numPrysmNodes(); n != 4 {
+		t.Errorf("Expected 4 Prysm nodes by default, received %d", n)
+	}
+}
+
+func TestLighthouseArgs(t *testing.T) {
+	config := &end2EndConfig{
+		minimalConfig: true,
+		portOffset:    100,
+		bootNodeENR:   "enr:-abc",
+	}
+	node := &lighthouseNodeInfo{
+		datadir:    "/tmp/lighthouse-node-3",
+		httpPort:   5052,
+		p2pTCPPort: 13003,
+		p2pUDPPort: 12003,
+	}
+	args := lighthouseArgs(config, node, "/tmp/lighthouse-testnet", []string{"/ip4/10.0.0.5/tcp/13000/p2p/16Uiu2A", "/ip4/10.0.0.5/tcp/13001/p2p/16Uiu2B"})
+	expected := []string{
+		"--spec=minimal",
+		"beacon_node",
+		"--datadir=/tmp/lighthouse-node-3",
+		"--testnet-dir=/tmp/lighthouse-testnet",
+		"--port=13003",
+		"--discovery-port=12003",
+		"--http",
+		"--http-port=5052",
+		"--eth1",
+		"--eth1-endpoint=http://127.0.0.1:8645",
+		"--boot-nodes=enr:-abc",
+		"--libp2p-addresses=/ip4/10.0.0.5/tcp/13000/p2p/16Uiu2A,/ip4/10.0.0.5/tcp/13001/p2p/16Uiu2B",
+	}
+	if !reflect.DeepEqual(args, expected) {
+		t.Errorf("Expected flags %v, received %v", expected, args)
+	}
+}
+
+func TestWriteLighthouseTestnetDir(t *testing.T) {
+	dir, err := ioutil.TempDir("", "lighthouse")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	specConfig := path.Join(dir, "minimal.yaml")
+	if err := ioutil.WriteFile(specConfig, []byte("SLOTS_PER_EPOCH: 8\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	previous := os.Getenv(lighthouseConfigEnvVar)
+	defer os.Setenv(lighthouseConfigEnvVar, previous)
+	if err := os.Setenv(lighthouseConfigEnvVar, specConfig); err != nil {
+		t.Fatal(err)
+	}
+	config := &end2EndConfig{
+		tmpPath:                 dir,
+		contractAddr:            common.HexToAddress("0x4689a3C63CE249355C8a573B5974db21D2d1b8Ef"),
+		contractDeploymentBlock: 42,
+		bootNodeENR:             "enr:-abc",
+	}
+
+	testnetDir, err := writeLighthouseTestnetDir(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]string{
+		"config.yaml":          "SLOTS_PER_EPOCH: 8\n",
+		"deposit_contract.txt": "0x4689a3C63CE249355C8a573B5974db21D2d1b8Ef",
+		"deploy_block.txt":     "42",
+		"boot_enr.yaml":        "- enr:-abc\n",
+	}
+	for name, want := range expected {
+		content, err := ioutil.ReadFile(path.Join(testnetDir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(content) != want {
+			t.Errorf("Expected %s to hold %q, received %q", name, want, content)
+		}
+	}
+	if _, err := os.Stat(path.Join(testnetDir, "genesis.ssz")); !os.IsNotExist(err) {
+		t.Errorf("Expected no genesis state without genesisStateFile, received %v", err)
+	}
+}
+
+func TestWriteLighthouseKey(t *testing.T) {
+	dir, err := ioutil.TempDir("", "lighthouse")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	datadir := path.Join(dir, "lighthouse-node-2")
+	multiAddr, err := writeLighthouseKey(dir, 2, datadir, 13002)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(multiAddr, "/tcp/13002/p2p/") {
+		t.Errorf("Unexpected multiaddr %s", multiAddr)
+	}
+	key, err := ioutil.ReadFile(path.Join(datadir, "beacon", "network", "key"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(key) != 32 {
+		t.Errorf("Expected a raw 32 bytes secp256k1 key, received %d bytes", len(key))
+	}
+}
+
+func TestStartLighthouseNode_NoBinary(t *testing.T) {
+	previous := os.Getenv(lighthouseBinaryEnvVar)
+	defer os.Setenv(lighthouseBinaryEnvVar, previous)
+	if err := os.Unsetenv(lighthouseBinaryEnvVar); err != nil {
+		t.Fatal(err)
+	}
+	_, err := startLighthouseNode(context.Background(), t, &end2EndConfig{}, 3, nodePorts{}, "", nil)
+	if err == nil || !strings.Contains(err.Error(), "beacon node 3 failed to start during launch: "+lighthouseBinaryEnvVar) {
+		t.Errorf("Expected error about the missing binary, received %v", err)
+	}
+}
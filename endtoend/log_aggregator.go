@@ -0,0 +1,208 @@
+package endtoend
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"regexp"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+)
+
+// aggregatedLogsEnvVar enables the aggregation of the beacon node logs when set to 1.
+const aggregatedLogsEnvVar = "AGGREGATED_LOGS"
+
+// aggregatedLogFileName is the file the logs of all the beacon nodes are merged into.
+var aggregatedLogFileName = "beacon-aggregated.log"
+
+// aggregatePollInterval is how often the log files of the nodes are checked for new lines.
+var aggregatePollInterval = 500 * time.Millisecond
+
+// aggregateDelay is how long lines are held back before being written, so lines of other nodes
+// logged at the same time but read later can still be written before them.
+var aggregateDelay = 2 * time.Second
+
+// textTimeRegex matches the timestamp of a line written by the logrus text formatter.
+var textTimeRegex = regexp.MustCompile(`\btime="([^"]+)"`)
+
+// textTimeLayout is the timestamp format the beacon node uses for text logs.
+const textTimeLayout = "2006-01-02 15:04:05"
+
+// aggregatedLine is a log line of a node, along with the time it was logged at.
+type aggregatedLine struct {
+	time time.Time
+	text string
+}
+
+// AggregateNodeLogs tails the log file of every node and writes their lines to out, prefixed with
+// the index of the node they come from. Lines are held back for a moment and written in the order
+// of their timestamps, which is best-effort: lines read after their time was flushed are written
+// late. Lines without a timestamp, like stack traces, keep the time of the line before them.
+// The returned function stops the aggregation once every line read so far is written.
+func AggregateNodeLogs(nodes []*beaconNodeInfo, out io.Writer) (stop func(), err error) {
+	for _, node := range nodes {
+		if node.logFile == nil {
+			return nil, fmt.Errorf("beacon node %d has no log file", node.index)
+		}
+	}
+	lines := make(chan []aggregatedLine)
+	done := make(chan struct{})
+	var tails sync.WaitGroup
+	for _, node := range nodes {
+		tails.Add(1)
+		go func(node *beaconNodeInfo) {
+			defer tails.Done()
+			tailNodeLog(node, lines, done)
+		}(node)
+	}
+	go func() {
+		tails.Wait()
+		close(lines)
+	}()
+
+	merged := make(chan struct{})
+	go func() {
+		defer close(merged)
+		mergeNodeLines(lines, out)
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			close(done)
+			<-merged
+		})
+	}, nil
+}
+
+// aggregateBeaconLogs merges the logs of the beacon nodes into a file of the test directory, until
+// the context of the test is done. The nodes are already running, so failing to aggregate their logs
+// fails the test without stopping it, leaving the nodes to be stopped by the caller.
+func aggregateBeaconLogs(ctx context.Context, t testing.TB, tmpPath string, nodes []*beaconNodeInfo) {
+	file, err := os.Create(path.Join(tmpPath, aggregatedLogFileName))
+	if err != nil {
+		t.Errorf("Could not create aggregated log file: %v", err)
+		return
+	}
+	stop, err := AggregateNodeLogs(nodes, file)
+	if err != nil {
+		_ = file.Close()
+		t.Errorf("Could not aggregate beacon node logs: %v", err)
+		return
+	}
+	t.Logf("Aggregating beacon node logs to %s", file.Name())
+	go func() {
+		<-ctx.Done()
+		stop()
+		_ = file.Close()
+	}()
+}
+
+// tailNodeLog sends the lines appended to the log file of the node until done is closed, after a
+// last read.
+func tailNodeLog(node *beaconNodeInfo, lines chan<- []aggregatedLine, done <-chan struct{}) {
+	tail := &fileTail{file: node.logFile}
+	defer tail.close()
+	var last time.Time
+	for {
+		stopping := false
+		select {
+		case <-done:
+			stopping = true
+		case <-time.After(aggregatePollInterval):
+		}
+		read, err := tail.readLines()
+		if err != nil {
+			read = []string{fmt.Sprintf("could not read log file: %v", err)}
+			stopping = true
+		}
+		if len(read) > 0 {
+			batch := make([]aggregatedLine, len(read))
+			for i, line := range read {
+				if logged, ok := logLineTime(line); ok {
+					last = logged
+				} else if last.IsZero() {
+					last = time.Now()
+				}
+				batch[i] = aggregatedLine{time: last, text: prefixNodeLine(node.index, line)}
+			}
+			lines <- batch
+		}
+		if stopping {
+			return
+		}
+	}
+}
+
+// mergeNodeLines writes the lines received in the order of their timestamps, once they've been
+// held back for aggregateDelay. Everything left is written when lines is closed.
+func mergeNodeLines(lines <-chan []aggregatedLine, out io.Writer) {
+	var pending []aggregatedLine
+	ticker := time.NewTicker(aggregatePollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case batch, ok := <-lines:
+			if !ok {
+				ready, held := releaseLines(pending, time.Now())
+				writeLines(out, append(ready, held...))
+				return
+			}
+			pending = append(pending, batch...)
+		case <-ticker.C:
+			var ready []aggregatedLine
+			ready, pending = releaseLines(pending, time.Now().Add(-aggregateDelay))
+			writeLines(out, ready)
+		}
+	}
+}
+
+// releaseLines sorts the pending lines by time and splits them into the lines logged up to the
+// cutoff, which are ready to be written, and the ones to hold back. Lines logged at the same time
+// keep the order they were read in.
+func releaseLines(pending []aggregatedLine, cutoff time.Time) (ready []aggregatedLine, held []aggregatedLine) {
+	sort.SliceStable(pending, func(i, j int) bool {
+		return pending[i].time.Before(pending[j].time)
+	})
+	n := sort.Search(len(pending), func(i int) bool {
+		return pending[i].time.After(cutoff)
+	})
+	return pending[:n], pending[n:]
+}
+
+func writeLines(out io.Writer, lines []aggregatedLine) {
+	for _, line := range lines {
+		// Aggregation is a debugging aid, a failed write shouldn't stop it.
+		_, _ = fmt.Fprintln(out, line.text)
+	}
+}
+
+// prefixNodeLine prefixes the log line with the index of the node it comes from.
+func prefixNodeLine(index int, line string) string {
+	return fmt.Sprintf("[node-%d] %s", index, line)
+}
+
+// logLineTime returns the time the line was logged at, for both JSON and text logs.
+func logLineTime(line string) (time.Time, bool) {
+	if entry, err := parseJSONLogEntry(line); err == nil {
+		value, ok := entry["time"].(string)
+		if !ok {
+			return time.Time{}, false
+		}
+		logged, err := time.Parse(time.RFC3339Nano, value)
+		return logged, err == nil
+	}
+	match := textTimeRegex.FindStringSubmatch(line)
+	if match == nil {
+		return time.Time{}, false
+	}
+	if logged, err := time.ParseInLocation(textTimeLayout, match[1], time.Local); err == nil {
+		return logged, true
+	}
+	logged, err := time.Parse(time.RFC3339Nano, match[1])
+	return logged, err == nil
+}
@@ -0,0 +1,135 @@
+package endtoend
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPrefixNodeLine(t *testing.T) {
+	line := `level=info msg="Starting next epoch" epoch=2`
+	if prefixed := prefixNodeLine(3, line); prefixed != "[node-3] "+line {
+		t.Errorf("Unexpected prefixed line %q", prefixed)
+	}
+}
+
+func TestLogLineTime(t *testing.T) {
+	tests := []struct {
+		name     string
+		line     string
+		expected time.Time
+		ok       bool
+	}{
+		{
+			name:     "json",
+			line:     `{"level":"info","msg":"Starting next epoch","time":"2020-02-03T10:11:12+01:00"}`,
+			expected: time.Date(2020, 2, 3, 9, 11, 12, 0, time.UTC),
+			ok:       true,
+		},
+		{
+			name:     "text",
+			line:     `time="2020-02-03 10:11:12" level=info msg="Starting next epoch"`,
+			expected: time.Date(2020, 2, 3, 10, 11, 12, 0, time.Local),
+			ok:       true,
+		},
+		{
+			name: "json without time",
+			line: `{"level":"info","msg":"Starting next epoch"}`,
+		},
+		{
+			name: "no timestamp",
+			line: "goroutine 1 [running]:",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logged, ok := logLineTime(tt.line)
+			if ok != tt.ok {
+				t.Fatalf("Expected timestamp found to be %v, received %v", tt.ok, ok)
+			}
+			if ok && !logged.Equal(tt.expected) {
+				t.Errorf("Expected time %v, received %v", tt.expected, logged)
+			}
+		})
+	}
+}
+
+func TestReleaseLines(t *testing.T) {
+	start := time.Now()
+	pending := []aggregatedLine{
+		{time: start.Add(2 * time.Second), text: "[node-0] third"},
+		{time: start, text: "[node-1] first"},
+		{time: start.Add(3 * time.Second), text: "[node-1] held"},
+		{time: start, text: "[node-0] second"},
+	}
+
+	ready, held := releaseLines(pending, start.Add(2*time.Second))
+	expected := []string{"[node-1] first", "[node-0] second", "[node-0] third"}
+	if len(ready) != len(expected) {
+		t.Fatalf("Expected %d lines ready, received %d", len(expected), len(ready))
+	}
+	for i, line := range ready {
+		if line.text != expected[i] {
+			t.Errorf("Expected line %d to be %q, received %q", i, expected[i], line.text)
+		}
+	}
+	if len(held) != 1 || held[0].text != "[node-1] held" {
+		t.Errorf("Expected the last line to be held back, received %v", held)
+	}
+}
+
+func TestAggregateNodeLogs(t *testing.T) {
+	previousInterval, previousDelay := aggregatePollInterval, aggregateDelay
+	aggregatePollInterval, aggregateDelay = 10*time.Millisecond, 0
+	defer func() {
+		aggregatePollInterval, aggregateDelay = previousInterval, previousDelay
+	}()
+
+	var nodes []*beaconNodeInfo
+	logs := []string{
+		`{"level":"info","msg":"Chain started","time":"2020-02-03T10:11:12Z"}
+{"level":"info","msg":"Synced new block","time":"2020-02-03T10:11:15Z"}
+`,
+		`{"level":"info","msg":"Peer connected","time":"2020-02-03T10:11:13Z"}
+panic: unexpected state
+`,
+	}
+	for i, content := range logs {
+		file, err := ioutil.TempFile("", "beacon-*.log")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer os.Remove(file.Name())
+		if _, err := file.WriteString(content); err != nil {
+			t.Fatal(err)
+		}
+		nodes = append(nodes, &beaconNodeInfo{index: i, logFile: file})
+	}
+
+	var out bytes.Buffer
+	stop, err := AggregateNodeLogs(nodes, &out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stop()
+	stop()
+
+	expected := []string{
+		`[node-0] {"level":"info","msg":"Chain started","time":"2020-02-03T10:11:12Z"}`,
+		`[node-1] {"level":"info","msg":"Peer connected","time":"2020-02-03T10:11:13Z"}`,
+		"[node-1] panic: unexpected state",
+		`[node-0] {"level":"info","msg":"Synced new block","time":"2020-02-03T10:11:15Z"}`,
+	}
+	if merged := strings.TrimRight(out.String(), "\n"); merged != strings.Join(expected, "\n") {
+		t.Errorf("Unexpected aggregated logs:\n%s", merged)
+	}
+}
+
+func TestAggregateNodeLogs_NoLogFile(t *testing.T) {
+	if _, err := AggregateNodeLogs([]*beaconNodeInfo{{index: 2}}, ioutil.Discard); err == nil || !strings.Contains(err.Error(), "beacon node 2 has no log file") {
+		t.Errorf("Expected missing log file error, received %v", err)
+	}
+}
@@ -0,0 +1,138 @@
+package endtoend
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+// streamLogsEnvVar enables the streaming of the beacon node logs to the test output when set to 1.
+const streamLogsEnvVar = "STREAM_LOGS"
+
+// streamPollInterval is how often the log files of the nodes are checked for new lines to stream.
+var streamPollInterval = 500 * time.Millisecond
+
+// streamLinesPerSecond is how many lines of a node are streamed per second at most, the others are
+// skipped so debug output doesn't flood the test output.
+var streamLinesPerSecond = 50
+
+// streamHeldLines is how many of the latest skipped lines of a node are kept, to be streamed at the
+// end of a failed run.
+var streamHeldLines = 100
+
+// StreamNodeLogs tails the log file of every node and writes its lines to the test output, prefixed
+// with the node they come from, e.g. "[beacon-0] ". Lines over streamLinesPerSecond are skipped and
+// counted. The returned function stops the streaming once the lines logged so far are read and,
+// when the test failed, writes the latest skipped lines so the last ones before a crash are shown.
+func StreamNodeLogs(t testing.TB, nodes []*beaconNodeInfo) (stop func(), err error) {
+	return streamNodeLogs(t, t.Failed, nodes)
+}
+
+func streamNodeLogs(logger Logger, failed func() bool, nodes []*beaconNodeInfo) (func(), error) {
+	for _, node := range nodes {
+		if node.logFile == nil {
+			return nil, fmt.Errorf("beacon node %d has no log file", node.index)
+		}
+	}
+	done := make(chan struct{})
+	var streams sync.WaitGroup
+	for _, node := range nodes {
+		streams.Add(1)
+		go func(node *beaconNodeInfo) {
+			defer streams.Done()
+			stream := &nodeLogStream{
+				logger: logger,
+				prefix: fmt.Sprintf("[beacon-%d] ", node.index),
+				limit:  streamLinesPerSecond,
+			}
+			stream.tail(node, done)
+			stream.flush(failed())
+		}(node)
+	}
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			close(done)
+			streams.Wait()
+		})
+	}, nil
+}
+
+// nodeLogStream writes the lines of a node to the logger, limit per second at most.
+type nodeLogStream struct {
+	logger Logger
+	prefix string
+	limit  int
+	// window is when the current second started, written the lines streamed since then.
+	window  time.Time
+	written int
+	// skipped counts the lines skipped since it was last reported, held are the latest of them.
+	skipped int
+	held    []string
+}
+
+// tail streams the lines appended to the log file of the node until done is closed, after a last
+// read.
+func (s *nodeLogStream) tail(node *beaconNodeInfo, done <-chan struct{}) {
+	tail := &fileTail{file: node.logFile}
+	defer tail.close()
+	for {
+		stopping := false
+		select {
+		case <-done:
+			stopping = true
+		case <-time.After(streamPollInterval):
+		}
+		lines, err := tail.readLines()
+		if err != nil {
+			s.logger.Logf("%scould not read log file: %v", s.prefix, err)
+			return
+		}
+		s.write(lines, time.Now())
+		if stopping {
+			return
+		}
+	}
+}
+
+// write streams the lines read at the given time, skipping the ones over the limit of the second.
+func (s *nodeLogStream) write(lines []string, now time.Time) {
+	if now.Sub(s.window) >= time.Second {
+		s.reportSkipped()
+		s.window, s.written = now, 0
+	}
+	for _, line := range lines {
+		if s.written < s.limit {
+			s.logger.Logf("%s%s", s.prefix, line)
+			s.written++
+			// Lines skipped before a streamed one are older than it, they're not worth showing anymore.
+			s.held = s.held[:0]
+			continue
+		}
+		s.skipped++
+		s.held = append(s.held, line)
+		if len(s.held) > streamHeldLines {
+			s.held = s.held[1:]
+		}
+	}
+}
+
+// flush reports the lines skipped last, and streams the ones held when the test failed.
+func (s *nodeLogStream) flush(failed bool) {
+	if failed && len(s.held) > 0 {
+		s.logger.Logf("%slast %d skipped lines:", s.prefix, len(s.held))
+		for _, line := range s.held {
+			s.logger.Logf("%s%s", s.prefix, line)
+		}
+	}
+	s.reportSkipped()
+}
+
+func (s *nodeLogStream) reportSkipped() {
+	if s.skipped == 0 {
+		return
+	}
+	s.logger.Logf("%s%d lines skipped, over %d lines per second", s.prefix, s.skipped, s.limit)
+	s.skipped = 0
+}
@@ -0,0 +1,146 @@
+package endtoend
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// lockedLogger records the lines logged from several goroutines.
+type lockedLogger struct {
+	mu    sync.Mutex
+	lines []string
+}
+
+func (l *lockedLogger) Logf(format string, args ...interface{}) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.lines = append(l.lines, fmt.Sprintf(format, args...))
+}
+
+func TestNodeLogStream_RateLimit(t *testing.T) {
+	defaultHeld := streamHeldLines
+	streamHeldLines = 3
+	defer func() {
+		streamHeldLines = defaultHeld
+	}()
+	lines := []string{"line 1", "line 2", "line 3", "line 4", "line 5", "line 6", "line 7"}
+
+	tests := []struct {
+		name     string
+		failed   bool
+		expected []string
+	}{
+		{
+			name:   "passed",
+			failed: false,
+			expected: []string{
+				"[beacon-0] line 1",
+				"[beacon-0] line 2",
+				"[beacon-0] 5 lines skipped, over 2 lines per second",
+			},
+		},
+		{
+			name:   "failed",
+			failed: true,
+			expected: []string{
+				"[beacon-0] line 1",
+				"[beacon-0] line 2",
+				"[beacon-0] last 3 skipped lines:",
+				"[beacon-0] line 5",
+				"[beacon-0] line 6",
+				"[beacon-0] line 7",
+				"[beacon-0] 5 lines skipped, over 2 lines per second",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logger := &recordingLogger{}
+			stream := &nodeLogStream{logger: logger, prefix: "[beacon-0] ", limit: 2}
+			stream.write(lines, time.Now())
+			stream.flush(tt.failed)
+			if !reflect.DeepEqual(logger.lines, tt.expected) {
+				t.Errorf("Expected lines %q, received %q", tt.expected, logger.lines)
+			}
+		})
+	}
+}
+
+func TestNodeLogStream_NextSecond(t *testing.T) {
+	logger := &recordingLogger{}
+	stream := &nodeLogStream{logger: logger, prefix: "[beacon-1] ", limit: 1}
+	start := time.Now()
+	stream.write([]string{"first", "skipped"}, start)
+	stream.write([]string{"second"}, start.Add(time.Second))
+	// The skipped line is older than the streamed one, it's not held for the end of a failed run.
+	stream.flush(true)
+
+	expected := []string{
+		"[beacon-1] first",
+		"[beacon-1] 1 lines skipped, over 1 lines per second",
+		"[beacon-1] second",
+	}
+	if !reflect.DeepEqual(logger.lines, expected) {
+		t.Errorf("Expected lines %q, received %q", expected, logger.lines)
+	}
+}
+
+func TestStreamNodeLogs(t *testing.T) {
+	defaultInterval := streamPollInterval
+	streamPollInterval = 10 * time.Millisecond
+	defer func() {
+		streamPollInterval = defaultInterval
+	}()
+
+	nodes := make([]*beaconNodeInfo, 2)
+	for i := range nodes {
+		file, err := ioutil.TempFile("", "beacon-stream-*.log")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer os.Remove(file.Name())
+		defer file.Close()
+		nodes[i] = &beaconNodeInfo{index: i, logFile: file}
+	}
+	logger := &lockedLogger{}
+	stop, err := streamNodeLogs(logger, func() bool { return false }, nodes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, node := range nodes {
+		if _, err := fmt.Fprintf(node.logFile, "level=info msg=\"Starting next epoch\" node=%d\n", i); err != nil {
+			t.Fatal(err)
+		}
+	}
+	time.Sleep(50 * time.Millisecond)
+	// The last line is read when the streaming stops.
+	if _, err := fmt.Fprintln(nodes[1].logFile, `level=error msg="Could not process block"`); err != nil {
+		t.Fatal(err)
+	}
+	stop()
+	stop()
+
+	sort.Strings(logger.lines)
+	expected := []string{
+		`[beacon-0] level=info msg="Starting next epoch" node=0`,
+		`[beacon-1] level=error msg="Could not process block"`,
+		`[beacon-1] level=info msg="Starting next epoch" node=1`,
+	}
+	if strings.Join(logger.lines, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("Expected lines %q, received %q", expected, logger.lines)
+	}
+}
+
+func TestStreamNodeLogs_NoLogFile(t *testing.T) {
+	_, err := streamNodeLogs(&lockedLogger{}, func() bool { return false }, []*beaconNodeInfo{{index: 2}})
+	if err == nil || err.Error() != "beacon node 2 has no log file" {
+		t.Errorf("Expected error for a node without log file, received %v", err)
+	}
+}
@@ -0,0 +1,136 @@
+package endtoend
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// rotatingWriter writes to a log file, moving it aside to a numbered segment once it exceeds
+// maxSize. Segments are numbered in the order they were written, name.1 being the oldest, and
+// the file at name always holds the latest output.
+type rotatingWriter struct {
+	lock     sync.Mutex
+	name     string
+	maxSize  int64
+	file     *os.File
+	size     int64
+	segments int
+}
+
+// newRotatingWriter appends to the log file at name, which is rotated once it exceeds maxSize bytes.
+func newRotatingWriter(name string, maxSize int64) (*rotatingWriter, error) {
+	file, err := os.OpenFile(name, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		_ = file.Close()
+		return nil, err
+	}
+	return &rotatingWriter{
+		name:     name,
+		maxSize:  maxSize,
+		file:     file,
+		size:     info.Size(),
+		segments: len(logSegments(name)) - 1,
+	}, nil
+}
+
+// Write writes p to the current log file, rotating it first if p doesn't fit. Output larger
+// than maxSize is written whole to a segment of its own.
+func (w *rotatingWriter) Write(p []byte) (int, error) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	if w.size > 0 && w.size+int64(len(p)) > w.maxSize {
+		if err := w.rotate(); err != nil {
+			return 0, errors.Wrap(err, "could not rotate log file")
+		}
+	}
+	n, err := w.file.Write(p)
+	w.size += int64(n)
+	return n, err
+}
+
+func (w *rotatingWriter) rotate() error {
+	if err := w.file.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(w.name, segmentName(w.name, w.segments+1)); err != nil {
+		return err
+	}
+	w.segments++
+	file, err := os.OpenFile(w.name, os.O_WRONLY|os.O_APPEND|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	w.file = file
+	w.size = 0
+	return nil
+}
+
+// Close flushes and closes the current log file.
+func (w *rotatingWriter) Close() error {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	if err := w.file.Sync(); err != nil {
+		return err
+	}
+	return w.file.Close()
+}
+
+func segmentName(name string, segment int) string {
+	return fmt.Sprintf("%s.%d", name, segment)
+}
+
+// logSegments returns the paths of all the segments of the log file at name, oldest first.
+// The log file itself is always last, logs that were never rotated only have this segment.
+func logSegments(name string) []string {
+	var segments []string
+	for i := 1; ; i++ {
+		segment := segmentName(name, i)
+		if _, err := os.Stat(segment); err != nil {
+			break
+		}
+		segments = append(segments, segment)
+	}
+	return append(segments, name)
+}
+
+// logReader reads all the segments of a log file in order, as if they were a single file.
+type logReader struct {
+	io.Reader
+	files []*os.File
+}
+
+// openLog opens every segment of the log file at name for reading.
+func openLog(name string) (*logReader, error) {
+	reader := &logReader{}
+	var readers []io.Reader
+	for _, segment := range logSegments(name) {
+		file, err := os.Open(segment)
+		if err != nil {
+			_ = reader.Close()
+			return nil, err
+		}
+		reader.files = append(reader.files, file)
+		readers = append(readers, file)
+	}
+	reader.Reader = io.MultiReader(readers...)
+	return reader, nil
+}
+
+// Close closes all the segments.
+func (r *logReader) Close() error {
+	var firstErr error
+	for _, file := range r.files {
+		if err := file.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
@@ -0,0 +1,190 @@
+package endtoend
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
+	"testing"
+)
+
+func TestRotatingWriter(t *testing.T) {
+	dir, err := ioutil.TempDir("", "rotation")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	name := path.Join(dir, "beacon-0.log")
+	writer, err := newRotatingWriter(name, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var written strings.Builder
+	for i := 0; i < 10; i++ {
+		line := fmt.Sprintf("level=info msg=\"line %d\" padding=%s\n", i, strings.Repeat("x", 10))
+		if _, err := writer.Write([]byte(line)); err != nil {
+			t.Fatal(err)
+		}
+		written.WriteString(line)
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	segments := logSegments(name)
+	// Lines are 36 bytes long, so 2 fit in every segment.
+	if len(segments) != 5 {
+		t.Fatalf("Expected 5 segments, received %v", segments)
+	}
+	if segments[0] != name+".1" || segments[4] != name {
+		t.Errorf("Expected segments from %s.1 to %s, received %v", name, name, segments)
+	}
+	for _, segment := range segments {
+		info, err := os.Stat(segment)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if info.Size() > 100 {
+			t.Errorf("Segment %s is %d bytes, larger than the limit", segment, info.Size())
+		}
+	}
+	log, err := openLog(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer log.Close()
+	content, err := ioutil.ReadAll(log)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(content) != written.String() {
+		t.Errorf("Expected segments to hold all the output in order, received:\n%s", content)
+	}
+}
+
+func TestRotatingWriter_OversizedWrite(t *testing.T) {
+	dir, err := ioutil.TempDir("", "rotation")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	name := path.Join(dir, "beacon-0.log")
+	writer, err := newRotatingWriter(name, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer writer.Close()
+
+	for _, output := range []string{"short\n", "a line longer than the limit\n", "short\n"} {
+		if _, err := writer.Write([]byte(output)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	segments := logSegments(name)
+	if len(segments) != 3 {
+		t.Fatalf("Expected 3 segments, received %v", segments)
+	}
+	content, err := ioutil.ReadFile(segments[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(content) != "a line longer than the limit\n" {
+		t.Errorf("Expected oversized output in a segment of its own, received %q", content)
+	}
+}
+
+func TestFileTail_FollowsRotation(t *testing.T) {
+	dir, err := ioutil.TempDir("", "rotation")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	name := path.Join(dir, "beacon-0.log")
+	file, err := os.Create(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	writer, err := newRotatingWriter(name, 40)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer writer.Close()
+	tail := &fileTail{file: file}
+	defer tail.close()
+
+	// The second line is split over two segments.
+	for _, output := range []string{"first line of the first segment\n", "level=info msg=\"Node started ", "p2p server\"\n"} {
+		if _, err := writer.Write([]byte(output)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	found, err := tail.contains("Node started p2p server")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !found {
+		t.Error("Expected to find text written after the log was rotated")
+	}
+
+	if _, err := writer.Write([]byte("a line long enough to rotate the log again\nlast line\n")); err != nil {
+		t.Fatal(err)
+	}
+	lines, err := tail.readLines()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(lines) != 2 || lines[1] != "last line" {
+		t.Errorf("Expected the lines of the latest segment, received %v", lines)
+	}
+}
+
+func TestGetMultiAddrFromLogFile_Rotated(t *testing.T) {
+	dir, err := ioutil.TempDir("", "rotation")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	name := path.Join(dir, "beacon-0.log")
+	writer, err := newRotatingWriter(name, 50)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer writer.Close()
+	// The node's handle is opened before anything is logged, it's the first segment once rotated.
+	file, err := os.Open(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	multiAddr := "/ip4/10.0.0.5/tcp/13000/p2p/16Uiu2HAmHJg5o8F5sBuDvC9FcJNXHbg2ruuuF6nuxDGBCFkFBH3n"
+	for _, output := range []string{
+		"{\"level\":\"info\",\"msg\":\"Starting beacon node\",\"prefix\":\"node\"}\n",
+		fmt.Sprintf("{\"level\":\"info\",\"msg\":\"Node started p2p server\",\"multiAddr\":\"%s\",\"prefix\":\"p2p\"}\n", multiAddr),
+		"{\"level\":\"info\",\"msg\":\"Starting initial chain sync...\",\"prefix\":\"initial-sync\"}\n",
+	} {
+		if _, err := writer.Write([]byte(output)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(logSegments(name)) != 3 {
+		t.Fatalf("Expected the log to be rotated twice, segments: %v", logSegments(name))
+	}
+
+	received, err := getMultiAddrFromLogFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if received != multiAddr {
+		t.Errorf("Expected multiaddr %s, received %s", multiAddr, received)
+	}
+	lines, err := SearchNodeLog(&beaconNodeInfo{logFile: writer.file}, "Starting")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(lines) != 2 {
+		t.Errorf("Expected lines from the first and last segments, received %v", lines)
+	}
+}
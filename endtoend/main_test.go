@@ -0,0 +1,25 @@
+package endtoend
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+)
+
+// TestMain fails the tests when they leave goroutines running, e.g. from connections to the
+// beacon nodes that were not closed. Setting SKIP_LEAK_CHECK=1 disables the check.
+func TestMain(m *testing.M) {
+	if os.Getenv(skipLeakCheckEnvVar) == "1" {
+		os.Exit(m.Run())
+	}
+	before := goroutineStacks()
+	code := m.Run()
+	if leaked := leakedGoroutines(before, leakCheckTimeout); len(leaked) > 0 {
+		fmt.Fprintf(os.Stderr, "Found %d leaked goroutines:\n\n%s\n", len(leaked), strings.Join(leaked, "\n\n"))
+		if code == 0 {
+			code = 1
+		}
+	}
+	os.Exit(code)
+}
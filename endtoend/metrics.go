@@ -0,0 +1,183 @@
+package endtoend
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	ev "github.com/prysmaticlabs/prysm/endtoend/evaluators"
+)
+
+var metricsSnapshotFileName = "epoch-%d-node-%d.prom"
+
+// metricsScrapeTimeout is how long a beacon node is given to serve its metrics.
+const metricsScrapeTimeout = 5 * time.Second
+
+// metrics holds the samples exposed by a beacon node, keyed by series as they appear in the
+// Prometheus text format, e.g. beacon_head_slot or p2p_peer_count{state="Connected"}.
+type metrics map[string]float64
+
+// metricsEvaluator defines an evaluation performed on the metrics of each beacon node, which are
+// scraped at the end of every epoch. The metrics of the previous epoch are nil when the node was
+// not alive then.
+type metricsEvaluator struct {
+	name       string
+	policy     func(currentEpoch uint64) bool
+	evaluation func(node *beaconNodeInfo, previous metrics, current metrics) error
+}
+
+// metricExpectation checks the metrics of a beacon node, compared to the previous epoch.
+type metricExpectation func(previous metrics, current metrics) error
+
+// MetricsEvaluator ensures the head slot of the beacon node advanced since the previous epoch
+// and that it's connected to peers.
+var MetricsEvaluator = metricsEvaluator{
+	name:   "metrics_expectations_epoch_%d",
+	policy: ev.AfterNthEpoch(0),
+	evaluation: metricsMeetExpectations(
+		metricIncreasing("beacon_head_slot"),
+		metricNonZero(`p2p_peer_count{state="Connected"}`),
+	),
+}
+
+// metricsMeetExpectations returns an evaluation checking all the expectations, reporting all the
+// ones that are not met.
+func metricsMeetExpectations(expectations ...metricExpectation) func(node *beaconNodeInfo, previous metrics, current metrics) error {
+	return func(node *beaconNodeInfo, previous metrics, current metrics) error {
+		var failures []string
+		for _, expectation := range expectations {
+			if err := expectation(previous, current); err != nil {
+				failures = append(failures, err.Error())
+			}
+		}
+		if len(failures) > 0 {
+			return fmt.Errorf("beacon node %d: %s", node.index, strings.Join(failures, "; "))
+		}
+		return nil
+	}
+}
+
+// metricIncreasing expects the series to be greater than at the previous epoch.
+func metricIncreasing(series string) metricExpectation {
+	return func(previous metrics, current metrics) error {
+		currentValue, ok := current[series]
+		if !ok {
+			return fmt.Errorf("no %s metric", series)
+		}
+		previousValue, ok := previous[series]
+		if !ok {
+			return nil
+		}
+		if currentValue <= previousValue {
+			return fmt.Errorf("%s did not increase, was %v and is %v", series, previousValue, currentValue)
+		}
+		return nil
+	}
+}
+
+// metricNonZero expects the series to have a value other than zero.
+func metricNonZero(series string) metricExpectation {
+	return func(_ metrics, current metrics) error {
+		value, ok := current[series]
+		if !ok {
+			return fmt.Errorf("no %s metric", series)
+		}
+		if value == 0 {
+			return fmt.Errorf("%s is 0", series)
+		}
+		return nil
+	}
+}
+
+// scrapeMetrics returns the metrics page of the beacon node, in the Prometheus text format.
+func scrapeMetrics(port uint64) ([]byte, error) {
+	client := &http.Client{Timeout: metricsScrapeTimeout}
+	response, err := client.Get(fmt.Sprintf("http://127.0.0.1:%d/metrics", port))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to scrape metrics")
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to scrape metrics, received status %s", response.Status)
+	}
+	body, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read metrics")
+	}
+	return body, nil
+}
+
+// fetchMetrics scrapes and parses the metrics served on the given monitoring port.
+func fetchMetrics(port uint64) (metrics, error) {
+	body, err := scrapeMetrics(port)
+	if err != nil {
+		return nil, err
+	}
+	return parseMetrics(bytes.NewReader(body))
+}
+
+// captureMetrics scrapes the metrics of each beacon node. When outputDir is set, the metrics are
+// also written to a snapshot file for the epoch.
+func captureMetrics(outputDir string, epoch uint64, nodes []*beaconNodeInfo) (map[int]metrics, error) {
+	captured := make(map[int]metrics, len(nodes))
+	for _, node := range nodes {
+		body, err := scrapeMetrics(node.monitorPort)
+		if err != nil {
+			return nil, errors.Wrapf(err, "beacon node %d", node.index)
+		}
+		if outputDir != "" {
+			fileName := path.Join(outputDir, fmt.Sprintf(metricsSnapshotFileName, epoch, node.index))
+			if err := ioutil.WriteFile(fileName, body, 0644); err != nil {
+				return nil, err
+			}
+		}
+		parsed, err := parseMetrics(bytes.NewReader(body))
+		if err != nil {
+			return nil, errors.Wrapf(err, "beacon node %d", node.index)
+		}
+		captured[node.index] = parsed
+	}
+	return captured, nil
+}
+
+// parseMetrics reads all the samples in the Prometheus text format. Timestamps are ignored.
+func parseMetrics(r io.Reader) (metrics, error) {
+	parsed := make(metrics)
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		// Label values may contain spaces, the series ends with the closing brace of its labels.
+		seriesEnd := strings.IndexAny(line, "{ ")
+		if seriesEnd != -1 && line[seriesEnd] == '{' {
+			labelsEnd := strings.LastIndex(line, "}")
+			if labelsEnd == -1 {
+				return nil, fmt.Errorf("malformed labels in line %q", line)
+			}
+			seriesEnd = labelsEnd + 1
+		}
+		if seriesEnd == -1 {
+			return nil, fmt.Errorf("no value in line %q", line)
+		}
+		fields := strings.Fields(line[seriesEnd:])
+		if len(fields) == 0 {
+			return nil, fmt.Errorf("no value in line %q", line)
+		}
+		value, err := strconv.ParseFloat(fields[0], 64)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid value in line %q", line)
+		}
+		parsed[line[:seriesEnd]] = value
+	}
+	return parsed, scanner.Err()
+}
@@ -0,0 +1,145 @@
+package endtoend
+
+import (
+	"context"
+	"encoding/csv"
+	"os"
+	"strconv"
+
+	ptypes "github.com/gogo/protobuf/types"
+	"github.com/pkg/errors"
+	eth "github.com/prysmaticlabs/ethereumapis/eth/v1alpha1"
+)
+
+// metricsCSVFileName is the file of tmpPath the chain metrics of every beacon node are written to,
+// a row per node at the end of each epoch.
+const metricsCSVFileName = "metrics.csv"
+
+// metricsCSVHeader names the columns of the metrics CSV, balances being in Gwei.
+var metricsCSVHeader = []string{
+	"epoch",
+	"node",
+	"headSlot",
+	"finalizedEpoch",
+	"validatorCount",
+	"avgBalance",
+	"p2pPeerCount",
+}
+
+// nodeEpochMetrics are the chain metrics of a beacon node at an epoch, a row of the metrics CSV.
+type nodeEpochMetrics struct {
+	epoch          uint64
+	node           int
+	headSlot       uint64
+	finalizedEpoch uint64
+	validatorCount uint64
+	avgBalance     uint64
+	peerCount      uint64
+}
+
+func (m *nodeEpochMetrics) record() []string {
+	return []string{
+		strconv.FormatUint(m.epoch, 10),
+		strconv.Itoa(m.node),
+		strconv.FormatUint(m.headSlot, 10),
+		strconv.FormatUint(m.finalizedEpoch, 10),
+		strconv.FormatUint(m.validatorCount, 10),
+		strconv.FormatUint(m.avgBalance, 10),
+		strconv.FormatUint(m.peerCount, 10),
+	}
+}
+
+// metricsCSVWriter writes the chain metrics of the beacon nodes, as served over gRPC, to a CSV
+// file so they can be plotted over the run. Unlike the Prometheus snapshots of metricsOutputDir,
+// it's written by every run.
+type metricsCSVWriter struct {
+	file   *os.File
+	writer *csv.Writer
+}
+
+// newMetricsCSVWriter creates the CSV file, replacing any file of a previous run.
+func newMetricsCSVWriter(fileName string) (*metricsCSVWriter, error) {
+	file, err := os.Create(fileName)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not create metrics CSV")
+	}
+	return &metricsCSVWriter{file: file, writer: csv.NewWriter(file)}, nil
+}
+
+// WriteHeader writes the names of the columns.
+func (w *metricsCSVWriter) WriteHeader() error {
+	return w.write(metricsCSVHeader)
+}
+
+// WriteEpoch requests the chain metrics of every given beacon node and writes a row per node. The
+// rows are flushed, so the file is complete for the epoch even if the run is then interrupted.
+func (w *metricsCSVWriter) WriteEpoch(epoch uint64, nodes []*beaconNodeInfo) error {
+	for _, node := range nodes {
+		nodeMetrics, err := fetchNodeEpochMetrics(node)
+		if err != nil {
+			return errors.Wrapf(err, "could not get metrics of beacon node %d", node.index)
+		}
+		nodeMetrics.epoch = epoch
+		if err := w.write(nodeMetrics.record()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (w *metricsCSVWriter) write(record []string) error {
+	if err := w.writer.Write(record); err != nil {
+		return errors.Wrap(err, "could not write metrics CSV")
+	}
+	w.writer.Flush()
+	return errors.Wrap(w.writer.Error(), "could not write metrics CSV")
+}
+
+// Close closes the CSV file.
+func (w *metricsCSVWriter) Close() error {
+	return w.file.Close()
+}
+
+// fetchNodeEpochMetrics requests the chain head, the validator balances and the peers of the
+// beacon node.
+func fetchNodeEpochMetrics(node *beaconNodeInfo) (*nodeEpochMetrics, error) {
+	conn, err := node.GRPCConn()
+	if err != nil {
+		return nil, err
+	}
+	chainClient := eth.NewBeaconChainClient(conn)
+	head, err := chainClient.GetChainHead(context.Background(), &ptypes.Empty{})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get chain head")
+	}
+	nodeMetrics := &nodeEpochMetrics{
+		node:           node.index,
+		headSlot:       head.HeadSlot,
+		finalizedEpoch: head.FinalizedEpoch,
+	}
+	var totalBalance uint64
+	req := &eth.ListValidatorBalancesRequest{}
+	for {
+		res, err := chainClient.ListValidatorBalances(context.Background(), req)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to get validator balances")
+		}
+		for _, balance := range res.Balances {
+			totalBalance += balance.Balance
+		}
+		nodeMetrics.validatorCount += uint64(len(res.Balances))
+		if res.NextPageToken == "" {
+			break
+		}
+		req.PageToken = res.NextPageToken
+	}
+	if nodeMetrics.validatorCount > 0 {
+		nodeMetrics.avgBalance = totalBalance / nodeMetrics.validatorCount
+	}
+	peers, err := eth.NewNodeClient(conn).ListPeers(context.Background(), &ptypes.Empty{})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list peers")
+	}
+	nodeMetrics.peerCount = uint64(len(peers.Peers))
+	return nodeMetrics, nil
+}
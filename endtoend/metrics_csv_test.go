@@ -0,0 +1,206 @@
+package endtoend
+
+import (
+	"context"
+	"encoding/csv"
+	"io/ioutil"
+	"net"
+	"os"
+	"path"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+
+	ptypes "github.com/gogo/protobuf/types"
+	eth "github.com/prysmaticlabs/ethereumapis/eth/v1alpha1"
+	"google.golang.org/grpc"
+)
+
+// chainMetricsServer serves the chain head, the validator balances, a page of pageSize at a time,
+// and the peers of a beacon node.
+type chainMetricsServer struct {
+	eth.BeaconChainServer
+	eth.NodeServer
+	headSlot uint64
+	balances []uint64
+	pageSize int
+	peers    int
+}
+
+func (s *chainMetricsServer) GetChainHead(_ context.Context, _ *ptypes.Empty) (*eth.ChainHead, error) {
+	return &eth.ChainHead{HeadSlot: s.headSlot, FinalizedEpoch: s.headSlot / 8}, nil
+}
+
+func (s *chainMetricsServer) ListValidatorBalances(_ context.Context, req *eth.ListValidatorBalancesRequest) (*eth.ValidatorBalances, error) {
+	start := 0
+	if req.PageToken != "" {
+		var err error
+		if start, err = strconv.Atoi(req.PageToken); err != nil {
+			return nil, err
+		}
+	}
+	end := start + s.pageSize
+	res := &eth.ValidatorBalances{}
+	if end < len(s.balances) {
+		res.NextPageToken = strconv.Itoa(end)
+	} else {
+		end = len(s.balances)
+	}
+	for i, balance := range s.balances[start:end] {
+		res.Balances = append(res.Balances, &eth.ValidatorBalances_Balance{Index: uint64(start + i), Balance: balance})
+	}
+	return res, nil
+}
+
+func (s *chainMetricsServer) ListPeers(_ context.Context, _ *ptypes.Empty) (*eth.Peers, error) {
+	peers := &eth.Peers{}
+	for i := 0; i < s.peers; i++ {
+		peers.Peers = append(peers.Peers, &eth.Peer{Address: "/ip4/127.0.0.1/tcp/" + strconv.Itoa(13000+i)})
+	}
+	return peers, nil
+}
+
+func startChainMetricsServer(t *testing.T, index int, metricsServer *chainMetricsServer) (*beaconNodeInfo, func()) {
+	port, stopServer := startServer(t, func(server *grpc.Server) {
+		eth.RegisterBeaconChainServer(server, metricsServer)
+		eth.RegisterNodeServer(server, metricsServer)
+	})
+	node := &beaconNodeInfo{index: index, rpcPort: port, alive: true}
+	return node, func() {
+		if err := node.Close(); err != nil {
+			t.Error(err)
+		}
+		stopServer()
+	}
+}
+
+func TestNodeEpochMetrics_Record(t *testing.T) {
+	nodeMetrics := &nodeEpochMetrics{
+		epoch:          4,
+		node:           1,
+		headSlot:       39,
+		finalizedEpoch: 2,
+		validatorCount: 64,
+		avgBalance:     32000000123,
+		peerCount:      3,
+	}
+	expected := []string{"4", "1", "39", "2", "64", "32000000123", "3"}
+	if record := nodeMetrics.record(); !reflect.DeepEqual(record, expected) {
+		t.Errorf("Expected record %v, received %v", expected, record)
+	}
+	if len(expected) != len(metricsCSVHeader) {
+		t.Errorf("Expected a column per header, received %d columns for %d headers", len(expected), len(metricsCSVHeader))
+	}
+}
+
+func TestFetchNodeEpochMetrics(t *testing.T) {
+	node, stop := startChainMetricsServer(t, 2, &chainMetricsServer{
+		headSlot: 40,
+		balances: []uint64{32, 31, 33, 30, 31},
+		pageSize: 2,
+		peers:    3,
+	})
+	defer stop()
+
+	nodeMetrics, err := fetchNodeEpochMetrics(node)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := &nodeEpochMetrics{
+		node:           2,
+		headSlot:       40,
+		finalizedEpoch: 5,
+		validatorCount: 5,
+		avgBalance:     31,
+		peerCount:      3,
+	}
+	if !reflect.DeepEqual(nodeMetrics, expected) {
+		t.Errorf("Expected metrics %+v, received %+v", expected, nodeMetrics)
+	}
+}
+
+func TestMetricsCSVWriter(t *testing.T) {
+	dir, err := ioutil.TempDir("", "metrics-csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	nodes := make([]*beaconNodeInfo, 2)
+	servers := make([]*chainMetricsServer, len(nodes))
+	for i := range nodes {
+		servers[i] = &chainMetricsServer{balances: []uint64{32, 32}, pageSize: 100, peers: len(nodes) - 1}
+		node, stop := startChainMetricsServer(t, i, servers[i])
+		defer stop()
+		nodes[i] = node
+	}
+
+	fileName := path.Join(dir, metricsCSVFileName)
+	writer, err := newMetricsCSVWriter(fileName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := writer.WriteHeader(); err != nil {
+		t.Fatal(err)
+	}
+	epochs := uint64(3)
+	for epoch := uint64(0); epoch < epochs; epoch++ {
+		for _, server := range servers {
+			server.headSlot = epoch * 8
+		}
+		if err := writer.WriteEpoch(epoch, nodes); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	file, err := os.Open(fileName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	records, err := csv.NewReader(file).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := 1 + len(nodes)*int(epochs); len(records) != expected {
+		t.Fatalf("Expected %d records, received %d", expected, len(records))
+	}
+	if header := strings.Join(records[0], ","); header != "epoch,node,headSlot,finalizedEpoch,validatorCount,avgBalance,p2pPeerCount" {
+		t.Errorf("Unexpected header %s", header)
+	}
+	if last := strings.Join(records[len(records)-1], ","); last != "2,1,16,2,2,32,1" {
+		t.Errorf("Unexpected last record %s", last)
+	}
+}
+
+func TestMetricsCSVWriter_NodeDown(t *testing.T) {
+	dir, err := ioutil.TempDir("", "metrics-csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// Nothing listens on the port of the node anymore.
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	node := &beaconNodeInfo{index: 3, rpcPort: uint64(listener.Addr().(*net.TCPAddr).Port)}
+	if err := listener.Close(); err != nil {
+		t.Fatal(err)
+	}
+	defer node.Close()
+	writer, err := newMetricsCSVWriter(path.Join(dir, metricsCSVFileName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer writer.Close()
+	err = writer.WriteEpoch(0, []*beaconNodeInfo{node})
+	if err == nil || !strings.Contains(err.Error(), "could not get metrics of beacon node 3") {
+		t.Errorf("Expected error for a stopped node, received %v", err)
+	}
+}
@@ -0,0 +1,143 @@
+package endtoend
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"path"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const metricsPage = `# HELP beacon_head_slot Slot of the head block of the beacon chain
+# TYPE beacon_head_slot gauge
+beacon_head_slot 42
+beacon_head_slot_total 7
+p2p_peer_count{state="Connected"} 3
+p2p_peer_count{state="Disconnected"} 1
+p2p_topic_peer_count{topic="/eth2/beacon block/ssz"} 2 1579525532830
+process_start_time_seconds 1.57952553283e+09
+`
+
+func TestParseMetrics(t *testing.T) {
+	parsed, err := parseMetrics(strings.NewReader(metricsPage))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := metrics{
+		"beacon_head_slot":                                     42,
+		"beacon_head_slot_total":                               7,
+		`p2p_peer_count{state="Connected"}`:                    3,
+		`p2p_peer_count{state="Disconnected"}`:                 1,
+		`p2p_topic_peer_count{topic="/eth2/beacon block/ssz"}`: 2,
+		"process_start_time_seconds":                           1.57952553283e+09,
+	}
+	if !reflect.DeepEqual(parsed, want) {
+		t.Errorf("Expected %v, received %v", want, parsed)
+	}
+}
+
+func TestParseMetrics_Malformed(t *testing.T) {
+	for _, page := range []string{"beacon_head_slot abc\n", "beacon_head_slot{state=\"x\" 1\n", "beacon_head_slot\n"} {
+		if _, err := parseMetrics(strings.NewReader(page)); err == nil {
+			t.Errorf("Expected error parsing %q", page)
+		}
+	}
+}
+
+func TestFetchMetrics(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/metrics", func(w http.ResponseWriter, _ *http.Request) {
+		fmt.Fprint(w, metricsPage)
+	})
+	server := &http.Server{Handler: mux}
+	go server.Serve(listener)
+	defer server.Close()
+	port := uint64(listener.Addr().(*net.TCPAddr).Port)
+
+	fetched, err := fetchMetrics(port)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fetched["beacon_head_slot"] != 42 {
+		t.Errorf("Expected beacon_head_slot to be 42, received %v", fetched["beacon_head_slot"])
+	}
+
+	outputDir, err := ioutil.TempDir("", "metrics")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(outputDir)
+	captured, err := captureMetrics(outputDir, 3, []*beaconNodeInfo{{index: 1, monitorPort: port}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(captured[1], fetched) {
+		t.Errorf("Expected captured metrics %v, received %v", fetched, captured[1])
+	}
+	snapshot, err := ioutil.ReadFile(path.Join(outputDir, fmt.Sprintf(metricsSnapshotFileName, 3, 1)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(snapshot) != metricsPage {
+		t.Errorf("Expected snapshot to hold the metrics page, received %q", snapshot)
+	}
+}
+
+func TestMetricsEvaluator(t *testing.T) {
+	connected := `p2p_peer_count{state="Connected"}`
+	tests := []struct {
+		name     string
+		previous metrics
+		current  metrics
+		errorMsg string
+	}{
+		{
+			name:     "head advancing with peers",
+			previous: metrics{"beacon_head_slot": 12, connected: 3},
+			current:  metrics{"beacon_head_slot": 20, connected: 3},
+		},
+		{
+			name:    "no previous epoch",
+			current: metrics{"beacon_head_slot": 20, connected: 3},
+		},
+		{
+			name:     "head stalled",
+			previous: metrics{"beacon_head_slot": 12, connected: 3},
+			current:  metrics{"beacon_head_slot": 12, connected: 3},
+			errorMsg: "beacon node 1: beacon_head_slot did not increase, was 12 and is 12",
+		},
+		{
+			name:     "stalled without peers",
+			previous: metrics{"beacon_head_slot": 12, connected: 0},
+			current:  metrics{"beacon_head_slot": 12, connected: 0},
+			errorMsg: "beacon_head_slot did not increase, was 12 and is 12; " + connected + " is 0",
+		},
+		{
+			name:     "missing metric",
+			current:  metrics{connected: 3},
+			errorMsg: "no beacon_head_slot metric",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := MetricsEvaluator.evaluation(&beaconNodeInfo{index: 1}, tt.previous, tt.current)
+			if tt.errorMsg == "" {
+				if err != nil {
+					t.Errorf("Unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.errorMsg) {
+				t.Errorf("Expected error containing %q, received %v", tt.errorMsg, err)
+			}
+		})
+	}
+}
@@ -12,17 +12,44 @@ func TestEndToEnd_MinimalConfig(t *testing.T) {
 	testutil.ResetCache()
 	params.UseMinimalConfig()
 
+	numValidators := params.BeaconConfig().MinGenesisActiveValidatorCount
 	minimalConfig := &end2EndConfig{
 		minimalConfig:  true,
 		epochsToRun:    5,
 		numBeaconNodes: 4,
 		enableSSZCache: true,
-		numValidators:  params.BeaconConfig().MinGenesisActiveValidatorCount,
+		numValidators:  numValidators,
+		// Restart the first beacon node to make sure it catches up with the chain.
+		restartNodeAtEpoch: 2,
+		// Beacon node 0 also keeps the data of past epochs, which its restart must not lose.
+		archive: true,
+		// An empty beacon node joins once the chain is running, and must sync it from the others.
+		lateNodeAtEpoch:   3,
+		testSlasher:       true,
+		depositsAtEpoch:   1,
+		numMidRunDeposits: 8,
+		// The mid-run deposits must be counted within a voting period, so they can be voted in and
+		// processed by the time DepositsProcessed expects them.
+		maxDepositLatencyEpochs: 2,
 		evaluators: []ev.Evaluator{
-			ev.ValidatorsAreActive,
+			ev.ValidatorsAreActive(numValidators),
+			ev.PeersConnect(4),
 			ev.ValidatorsParticipating,
-			ev.FinalizationOccurs,
+			ev.ParticipationAtEpoch(ev.DefaultParticipationThreshold),
+			ev.FinalizationOccurs(),
+			ev.FinalizationEvaluator(3),
+			// The double vote of the slasher test can get its validator slashed.
+			ev.ValidatorsGainBalance(true /*slashingEnabled*/),
+			ev.RewardAccountingEvaluator(2),
+			ev.AttestationInclusionEvaluator(ev.DefaultAttestationInclusionRate),
 		},
+		logEvaluators: []logEvaluator{
+			stateTransitionsLogged,
+			justificationLogged,
+			NoSevereLogs(),
+		},
+		metricsOutputDir:  "metrics",
+		metricsEvaluators: []metricsEvaluator{MetricsEvaluator},
 	}
 	runEndToEndTest(t, minimalConfig)
 }
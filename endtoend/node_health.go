@@ -0,0 +1,112 @@
+package endtoend
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// nodeHealthInterval is how often the beacon node processes are checked during a run.
+const nodeHealthInterval = time.Second
+
+// errorReporter is the part of testing.T the node health monitor reports crashes to.
+type errorReporter interface {
+	Errorf(format string, args ...interface{})
+}
+
+// MonitorNodeHealth checks the process of every beacon node at each interval and fails the test
+// as soon as one of them died, so the run stops at the next epoch with the node named rather than
+// with the gRPC errors of the evaluators. Nodes killed, restarted or stopped by the E2E expect
+// their exit first and aren't reported. The returned function stops the monitoring.
+func MonitorNodeHealth(t testing.TB, nodes []*beaconNodeInfo, interval time.Duration) func() {
+	return monitorNodeHealth(t, nodes, interval)
+}
+
+func monitorNodeHealth(reporter errorReporter, nodes []*beaconNodeInfo, interval time.Duration) func() {
+	ticker := time.NewTicker(interval)
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		defer ticker.Stop()
+		// A crash is reported once, a restarted node is checked again under its new process ID.
+		reported := make(map[int]int)
+		for {
+			select {
+			case <-ticker.C:
+				for _, node := range nodes {
+					if pid := node.deadProcessID(); pid != 0 && reported[node.index] != pid {
+						reported[node.index] = pid
+						reporter.Errorf("beacon node %d (pid %d) died unexpectedly", node.index, pid)
+					}
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			close(done)
+			<-stopped
+		})
+	}
+}
+
+// deadProcessID returns the ID of the node process if it exited unexpectedly, zero otherwise or
+// when the node runs in a container. The lock is held during the check, so a process stopped on
+// purpose is never seen dead.
+func (b *beaconNodeInfo) deadProcessID() int {
+	b.processLock.Lock()
+	defer b.processLock.Unlock()
+	if b.processID == 0 || b.exitExpected || !processExited(b.processID) {
+		return 0
+	}
+	return b.processID
+}
+
+// crashedNodes returns the indices of the beacon nodes whose process exited unexpectedly.
+func crashedNodes(nodes []*beaconNodeInfo) []int {
+	var crashed []int
+	for _, node := range nodes {
+		if node.deadProcessID() != 0 {
+			crashed = append(crashed, node.index)
+		}
+	}
+	return crashed
+}
+
+// expectExit is called before the node process is stopped on purpose, so the health monitor
+// doesn't report it.
+func (b *beaconNodeInfo) expectExit() {
+	b.processLock.Lock()
+	b.exitExpected = true
+	b.processLock.Unlock()
+}
+
+// processExited reports whether the process is gone. A process that exited but wasn't waited for
+// yet still accepts signal 0 as a zombie, so its state is also read from /proc when available.
+func processExited(pid int) bool {
+	process, err := os.FindProcess(pid)
+	if err != nil {
+		return true
+	}
+	if err := process.Signal(syscall.Signal(0)); err != nil {
+		return true
+	}
+	content, err := ioutil.ReadFile(path.Join(procRoot, strconv.Itoa(pid), "stat"))
+	if err != nil {
+		return false
+	}
+	// The state follows the command name, which is in parentheses and may hold spaces.
+	stat := string(content)
+	fields := strings.Fields(stat[strings.LastIndex(stat, ")")+1:])
+	return len(fields) > 0 && (fields[0] == "Z" || fields[0] == "X")
+}
@@ -0,0 +1,94 @@
+package endtoend
+
+import (
+	"fmt"
+	"os/exec"
+	"sync"
+	"testing"
+	"time"
+)
+
+// recordingReporter records the errors reported by the health monitor from its goroutine.
+type recordingReporter struct {
+	mu     sync.Mutex
+	errors []string
+}
+
+func (r *recordingReporter) Errorf(format string, args ...interface{}) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+
+func (r *recordingReporter) reported() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string{}, r.errors...)
+}
+
+// startProcess runs a shell command as a beacon node process would be, without waiting for it.
+func startProcess(t *testing.T, script string) *exec.Cmd {
+	cmd := exec.Command("sh", "-c", script)
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	return cmd
+}
+
+func TestMonitorNodeHealth(t *testing.T) {
+	running := startProcess(t, "sleep 10")
+	defer func() {
+		_ = running.Process.Kill()
+		_ = running.Wait()
+	}()
+	// Exits right away, and is left as a zombie until waited for, as a crashed beacon node is.
+	crashed := startProcess(t, "exit 1")
+	defer func() {
+		_ = crashed.Wait()
+	}()
+	stoppedOnPurpose := startProcess(t, "exit 0")
+	defer func() {
+		_ = stoppedOnPurpose.Wait()
+	}()
+
+	nodes := []*beaconNodeInfo{
+		{index: 0, processID: running.Process.Pid},
+		{index: 1, processID: crashed.Process.Pid},
+		{index: 2, processID: stoppedOnPurpose.Process.Pid},
+		{index: 3}, // Runs in a container.
+	}
+	nodes[2].expectExit()
+	reporter := &recordingReporter{}
+	stop := monitorNodeHealth(reporter, nodes, 10*time.Millisecond)
+	deadline := time.Now().Add(5 * time.Second)
+	for len(reporter.reported()) == 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	// More checks run, the crash must only be reported once.
+	time.Sleep(50 * time.Millisecond)
+	stop()
+	stop()
+
+	expected := fmt.Sprintf("beacon node 1 (pid %d) died unexpectedly", crashed.Process.Pid)
+	if errors := reporter.reported(); len(errors) != 1 || errors[0] != expected {
+		t.Errorf("Expected %q to be reported once, received %q", expected, errors)
+	}
+	if crashed := crashedNodes(nodes); len(crashed) != 1 || crashed[0] != 1 {
+		t.Errorf("Expected beacon node 1 to have crashed, received %v", crashed)
+	}
+}
+
+func TestProcessExited(t *testing.T) {
+	cmd := startProcess(t, "sleep 10")
+	if processExited(cmd.Process.Pid) {
+		t.Error("Expected a running process not to have exited")
+	}
+	if err := cmd.Process.Kill(); err != nil {
+		t.Fatal(err)
+	}
+	// Once waited for, the process is gone and can't be signaled anymore.
+	_ = cmd.Wait()
+	if !processExited(cmd.Process.Pid) {
+		t.Error("Expected a reaped process to have exited")
+	}
+}
@@ -0,0 +1,183 @@
+package endtoend
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"os/exec"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// nodeLauncher runs beacon nodes, either as processes of the built binary or in containers of a
+// published image. See end2EndConfig.beaconNodeImage.
+type nodeLauncher interface {
+	// start runs the beacon node with the given flags, writing its output to output.
+	start(ctx context.Context, node *beaconNodeInfo, args []string, output io.Writer) (nodeProcess, error)
+}
+
+// nodeProcess is a running beacon node.
+type nodeProcess interface {
+	// pid is the ID of the process on the host, zero for containers.
+	pid() int
+	// String describes the process in the test logs.
+	String() string
+	// kill stops the node right away and waits for it to exit.
+	kill() error
+	// stop asks the node to shut down and waits for it to exit, killing it after the timeout.
+	stop(timeout time.Duration) error
+}
+
+// beaconNodeLauncher returns the launcher the beacon nodes of the config are run with.
+func (c *end2EndConfig) beaconNodeLauncher() nodeLauncher {
+	if c.beaconNodeImage == "" {
+		return processLauncher{}
+	}
+	mounts := []string{c.tmpPath}
+	if c.genesisStateFile != "" && !strings.HasPrefix(c.genesisStateFile, c.tmpPath+"/") {
+		mounts = append(mounts, path.Dir(c.genesisStateFile))
+	}
+	return &dockerLauncher{image: c.beaconNodeImage, mounts: mounts}
+}
+
+// processLauncher runs the beacon-chain binary found by findBinary on the host.
+type processLauncher struct{}
+
+func (processLauncher) start(ctx context.Context, _ *beaconNodeInfo, args []string, output io.Writer) (nodeProcess, error) {
+	binaryPath, err := findBinary("beacon-chain")
+	if err != nil {
+		return nil, err
+	}
+	cmd := exec.CommandContext(ctx, binaryPath, args...)
+	cmd.Stdout = output
+	cmd.Stderr = output
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+	return &hostProcess{cmd: cmd}, nil
+}
+
+// hostProcess is a beacon node running as a process of the host.
+type hostProcess struct {
+	cmd *exec.Cmd
+}
+
+func (p *hostProcess) pid() int {
+	return p.cmd.Process.Pid
+}
+
+func (p *hostProcess) String() string {
+	return fmt.Sprintf("process ID %d", p.cmd.Process.Pid)
+}
+
+func (p *hostProcess) kill() error {
+	if err := p.cmd.Process.Kill(); err != nil {
+		return err
+	}
+	// Wait returns an error for a killed process, it's only called to release its resources.
+	_ = p.cmd.Wait()
+	return nil
+}
+
+func (p *hostProcess) stop(timeout time.Duration) error {
+	return stopProcess(p.cmd, timeout)
+}
+
+// dockerCommand is the docker CLI the containers are managed with.
+var dockerCommand = "docker"
+
+// dockerLauncher runs beacon nodes in containers of a published image, with the given host
+// directories mounted at the same path so the flags of the node can be used unchanged. The
+// containers share the network of the host: the eth1 chain, the boot node and the other beacon
+// nodes are reached on 127.0.0.1, which is the container itself on a bridge network, so the ports
+// of the node are the same on the host rather than mapped.
+type dockerLauncher struct {
+	image  string
+	mounts []string
+}
+
+func (l *dockerLauncher) start(ctx context.Context, node *beaconNodeInfo, args []string, output io.Writer) (nodeProcess, error) {
+	// Restarted nodes get a container of their own, the previous one being removed once killed.
+	name := fmt.Sprintf("prysm-e2e-%d-beacon-%d-%d", os.Getpid(), node.index, node.restartCount)
+	if err := runDocker(l.runArgs(name, args)...); err != nil {
+		return nil, err
+	}
+	container := &dockerContainer{name: name}
+	// The output of the container is collected until it's removed, when docker logs returns.
+	container.logs = exec.CommandContext(ctx, dockerCommand, "logs", "--follow", name)
+	container.logs.Stdout = output
+	container.logs.Stderr = output
+	if err := container.logs.Start(); err != nil {
+		_ = runDocker("rm", "--force", name)
+		return nil, errors.Wrapf(err, "could not follow the logs of container %s", name)
+	}
+	return container, nil
+}
+
+// runArgs returns the arguments of docker run starting the container with the given name, the
+// flags of the beacon node are passed to the entrypoint of the image.
+func (l *dockerLauncher) runArgs(name string, args []string) []string {
+	runArgs := []string{
+		"run",
+		"--detach",
+		fmt.Sprintf("--name=%s", name),
+		"--network=host",
+		// Files written to the mounted directories remain removable by the test.
+		fmt.Sprintf("--user=%d:%d", os.Getuid(), os.Getgid()),
+	}
+	for _, mount := range l.mounts {
+		runArgs = append(runArgs, fmt.Sprintf("--volume=%s:%s", mount, mount))
+	}
+	runArgs = append(runArgs, l.image)
+	return append(runArgs, args...)
+}
+
+// dockerContainer is a beacon node running in a container, along with the docker logs command
+// writing its output to the log file of the node.
+type dockerContainer struct {
+	name string
+	logs *exec.Cmd
+}
+
+func (c *dockerContainer) pid() int {
+	return 0
+}
+
+func (c *dockerContainer) String() string {
+	return fmt.Sprintf("container %s", c.name)
+}
+
+func (c *dockerContainer) kill() error {
+	if err := runDocker("rm", "--force", c.name); err != nil {
+		return err
+	}
+	_ = c.logs.Wait()
+	return nil
+}
+
+func (c *dockerContainer) stop(timeout time.Duration) error {
+	seconds := int(math.Ceil(timeout.Seconds()))
+	if err := runDocker("stop", fmt.Sprintf("--time=%d", seconds), c.name); err != nil {
+		return err
+	}
+	if err := runDocker("rm", c.name); err != nil {
+		return err
+	}
+	// docker logs exits once the container is gone, the log file then has all the output.
+	_ = c.logs.Wait()
+	return nil
+}
+
+// runDocker runs the docker command with the given arguments, the error holds its output.
+func runDocker(args ...string) error {
+	output, err := exec.Command(dockerCommand, args...).CombinedOutput()
+	if err != nil {
+		return errors.Wrapf(err, "docker %s failed: %s", args[0], strings.TrimSpace(string(output)))
+	}
+	return nil
+}
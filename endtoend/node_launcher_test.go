@@ -0,0 +1,158 @@
+package endtoend
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestBeaconNodeLauncher(t *testing.T) {
+	config := &end2EndConfig{tmpPath: "/tmp/e2e"}
+	if _, ok := config.beaconNodeLauncher().(processLauncher); !ok {
+		t.Errorf("Expected nodes to run as processes without an image, received %T", config.beaconNodeLauncher())
+	}
+
+	config.beaconNodeImage = "gcr.io/prysmaticlabs/prysm/beacon-chain:latest"
+	config.genesisStateFile = "/tmp/e2e/genesis.ssz"
+	launcher, ok := config.beaconNodeLauncher().(*dockerLauncher)
+	if !ok {
+		t.Fatalf("Expected nodes to run in containers with an image, received %T", config.beaconNodeLauncher())
+	}
+	if !reflect.DeepEqual(launcher.mounts, []string{"/tmp/e2e"}) {
+		t.Errorf("Expected only the test directory to be mounted, received %v", launcher.mounts)
+	}
+
+	config.genesisStateFile = "/states/genesis.ssz"
+	launcher = config.beaconNodeLauncher().(*dockerLauncher)
+	if !reflect.DeepEqual(launcher.mounts, []string{"/tmp/e2e", "/states"}) {
+		t.Errorf("Expected the genesis state directory to be mounted, received %v", launcher.mounts)
+	}
+}
+
+func TestDockerLauncher_RunArgs(t *testing.T) {
+	launcher := &dockerLauncher{image: "prysm:test", mounts: []string{"/tmp/e2e", "/states"}}
+	args := launcher.runArgs("node", []string{"--rpc-port=4000", "--datadir=/tmp/e2e/eth2-beacon-node-0"})
+	expected := []string{
+		"run",
+		"--detach",
+		"--name=node",
+		"--network=host",
+		fmt.Sprintf("--user=%d:%d", os.Getuid(), os.Getgid()),
+		"--volume=/tmp/e2e:/tmp/e2e",
+		"--volume=/states:/states",
+		"prysm:test",
+		"--rpc-port=4000",
+		"--datadir=/tmp/e2e/eth2-beacon-node-0",
+	}
+	if !reflect.DeepEqual(args, expected) {
+		t.Errorf("Unexpected docker run arguments:\n%v\nexpected:\n%v", args, expected)
+	}
+}
+
+// fakeDocker replaces the docker CLI by a script recording its calls to the returned file, and
+// printing the output of a beacon node when asked for the logs of a container.
+func fakeDocker(t *testing.T, dir string) (string, func()) {
+	calls := path.Join(dir, "calls")
+	script := fmt.Sprintf(`#!/bin/sh
+echo "$@" >> %s
+if [ "$1" = logs ]; then
+  echo 'level=info msg="Node started p2p server"'
+fi
+`, calls)
+	previous := dockerCommand
+	dockerCommand = writeScript(t, dir, "docker", script)
+	return calls, func() {
+		dockerCommand = previous
+	}
+}
+
+func writeScript(t *testing.T, dir string, name string, script string) string {
+	scriptPath := path.Join(dir, name)
+	if err := ioutil.WriteFile(scriptPath, []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	return scriptPath
+}
+
+func TestDockerLauncher_StartAndStop(t *testing.T) {
+	dir, err := ioutil.TempDir("", "docker")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	callsFile, restore := fakeDocker(t, dir)
+	defer restore()
+	logFile, err := os.Create(path.Join(dir, "beacon-1.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer logFile.Close()
+
+	launcher := &dockerLauncher{image: "prysm:test", mounts: []string{dir}}
+	node := &beaconNodeInfo{index: 1}
+	process, err := launcher.start(context.Background(), node, []string{"--rpc-port=4000"}, logFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	name := fmt.Sprintf("prysm-e2e-%d-beacon-1-0", os.Getpid())
+	if process.String() != "container "+name || process.pid() != 0 {
+		t.Errorf("Unexpected container %s with process ID %d", process, process.pid())
+	}
+	if err := process.stop(beaconNodeShutdownTimeout); err != nil {
+		t.Fatal(err)
+	}
+
+	content, err := ioutil.ReadFile(callsFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	calls := strings.Split(strings.TrimSpace(string(content)), "\n")
+	if len(calls) != 4 || !strings.HasPrefix(calls[0], "run --detach --name="+name) || !strings.HasSuffix(calls[0], "prysm:test --rpc-port=4000") {
+		t.Fatalf("Expected the container to be run first, received calls %v", calls)
+	}
+	// docker logs runs concurrently, its call can be recorded at any point after the container is run.
+	order := make(map[string]int)
+	for i, call := range calls {
+		order[call] = i
+	}
+	for _, expected := range []string{"logs --follow " + name, "stop --time=5 " + name, "rm " + name} {
+		if _, ok := order[expected]; !ok {
+			t.Errorf("Expected call %q, received %v", expected, calls)
+		}
+	}
+	if order["rm "+name] < order["stop --time=5 "+name] {
+		t.Errorf("Expected the container to be removed once stopped, received %v", calls)
+	}
+
+	output, err := ioutil.ReadFile(logFile.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(output), `msg="Node started p2p server"`) {
+		t.Errorf("Expected the container logs in the log file, received %q", output)
+	}
+}
+
+func TestDockerLauncher_RunFails(t *testing.T) {
+	dir, err := ioutil.TempDir("", "docker")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	previous := dockerCommand
+	dockerCommand = writeScript(t, dir, "docker", "#!/bin/sh\necho 'Unable to find image' >&2\nexit 125\n")
+	defer func() {
+		dockerCommand = previous
+	}()
+
+	launcher := &dockerLauncher{image: "prysm:missing"}
+	_, err = launcher.start(context.Background(), &beaconNodeInfo{}, nil, ioutil.Discard)
+	if err == nil || !strings.Contains(err.Error(), "docker run failed: Unable to find image") {
+		t.Errorf("Expected docker run error, received %v", err)
+	}
+}
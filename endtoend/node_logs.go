@@ -0,0 +1,356 @@
+package endtoend
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	ev "github.com/prysmaticlabs/prysm/endtoend/evaluators"
+)
+
+// logEvaluator defines an evaluation that is performed on the logs of each beacon node,
+// complementing the ev.Evaluator checks which use the beacon node API. Evaluations only rely on
+// info level messages, the level beacon nodes run at unless end2EndConfig.verbosity is set.
+type logEvaluator struct {
+	name       string
+	policy     func(currentEpoch uint64) bool
+	evaluation func(node *beaconNodeInfo) error
+}
+
+// stateTransitionsLogged ensures the beacon node has applied block state transitions.
+var stateTransitionsLogged = logEvaluator{
+	name:       "state_transitions_logged_epoch_%d",
+	policy:     afterSecondEpoch,
+	evaluation: logEntryContains("Finished applying state transition", nil, `msg="Finished applying state transition"`),
+}
+
+// justificationLogged ensures the beacon node has logged an epoch transition with a justified checkpoint.
+var justificationLogged = logEvaluator{
+	name:       "justification_logged_epoch_%d",
+	policy:     afterSecondEpoch,
+	evaluation: logEntryContains("Starting next epoch", justifiedEpochSet, `msg="Starting next epoch".* justifiedEpoch=[1-9]`),
+}
+
+// severeLogLevels are the log levels NoSevereLogs fails on.
+var severeLogLevels = map[string]bool{"error": true, "fatal": true}
+
+// textLevelRegex matches the level of a line written by the logrus text formatter.
+var textLevelRegex = regexp.MustCompile(`\blevel=(\w+)`)
+
+// NoSevereLogs returns a log evaluator that fails when a beacon node logged error or fatal lines,
+// other than the ones containing any of the allowed messages. Every epoch only the lines logged
+// since the previous one are checked.
+func NoSevereLogs(allowed ...string) logEvaluator {
+	tails := make(map[int]*fileTail)
+	return logEvaluator{
+		name:   "no_severe_logs_epoch_%d",
+		policy: ev.AllEpochs,
+		evaluation: func(node *beaconNodeInfo) error {
+			tail, ok := tails[node.index]
+			if !ok {
+				tail = &fileTail{file: node.logFile}
+				tails[node.index] = tail
+			}
+			lines, err := tail.readLines()
+			if err != nil {
+				return errors.Wrapf(err, "could not read logs of beacon node %d", node.index)
+			}
+			severe := severeLines(lines, allowed)
+			if len(severe) > 0 {
+				return fmt.Errorf("beacon node %d logged %d severe lines:\n%s", node.index, len(severe), strings.Join(severe, "\n"))
+			}
+			return nil
+		},
+	}
+}
+
+// severeLines returns the lines logged at a severe level that contain none of the allowed messages.
+func severeLines(lines []string, allowed []string) []string {
+	var severe []string
+	for _, line := range lines {
+		if !severeLogLevels[lineLevel(line)] || containsAny(line, allowed) {
+			continue
+		}
+		severe = append(severe, line)
+	}
+	return severe
+}
+
+// lineLevel returns the level the line was logged at, in either the JSON or the text format, empty
+// if the line has none.
+func lineLevel(line string) string {
+	if strings.HasPrefix(line, "{") {
+		entry, err := parseJSONLogEntry(line)
+		if err != nil {
+			return ""
+		}
+		level, ok := entry["level"].(string)
+		if !ok {
+			return ""
+		}
+		return level
+	}
+	match := textLevelRegex.FindStringSubmatch(line)
+	if match == nil {
+		return ""
+	}
+	return match[1]
+}
+
+func containsAny(line string, substrings []string) bool {
+	for _, substring := range substrings {
+		if strings.Contains(line, substring) {
+			return true
+		}
+	}
+	return false
+}
+
+func afterSecondEpoch(currentEpoch uint64) bool {
+	return currentEpoch > 2
+}
+
+func logContains(pattern string) func(node *beaconNodeInfo) error {
+	return func(node *beaconNodeInfo) error {
+		lines, err := SearchNodeLog(node, pattern)
+		if err != nil {
+			return err
+		}
+		if len(lines) == 0 {
+			return fmt.Errorf("no line matching %q in logs of beacon node %d", pattern, node.index)
+		}
+		return nil
+	}
+}
+
+// logEntryContains checks the beacon node logged a JSON entry with the message for which match,
+// when set, returns true. Nodes logging in the text format are checked against textPattern instead.
+func logEntryContains(msg string, match func(entry map[string]interface{}) bool, textPattern string) func(node *beaconNodeInfo) error {
+	return func(node *beaconNodeInfo) error {
+		entries, err := FilterJSONLog(node.logFile, "msg", msg)
+		if err != nil {
+			return err
+		}
+		for _, entry := range entries {
+			if match == nil || match(entry) {
+				return nil
+			}
+		}
+		return logContains(textPattern)(node)
+	}
+}
+
+// justifiedEpochSet returns true for the epoch transition entries with a justified checkpoint.
+func justifiedEpochSet(entry map[string]interface{}) bool {
+	epoch, err := strconv.ParseUint(jsonFieldString(entry["justifiedEpoch"]), 10, 64)
+	return err == nil && epoch > 0
+}
+
+// SearchNodeLog returns all the lines of the beacon node log matching the regular expression pattern.
+func SearchNodeLog(node *beaconNodeInfo, pattern string) ([]string, error) {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid pattern")
+	}
+	// Open separate handles, seeking the node's own log file would move the offset the node writes at.
+	log, err := openLog(node.logFile.Name())
+	if err != nil {
+		return nil, err
+	}
+	defer log.Close()
+
+	var matches []string
+	scanner := bufio.NewScanner(log)
+	for scanner.Scan() {
+		if re.MatchString(scanner.Text()) {
+			matches = append(matches, scanner.Text())
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return matches, nil
+}
+
+// parseJSONLogEntry parses a log line written by the logrus JSON formatter.
+func parseJSONLogEntry(line string) (map[string]interface{}, error) {
+	var entry map[string]interface{}
+	if err := json.Unmarshal([]byte(line), &entry); err != nil {
+		return nil, errors.Wrap(err, "not a JSON log entry")
+	}
+	return entry, nil
+}
+
+// FilterJSONLog returns the JSON entries of the log file whose field has the given value, numbers
+// and booleans are compared in their text form. Plain text lines are skipped, so logs mixing both
+// formats can be filtered.
+func FilterJSONLog(file *os.File, field string, value string) ([]map[string]interface{}, error) {
+	// Open separate handles, seeking the file would move the offset the process writes at.
+	log, err := openLog(file.Name())
+	if err != nil {
+		return nil, err
+	}
+	defer log.Close()
+
+	var entries []map[string]interface{}
+	scanner := bufio.NewScanner(log)
+	// Entries with large fields, like the flags of the node, don't fit the default line limit.
+	scanner.Buffer(make([]byte, bufio.MaxScanTokenSize), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if !strings.HasPrefix(line, "{") {
+			continue
+		}
+		entry, err := parseJSONLogEntry(line)
+		if err != nil {
+			continue
+		}
+		if fieldValue, ok := entry[field]; ok && jsonFieldString(fieldValue) == value {
+			entries = append(entries, entry)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+func jsonFieldString(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case float64:
+		// JSON numbers are decoded as float64, slots and epochs are printed as integers.
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// lastLinesOfFile returns the last n lines of the file.
+func lastLinesOfFile(name string, n int) (string, error) {
+	content, err := ioutil.ReadFile(name)
+	if err != nil {
+		return "", err
+	}
+	lines := strings.Split(strings.TrimRight(string(content), "\n"), "\n")
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+// fileTail incrementally reads a file that is being appended to, every read only returns
+// the content appended since the previous read. When the file is rotated, the tail moves on
+// to the following segments.
+type fileTail struct {
+	file   *os.File
+	offset int64
+	// partial is the last line read when it was not terminated yet, it is completed by the next read.
+	partial string
+	// name is the path of the log file, file no longer has this name once it's rotated.
+	name string
+	// opened is set once the tail has moved on to a segment it opened itself.
+	opened bool
+}
+
+// readLines returns the lines completed since the previous read.
+func (f *fileTail) readLines() ([]string, error) {
+	if f.name == "" {
+		f.name = f.file.Name()
+	}
+	var lines []string
+	for {
+		// Rotated segments are no longer written to, so checking first ensures they're read to the end.
+		next, err := f.nextSegment()
+		if err != nil {
+			return nil, err
+		}
+		appended, err := f.readAppended()
+		if err != nil {
+			return nil, err
+		}
+		lines = append(lines, appended...)
+		if next == "" {
+			return lines, nil
+		}
+		file, err := os.Open(next)
+		if os.IsNotExist(err) {
+			// The new log file is not created yet, it's opened by the next read.
+			return lines, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		f.close()
+		f.file = file
+		f.offset = 0
+		f.opened = true
+	}
+}
+
+// nextSegment returns the segment following the file being read, if it has been rotated.
+func (f *fileTail) nextSegment() (string, error) {
+	info, err := f.file.Stat()
+	if err != nil {
+		return "", err
+	}
+	segments := logSegments(f.name)
+	for i, segment := range segments[:len(segments)-1] {
+		if segmentInfo, err := os.Stat(segment); err == nil && os.SameFile(info, segmentInfo) {
+			return segments[i+1], nil
+		}
+	}
+	return "", nil
+}
+
+func (f *fileTail) readAppended() ([]string, error) {
+	info, err := f.file.Stat()
+	if err != nil {
+		return nil, err
+	}
+	if info.Size() <= f.offset {
+		return nil, nil
+	}
+	buf := make([]byte, info.Size()-f.offset)
+	// ReadAt is used since it doesn't move the file offset, which may be shared with the process writing to the file.
+	n, err := f.file.ReadAt(buf, f.offset)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+	f.offset += int64(n)
+
+	lines := strings.Split(f.partial+string(buf[:n]), "\n")
+	f.partial = lines[len(lines)-1]
+	return lines[:len(lines)-1], nil
+}
+
+// close closes the segment being read if the tail opened it, the file it was created with
+// belongs to the caller.
+func (f *fileTail) close() {
+	if f.opened {
+		_ = f.file.Close()
+	}
+}
+
+// contains reads the newly appended content and reports whether any line contains the text.
+// The last line is checked even if it's not terminated yet.
+func (f *fileTail) contains(text string) (bool, error) {
+	lines, err := f.readLines()
+	if err != nil {
+		return false, err
+	}
+	for _, line := range lines {
+		if strings.Contains(line, text) {
+			return true, nil
+		}
+	}
+	return strings.Contains(f.partial, text), nil
+}
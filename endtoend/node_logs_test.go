@@ -0,0 +1,343 @@
+package endtoend
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSearchNodeLog(t *testing.T) {
+	file, err := ioutil.TempFile("", "beacon-*.log")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(file.Name())
+	logContent := `level=info msg="Starting next epoch" epoch=2 finalizedEpoch=0 justifiedEpoch=0 prefix=forkchoice
+level=info msg="Finished applying state transition" attestations=4 deposits=0 prefix=blockchain slot=17
+level=info msg="Starting next epoch" epoch=3 finalizedEpoch=1 justifiedEpoch=2 prefix=forkchoice
+`
+	if _, err := file.WriteString(logContent); err != nil {
+		t.Fatal(err)
+	}
+	node := &beaconNodeInfo{logFile: file}
+
+	lines, err := SearchNodeLog(node, `msg="Starting next epoch"`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(lines) != 2 {
+		t.Errorf("Expected 2 matching lines, received %d", len(lines))
+	}
+	if err := justificationLogged.evaluation(node); err != nil {
+		t.Errorf("Expected justification to be found: %v", err)
+	}
+	if err := logContains(`msg="Peer connected"`)(node); err == nil {
+		t.Error("Expected error for missing log line")
+	}
+	if _, err := SearchNodeLog(node, "("); err == nil {
+		t.Error("Expected error for invalid pattern")
+	}
+}
+
+func TestParseJSONLogEntry(t *testing.T) {
+	entry, err := parseJSONLogEntry(`{"epoch":3,"level":"info","msg":"Starting next epoch","prefix":"forkchoice"}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if entry["msg"] != "Starting next epoch" || entry["epoch"] != float64(3) {
+		t.Errorf("Unexpected entry %v", entry)
+	}
+	if _, err := parseJSONLogEntry(`level=info msg="Starting next epoch" epoch=3`); err == nil {
+		t.Error("Expected error for plain text line")
+	}
+}
+
+func TestFilterJSONLog(t *testing.T) {
+	file, err := ioutil.TempFile("", "beacon-*.log")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(file.Name())
+	logContent := `Starting beacon node. Version: Prysm/Git commit: Local build
+{"epoch":2,"finalizedEpoch":0,"justifiedEpoch":0,"level":"info","msg":"Starting next epoch","prefix":"forkchoice"}
+level=info msg="Starting next epoch" epoch=3 finalizedEpoch=1 justifiedEpoch=2 prefix=forkchoice
+{"attestations":4,"level":"info","msg":"Finished applying state transition","prefix":"blockchain","slot":17}
+{"epoch":3,"finalizedEpoch":1,"justifiedEpoch":2,"level":"info","msg":"Starting next epoch","prefix":"forkchoice"}
+{"epoch":3,"level":"debug","msg":"truncated entry
+{"level":"info","msg":"Sending genesis time notification","synced":true}
+`
+	if _, err := file.WriteString(logContent); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		field string
+		value string
+		msgs  []string
+	}{
+		{field: "msg", value: "Starting next epoch", msgs: []string{"Starting next epoch", "Starting next epoch"}},
+		{field: "epoch", value: "3", msgs: []string{"Starting next epoch"}},
+		{field: "slot", value: "17", msgs: []string{"Finished applying state transition"}},
+		{field: "synced", value: "true", msgs: []string{"Sending genesis time notification"}},
+		{field: "epoch", value: "4"},
+		{field: "missing", value: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.field+"="+tt.value, func(t *testing.T) {
+			entries, err := FilterJSONLog(file, tt.field, tt.value)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(entries) != len(tt.msgs) {
+				t.Fatalf("Expected %d entries, received %v", len(tt.msgs), entries)
+			}
+			for i, entry := range entries {
+				if entry["msg"] != tt.msgs[i] {
+					t.Errorf("Expected entry with message %q, received %v", tt.msgs[i], entry)
+				}
+			}
+		})
+	}
+}
+
+func TestFileTail_ReadsOnlyAppendedLines(t *testing.T) {
+	file, err := ioutil.TempFile("", "beacon-*.log")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(file.Name())
+	tail := &fileTail{file: file}
+
+	if _, err := file.WriteString("first line\nsecond "); err != nil {
+		t.Fatal(err)
+	}
+	lines, err := tail.readLines()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(lines) != 1 || lines[0] != "first line" {
+		t.Errorf("Expected only the first line, received %v", lines)
+	}
+
+	if _, err := file.WriteString("line\nthird line\n"); err != nil {
+		t.Fatal(err)
+	}
+	lines, err = tail.readLines()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(lines) != 2 || lines[0] != "second line" || lines[1] != "third line" {
+		t.Errorf("Expected the second and third lines, received %v", lines)
+	}
+
+	lines, err = tail.readLines()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(lines) != 0 {
+		t.Errorf("Expected no new lines, received %v", lines)
+	}
+}
+
+func TestFileTail_TextStraddlesAppends(t *testing.T) {
+	file, err := ioutil.TempFile("", "beacon-*.log")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(file.Name())
+	tail := &fileTail{file: file}
+
+	if _, err := file.WriteString("level=info msg=\"Node started p2"); err != nil {
+		t.Fatal(err)
+	}
+	found, err := tail.contains("Node started p2p server")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if found {
+		t.Fatal("Did not expect to find text before it was fully written")
+	}
+	if _, err := file.WriteString("p server\" prefix=p2p\n"); err != nil {
+		t.Fatal(err)
+	}
+	found, err = tail.contains("Node started p2p server")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !found {
+		t.Error("Expected to find text split over two appends")
+	}
+}
+
+func TestWaitForTextInFile_GrowingFile(t *testing.T) {
+	file, err := ioutil.TempFile("", "beacon-*.log")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(file.Name())
+
+	// Keep appending unrelated lines while waiting, and split the awaited text over two appends.
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 20; i++ {
+			if _, err := file.WriteString("level=debug msg=\"Unrelated log line\"\n"); err != nil {
+				t.Error(err)
+			}
+			time.Sleep(100 * time.Millisecond)
+		}
+		if _, err := file.WriteString("level=info msg=\"Sending genesis"); err != nil {
+			t.Error(err)
+		}
+		time.Sleep(2500 * time.Millisecond)
+		if _, err := file.WriteString(" time notification\"\n"); err != nil {
+			t.Error(err)
+		}
+	}()
+
+	if err := waitForTextInFile(context.Background(), file, "Sending genesis time notification", 10*time.Second); err != nil {
+		t.Fatal(err)
+	}
+	<-done
+}
+
+func TestWaitForTextInFile_ContextCancelled(t *testing.T) {
+	file, err := ioutil.TempFile("", "beacon-*.log")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(file.Name())
+	if _, err := file.WriteString("level=info msg=\"Starting beacon node\"\nlevel=error msg=\"Could not connect to eth1\"\n"); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	err = waitForTextInFile(ctx, file, "Node started p2p server", time.Minute)
+	if err == nil {
+		t.Fatal("Expected error when the context is cancelled")
+	}
+	if time.Since(start) > 5*time.Second {
+		t.Errorf("Expected to stop waiting promptly, waited %v", time.Since(start))
+	}
+	if !strings.Contains(err.Error(), "Could not connect to eth1") {
+		t.Errorf("Expected error to include the tail of the log, received: %v", err)
+	}
+}
+
+func TestNoSevereLogs(t *testing.T) {
+	file, err := ioutil.TempFile("", "beacon-*.log")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(file.Name())
+	node := &beaconNodeInfo{index: 2, logFile: file}
+	evaluator := NoSevereLogs("Could not connect to eth1")
+
+	write := func(content string) {
+		if _, err := file.WriteString(content); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("level=info msg=\"Starting beacon node\"\nlevel=error msg=\"Could not connect to eth1\"\n")
+	if err := evaluator.evaluation(node); err != nil {
+		t.Errorf("Unexpected error for allowed error lines: %v", err)
+	}
+
+	write("level=warning msg=\"Peer disconnected\"\nlevel=error msg=\"Could not process block\"\nlevel=fatal msg=\"Database corrupted\"\n")
+	err = evaluator.evaluation(node)
+	if err == nil {
+		t.Fatal("Expected error for severe lines")
+	}
+	for _, want := range []string{"beacon node 2 logged 2 severe lines", "Could not process block", "Database corrupted"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Expected error to contain %q, received: %v", want, err)
+		}
+	}
+
+	// The lines already reported are not read again.
+	write("level=info msg=\"Synced new block\"\n")
+	if err := evaluator.evaluation(node); err != nil {
+		t.Errorf("Unexpected error once no new severe lines were logged: %v", err)
+	}
+}
+
+func TestLogEvaluators_JSON(t *testing.T) {
+	file, err := ioutil.TempFile("", "beacon-*.log")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(file.Name())
+	node := &beaconNodeInfo{logFile: file}
+	write := func(content string) {
+		if _, err := file.WriteString(content); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write(`{"epoch":2,"finalizedEpoch":0,"justifiedEpoch":0,"level":"info","msg":"Starting next epoch","prefix":"forkchoice"}
+{"attestations":4,"level":"info","msg":"Finished applying state transition","prefix":"blockchain","slot":17}
+`)
+	if err := stateTransitionsLogged.evaluation(node); err != nil {
+		t.Errorf("Expected state transition to be found: %v", err)
+	}
+	if err := justificationLogged.evaluation(node); err == nil {
+		t.Error("Expected error when no epoch was justified")
+	}
+	write(`{"epoch":3,"finalizedEpoch":1,"justifiedEpoch":2,"level":"info","msg":"Starting next epoch","prefix":"forkchoice"}` + "\n")
+	if err := justificationLogged.evaluation(node); err != nil {
+		t.Errorf("Expected justification to be found: %v", err)
+	}
+}
+
+func TestSevereLines_JSON(t *testing.T) {
+	lines := []string{
+		`{"level":"info","msg":"Starting beacon node","prefix":"node"}`,
+		`{"level":"error","msg":"Could not connect to eth1","prefix":"powchain"}`,
+		`{"level":"info","msg":"Could not find level=error in the text","prefix":"sync"}`,
+		`{"level":"fatal","msg":"Database corrupted","prefix":"db"}`,
+		`level=error msg="Could not process block" prefix=blockchain`,
+	}
+	severe := severeLines(lines, []string{"Could not connect to eth1"})
+	if len(severe) != 2 || !strings.Contains(severe[0], "Database corrupted") || !strings.Contains(severe[1], "Could not process block") {
+		t.Errorf("Unexpected severe lines %v", severe)
+	}
+}
+
+func TestWaitForLogField(t *testing.T) {
+	file, err := ioutil.TempFile("", "beacon-*.log")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(file.Name())
+	if _, err := file.WriteString(`{"level":"info","msg":"Starting beacon node","prefix":"node"}` + "\n"); err != nil {
+		t.Fatal(err)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		time.Sleep(500 * time.Millisecond)
+		if _, err := file.WriteString(`{"level":"info","msg":"Connected to eth1 proof-of-work chain","prefix":"powchain","latestBlock":42}` + "\n"); err != nil {
+			t.Error(err)
+		}
+	}()
+	block, err := waitForLogField(context.Background(), file, "Connected to eth1 proof-of-work chain", "latestBlock", 10*time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if block != "42" {
+		t.Errorf("Expected block 42, received %s", block)
+	}
+	<-done
+
+	if _, err := waitForLogField(context.Background(), file, "Connected to eth1 proof-of-work chain", "peers", 0); err == nil {
+		t.Error("Expected error for missing field")
+	}
+	if _, err := waitForLogField(context.Background(), file, "Node started p2p server", "multiAddr", 0); err == nil {
+		t.Error("Expected error for missing entry")
+	}
+}
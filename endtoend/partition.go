@@ -0,0 +1,142 @@
+package endtoend
+
+import (
+	"fmt"
+	"os/exec"
+	"regexp"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// pfAnchor is the pf anchor the partition rules are loaded in on macOS. The default pf.conf
+// evaluates the anchors under com.apple, so no change to the main ruleset is needed.
+const pfAnchor = "com.apple/prysm-e2e"
+
+// pfTokenRegex matches the reference printed by pfctl -E, used to release pf once restored.
+var pfTokenRegex = regexp.MustCompile(`Token : (\d+)`)
+
+// firewallCommand is a command line run to change the firewall rules, with the data given on its stdin.
+type firewallCommand struct {
+	args  []string
+	stdin string
+}
+
+// PartitionNodes blocks the p2p TCP traffic between the beacon nodes of group1 and group2, with
+// iptables on Linux or pf on macOS, until the returned restore function is called. The nodes of
+// each group can still reach each other. As all the nodes run on the loopback interface, the
+// connections are told apart by their ports, which relies on libp2p dialing from its listen port.
+// Changing the firewall needs root privileges.
+func PartitionNodes(t testing.TB, group1, group2 []*beaconNodeInfo) (restore func()) {
+	apply, undo, err := partitionCommands(runtime.GOOS, group1, group2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, cmd := range apply {
+		output, err := cmd.run()
+		if err != nil {
+			// Don't leave the rules applied so far behind, the others fail to be removed.
+			for _, cmd := range undo {
+				_, _ = cmd.run()
+			}
+			t.Fatalf("Could not partition beacon nodes with %s: %v, output: %s", strings.Join(apply[i].args, " "), err, output)
+		}
+		if match := pfTokenRegex.FindSubmatch(output); match != nil {
+			undo = append(undo, firewallCommand{args: []string{"pfctl", "-X", string(match[1])}})
+		}
+	}
+	t.Logf("Partitioned beacon nodes %v from beacon nodes %v", nodeIndices(group1), nodeIndices(group2))
+	return func() {
+		runFirewallCommands(t, undo)
+		t.Logf("Restored connectivity between beacon nodes %v and beacon nodes %v", nodeIndices(group1), nodeIndices(group2))
+	}
+}
+
+// partitionGroups splits the beacon nodes into the ones listed in end2EndConfig.partitionedNodes
+// and the others, or into the first and the second half when none are listed.
+func (c *end2EndConfig) partitionGroups(nodes []*beaconNodeInfo) (partitioned []*beaconNodeInfo, others []*beaconNodeInfo) {
+	if len(c.partitionedNodes) == 0 {
+		half := len(nodes) / 2
+		return nodes[:half], nodes[half:]
+	}
+	listed := make(map[int]bool, len(c.partitionedNodes))
+	for _, index := range c.partitionedNodes {
+		listed[index] = true
+	}
+	for _, node := range nodes {
+		if listed[node.index] {
+			partitioned = append(partitioned, node)
+		} else {
+			others = append(others, node)
+		}
+	}
+	return partitioned, others
+}
+
+// partitionCommands returns the commands blocking the traffic between the two groups of beacon
+// nodes on the given OS, along with the commands removing the rules again.
+func partitionCommands(goos string, group1, group2 []*beaconNodeInfo) (apply []firewallCommand, restore []firewallCommand, err error) {
+	if len(group1) == 0 || len(group2) == 0 {
+		return nil, nil, fmt.Errorf("can't partition %d beacon nodes from %d, both groups need at least one", len(group1), len(group2))
+	}
+	// Blocking the packets from one node's port to the other's, both ways, cuts the connections
+	// dialed by either node.
+	var portPairs [][2]uint64
+	for _, a := range group1 {
+		for _, b := range group2 {
+			portPairs = append(portPairs, [2]uint64{a.p2pTCPPort, b.p2pTCPPort}, [2]uint64{b.p2pTCPPort, a.p2pTCPPort})
+		}
+	}
+
+	switch goos {
+	case "linux":
+		for _, ports := range portPairs {
+			rule := []string{
+				"OUTPUT", "-o", "lo", "-p", "tcp",
+				"--sport", fmt.Sprintf("%d", ports[0]),
+				"--dport", fmt.Sprintf("%d", ports[1]),
+				"-j", "DROP",
+			}
+			apply = append(apply, firewallCommand{args: append([]string{"iptables", "-I"}, rule...)})
+			restore = append(restore, firewallCommand{args: append([]string{"iptables", "-D"}, rule...)})
+		}
+		return apply, restore, nil
+	case "darwin":
+		var rules []string
+		for _, ports := range portPairs {
+			rules = append(rules, fmt.Sprintf("block drop quick proto tcp from any port %d to any port %d", ports[0], ports[1]))
+		}
+		apply = []firewallCommand{
+			{args: []string{"pfctl", "-a", pfAnchor, "-f", "-"}, stdin: strings.Join(rules, "\n") + "\n"},
+			// Enables pf if it isn't already, the printed token is used to release it.
+			{args: []string{"pfctl", "-E"}},
+		}
+		restore = []firewallCommand{{args: []string{"pfctl", "-a", pfAnchor, "-F", "rules"}}}
+		return apply, restore, nil
+	default:
+		return nil, nil, fmt.Errorf("partitioning beacon nodes is not supported on %s", goos)
+	}
+}
+
+func (c firewallCommand) run() ([]byte, error) {
+	cmd := exec.Command(c.args[0], c.args[1:]...)
+	cmd.Stdin = strings.NewReader(c.stdin)
+	return cmd.CombinedOutput()
+}
+
+// runFirewallCommands runs all the commands, reporting the ones that fail without stopping.
+func runFirewallCommands(t testing.TB, cmds []firewallCommand) {
+	for _, cmd := range cmds {
+		if output, err := cmd.run(); err != nil {
+			t.Errorf("Could not run %s: %v, output: %s", strings.Join(cmd.args, " "), err, output)
+		}
+	}
+}
+
+func nodeIndices(nodes []*beaconNodeInfo) []int {
+	indices := make([]int, len(nodes))
+	for i, node := range nodes {
+		indices[i] = node.index
+	}
+	return indices
+}
@@ -0,0 +1,43 @@
+package endtoend
+
+import (
+	"os"
+	"testing"
+
+	ev "github.com/prysmaticlabs/prysm/endtoend/evaluators"
+	"github.com/prysmaticlabs/prysm/shared/params"
+	"github.com/prysmaticlabs/prysm/shared/testutil"
+)
+
+func TestEndToEnd_NetworkPartition(t *testing.T) {
+	if os.Geteuid() != 0 {
+		t.Skip("Partitioning beacon nodes needs root privileges to change the firewall rules")
+	}
+	testutil.ResetCache()
+	params.UseMinimalConfig()
+
+	// The two halves of the network are cut off from each other for 3 epochs, neither of them
+	// having enough validators to finalize. Once reconnected, they must agree on the same head
+	// within 2 epochs and finalize again within 4.
+	partitionAtEpoch := uint64(1)
+	partitionEpochs := uint64(3)
+	healEpoch := partitionAtEpoch + partitionEpochs
+	finalityEpochs := uint64(4)
+	numValidators := params.BeaconConfig().MinGenesisActiveValidatorCount
+	partitionConfig := &end2EndConfig{
+		minimalConfig:    true,
+		epochsToRun:      healEpoch + finalityEpochs + 1,
+		numBeaconNodes:   4,
+		numValidators:    numValidators,
+		portOffset:       400,
+		partitionAtEpoch: partitionAtEpoch,
+		partitionEpochs:  partitionEpochs,
+		evaluators: []ev.Evaluator{
+			ev.ValidatorsAreActive(numValidators),
+			ev.PeersConnect(4),
+			ev.PartitionHealed(healEpoch, 2*params.BeaconConfig().SlotsPerEpoch),
+			ev.FinalityResumes(healEpoch, finalityEpochs),
+		},
+	}
+	runEndToEndTest(t, partitionConfig)
+}
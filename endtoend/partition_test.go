@@ -0,0 +1,110 @@
+package endtoend
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestPartitionCommands(t *testing.T) {
+	group1 := []*beaconNodeInfo{{index: 0, p2pTCPPort: 13000}}
+	group2 := []*beaconNodeInfo{{index: 1, p2pTCPPort: 13001}, {index: 2, p2pTCPPort: 13002}}
+
+	t.Run("linux", func(t *testing.T) {
+		apply, restore, err := partitionCommands("linux", group1, group2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var applied, removed []string
+		for _, cmd := range apply {
+			applied = append(applied, strings.Join(cmd.args, " "))
+		}
+		for _, cmd := range restore {
+			removed = append(removed, strings.Join(cmd.args, " "))
+		}
+		wantApplied := []string{
+			"iptables -I OUTPUT -o lo -p tcp --sport 13000 --dport 13001 -j DROP",
+			"iptables -I OUTPUT -o lo -p tcp --sport 13001 --dport 13000 -j DROP",
+			"iptables -I OUTPUT -o lo -p tcp --sport 13000 --dport 13002 -j DROP",
+			"iptables -I OUTPUT -o lo -p tcp --sport 13002 --dport 13000 -j DROP",
+		}
+		if !reflect.DeepEqual(applied, wantApplied) {
+			t.Errorf("Expected rules %v, received %v", wantApplied, applied)
+		}
+		wantRemoved := make([]string, len(wantApplied))
+		for i, rule := range wantApplied {
+			wantRemoved[i] = strings.Replace(rule, " -I ", " -D ", 1)
+		}
+		if !reflect.DeepEqual(removed, wantRemoved) {
+			t.Errorf("Expected rules %v to be removed, received %v", wantRemoved, removed)
+		}
+	})
+
+	t.Run("darwin", func(t *testing.T) {
+		apply, restore, err := partitionCommands("darwin", group1, group2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		wantRules := "block drop quick proto tcp from any port 13000 to any port 13001\n" +
+			"block drop quick proto tcp from any port 13001 to any port 13000\n" +
+			"block drop quick proto tcp from any port 13000 to any port 13002\n" +
+			"block drop quick proto tcp from any port 13002 to any port 13000\n"
+		if len(apply) != 2 || apply[0].stdin != wantRules {
+			t.Errorf("Expected pf rules %q, received %+v", wantRules, apply)
+		}
+		wantRestore := []string{"pfctl", "-a", pfAnchor, "-F", "rules"}
+		if len(restore) != 1 || !reflect.DeepEqual(restore[0].args, wantRestore) {
+			t.Errorf("Expected restore command %v, received %+v", wantRestore, restore)
+		}
+	})
+
+	t.Run("unsupported OS", func(t *testing.T) {
+		if _, _, err := partitionCommands("windows", group1, group2); err == nil {
+			t.Error("Expected an error on windows")
+		}
+	})
+
+	t.Run("empty group", func(t *testing.T) {
+		if _, _, err := partitionCommands("linux", group1, nil); err == nil {
+			t.Error("Expected an error when a group is empty")
+		}
+	})
+}
+
+func TestPfTokenRegex(t *testing.T) {
+	output := []byte("No ALTQ support in kernel\nALTQ related functions disabled\npf enabled\nToken : 6715515240573173031\n")
+	match := pfTokenRegex.FindSubmatch(output)
+	if match == nil || string(match[1]) != "6715515240573173031" {
+		t.Errorf("Expected token to be parsed from %q, received %q", output, match)
+	}
+}
+
+func TestEnd2EndConfig_PartitionGroups(t *testing.T) {
+	var nodes []*beaconNodeInfo
+	for i := 0; i < 5; i++ {
+		nodes = append(nodes, &beaconNodeInfo{index: i})
+	}
+	tests := []struct {
+		partitionedNodes []int
+		partitioned      []int
+		others           []int
+	}{
+		{partitioned: []int{0, 1}, others: []int{2, 3, 4}},
+		{partitionedNodes: []int{4}, partitioned: []int{4}, others: []int{0, 1, 2, 3}},
+		{partitionedNodes: []int{3, 0}, partitioned: []int{0, 3}, others: []int{1, 2, 4}},
+	}
+	for _, tt := range tests {
+		config := &end2EndConfig{partitionedNodes: tt.partitionedNodes}
+		partitioned, others := config.partitionGroups(nodes)
+		if !reflect.DeepEqual(nodeIndices(partitioned), tt.partitioned) || !reflect.DeepEqual(nodeIndices(others), tt.others) {
+			t.Errorf(
+				"Expected partitionedNodes %v to split the nodes into %v and %v, received %v and %v",
+				tt.partitionedNodes,
+				tt.partitioned,
+				tt.others,
+				nodeIndices(partitioned),
+				nodeIndices(others),
+			)
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package endtoend
+
+import (
+	"net"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// nodePorts are the ports a beacon node listens on.
+type nodePorts struct {
+	rpc         uint64
+	grpcGateway uint64
+	monitoring  uint64
+	p2pTCP      uint64
+	p2pUDP      uint64
+}
+
+// portAllocator finds free ports by letting the OS pick one for a listener bound to port 0.
+// It never hands out the same port twice, even if the OS reuses a port that was released.
+type portAllocator struct {
+	lock sync.Mutex
+	used map[uint64]bool
+}
+
+// freePorts is shared by all the nodes started by the test binary, so no two nodes share a port.
+var freePorts = &portAllocator{used: make(map[uint64]bool)}
+
+// findFreePort returns a free TCP port that no other node of the test binary was given.
+func findFreePort() (uint64, error) {
+	return freePorts.tcpPort()
+}
+
+// nodePorts allocates all the ports needed by a beacon node.
+func (p *portAllocator) nodePorts() (nodePorts, error) {
+	var ports nodePorts
+	var err error
+	for _, port := range []*uint64{&ports.rpc, &ports.grpcGateway, &ports.monitoring, &ports.p2pTCP} {
+		if *port, err = p.tcpPort(); err != nil {
+			return nodePorts{}, err
+		}
+	}
+	if ports.p2pUDP, err = p.udpPort(); err != nil {
+		return nodePorts{}, err
+	}
+	return ports, nil
+}
+
+// tcpPort returns a free TCP port that was not returned before.
+func (p *portAllocator) tcpPort() (uint64, error) {
+	return p.allocate(func() (uint64, error) {
+		listener, err := net.Listen("tcp", ":0")
+		if err != nil {
+			return 0, err
+		}
+		defer listener.Close()
+		return uint64(listener.Addr().(*net.TCPAddr).Port), nil
+	})
+}
+
+// udpPort returns a free UDP port that was not returned before.
+func (p *portAllocator) udpPort() (uint64, error) {
+	return p.allocate(func() (uint64, error) {
+		conn, err := net.ListenPacket("udp", ":0")
+		if err != nil {
+			return 0, err
+		}
+		defer conn.Close()
+		return uint64(conn.LocalAddr().(*net.UDPAddr).Port), nil
+	})
+}
+
+func (p *portAllocator) allocate(listen func() (uint64, error)) (uint64, error) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	// Retry a few times in case the OS hands back a port that was already allocated.
+	for i := 0; i < 10; i++ {
+		port, err := listen()
+		if err != nil {
+			return 0, errors.Wrap(err, "could not find free port")
+		}
+		if !p.used[port] {
+			p.used[port] = true
+			return port, nil
+		}
+	}
+	return 0, errors.New("could not find a port that was not already allocated")
+}
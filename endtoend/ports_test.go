@@ -0,0 +1,60 @@
+package endtoend
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestFindFreePort_Concurrent(t *testing.T) {
+	const calls = 100
+	ports := make(chan uint64, calls)
+	var wg sync.WaitGroup
+	for i := 0; i < calls; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			port, err := findFreePort()
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			ports <- port
+		}()
+	}
+	wg.Wait()
+	close(ports)
+
+	seen := make(map[uint64]bool)
+	for port := range ports {
+		if port == 0 {
+			t.Error("Received port 0")
+		}
+		if seen[port] {
+			t.Errorf("Port %d was returned more than once", port)
+		}
+		seen[port] = true
+	}
+	if len(seen) != calls {
+		t.Errorf("Expected %d distinct ports, received %d", calls, len(seen))
+	}
+}
+
+func TestPortAllocator_NodePorts(t *testing.T) {
+	allocator := &portAllocator{used: make(map[uint64]bool)}
+	seen := make(map[uint64]int)
+	for index := 0; index < 16; index++ {
+		ports, err := allocator.nodePorts()
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, port := range []uint64{ports.rpc, ports.grpcGateway, ports.monitoring, ports.p2pTCP, ports.p2pUDP} {
+			if port == 0 {
+				t.Errorf("Beacon node %d was given port 0", index)
+			}
+			if other, ok := seen[port]; ok {
+				t.Errorf("Port %d was given to beacon nodes %d and %d", port, other, index)
+			}
+			seen[port] = index
+		}
+	}
+}
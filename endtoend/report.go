@@ -0,0 +1,373 @@
+package endtoend
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"os/exec"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// resultsFileName is the JSON report of the evaluator outcomes written to tmpPath at the end of a run.
+const resultsFileName = "results.json"
+
+// junitFileName is the JUnit XML version of the report, written when end2EndConfig.junitReport is set.
+const junitFileName = "results.xml"
+
+// versionTimeout is how long the beacon-chain binary is given to print its version.
+const versionTimeout = 10 * time.Second
+
+// evaluatorResult is the outcome of an evaluator at an epoch.
+type evaluatorResult struct {
+	Epoch           uint64  `json:"epoch"`
+	Evaluator       string  `json:"evaluator"`
+	Passed          bool    `json:"passed"`
+	DurationSeconds float64 `json:"duration_seconds"`
+	Error           string  `json:"error,omitempty"`
+}
+
+// reportConfig is the part of end2EndConfig written to the report. The eth1 endpoints are left out
+// as they may hold API keys.
+type reportConfig struct {
+	MinimalConfig         bool           `json:"minimal_config"`
+	EpochsToRun           uint64         `json:"epochs_to_run"`
+	NumValidators         uint64         `json:"num_validators"`
+	NumBeaconNodes        uint64         `json:"num_beacon_nodes"`
+	NumValidatorsPerNode  uint64         `json:"num_validators_per_node,omitempty"`
+	EnableSSZCache        bool           `json:"enable_ssz_cache"`
+	PortOffset            uint64         `json:"port_offset"`
+	ContractAddr          string         `json:"contract_addr"`
+	ExternalEth1          bool           `json:"external_eth1"`
+	GenesisStateFile      string         `json:"genesis_state_file,omitempty"`
+	StaticPeers           bool           `json:"static_peers"`
+	RestartNodeAtEpoch    uint64         `json:"restart_node_at_epoch,omitempty"`
+	KillNodeAtEpoch       uint64         `json:"kill_node_at_epoch,omitempty"`
+	NodesToKill           uint64         `json:"nodes_to_kill,omitempty"`
+	DepositsAtEpoch       uint64         `json:"deposits_at_epoch,omitempty"`
+	NumMidRunDeposits     uint64         `json:"num_mid_run_deposits,omitempty"`
+	PartitionAtEpoch      uint64         `json:"partition_at_epoch,omitempty"`
+	PartitionEpochs       uint64         `json:"partition_epochs,omitempty"`
+	PartitionedNodes      []int          `json:"partitioned_nodes,omitempty"`
+	TestSlasher           bool           `json:"test_slasher"`
+	DoubleProposalAtEpoch uint64         `json:"double_proposal_at_epoch,omitempty"`
+	ExtraBeaconFlags      []string       `json:"extra_beacon_flags,omitempty"`
+	SlotDurationSeconds   uint64         `json:"slot_duration_seconds,omitempty"`
+	UseInteropGenesis     bool           `json:"use_interop_genesis"`
+	BeaconNodeImage       string         `json:"beacon_node_image,omitempty"`
+	MaxNodeRSSMB          uint64         `json:"max_node_rss_mb,omitempty"`
+	Verbosity             string         `json:"verbosity,omitempty"`
+	PerNodeVerbosity      map[int]string `json:"per_node_verbosity,omitempty"`
+	Archive               bool           `json:"archive"`
+	NodeLatencyMs         uint64         `json:"node_latency_ms,omitempty"`
+	NodeLatencyJitterMs   uint64         `json:"node_latency_jitter_ms,omitempty"`
+	TLSRPC                bool           `json:"tls_rpc"`
+	Topology              string         `json:"topology"`
+	LateNodeAtEpoch       uint64         `json:"late_node_at_epoch,omitempty"`
+	ContinueOnFailure     bool           `json:"continue_on_failure"`
+}
+
+// runReport is the report of a run, which CI can collect to follow the evaluators over time.
+type runReport struct {
+	Suite              string            `json:"suite"`
+	Started            time.Time         `json:"started"`
+	Finished           time.Time         `json:"finished"`
+	Passed             bool              `json:"passed"`
+	BeaconChainVersion string            `json:"beacon_chain_version,omitempty"`
+	Config             reportConfig      `json:"config"`
+	StartupResults     []evaluatorResult `json:"startup_results,omitempty"`
+	Results            []evaluatorResult `json:"results"`
+	NodeResources      []nodeResources   `json:"node_resources,omitempty"`
+}
+
+// nodeResources are the resource usage samples of a beacon node, one per epoch.
+type nodeResources struct {
+	Node    int              `json:"node"`
+	Samples []resourceSample `json:"samples"`
+}
+
+// resultsCollector records the outcome of every evaluator run during the E2E.
+type resultsCollector struct {
+	lock    sync.Mutex
+	config  *end2EndConfig
+	report  runReport
+	results []evaluatorResult
+	// startupResults are the outcomes of the evaluators run once after startup.
+	startupResults []evaluatorResult
+	// nodes are the beacon nodes whose resource usage is reported.
+	nodes []*beaconNodeInfo
+}
+
+// newResultsCollector returns a collector for the suite run with the given config, which is read
+// when the report is written so it includes the fields set while the run starts.
+func newResultsCollector(suite string, config *end2EndConfig, beaconChainVersion string) *resultsCollector {
+	return &resultsCollector{
+		config: config,
+		report: runReport{
+			Suite:              suite,
+			Started:            time.Now(),
+			BeaconChainVersion: beaconChainVersion,
+		},
+	}
+}
+
+// record adds the outcome of the evaluator at the epoch, a nil error meaning it passed. Nothing is
+// recorded by a nil collector.
+func (c *resultsCollector) record(epoch uint64, evaluator string, duration time.Duration, err error) {
+	if c == nil {
+		return
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.results = append(c.results, newEvaluatorResult(epoch, evaluator, duration, err))
+}
+
+// recordStartup adds the outcome of an evaluator run once after startup, a nil error meaning it
+// passed. Nothing is recorded by a nil collector.
+func (c *resultsCollector) recordStartup(evaluator string, duration time.Duration, err error) {
+	if c == nil {
+		return
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.startupResults = append(c.startupResults, newEvaluatorResult(0, evaluator, duration, err))
+}
+
+func newEvaluatorResult(epoch uint64, evaluator string, duration time.Duration, err error) evaluatorResult {
+	result := evaluatorResult{
+		Epoch:           epoch,
+		Evaluator:       evaluator,
+		Passed:          err == nil,
+		DurationSeconds: duration.Seconds(),
+	}
+	if err != nil {
+		result.Error = err.Error()
+	}
+	return result
+}
+
+// failureSummary lists the failures of the evaluators run at every epoch, grouped by evaluator in
+// the order they first failed, with the error of each epoch. It's empty when none failed.
+func (c *resultsCollector) failureSummary() string {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	var evaluators []string
+	failures := make(map[string][]evaluatorResult)
+	for _, result := range c.results {
+		if result.Passed {
+			continue
+		}
+		// The names are formatted with the epoch the evaluator ran at, it's grouped by its unformatted name.
+		evaluator := result.Evaluator
+		if epoch := strconv.FormatUint(result.Epoch, 10); strings.HasSuffix(evaluator, epoch) {
+			evaluator = strings.TrimSuffix(evaluator, epoch) + "%d"
+		}
+		if _, ok := failures[evaluator]; !ok {
+			evaluators = append(evaluators, evaluator)
+		}
+		failures[evaluator] = append(failures[evaluator], result)
+	}
+	var summary strings.Builder
+	for _, evaluator := range evaluators {
+		epochs := make([]string, len(failures[evaluator]))
+		for i, result := range failures[evaluator] {
+			epochs[i] = strconv.FormatUint(result.Epoch, 10)
+		}
+		fmt.Fprintf(&summary, "%s failed at epochs %s\n", evaluator, strings.Join(epochs, ", "))
+		for _, result := range failures[evaluator] {
+			fmt.Fprintf(&summary, "  epoch %d: %s\n", result.Epoch, result.Error)
+		}
+	}
+	return summary.String()
+}
+
+// reportFailures fails the test with the summary of the evaluator failures, which are otherwise
+// spread over the subtests of the epochs the run went on with. See end2EndConfig.continueOnFailure.
+func reportFailures(t testing.TB, results *resultsCollector) {
+	if summary := results.failureSummary(); summary != "" {
+		t.Errorf("Evaluators failed during the run:\n%s", summary)
+	}
+}
+
+// addNodes reports the resource usage of the beacon nodes, as sampled until the report is written.
+func (c *resultsCollector) addNodes(nodes []*beaconNodeInfo) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.nodes = append(c.nodes, nodes...)
+}
+
+// write writes the JSON report to dir, along with the JUnit XML one when junit is set.
+func (c *resultsCollector) write(dir string, passed bool, junit bool) error {
+	c.lock.Lock()
+	report := c.report
+	report.Results = append([]evaluatorResult{}, c.results...)
+	report.StartupResults = append([]evaluatorResult(nil), c.startupResults...)
+	nodes := c.nodes
+	c.lock.Unlock()
+	for _, node := range nodes {
+		if samples := node.resourceUsage(); len(samples) > 0 {
+			report.NodeResources = append(report.NodeResources, nodeResources{Node: node.index, Samples: samples})
+		}
+	}
+	report.Finished = time.Now()
+	report.Passed = passed
+	report.Config = newReportConfig(c.config)
+
+	encoded, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(path.Join(dir, resultsFileName), encoded, 0644); err != nil {
+		return errors.Wrap(err, "could not write JSON report")
+	}
+	if !junit {
+		return nil
+	}
+	encoded, err = xml.MarshalIndent(report.junit(), "", "  ")
+	if err != nil {
+		return err
+	}
+	encoded = append([]byte(xml.Header), encoded...)
+	if err := ioutil.WriteFile(path.Join(dir, junitFileName), encoded, 0644); err != nil {
+		return errors.Wrap(err, "could not write JUnit report")
+	}
+	return nil
+}
+
+func newReportConfig(c *end2EndConfig) reportConfig {
+	return reportConfig{
+		MinimalConfig:         c.minimalConfig,
+		EpochsToRun:           c.epochsToRun,
+		NumValidators:         c.numValidators,
+		NumBeaconNodes:        c.numBeaconNodes,
+		NumValidatorsPerNode:  c.numValidatorsPerNode,
+		EnableSSZCache:        c.enableSSZCache,
+		PortOffset:            c.portOffset,
+		ContractAddr:          c.contractAddr.Hex(),
+		ExternalEth1:          c.eth1Endpoint != "",
+		GenesisStateFile:      c.genesisStateFile,
+		StaticPeers:           c.staticPeers,
+		RestartNodeAtEpoch:    c.restartNodeAtEpoch,
+		KillNodeAtEpoch:       c.killNodeAtEpoch,
+		NodesToKill:           c.nodesToKill,
+		DepositsAtEpoch:       c.depositsAtEpoch,
+		NumMidRunDeposits:     c.numMidRunDeposits,
+		PartitionAtEpoch:      c.partitionAtEpoch,
+		PartitionEpochs:       c.partitionEpochs,
+		PartitionedNodes:      c.partitionedNodes,
+		TestSlasher:           c.testSlasher,
+		DoubleProposalAtEpoch: c.doubleProposalAtEpoch,
+		ExtraBeaconFlags:      c.extraBeaconFlags,
+		SlotDurationSeconds:   c.slotDurationSeconds,
+		UseInteropGenesis:     c.useInteropGenesis,
+		BeaconNodeImage:       c.beaconNodeImage,
+		MaxNodeRSSMB:          c.maxNodeRSSMB,
+		Verbosity:             c.verbosity,
+		PerNodeVerbosity:      c.perNodeVerbosity,
+		Archive:               c.archive,
+		NodeLatencyMs:         c.nodeLatencyMs,
+		NodeLatencyJitterMs:   c.nodeLatencyJitterMs,
+		TLSRPC:                c.tlsRPC,
+		Topology:              c.topology.String(),
+		LateNodeAtEpoch:       c.lateNodeAtEpoch,
+		ContinueOnFailure:     c.continueOnFailure,
+	}
+}
+
+type junitTestSuites struct {
+	XMLName xml.Name         `xml:"testsuites"`
+	Suites  []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	Time      float64         `xml:"time,attr"`
+	Timestamp string          `xml:"timestamp,attr"`
+	TestCases []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Time      float64       `xml:"time,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// junit returns the report as a JUnit test suite, with a test case per evaluator run. The
+// evaluators run once after startup make a suite of their own.
+func (r *runReport) junit() *junitTestSuites {
+	suites := &junitTestSuites{}
+	if len(r.StartupResults) > 0 {
+		startup := r.junitSuite(r.Suite+"/startup", r.StartupResults)
+		for _, testCase := range startup.TestCases {
+			startup.Time += testCase.Time
+		}
+		suites.Suites = append(suites.Suites, startup)
+	}
+	suite := r.junitSuite(r.Suite, r.Results)
+	suite.Time = r.Finished.Sub(r.Started).Seconds()
+	suites.Suites = append(suites.Suites, suite)
+	return suites
+}
+
+func (r *runReport) junitSuite(name string, results []evaluatorResult) junitTestSuite {
+	suite := junitTestSuite{
+		Name:      name,
+		Tests:     len(results),
+		Timestamp: r.Started.UTC().Format(time.RFC3339),
+	}
+	for _, result := range results {
+		testCase := junitTestCase{
+			Name:      result.Evaluator,
+			ClassName: name,
+			Time:      result.DurationSeconds,
+		}
+		if !result.Passed {
+			suite.Failures++
+			// The message is the first line, multi-line errors such as head tables go in the text.
+			message := strings.SplitN(result.Error, "\n", 2)[0]
+			testCase.Failure = &junitFailure{Message: message, Text: result.Error}
+		}
+		suite.TestCases = append(suite.TestCases, testCase)
+	}
+	return suite
+}
+
+// beaconChainVersion returns the version the beacon-chain binary reports, empty if it can't be run.
+func beaconChainVersion() string {
+	binaryPath, err := findBinary("beacon-chain")
+	if err != nil {
+		return ""
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), versionTimeout)
+	defer cancel()
+	output, err := exec.CommandContext(ctx, binaryPath, "--version").Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(output))
+}
+
+// writeResults writes the report of the run to tmpPath, it's meant to be deferred by the suite.
+func writeResults(t testing.TB, results *resultsCollector, config *end2EndConfig) {
+	if err := results.write(config.tmpPath, !t.Failed(), config.junitReport); err != nil {
+		t.Errorf("Could not write evaluator results: %v", err)
+		return
+	}
+	t.Logf("Evaluator results written to %s", path.Join(config.tmpPath, resultsFileName))
+}
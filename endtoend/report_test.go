@@ -0,0 +1,211 @@
+package endtoend
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func TestResultsCollector_Write(t *testing.T) {
+	dir, err := ioutil.TempDir("", "results")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	config := &end2EndConfig{
+		epochsToRun:    6,
+		numValidators:  64,
+		numBeaconNodes: 2,
+		eth1Endpoint:   "https://goerli.infura.io/v3/secret",
+	}
+	results := newResultsCollector("TestEndToEnd_Minimal", config, "beacon-chain version Prysm/v0.3.0")
+	// Fields set once the run started are part of the report.
+	config.contractAddr = common.HexToAddress("0x1234")
+	results.record(1, "finalized_epochs_epoch_1", 2*time.Second, nil)
+	results.record(2, "finalized_epochs_epoch_2", time.Second, errors.New("expected finalized epoch to be 1\nreceived: 0"))
+
+	if err := results.write(dir, false /*passed*/, true /*junit*/); err != nil {
+		t.Fatal(err)
+	}
+
+	encoded, err := ioutil.ReadFile(path.Join(dir, resultsFileName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(encoded, []byte("secret")) {
+		t.Error("Expected the eth1 endpoint to be left out of the report")
+	}
+	var report runReport
+	if err := json.Unmarshal(encoded, &report); err != nil {
+		t.Fatal(err)
+	}
+	if report.Suite != "TestEndToEnd_Minimal" || report.Passed || report.BeaconChainVersion != "beacon-chain version Prysm/v0.3.0" {
+		t.Errorf("Unexpected report %+v", report)
+	}
+	if report.Config.NumValidators != 64 || report.Config.ContractAddr != config.contractAddr.Hex() || !report.Config.ExternalEth1 {
+		t.Errorf("Unexpected config in report %+v", report.Config)
+	}
+	if len(report.Results) != 2 {
+		t.Fatalf("Expected 2 results, received %+v", report.Results)
+	}
+	if !report.Results[0].Passed || report.Results[0].DurationSeconds != 2 || report.Results[0].Error != "" {
+		t.Errorf("Unexpected passing result %+v", report.Results[0])
+	}
+	if report.Results[1].Passed || report.Results[1].Epoch != 2 || report.Results[1].Error != "expected finalized epoch to be 1\nreceived: 0" {
+		t.Errorf("Unexpected failing result %+v", report.Results[1])
+	}
+
+	encoded, err = ioutil.ReadFile(path.Join(dir, junitFileName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var suites junitTestSuites
+	if err := xml.Unmarshal(encoded, &suites); err != nil {
+		t.Fatal(err)
+	}
+	if len(suites.Suites) != 1 || suites.Suites[0].Tests != 2 || suites.Suites[0].Failures != 1 {
+		t.Fatalf("Unexpected JUnit report %+v", suites)
+	}
+	failure := suites.Suites[0].TestCases[1].Failure
+	if failure == nil || failure.Message != "expected finalized epoch to be 1" {
+		t.Errorf("Expected the failure message to be the first line of the error, received %+v", failure)
+	}
+}
+
+func TestResultsCollector_StartupResults(t *testing.T) {
+	dir, err := ioutil.TempDir("", "results")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	results := newResultsCollector("TestEndToEnd_Minimal", &end2EndConfig{}, "")
+	results.recordStartup("genesis_agreement", time.Second, nil)
+	results.recordStartup("chain_config_loaded", 2*time.Second, errors.New("expected committees for 8 slots per epoch, received 32"))
+	results.record(1, "finalized_epochs_epoch_1", time.Second, nil)
+
+	if err := results.write(dir, false /*passed*/, true /*junit*/); err != nil {
+		t.Fatal(err)
+	}
+
+	encoded, err := ioutil.ReadFile(path.Join(dir, resultsFileName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var report runReport
+	if err := json.Unmarshal(encoded, &report); err != nil {
+		t.Fatal(err)
+	}
+	if len(report.StartupResults) != 2 || !report.StartupResults[0].Passed || report.StartupResults[1].Passed {
+		t.Errorf("Unexpected startup results %+v", report.StartupResults)
+	}
+	if len(report.Results) != 1 || report.Results[0].Evaluator != "finalized_epochs_epoch_1" {
+		t.Errorf("Expected the startup evaluators apart from the epoch results, received %+v", report.Results)
+	}
+
+	encoded, err = ioutil.ReadFile(path.Join(dir, junitFileName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var suites junitTestSuites
+	if err := xml.Unmarshal(encoded, &suites); err != nil {
+		t.Fatal(err)
+	}
+	if len(suites.Suites) != 2 {
+		t.Fatalf("Expected a startup suite and an epoch suite, received %+v", suites)
+	}
+	startup := suites.Suites[0]
+	if startup.Name != "TestEndToEnd_Minimal/startup" || startup.Tests != 2 || startup.Failures != 1 || startup.Time != 3 {
+		t.Errorf("Unexpected startup suite %+v", startup)
+	}
+	if suites.Suites[1].Name != "TestEndToEnd_Minimal" || suites.Suites[1].Tests != 1 {
+		t.Errorf("Unexpected epoch suite %+v", suites.Suites[1])
+	}
+}
+
+func TestResultsCollector_FailureSummary(t *testing.T) {
+	results := newResultsCollector("TestEndToEnd_Minimal", &end2EndConfig{}, "")
+	if summary := results.failureSummary(); summary != "" {
+		t.Errorf("Expected no summary without failures, received %q", summary)
+	}
+	results.recordStartup("genesis_agreement", time.Second, errors.New("genesis time differs"))
+	results.record(2, "finalizes_at_epoch_2", time.Second, errors.New("node 0 is lagging 2 epochs behind"))
+	results.record(2, "peers_connect_epoch_2", time.Second, nil)
+	results.record(3, "participation_above_threshold_epoch_3", time.Second, errors.New("participation of 0.50 below 0.95"))
+	results.record(3, "finalizes_at_epoch_3", time.Second, errors.New("node 0 is lagging 3 epochs behind"))
+	results.record(4, "no_severe_logs", time.Second, errors.New("beacon node 1 logged 2 errors"))
+
+	expected := strings.Join([]string{
+		"finalizes_at_epoch_%d failed at epochs 2, 3",
+		"  epoch 2: node 0 is lagging 2 epochs behind",
+		"  epoch 3: node 0 is lagging 3 epochs behind",
+		"participation_above_threshold_epoch_%d failed at epochs 3",
+		"  epoch 3: participation of 0.50 below 0.95",
+		"no_severe_logs failed at epochs 4",
+		"  epoch 4: beacon node 1 logged 2 errors",
+		"",
+	}, "\n")
+	if summary := results.failureSummary(); summary != expected {
+		t.Errorf("Expected summary:\n%s\nreceived:\n%s", expected, summary)
+	}
+}
+
+func TestResultsCollector_WriteWithoutJUnit(t *testing.T) {
+	dir, err := ioutil.TempDir("", "results")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	results := newResultsCollector("TestEndToEnd_Minimal", &end2EndConfig{}, "")
+	if err := results.write(dir, true /*passed*/, false /*junit*/); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(path.Join(dir, resultsFileName)); err != nil {
+		t.Error(err)
+	}
+	if _, err := os.Stat(path.Join(dir, junitFileName)); !os.IsNotExist(err) {
+		t.Errorf("Expected no JUnit report, received %v", err)
+	}
+}
+
+func TestResultsCollector_ResultSchema(t *testing.T) {
+	dir, err := ioutil.TempDir("", "results")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	results := newResultsCollector("TestEndToEnd_Minimal", &end2EndConfig{}, "")
+	results.record(1, "peers_connect_epoch_1", 1500*time.Millisecond, nil)
+	results.record(3, "finalizes_at_epoch_3", time.Second, errors.New("node 0 is lagging 2 epochs behind"))
+	if err := results.write(dir, false /*passed*/, false /*junit*/); err != nil {
+		t.Fatal(err)
+	}
+
+	// CI reads the results by field name, so the names are checked on the raw JSON.
+	encoded, err := ioutil.ReadFile(path.Join(dir, resultsFileName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var report struct {
+		Results []map[string]interface{} `json:"results"`
+	}
+	if err := json.Unmarshal(encoded, &report); err != nil {
+		t.Fatal(err)
+	}
+	want := []map[string]interface{}{
+		{"epoch": 1.0, "evaluator": "peers_connect_epoch_1", "passed": true, "duration_seconds": 1.5},
+		{"epoch": 3.0, "evaluator": "finalizes_at_epoch_3", "passed": false, "duration_seconds": 1.0, "error": "node 0 is lagging 2 epochs behind"},
+	}
+	if !reflect.DeepEqual(report.Results, want) {
+		t.Errorf("Expected results %v, received %v", want, report.Results)
+	}
+}
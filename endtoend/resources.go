@@ -0,0 +1,141 @@
+package endtoend
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	ev "github.com/prysmaticlabs/prysm/endtoend/evaluators"
+)
+
+// procRoot is where the proc filesystem is mounted, it's replaced in tests.
+var procRoot = "/proc"
+
+// clockTicksPerSecond is the unit of the CPU times in /proc/<pid>/stat, USER_HZ is 100 on every
+// architecture Linux supports.
+const clockTicksPerSecond = 100
+
+// resourceSample is the resource usage of a beacon node process at an epoch.
+type resourceSample struct {
+	Epoch      uint64  `json:"epoch"`
+	RSSBytes   uint64  `json:"rss_bytes"`
+	CPUSeconds float64 `json:"cpu_seconds"`
+}
+
+// sampleProcess reads the resident memory and the CPU time used so far by the process from
+// /proc/<pid>/stat.
+func sampleProcess(pid int) (resourceSample, error) {
+	content, err := ioutil.ReadFile(path.Join(procRoot, strconv.Itoa(pid), "stat"))
+	if err != nil {
+		return resourceSample{}, err
+	}
+	// The command name is in parentheses and may hold spaces, fields are counted after it.
+	stat := string(content)
+	end := strings.LastIndex(stat, ")")
+	if end < 0 {
+		return resourceSample{}, fmt.Errorf("unexpected stat of process %d: %q", pid, stat)
+	}
+	fields := strings.Fields(stat[end+1:])
+	// utime, stime and rss are the fields 14, 15 and 24, the state being field 3.
+	if len(fields) < 22 {
+		return resourceSample{}, fmt.Errorf("unexpected stat of process %d: %q", pid, stat)
+	}
+	var values [3]uint64
+	for i, field := range []int{11, 12, 21} {
+		values[i], err = strconv.ParseUint(fields[field], 10, 64)
+		if err != nil {
+			return resourceSample{}, errors.Wrapf(err, "unexpected stat of process %d", pid)
+		}
+	}
+	return resourceSample{
+		RSSBytes:   values[2] * uint64(os.Getpagesize()),
+		CPUSeconds: float64(values[0]+values[1]) / clockTicksPerSecond,
+	}, nil
+}
+
+// monitorResources samples the resource usage of every beacon node at each epoch, at the given
+// offset in the epoch as for the evaluations, and keeps the samples on the nodes. Nodes that can't
+// be sampled, e.g. killed nodes or nodes running in containers, are skipped. The returned function
+// stops the monitoring.
+func monitorResources(nodes []*beaconNodeInfo, clock *epochClock, offset time.Duration) func() {
+	ticker := clock.ticker(offset)
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		for {
+			select {
+			case epoch := <-ticker.C():
+				for _, node := range nodes {
+					node.sampleResources(epoch)
+				}
+			case <-done:
+				ticker.Done()
+				return
+			}
+		}
+	}()
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			close(done)
+			<-stopped
+		})
+	}
+}
+
+// sampleResources records the resource usage of the node process at the epoch.
+func (b *beaconNodeInfo) sampleResources(epoch uint64) {
+	b.processLock.Lock()
+	defer b.processLock.Unlock()
+	if b.processID == 0 {
+		return
+	}
+	sample, err := sampleProcess(b.processID)
+	if err != nil {
+		return
+	}
+	sample.Epoch = epoch
+	b.resourceSamples = append(b.resourceSamples, sample)
+}
+
+// resourceUsage returns the resource usage samples of the node so far.
+func (b *beaconNodeInfo) resourceUsage() []resourceSample {
+	b.processLock.Lock()
+	defer b.processLock.Unlock()
+	return append([]resourceSample{}, b.resourceSamples...)
+}
+
+// NodeMemoryEvaluator returns an evaluator that fails when the resident memory of a beacon node
+// exceeds maxRSSMB, as last sampled by the resource monitor.
+func NodeMemoryEvaluator(nodes []*beaconNodeInfo, maxRSSMB uint64) ev.Evaluator {
+	return ev.Evaluator{
+		Name:   "node_memory_epoch_%d",
+		Policy: ev.AllEpochs,
+		Evaluation: func(_ *ev.NodeConns) error {
+			for _, node := range nodes {
+				samples := node.resourceUsage()
+				if len(samples) == 0 {
+					continue
+				}
+				last := samples[len(samples)-1]
+				if rssMB := last.RSSBytes / (1024 * 1024); rssMB > maxRSSMB {
+					return fmt.Errorf(
+						"beacon node %d used %d MB of memory at epoch %d, more than the %d MB allowed",
+						node.index,
+						rssMB,
+						last.Epoch,
+						maxRSSMB,
+					)
+				}
+			}
+			return nil
+		},
+	}
+}
@@ -0,0 +1,139 @@
+package endtoend
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSampleProcess(t *testing.T) {
+	dir, err := ioutil.TempDir("", "proc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	previous := procRoot
+	procRoot = dir
+	defer func() {
+		procRoot = previous
+	}()
+	if err := os.MkdirAll(path.Join(dir, "1234"), os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	// The command name holds a space and a parenthesis, utime is 250 ticks, stime 150 and rss 1000 pages.
+	stat := "1234 (beacon chain) S 1 1234 1234 0 -1 4194560 5000 0 0 0 250 150 0 0 20 0 30 0 100 2000000000 1000 18446744073709551615\n"
+	if err := ioutil.WriteFile(path.Join(dir, "1234", "stat"), []byte(stat), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	sample, err := sampleProcess(1234)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := resourceSample{RSSBytes: 1000 * uint64(os.Getpagesize()), CPUSeconds: 4}
+	if sample != expected {
+		t.Errorf("Expected sample %+v, received %+v", expected, sample)
+	}
+
+	if _, err := sampleProcess(5678); !os.IsNotExist(err) {
+		t.Errorf("Expected missing process error, received %v", err)
+	}
+}
+
+func TestSampleProcess_Self(t *testing.T) {
+	if _, err := os.Stat(path.Join(procRoot, "self")); err != nil {
+		t.Skip("No proc filesystem to read the process stat from")
+	}
+	sample, err := sampleProcess(os.Getpid())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sample.RSSBytes == 0 {
+		t.Error("Expected the test process to use memory")
+	}
+}
+
+func TestBeaconNodeInfo_SampleResources(t *testing.T) {
+	if _, err := os.Stat(path.Join(procRoot, "self")); err != nil {
+		t.Skip("No proc filesystem to read the process stat from")
+	}
+	node := &beaconNodeInfo{processID: os.Getpid()}
+	node.sampleResources(3)
+	// Nodes running in containers have no process ID and are skipped.
+	(&beaconNodeInfo{}).sampleResources(3)
+
+	samples := node.resourceUsage()
+	if len(samples) != 1 || samples[0].Epoch != 3 || samples[0].RSSBytes == 0 {
+		t.Errorf("Unexpected samples %+v", samples)
+	}
+}
+
+func TestMonitorResources(t *testing.T) {
+	if _, err := os.Stat(path.Join(procRoot, "self")); err != nil {
+		t.Skip("No proc filesystem to read the process stat from")
+	}
+	node := &beaconNodeInfo{processID: os.Getpid()}
+	stop := monitorResources([]*beaconNodeInfo{node}, &epochClock{genesisTime: time.Now(), epochSeconds: 1}, 0)
+	defer stop()
+
+	deadline := time.Now().Add(3 * time.Second)
+	for len(node.resourceUsage()) < 2 && time.Now().Before(deadline) {
+		time.Sleep(100 * time.Millisecond)
+	}
+	stop()
+	samples := node.resourceUsage()
+	if len(samples) < 2 || samples[1].Epoch != samples[0].Epoch+1 {
+		t.Errorf("Expected a sample at every epoch, received %+v", samples)
+	}
+}
+
+func TestNodeMemoryEvaluator(t *testing.T) {
+	nodes := []*beaconNodeInfo{
+		{index: 0, resourceSamples: []resourceSample{{Epoch: 1, RSSBytes: 600 << 20}, {Epoch: 2, RSSBytes: 300 << 20}}},
+		{index: 1},
+		{index: 2, resourceSamples: []resourceSample{{Epoch: 2, RSSBytes: 400 << 20}}},
+	}
+	if err := NodeMemoryEvaluator(nodes, 500).Evaluation(nil); err != nil {
+		t.Errorf("Expected the last samples to be under the ceiling: %v", err)
+	}
+	err := NodeMemoryEvaluator(nodes, 350).Evaluation(nil)
+	expected := "beacon node 2 used 400 MB of memory at epoch 2, more than the 350 MB allowed"
+	if err == nil || err.Error() != expected {
+		t.Errorf("Expected error %q, received %v", expected, err)
+	}
+}
+
+func TestResultsCollector_NodeResources(t *testing.T) {
+	dir, err := ioutil.TempDir("", "results")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	samples := []resourceSample{{Epoch: 1, RSSBytes: 300 << 20, CPUSeconds: 12.5}}
+	results := newResultsCollector("TestEndToEnd_Minimal", &end2EndConfig{}, "")
+	results.addNodes([]*beaconNodeInfo{{index: 0, resourceSamples: samples}, {index: 1}})
+
+	if err := results.write(dir, true /*passed*/, false /*junit*/); err != nil {
+		t.Fatal(err)
+	}
+	encoded, err := ioutil.ReadFile(path.Join(dir, resultsFileName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(encoded), `"rss_bytes": 314572800`) {
+		t.Errorf("Expected the samples in the report, received %s", encoded)
+	}
+	var report runReport
+	if err := json.Unmarshal(encoded, &report); err != nil {
+		t.Fatal(err)
+	}
+	expected := []nodeResources{{Node: 0, Samples: samples}}
+	if !reflect.DeepEqual(report.NodeResources, expected) {
+		t.Errorf("Expected resources %+v, received %+v", expected, report.NodeResources)
+	}
+}
@@ -0,0 +1,129 @@
+package endtoend
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/pkg/errors"
+)
+
+// Logger receives the progress of the harness, *testing.T satisfies it.
+type Logger interface {
+	Logf(format string, args ...interface{})
+}
+
+// NetworkConfig describes the beacon nodes started by a Runner. The eth1 chain holding the deposit
+// contract and the boot node are run by the caller.
+type NetworkConfig struct {
+	// TmpPath is where the beacon nodes write their data, p2p keys and logs.
+	TmpPath        string
+	NumBeaconNodes uint64
+	// MinimalConfig runs the beacon nodes with the minimal config rather than the mainnet one.
+	MinimalConfig bool
+	// PortOffset shifts the eth1 ports the beacon nodes connect to, 8545 for HTTP and 8546 for websockets.
+	PortOffset              uint64
+	ContractAddr            common.Address
+	ContractDeploymentBlock uint64
+	BootNodeENR             string
+	// GenesisStateFile is an SSZ genesis state the beacon nodes start from, instead of computing it
+	// from the deposits on the eth1 chain.
+	GenesisStateFile string
+	// StaticPeers additionally peers every beacon node with all the others through --peer flags.
+	StaticPeers bool
+	// ExtraBeaconFlags are appended to the flags of every beacon node, overriding the computed ones.
+	ExtraBeaconFlags []string
+	// NodeStartupTimeout is how long a beacon node is given to start its p2p server, 72 seconds when zero.
+	NodeStartupTimeout time.Duration
+}
+
+// Runner starts a local network of beacon nodes, so other repositories can run Prysm in their own
+// integration tests. Nodes are referred to by their index, from 0 to NumBeaconNodes-1.
+type Runner struct {
+	config *end2EndConfig
+	logger Logger
+	nodes  []*beaconNodeInfo
+}
+
+// NewRunner returns a runner for the network, which is started by Start.
+func NewRunner(config NetworkConfig, logger Logger) *Runner {
+	return &Runner{
+		config: &end2EndConfig{
+			tmpPath:                 config.TmpPath,
+			numBeaconNodes:          config.NumBeaconNodes,
+			minimalConfig:           config.MinimalConfig,
+			portOffset:              config.PortOffset,
+			contractAddr:            config.ContractAddr,
+			contractDeploymentBlock: config.ContractDeploymentBlock,
+			bootNodeENR:             config.BootNodeENR,
+			genesisStateFile:        config.GenesisStateFile,
+			staticPeers:             config.StaticPeers,
+			extraBeaconFlags:        config.ExtraBeaconFlags,
+			nodeStartupTimeout:      config.NodeStartupTimeout,
+		},
+		logger: logger,
+	}
+}
+
+// Start starts the beacon nodes and waits for their p2p servers to be up. The nodes are killed
+// when the context is cancelled.
+func (r *Runner) Start(ctx context.Context) error {
+	if r.nodes != nil {
+		return errors.New("beacon nodes are already started")
+	}
+	if r.config.numBeaconNodes == 0 {
+		return errors.New("NumBeaconNodes must be at least 1")
+	}
+	if r.config.tmpPath == "" {
+		return errors.New("TmpPath must be set")
+	}
+	nodes, err := launchBeaconNodes(ctx, r.logger, r.config)
+	if err != nil {
+		logNodeStartFailure(r.logger, r.config.tmpPath, err)
+		return err
+	}
+	r.nodes = nodes
+	return nil
+}
+
+// Stop stops all the beacon nodes, see beaconNodeInfo.Stop.
+func (r *Runner) Stop() error {
+	var stopErrs []string
+	for _, node := range r.nodes {
+		if err := node.Stop(beaconNodeShutdownTimeout); err != nil {
+			stopErrs = append(stopErrs, fmt.Sprintf("node %d: %v", node.index, err))
+		}
+	}
+	r.nodes = nil
+	if len(stopErrs) > 0 {
+		return fmt.Errorf("could not stop beacon nodes: %s", strings.Join(stopErrs, "; "))
+	}
+	return nil
+}
+
+// NumBeaconNodes returns how many beacon nodes are running.
+func (r *Runner) NumBeaconNodes() int {
+	return len(r.nodes)
+}
+
+// RPCPort returns the port of the gRPC server of the beacon node.
+func (r *Runner) RPCPort(index int) uint64 {
+	return r.nodes[index].rpcPort
+}
+
+// GatewayPort returns the port of the JSON gateway to the gRPC server of the beacon node.
+func (r *Runner) GatewayPort(index int) uint64 {
+	return r.nodes[index].grpcPort
+}
+
+// DataDir returns the directory the beacon node keeps its database in.
+func (r *Runner) DataDir(index int) string {
+	return r.nodes[index].datadir
+}
+
+// Multiaddr returns the p2p address of the beacon node, which other nodes can peer with.
+func (r *Runner) Multiaddr(index int) string {
+	return r.nodes[index].multiAddr
+}
@@ -0,0 +1,101 @@
+package endtoend
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func TestNewRunner(t *testing.T) {
+	runner := NewRunner(NetworkConfig{
+		TmpPath:                 "/tmp/network",
+		NumBeaconNodes:          3,
+		MinimalConfig:           true,
+		PortOffset:              200,
+		ContractAddr:            common.HexToAddress("0x4689a3C63CE249355C8a573B5974db21D2d1b8Ef"),
+		ContractDeploymentBlock: 12,
+		BootNodeENR:             "enr:-boot",
+		GenesisStateFile:        "/tmp/genesis.ssz",
+		StaticPeers:             true,
+		ExtraBeaconFlags:        []string{"--enable-ssz-cache"},
+		NodeStartupTimeout:      time.Minute,
+	}, t)
+
+	expected := &end2EndConfig{
+		tmpPath:                 "/tmp/network",
+		numBeaconNodes:          3,
+		minimalConfig:           true,
+		portOffset:              200,
+		contractAddr:            common.HexToAddress("0x4689a3C63CE249355C8a573B5974db21D2d1b8Ef"),
+		contractDeploymentBlock: 12,
+		bootNodeENR:             "enr:-boot",
+		genesisStateFile:        "/tmp/genesis.ssz",
+		staticPeers:             true,
+		extraBeaconFlags:        []string{"--enable-ssz-cache"},
+		nodeStartupTimeout:      time.Minute,
+	}
+	if !reflect.DeepEqual(runner.config, expected) {
+		t.Errorf("Expected config %+v, received %+v", expected, runner.config)
+	}
+	if runner.config.eth1WSProvider() != "ws://127.0.0.1:8746" {
+		t.Errorf("Expected the eth1 ports to be shifted, received %s", runner.config.eth1WSProvider())
+	}
+}
+
+func TestRunner_StartInvalidConfig(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   NetworkConfig
+		errorMsg string
+	}{
+		{
+			name:     "no beacon nodes",
+			config:   NetworkConfig{TmpPath: "/tmp/network"},
+			errorMsg: "NumBeaconNodes must be at least 1",
+		},
+		{
+			name:     "no tmp path",
+			config:   NetworkConfig{NumBeaconNodes: 2},
+			errorMsg: "TmpPath must be set",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := NewRunner(tt.config, t).Start(context.Background())
+			if err == nil || !strings.Contains(err.Error(), tt.errorMsg) {
+				t.Errorf("Expected error containing %q, received %v", tt.errorMsg, err)
+			}
+		})
+	}
+}
+
+func TestRunner_Accessors(t *testing.T) {
+	runner := NewRunner(NetworkConfig{TmpPath: "/tmp/network", NumBeaconNodes: 2}, t)
+	runner.nodes = []*beaconNodeInfo{
+		{index: 0, rpcPort: 4000, grpcPort: 3200, datadir: "/tmp/network/eth2-beacon-node-0", multiAddr: "/ip4/10.0.0.5/tcp/13000/p2p/16Uiu2A"},
+		{index: 1, rpcPort: 4001, grpcPort: 3201, datadir: "/tmp/network/eth2-beacon-node-1", multiAddr: "/ip4/10.0.0.5/tcp/13001/p2p/16Uiu2B"},
+	}
+
+	if runner.NumBeaconNodes() != 2 {
+		t.Errorf("Expected 2 beacon nodes, received %d", runner.NumBeaconNodes())
+	}
+	if port := runner.RPCPort(1); port != 4001 {
+		t.Errorf("Expected RPC port 4001, received %d", port)
+	}
+	if port := runner.GatewayPort(1); port != 3201 {
+		t.Errorf("Expected gateway port 3201, received %d", port)
+	}
+	if dir := runner.DataDir(0); dir != "/tmp/network/eth2-beacon-node-0" {
+		t.Errorf("Unexpected datadir %s", dir)
+	}
+	if addr := runner.Multiaddr(0); addr != "/ip4/10.0.0.5/tcp/13000/p2p/16Uiu2A" {
+		t.Errorf("Unexpected multiaddr %s", addr)
+	}
+	if err := runner.Start(context.Background()); err == nil || !strings.Contains(err.Error(), "already started") {
+		t.Errorf("Expected starting twice to fail, received %v", err)
+	}
+}
@@ -0,0 +1,156 @@
+package endtoend
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path"
+	"strings"
+	"testing"
+	"time"
+
+	ptypes "github.com/gogo/protobuf/types"
+	"github.com/pkg/errors"
+	ev "github.com/prysmaticlabs/prysm/endtoend/evaluators"
+	slashpb "github.com/prysmaticlabs/prysm/proto/slashing"
+	"google.golang.org/grpc"
+)
+
+// slasherShutdownTimeout is how long the slasher is given to exit after SIGTERM before it's killed.
+const slasherShutdownTimeout = 5 * time.Second
+
+type slasherInfo struct {
+	processID int
+	cmd       *exec.Cmd
+	logFile   *os.File
+	datadir   string
+	rpcPort   uint64
+}
+
+var slasherLogFileName = "slasher.log"
+
+// startSlasher starts a slasher connected to the given beacon node and waits for its RPC server to listen.
+func startSlasher(ctx context.Context, t testing.TB, config *end2EndConfig, beaconNode *beaconNodeInfo) *slasherInfo {
+	binaryPath, err := findBinary("slasher")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	file, err := os.Create(path.Join(config.tmpPath, slasherLogFileName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	rpcPort, err := findFreePort()
+	if err != nil {
+		t.Fatal(err)
+	}
+	monitorPort, err := findFreePort()
+	if err != nil {
+		t.Fatal(err)
+	}
+	slasher := &slasherInfo{
+		logFile: file,
+		datadir: path.Join(config.tmpPath, "slasher-data"),
+		rpcPort: rpcPort,
+	}
+	args := []string{
+		fmt.Sprintf("--datadir=%s", slasher.datadir),
+		fmt.Sprintf("--rpc-port=%d", slasher.rpcPort),
+		fmt.Sprintf("--monitoring-port=%d", monitorPort),
+		fmt.Sprintf("--beacon-rpc-provider=localhost:%d", beaconNode.rpcPort),
+	}
+	if config.tlsRPC {
+		args = append(args, fmt.Sprintf("--beacon-tls-cert=%s", config.tlsCertFile()))
+	}
+	t.Logf("Starting slasher with flags: %s", strings.Join(args, " "))
+	cmd := exec.CommandContext(ctx, binaryPath, args...)
+	cmd.Stdout = file
+	cmd.Stderr = file
+	if err := cmd.Start(); err != nil {
+		t.Fatalf("Failed to start slasher: %v", err)
+	}
+	slasher.processID = cmd.Process.Pid
+	slasher.cmd = cmd
+
+	if err := waitForTextInFile(ctx, file, "Listening on port", config.startupTimeout()); err != nil {
+		_ = slasher.Stop(slasherShutdownTimeout)
+		t.Fatalf("Slasher did not start: %v", err)
+	}
+	return slasher
+}
+
+// Stop stops the slasher, see beaconNodeInfo.Stop.
+func (s *slasherInfo) Stop(timeout time.Duration) error {
+	if err := stopProcess(s.cmd, timeout); err != nil {
+		return err
+	}
+	return s.logFile.Close()
+}
+
+// stopSlasher stops the slasher, it's meant to be deferred by the test that started it.
+func stopSlasher(t testing.TB, slasher *slasherInfo) {
+	if err := slasher.Stop(slasherShutdownTimeout); err != nil {
+		t.Errorf("Could not stop slasher: %v", err)
+	}
+}
+
+// SlasherEvaluator returns an evaluator that ensures the slasher is still connected to its beacon
+// node, having logged no error or fatal line since the previous epoch, and that it reports exactly
+// proposerSlashings proposer slashings once the chain head reaches fromEpoch, and none before. The
+// slasher only checks what is submitted to it, so a normal run expects none. Attester slashings are
+// not counted, SlasherDetectsDoubleVote submitting a double vote in every run with a slasher.
+func SlasherEvaluator(slasher *slasherInfo, proposerSlashings uint64, fromEpoch uint64) ev.Evaluator {
+	tail := &fileTail{file: slasher.logFile}
+	return ev.Evaluator{
+		Name:   "slasher_reports_slashings_epoch_%d",
+		Policy: ev.AllEpochs,
+		Evaluation: func(conns *ev.NodeConns) error {
+			lines, err := tail.readLines()
+			if err != nil {
+				return errors.Wrap(err, "could not read slasher logs")
+			}
+			if severe := severeLines(lines, nil); len(severe) > 0 {
+				return fmt.Errorf("slasher logged %d severe lines:\n%s", len(severe), strings.Join(severe, "\n"))
+			}
+			head, err := conns.BeaconChainClient().GetChainHead(context.Background(), &ptypes.Empty{})
+			if err != nil {
+				return errors.Wrap(err, "failed to get chain head")
+			}
+			conn, err := grpc.Dial(fmt.Sprintf("127.0.0.1:%d", slasher.rpcPort), grpc.WithInsecure())
+			if err != nil {
+				return errors.Wrap(err, "failed to dial slasher")
+			}
+			defer conn.Close()
+			expected := uint64(0)
+			if head.HeadEpoch >= fromEpoch {
+				expected = proposerSlashings
+			}
+			return proposerSlashingsReported(slashpb.NewSlasherClient(conn), expected)
+		},
+	}
+}
+
+// proposerSlashingsReported ensures the slasher reports the expected amount of proposer slashings.
+func proposerSlashingsReported(client slashpb.SlasherClient, expected uint64) error {
+	stream, err := client.SlashableProposals(context.Background(), &ptypes.Empty{})
+	if err != nil {
+		return errors.Wrap(err, "failed to request proposer slashings from slasher")
+	}
+	var reported uint64
+	for {
+		_, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return errors.Wrap(err, "failed to receive proposer slashings from slasher")
+		}
+		reported++
+	}
+	if reported != expected {
+		return fmt.Errorf("expected slasher to report %d proposer slashings, received %d", expected, reported)
+	}
+	return nil
+}
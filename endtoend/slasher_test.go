@@ -0,0 +1,111 @@
+package endtoend
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+
+	ptypes "github.com/gogo/protobuf/types"
+	eth "github.com/prysmaticlabs/ethereumapis/eth/v1alpha1"
+	slashpb "github.com/prysmaticlabs/prysm/proto/slashing"
+	"google.golang.org/grpc"
+)
+
+// proposalsClient is a slasher streaming the given proposer slashings.
+type proposalsClient struct {
+	slashpb.SlasherClient
+	slashings []*eth.ProposerSlashing
+	err       error
+}
+
+func (c *proposalsClient) SlashableProposals(_ context.Context, _ *ptypes.Empty, _ ...grpc.CallOption) (slashpb.Slasher_SlashableProposalsClient, error) {
+	if c.err != nil {
+		return nil, c.err
+	}
+	return &proposalsStream{slashings: c.slashings}, nil
+}
+
+type proposalsStream struct {
+	grpc.ClientStream
+	slashings []*eth.ProposerSlashing
+}
+
+func (s *proposalsStream) Recv() (*eth.ProposerSlashing, error) {
+	if len(s.slashings) == 0 {
+		return nil, io.EOF
+	}
+	slashing := s.slashings[0]
+	s.slashings = s.slashings[1:]
+	return slashing, nil
+}
+
+func TestProposerSlashingsReported(t *testing.T) {
+	tests := []struct {
+		name     string
+		client   *proposalsClient
+		expected uint64
+		errorMsg string
+	}{
+		{
+			name:   "no slashing",
+			client: &proposalsClient{},
+		},
+		{
+			name:     "expected slashing",
+			client:   &proposalsClient{slashings: []*eth.ProposerSlashing{{ProposerIndex: 3}}},
+			expected: 1,
+		},
+		{
+			name:     "unexpected slashing",
+			client:   &proposalsClient{slashings: []*eth.ProposerSlashing{{ProposerIndex: 3}}},
+			errorMsg: "expected slasher to report 0 proposer slashings, received 1",
+		},
+		{
+			name:     "missing slashing",
+			client:   &proposalsClient{},
+			expected: 1,
+			errorMsg: "expected slasher to report 1 proposer slashings, received 0",
+		},
+		{
+			name:     "slasher unreachable",
+			client:   &proposalsClient{err: errors.New("connection refused")},
+			errorMsg: "failed to request proposer slashings from slasher: connection refused",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := proposerSlashingsReported(tt.client, tt.expected)
+			if tt.errorMsg == "" {
+				if err != nil {
+					t.Errorf("Unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.errorMsg) {
+				t.Errorf("Expected error containing %q, received %v", tt.errorMsg, err)
+			}
+		})
+	}
+}
+
+func TestSlasherEvaluator_SevereLogs(t *testing.T) {
+	file, err := ioutil.TempFile("", "slasher")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(file.Name())
+	defer file.Close()
+	if _, err := file.WriteString(`time="2020-01-20 10:00:00" level=error msg="rpc error: code = Unavailable desc = all SubConns are in TransientFailure" prefix=slasher` + "\n"); err != nil {
+		t.Fatal(err)
+	}
+
+	evaluator := SlasherEvaluator(&slasherInfo{logFile: file}, 0, 0)
+	err = evaluator.Evaluation(nil)
+	if err == nil || !strings.Contains(err.Error(), "slasher logged 1 severe lines") {
+		t.Errorf("Expected severe logs error, received %v", err)
+	}
+}
@@ -0,0 +1,38 @@
+package endtoend
+
+import (
+	"testing"
+
+	ev "github.com/prysmaticlabs/prysm/endtoend/evaluators"
+	"github.com/prysmaticlabs/prysm/shared/params"
+	"github.com/prysmaticlabs/prysm/shared/testutil"
+)
+
+// TestSSZCacheConsistency runs a beacon node with the SSZ cache next to one without it, from the
+// same deterministic genesis. Each of them checks the state root of the blocks proposed by the
+// other, so any change of semantics by the cache forks them apart, which NodesAgreeOnHead catches
+// by comparing their head block roots at the same slot.
+func TestSSZCacheConsistency(t *testing.T) {
+	testutil.ResetCache()
+	params.UseMinimalConfig()
+
+	numValidators := params.BeaconConfig().MinGenesisActiveValidatorCount
+	sszCacheConfig := &end2EndConfig{
+		minimalConfig:     true,
+		epochsToRun:       4,
+		numBeaconNodes:    2,
+		numValidators:     numValidators,
+		portOffset:        1100,
+		useInteropGenesis: true,
+		enableSSZCache:    false,
+		perNodeFlags: map[int][]string{
+			0: {"--enable-ssz-cache"},
+		},
+		evaluators: []ev.Evaluator{
+			ev.ValidatorsAreActive(numValidators),
+			ev.NodesAgreeOnHead,
+			ev.FinalizationOccurs(),
+		},
+	}
+	runEndToEndTest(t, sszCacheConfig)
+}
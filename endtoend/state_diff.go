@@ -0,0 +1,85 @@
+package endtoend
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+
+	"github.com/pkg/errors"
+	"github.com/prysmaticlabs/prysm/beacon-chain/db/kv"
+	ev "github.com/prysmaticlabs/prysm/endtoend/evaluators"
+	pb "github.com/prysmaticlabs/prysm/proto/beacon/p2p/v1"
+)
+
+// StateDiffEvaluator returns an evaluator that logs, at every epoch, the changes of the head state
+// of the evaluated node since the previous epoch, as summarized by ev.BeaconStateDiffer. The API
+// doesn't serve full states, so the state is read from a backup of the database of the node, as
+// DBIntegrityEvaluator does. It only fails when the state can't be read. datadirs are the data
+// directories, by node index.
+func StateDiffEvaluator(logger Logger, datadirs []string) ev.Evaluator {
+	var prev *pb.BeaconState
+	return ev.Evaluator{
+		Name:   "state_diff_epoch_%d",
+		Policy: ev.AllEpochs,
+		Evaluation: func(conns *ev.NodeConns) error {
+			index := conns.Evaluated
+			if index >= len(datadirs) {
+				return fmt.Errorf("no datadir for beacon node %d", index)
+			}
+			state, err := nodeHeadState(conns.MonitorPorts[index], datadirs[index])
+			if err != nil {
+				return errors.Wrapf(err, "could not read head state of beacon node %d", index)
+			}
+			if prev == nil {
+				logger.Logf("Head state of beacon node %d at slot %d", index, state.Slot)
+			} else {
+				logger.Logf(
+					"Head state of beacon node %d from slot %d to slot %d: %s",
+					index,
+					prev.Slot,
+					state.Slot,
+					ev.BeaconStateDiffer{}.Diff(prev, state),
+				)
+			}
+			prev = state
+			return nil
+		},
+	}
+}
+
+// nodeHeadState has the beacon node back up its database to datadir and reads the head state from
+// the backup, which is then removed.
+func nodeHeadState(monitorPort uint64, datadir string) (*pb.BeaconState, error) {
+	backupPath, err := backupNodeDB(monitorPort, datadir)
+	if err != nil {
+		return nil, err
+	}
+	// The database is opened from a directory, under its usual file name.
+	dir, err := ioutil.TempDir(datadir, "state-diff")
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = os.RemoveAll(dir)
+	}()
+	if err := os.Rename(backupPath, path.Join(dir, "beaconchain.db")); err != nil {
+		return nil, errors.Wrap(err, "could not move backup")
+	}
+	backup, err := kv.NewKVStore(dir)
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not open backup %s", backupPath)
+	}
+	defer func() {
+		_ = backup.Close()
+	}()
+	state, err := backup.HeadState(context.Background())
+	if err != nil {
+		return nil, errors.Wrap(err, "could not read head state")
+	}
+	if state == nil {
+		return nil, errors.New("no head state in the database")
+	}
+	return state, nil
+}
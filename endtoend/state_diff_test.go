@@ -0,0 +1,99 @@
+package endtoend
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
+	"testing"
+
+	eth "github.com/prysmaticlabs/ethereumapis/eth/v1alpha1"
+	"github.com/prysmaticlabs/prysm/beacon-chain/db/kv"
+	ev "github.com/prysmaticlabs/prysm/endtoend/evaluators"
+	pb "github.com/prysmaticlabs/prysm/proto/beacon/p2p/v1"
+	"google.golang.org/grpc"
+)
+
+// writeHeadStateDB writes a database holding the given head state to dir, returning its content.
+func writeHeadStateDB(t *testing.T, dir string, state *pb.BeaconState) string {
+	beaconDB, err := kv.NewKVStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	root := [32]byte{byte(state.Slot)}
+	if err := beaconDB.SaveState(context.Background(), state, root); err != nil {
+		t.Fatal(err)
+	}
+	if err := beaconDB.SaveHeadBlockRoot(context.Background(), root); err != nil {
+		t.Fatal(err)
+	}
+	if err := beaconDB.Close(); err != nil {
+		t.Fatal(err)
+	}
+	content, err := ioutil.ReadFile(path.Join(dir, "beaconchain.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(content)
+}
+
+func TestStateDiffEvaluator(t *testing.T) {
+	dir, err := ioutil.TempDir("", "state-diff")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	datadir := path.Join(dir, "eth2-beacon-node-0")
+	logger := &recordingLogger{}
+	evaluator := StateDiffEvaluator(logger, []string{datadir})
+
+	states := []*pb.BeaconState{
+		{Slot: 8, Balances: []uint64{32, 32}, Validators: []*eth.Validator{{PublicKey: []byte{0}}, {PublicKey: []byte{1}}}},
+		{Slot: 16, Balances: []uint64{31, 33, 32}, Validators: []*eth.Validator{{PublicKey: []byte{0}}, {PublicKey: []byte{1}}, {PublicKey: []byte{2}}}},
+	}
+	for i, state := range states {
+		content := writeHeadStateDB(t, path.Join(dir, fmt.Sprintf("db-%d", i)), state)
+		port, stop := startBackupServer(t, datadir, content)
+		conns := &ev.NodeConns{Conns: map[int]*grpc.ClientConn{0: nil}, MonitorPorts: map[int]uint64{0: port}}
+		err := evaluator.Evaluation(conns)
+		stop()
+		if err != nil {
+			t.Fatalf("Unexpected error at slot %d: %v", state.Slot, err)
+		}
+	}
+
+	expected := []string{
+		"Head state of beacon node 0 at slot 8",
+		"Head state of beacon node 0 from slot 8 to slot 16: Slot: 8→16, Validators: 2→3, Balances: 2→3 with 2 entries changed",
+	}
+	if strings.Join(logger.lines, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("Expected logs %q, received %q", expected, logger.lines)
+	}
+	files, err := ioutil.ReadDir(datadir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, file := range files {
+		if strings.HasPrefix(file.Name(), "state-diff") {
+			t.Errorf("Expected the opened backup to be removed, found %s", file.Name())
+		}
+	}
+}
+
+func TestStateDiffEvaluator_NoHeadState(t *testing.T) {
+	dir, err := ioutil.TempDir("", "state-diff")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	datadir := path.Join(dir, "eth2-beacon-node-0")
+	port, stop := startBackupServer(t, datadir, "")
+	defer stop()
+	conns := &ev.NodeConns{Conns: map[int]*grpc.ClientConn{0: nil}, MonitorPorts: map[int]uint64{0: port}}
+	err = StateDiffEvaluator(&recordingLogger{}, []string{datadir}).Evaluation(conns)
+	if err == nil || !strings.Contains(err.Error(), "could not read head state of beacon node 0") {
+		t.Errorf("Expected error for a database without head state, received %v", err)
+	}
+}
@@ -0,0 +1,96 @@
+package endtoend
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"os"
+	"path"
+	"time"
+
+	"github.com/pkg/errors"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+)
+
+// tlsDirName is the directory of tmpPath the certificate of the beacon nodes is written to when
+// end2EndConfig.tlsRPC is set.
+const tlsDirName = "tls"
+
+// tlsCertValidity is how long the generated certificate is valid for, longer than any run.
+const tlsCertValidity = 24 * time.Hour
+
+// tlsCertFile is the certificate the beacon nodes serve gRPC with, which the clients trust as their CA.
+func (c *end2EndConfig) tlsCertFile() string {
+	return path.Join(c.tmpPath, tlsDirName, "beacon.crt")
+}
+
+// tlsKeyFile is the private key of the certificate.
+func (c *end2EndConfig) tlsKeyFile() string {
+	return path.Join(c.tmpPath, tlsDirName, "beacon.key")
+}
+
+// generateTLSCertificate writes a self-signed certificate for 127.0.0.1 and localhost to certFile,
+// and its key to keyFile. The certificate is its own CA, so the clients verify the nodes with it.
+func generateTLSCertificate(certFile, keyFile string) error {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return errors.Wrap(err, "could not generate key")
+	}
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return errors.Wrap(err, "could not generate serial number")
+	}
+	now := time.Now()
+	template := &x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{Organization: []string{"Prysm E2E"}},
+		NotBefore:             now.Add(-time.Hour),
+		NotAfter:              now.Add(tlsCertValidity),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+		IPAddresses:           []net.IP{net.ParseIP("127.0.0.1")},
+		DNSNames:              []string{"localhost"},
+	}
+	cert, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return errors.Wrap(err, "could not create certificate")
+	}
+	encodedKey, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return errors.Wrap(err, "could not encode key")
+	}
+	for _, dir := range []string{path.Dir(certFile), path.Dir(keyFile)} {
+		if err := os.MkdirAll(dir, 0700); err != nil {
+			return err
+		}
+	}
+	if err := ioutil.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert}), 0644); err != nil {
+		return errors.Wrap(err, "could not write certificate")
+	}
+	if err := ioutil.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: encodedKey}), 0600); err != nil {
+		return errors.Wrap(err, "could not write key")
+	}
+	return nil
+}
+
+// transportOption returns the dial option securing the connections to the gRPC server of the node
+// with the certificate it serves, or an insecure one when the node serves plaintext.
+func (b *beaconNodeInfo) transportOption() (grpc.DialOption, error) {
+	if b.tlsCert == "" {
+		return grpc.WithInsecure(), nil
+	}
+	creds, err := credentials.NewClientTLSFromFile(b.tlsCert, "")
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not load certificate of beacon node %d", b.index)
+	}
+	return grpc.WithTransportCredentials(creds), nil
+}
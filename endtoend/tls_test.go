@@ -0,0 +1,83 @@
+package endtoend
+
+import (
+	"context"
+	"io/ioutil"
+	"net"
+	"os"
+	"path"
+	"strings"
+	"testing"
+	"time"
+
+	ptypes "github.com/gogo/protobuf/types"
+	eth "github.com/prysmaticlabs/ethereumapis/eth/v1alpha1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+)
+
+func TestGenerateTLSCertificate(t *testing.T) {
+	tmpPath, err := ioutil.TempDir("", "tls")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpPath)
+	config := &end2EndConfig{tmpPath: tmpPath, tlsRPC: true}
+	if err := generateTLSCertificate(config.tlsCertFile(), config.tlsKeyFile()); err != nil {
+		t.Fatal(err)
+	}
+
+	// The node serves the certificate, which the E2E dials it with.
+	creds, err := credentials.NewServerTLSFromFile(config.tlsCertFile(), config.tlsKeyFile())
+	if err != nil {
+		t.Fatal(err)
+	}
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := grpc.NewServer(grpc.Creds(creds))
+	eth.RegisterNodeServer(server, &versionServer{})
+	go func() {
+		if err := server.Serve(listener); err != nil {
+			t.Error(err)
+		}
+	}()
+	defer server.Stop()
+	port := uint64(listener.Addr().(*net.TCPAddr).Port)
+
+	node := &beaconNodeInfo{index: 0, rpcPort: port, tlsCert: config.tlsCertFile()}
+	defer node.Close()
+	conn, err := node.GRPCConn()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	version, err := eth.NewNodeClient(conn).GetVersion(ctx, &ptypes.Empty{})
+	if err != nil {
+		t.Fatalf("Could not request version over TLS: %v", err)
+	}
+	if version.Version != "test" {
+		t.Errorf("Expected version test, received %s", version.Version)
+	}
+
+	plaintext := &beaconNodeInfo{index: 1, rpcPort: port}
+	defer plaintext.Close()
+	conn, err = plaintext.GRPCConn()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel = context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if _, err := eth.NewNodeClient(conn).GetVersion(ctx, &ptypes.Empty{}); err == nil {
+		t.Error("Expected plaintext request to a node using TLS to fail")
+	}
+}
+
+func TestBeaconNodeInfo_GRPCConnMissingCertificate(t *testing.T) {
+	node := &beaconNodeInfo{index: 2, rpcPort: 4000, tlsCert: path.Join(os.TempDir(), "missing", "beacon.crt")}
+	if _, err := node.GRPCConn(); err == nil || !strings.Contains(err.Error(), "could not load certificate of beacon node 2") {
+		t.Errorf("Expected missing certificate error, received %v", err)
+	}
+}
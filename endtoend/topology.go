@@ -0,0 +1,93 @@
+package endtoend
+
+import (
+	"fmt"
+)
+
+// peerTopology is how the Prysm beacon nodes are peered with each other. See end2EndConfig.topology.
+type peerTopology int
+
+const (
+	// FullMeshTopology peers every beacon node with all the others, through the boot node and, with
+	// staticPeers, through --peer flags.
+	FullMeshTopology peerTopology = iota
+	// StarTopology peers every beacon node with node 0 only, which relays all the gossip.
+	StarTopology
+	// ChainTopology peers beacon node N with node N-1 only, so gossip from one end of the chain has
+	// to be relayed by every node to reach the other end.
+	ChainTopology
+)
+
+func (p peerTopology) String() string {
+	switch p {
+	case FullMeshTopology:
+		return "full_mesh"
+	case StarTopology:
+		return "star"
+	case ChainTopology:
+		return "chain"
+	default:
+		return fmt.Sprintf("topology(%d)", int(p))
+	}
+}
+
+// discovery reports whether the nodes find each other through the boot node. Discovery would peer
+// every node with all the others, so the other topologies run without it and rely on --peer flags.
+func (p peerTopology) discovery() bool {
+	return p == FullMeshTopology
+}
+
+// staticPeers returns the indices of the nodes the node at index dials with --peer flags, out of
+// numNodes. Each link is only dialed by one of its ends. A full mesh only has static peers with
+// end2EndConfig.staticPeers.
+func (p peerTopology) staticPeers(index int, numNodes int, fullMesh bool) []int {
+	var peers []int
+	switch p {
+	case FullMeshTopology:
+		for i := 0; i < numNodes && fullMesh; i++ {
+			if i != index {
+				peers = append(peers, i)
+			}
+		}
+	case StarTopology:
+		if index != 0 {
+			peers = append(peers, 0)
+		}
+	case ChainTopology:
+		if index != 0 {
+			peers = append(peers, index-1)
+		}
+	}
+	return peers
+}
+
+// startAfter returns the indices of the nodes the node at index must wait for before it starts,
+// out of numNodes. Static peers are only dialed when a node starts and are never redialed, so a node
+// is started once the peers it dials with a lower index are listening. Peers with a higher index
+// start after it and dial it themselves in a full mesh, which starts the nodes one at a time.
+func (p peerTopology) startAfter(index int, numNodes int, fullMesh bool) []int {
+	var after []int
+	for _, peer := range p.staticPeers(index, numNodes, fullMesh) {
+		if peer < index {
+			after = append(after, peer)
+		}
+	}
+	return after
+}
+
+// expectedPeers returns how many peers the node at index ends up with, out of numNodes, counting
+// the links dialed by the other nodes.
+func (p peerTopology) expectedPeers(index int, numNodes int) uint64 {
+	switch {
+	case numNodes < 2:
+		return 0
+	case p == StarTopology && index != 0:
+		return 1
+	case p == ChainTopology && (index == 0 || index == numNodes-1):
+		return 1
+	case p == ChainTopology:
+		return 2
+	default:
+		return uint64(numNodes - 1)
+	}
+}
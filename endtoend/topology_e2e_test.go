@@ -0,0 +1,35 @@
+package endtoend
+
+import (
+	"testing"
+
+	ev "github.com/prysmaticlabs/prysm/endtoend/evaluators"
+	"github.com/prysmaticlabs/prysm/shared/params"
+	"github.com/prysmaticlabs/prysm/shared/testutil"
+)
+
+func TestEndToEnd_ChainTopology(t *testing.T) {
+	testutil.ResetCache()
+	params.UseMinimalConfig()
+
+	// Each beacon node only peers with its neighbours, so the blocks and attestations of the nodes
+	// at the ends of the chain are relayed by all the others. The peer counts of the topology are
+	// checked before the chain starts.
+	numValidators := params.BeaconConfig().MinGenesisActiveValidatorCount
+	chainConfig := &end2EndConfig{
+		minimalConfig:  true,
+		epochsToRun:    5,
+		numBeaconNodes: 4,
+		numValidators:  numValidators,
+		portOffset:     1000,
+		topology:       ChainTopology,
+		evaluators: []ev.Evaluator{
+			ev.ValidatorsAreActive(numValidators),
+			ev.ValidatorsParticipating,
+			ev.ParticipationAtEpoch(ev.DefaultParticipationThreshold),
+			ev.NodesAgreeOnHead,
+			ev.FinalizationOccurs(),
+		},
+	}
+	runEndToEndTest(t, chainConfig)
+}
@@ -0,0 +1,93 @@
+package endtoend
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestPeerTopology_StaticPeers(t *testing.T) {
+	tests := []struct {
+		topology  peerTopology
+		fullMesh  bool
+		peers     [][]int
+		peerCount []uint64
+	}{
+		{
+			topology:  FullMeshTopology,
+			peers:     [][]int{nil, nil, nil, nil},
+			peerCount: []uint64{3, 3, 3, 3},
+		},
+		{
+			topology:  FullMeshTopology,
+			fullMesh:  true,
+			peers:     [][]int{{1, 2, 3}, {0, 2, 3}, {0, 1, 3}, {0, 1, 2}},
+			peerCount: []uint64{3, 3, 3, 3},
+		},
+		{
+			topology:  StarTopology,
+			peers:     [][]int{nil, {0}, {0}, {0}},
+			peerCount: []uint64{3, 1, 1, 1},
+		},
+		{
+			topology:  ChainTopology,
+			peers:     [][]int{nil, {0}, {1}, {2}},
+			peerCount: []uint64{1, 2, 2, 1},
+		},
+	}
+	for _, tt := range tests {
+		for index := range tt.peers {
+			peers := tt.topology.staticPeers(index, len(tt.peers), tt.fullMesh)
+			if !reflect.DeepEqual(peers, tt.peers[index]) {
+				t.Errorf("Expected node %d of the %s topology to dial %v, received %v", index, tt.topology, tt.peers[index], peers)
+			}
+			if count := tt.topology.expectedPeers(index, len(tt.peers)); count != tt.peerCount[index] {
+				t.Errorf("Expected node %d of the %s topology to have %d peers, received %d", index, tt.topology, tt.peerCount[index], count)
+			}
+		}
+	}
+}
+
+func TestPeerTopology_StartAfter(t *testing.T) {
+	tests := []struct {
+		topology peerTopology
+		fullMesh bool
+		after    [][]int
+	}{
+		{
+			topology: FullMeshTopology,
+			after:    [][]int{nil, nil, nil, nil},
+		},
+		{
+			topology: FullMeshTopology,
+			fullMesh: true,
+			after:    [][]int{nil, {0}, {0, 1}, {0, 1, 2}},
+		},
+		{
+			topology: StarTopology,
+			after:    [][]int{nil, {0}, {0}, {0}},
+		},
+		{
+			topology: ChainTopology,
+			after:    [][]int{nil, {0}, {1}, {2}},
+		},
+	}
+	for _, tt := range tests {
+		for index := range tt.after {
+			after := tt.topology.startAfter(index, len(tt.after), tt.fullMesh)
+			if !reflect.DeepEqual(after, tt.after[index]) {
+				t.Errorf("Expected node %d of the %s topology to start after %v, received %v", index, tt.topology, tt.after[index], after)
+			}
+		}
+	}
+}
+
+func TestPeerTopology_SingleNode(t *testing.T) {
+	for _, topology := range []peerTopology{FullMeshTopology, StarTopology, ChainTopology} {
+		if peers := topology.staticPeers(0, 1, true); len(peers) != 0 {
+			t.Errorf("Expected a single node of the %s topology to dial no peers, received %v", topology, peers)
+		}
+		if count := topology.expectedPeers(0, 1); count != 0 {
+			t.Errorf("Expected a single node of the %s topology to have no peers, received %d", topology, count)
+		}
+	}
+}
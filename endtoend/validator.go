@@ -1,133 +1,173 @@
 package endtoend
 
 import (
-	"bytes"
 	"context"
 	"fmt"
-	"io/ioutil"
-	"math/big"
 	"os"
 	"os/exec"
 	"path"
 	"strings"
 	"testing"
+	"time"
 
-	"github.com/bazelbuild/rules_go/go/tools/bazel"
-	"github.com/ethereum/go-ethereum/accounts/abi/bind"
-	"github.com/ethereum/go-ethereum/accounts/keystore"
-	"github.com/ethereum/go-ethereum/ethclient"
-	"github.com/ethereum/go-ethereum/rpc"
-	contracts "github.com/prysmaticlabs/prysm/contracts/deposit-contract"
+	"github.com/pkg/errors"
+	"github.com/prysmaticlabs/prysm/shared/bls"
 	"github.com/prysmaticlabs/prysm/shared/params"
 	"github.com/prysmaticlabs/prysm/shared/testutil"
 )
 
 type validatorClientInfo struct {
-	processID   int
-	monitorPort uint64
+	index         uint64
+	processID     int
+	cmd           *exec.Cmd
+	datadir       string
+	logFile       *os.File
+	beaconRPCPort uint64
+	monitorPort   uint64
+	startIndex    uint64 // Index of the first interop validator run by the client.
+	numValidators uint64
+	alive         bool
 }
 
-var validatorLogFileName = "vals-%d.log"
+var validatorLogFileName = "validator-%d.log"
 
-// initializeValidators sends the deposits to the eth1 chain and starts the validator clients.
+// initializeValidators starts the validator clients and sends their deposits to the eth1 chain,
+// unless the beacon nodes start from a genesis state file. When the deposits are sent in batches,
+// they are sent in the background and the result is reported on the returned channel, which is
+// nil otherwise.
 func initializeValidators(
-	t *testing.T,
+	ctx context.Context,
+	t testing.TB,
 	config *end2EndConfig,
 	keystorePath string,
-) []*validatorClientInfo {
-	binaryPath, found := bazel.FindBinary("validator", "validator")
-	if !found {
-		t.Fatal("validator binary not found")
+	beaconNodes []*beaconNodeInfo,
+) ([]*validatorClientInfo, <-chan error) {
+	valClients := startValidatorClients(ctx, t, config, beaconNodes)
+	// The validators of a genesis state file are already in the registry.
+	if config.genesisStateFile != "" {
+		return valClients, nil
+	}
+	_, keys, err := testutil.DeterministicDepositsAndKeys(config.numValidators)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.depositBatchSize > 0 {
+		depositErrs := make(chan error, 1)
+		go func() {
+			depositErrs <- sendDepositBatches(ctx, config, keystorePath, keys)
+		}()
+		return valClients, depositErrs
+	}
+	amount := params.BeaconConfig().MaxEffectiveBalance
+	blockNumbers, err := sendDeposits(ctx, config.eth1HTTPProvider(), config.contractAddr, keystorePath, keys, amount)
+	if err != nil {
+		t.Fatal(err)
 	}
+	config.submittedDeposits.record(blockNumbers)
+	return valClients, nil
+}
 
-	tmpPath := config.tmpPath
-	validatorNum := config.numValidators
-	beaconNodeNum := config.numBeaconNodes
-	if validatorNum%beaconNodeNum != 0 {
-		t.Fatal("Validator count is not easily divisible by beacon node count.")
+// sendDepositBatches deposits the keys in batches of config.depositBatchSize, waiting
+// config.depositDelay between batches, so the validators join the chain gradually.
+func sendDepositBatches(ctx context.Context, config *end2EndConfig, keystorePath string, keys []*bls.SecretKey) error {
+	amount := params.BeaconConfig().MaxEffectiveBalance
+	for start := uint64(0); start < uint64(len(keys)); start += config.depositBatchSize {
+		if start > 0 {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(config.depositDelay):
+			}
+		}
+		end := start + config.depositBatchSize
+		if end > uint64(len(keys)) {
+			end = uint64(len(keys))
+		}
+		blockNumbers, err := sendDeposits(ctx, config.eth1HTTPProvider(), config.contractAddr, keystorePath, keys[start:end], amount)
+		if err != nil {
+			return errors.Wrapf(err, "could not send deposits %d to %d", start, end-1)
+		}
+		config.submittedDeposits.record(blockNumbers)
 	}
+	return nil
+}
 
-	valClients := make([]*validatorClientInfo, beaconNodeNum)
-	validatorsPerNode := validatorNum / beaconNodeNum
-	for n := uint64(0); n < beaconNodeNum; n++ {
-		file, err := os.Create(path.Join(tmpPath, fmt.Sprintf(validatorLogFileName, n)))
+// startValidatorClients starts one validator client per beacon node, each running its own
+// range of the interop validators against that beacon node, and waits for them to connect.
+func startValidatorClients(
+	ctx context.Context,
+	t testing.TB,
+	config *end2EndConfig,
+	beaconNodes []*beaconNodeInfo,
+) []*validatorClientInfo {
+	binaryPath, err := findBinary("validator")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	valClients := make([]*validatorClientInfo, len(beaconNodes))
+	for n, beaconNode := range beaconNodes {
+		index := uint64(n)
+		if err := waitForNodeReady(ctx, beaconNode, config.startupTimeout()); err != nil {
+			t.Fatalf("Validator client %d has no beacon node to connect to: %v", index, err)
+		}
+		file, err := os.Create(path.Join(config.tmpPath, fmt.Sprintf(validatorLogFileName, index)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		monitorPort, err := findFreePort()
 		if err != nil {
 			t.Fatal(err)
 		}
+		startIndex, numValidators := config.validatorRange(index)
+		valClient := &validatorClientInfo{
+			index:         index,
+			datadir:       fmt.Sprintf("%s/eth2-val-%d", config.tmpPath, index),
+			logFile:       file,
+			beaconRPCPort: beaconNode.rpcPort,
+			monitorPort:   monitorPort,
+			startIndex:    startIndex,
+			numValidators: numValidators,
+		}
 		args := []string{
 			"--force-clear-db",
-			fmt.Sprintf("--interop-num-validators=%d", validatorsPerNode),
-			fmt.Sprintf("--interop-start-index=%d", validatorsPerNode*n),
-			fmt.Sprintf("--monitoring-port=%d", 9080+n),
-			fmt.Sprintf("--datadir=%s/eth2-val-%d", tmpPath, n),
-			fmt.Sprintf("--beacon-rpc-provider=localhost:%d", 4000+n),
+			fmt.Sprintf("--interop-num-validators=%d", valClient.numValidators),
+			fmt.Sprintf("--interop-start-index=%d", valClient.startIndex),
+			fmt.Sprintf("--monitoring-port=%d", valClient.monitorPort),
+			fmt.Sprintf("--datadir=%s", valClient.datadir),
+			fmt.Sprintf("--beacon-rpc-provider=localhost:%d", valClient.beaconRPCPort),
 		}
 		if config.minimalConfig {
 			args = append(args, "--minimal-config")
 		}
-		cmd := exec.Command(binaryPath, args...)
+		if config.slotDurationSeconds > 0 {
+			args = append(args, fmt.Sprintf("--e2e-config-slot-duration=%d", config.slotDurationSeconds))
+		}
+		if config.tlsRPC {
+			args = append(args, fmt.Sprintf("--tls-cert=%s", config.tlsCertFile()))
+		}
+		cmd := exec.CommandContext(ctx, binaryPath, args...)
 		cmd.Stdout = file
 		cmd.Stderr = file
 		t.Logf("Starting validator client with flags: %s", strings.Join(args, " "))
 		if err := cmd.Start(); err != nil {
 			t.Fatal(err)
 		}
-		valClients[n] = &validatorClientInfo{
-			processID:   cmd.Process.Pid,
-			monitorPort: 9080 + n,
-		}
+		valClient.processID = cmd.Process.Pid
+		valClient.cmd = cmd
+		valClient.alive = true
+		valClients[n] = valClient
 	}
 
-	client, err := rpc.DialHTTP("http://127.0.0.1:8545")
-	if err != nil {
-		t.Fatal(err)
-	}
-	web3 := ethclient.NewClient(client)
-
-	jsonBytes, err := ioutil.ReadFile(keystorePath)
-	if err != nil {
-		t.Fatal(err)
-	}
-	txOps, err := bind.NewTransactor(bytes.NewReader(jsonBytes), "" /*password*/)
-	if err != nil {
-		t.Fatal(err)
-	}
-	depositInGwei := big.NewInt(int64(params.BeaconConfig().MaxEffectiveBalance))
-	txOps.Value = depositInGwei.Mul(depositInGwei, big.NewInt(int64(params.BeaconConfig().GweiPerEth)))
-	txOps.GasLimit = 4000000
-	nonce, err := web3.PendingNonceAt(context.Background(), txOps.From)
-	if err != nil {
-		t.Fatal(err)
-	}
-	txOps.Nonce = big.NewInt(int64(nonce))
-
-	contract, err := contracts.NewDepositContract(config.contractAddr, web3)
-	if err != nil {
-		t.Fatal(err)
-	}
-
-	deposits, _, _ := testutil.DeterministicDepositsAndKeys(validatorNum)
-	_, roots, err := testutil.DeterministicDepositTrie(len(deposits))
-	if err != nil {
-		t.Fatal(err)
-	}
-	for index, dd := range deposits {
-		_, err = contract.Deposit(txOps, dd.Data.PublicKey, dd.Data.WithdrawalCredentials, dd.Data.Signature, roots[index])
-		if err != nil {
-			t.Errorf("unable to send transaction to contract: %v", err)
+	for _, valClient := range valClients {
+		if err := waitForTextInFile(ctx, valClient.logFile, "Successfully started gRPC connection", config.startupTimeout()); err != nil {
+			for _, started := range valClients {
+				// Wait returns an error for a killed process, it's only called to release its resources.
+				_ = started.cmd.Process.Kill()
+				_ = started.cmd.Wait()
+			}
+			t.Fatalf("Validator client %d did not connect to its beacon node: %v", valClient.index, err)
 		}
-		txOps.Nonce = txOps.Nonce.Add(txOps.Nonce, big.NewInt(1))
 	}
-
-	keystore, err := keystore.DecryptKey(jsonBytes, "" /*password*/)
-	if err != nil {
-		t.Fatal(err)
-	}
-
-	// "Safe" amount of blocks to mine to make sure the deposits are seen.
-	if err := mineBlocks(web3, keystore, 20); err != nil {
-		t.Fatalf("failed to mine blocks %v", err)
-	}
-
 	return valClients
 }
@@ -0,0 +1,59 @@
+package endtoend
+
+import (
+	"os"
+	"path"
+	"testing"
+
+	"github.com/bazelbuild/rules_go/go/tools/bazel"
+	"github.com/prysmaticlabs/prysm/shared/params"
+	"github.com/prysmaticlabs/prysm/shared/testutil"
+)
+
+func TestStartValidatorClients(t *testing.T) {
+	testutil.ResetCache()
+	params.UseMinimalConfig()
+
+	tmpPath := path.Join(bazel.TestTmpDir(), t.Name())
+	if err := os.MkdirAll(tmpPath, os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	config := &end2EndConfig{
+		minimalConfig:  true,
+		tmpPath:        tmpPath,
+		epochsToRun:    1,
+		numBeaconNodes: 1,
+		numValidators:  params.BeaconConfig().MinGenesisActiveValidatorCount,
+		portOffset:     200,
+	}
+	ctx, cancel := testContext()
+	defer cancel()
+
+	eth1Node := startEth1(ctx, t, config)
+	defer stopEth1Node(t, eth1Node)
+	config.contractAddr = eth1Node.contractAddr
+	config.contractDeploymentBlock = eth1Node.deploymentBlock
+	bootNode := startBootNode(t, config)
+	defer stopBootNode(t, bootNode)
+	config.bootNodeENR = bootNode.enr
+	validateConfig(t, config)
+	beaconNodes := startBeaconNodes(ctx, t, config)
+	defer stopBeaconNodes(t, beaconNodes)
+	valClients := startValidatorClients(ctx, t, config, beaconNodes)
+	defer killProcesses(t, []int{valClients[0].processID})
+	_, keys, err := testutil.DeterministicDepositsAndKeys(config.numValidators)
+	if err != nil {
+		t.Fatal(err)
+	}
+	amount := params.BeaconConfig().MaxEffectiveBalance
+	if _, err := sendDeposits(ctx, eth1Node.httpEndpoint, eth1Node.contractAddr, eth1Node.keystorePath, keys, amount); err != nil {
+		t.Fatal(err)
+	}
+
+	if valClients[0].beaconRPCPort != beaconNodes[0].rpcPort {
+		t.Errorf("Expected validator client to use RPC port %d, received %d", beaconNodes[0].rpcPort, valClients[0].beaconRPCPort)
+	}
+	if err := waitForTextInFile(ctx, valClients[0].logFile, "Beacon chain started", config.startupTimeout()); err != nil {
+		t.Fatalf("Validator client did not see the chain start: %v", err)
+	}
+}
@@ -36,6 +36,7 @@ type Flags struct {
 	InitSyncCacheState        bool   // InitSyncCacheState caches state during initial sync.
 	KafkaBootstrapServers     string // KafkaBootstrapServers to find kafka servers to stream blocks, attestations, etc.
 	BlockDoubleProposals      bool   // BlockDoubleProposals prevents the validator client from signing any proposals that would be considered a slashable offense.
+	E2ESlotDuration           uint64 // E2ESlotDuration overrides the seconds per slot of the chain config in end-to-end tests.
 
 	// Cache toggles.
 	EnableAttestationCache   bool // EnableAttestationCache; see https://github.com/prysmaticlabs/prysm/issues/3106.
@@ -75,6 +76,10 @@ func ConfigureBeaconChain(ctx *cli.Context) {
 		log.Warn("Using minimal config")
 		cfg.MinimalConfig = true
 	}
+	if ctx.GlobalUint64(e2eConfigSlotDurationFlag.Name) > 0 {
+		log.Warn("Overriding the slot duration for end-to-end tests")
+		cfg.E2ESlotDuration = ctx.GlobalUint64(e2eConfigSlotDurationFlag.Name)
+	}
 	if ctx.GlobalBool(writeSSZStateTransitionsFlag.Name) {
 		log.Warn("Writing SSZ states and blocks after state transitions")
 		cfg.WriteSSZStateTransitions = true
@@ -141,6 +146,10 @@ func ConfigureValidator(ctx *cli.Context) {
 		log.Warn("Using minimal config")
 		cfg.MinimalConfig = true
 	}
+	if ctx.GlobalUint64(e2eConfigSlotDurationFlag.Name) > 0 {
+		log.Warn("Overriding the slot duration for end-to-end tests")
+		cfg.E2ESlotDuration = ctx.GlobalUint64(e2eConfigSlotDurationFlag.Name)
+	}
 	if ctx.GlobalBool(blockDoubleProposals.Name) {
 		log.Warn("Enabled validator double proposal slashing protection.")
 		cfg.BlockDoubleProposals = true
@@ -27,3 +27,18 @@ func TestConfigureBeaconConfig(t *testing.T) {
 		t.Errorf("MinimalConfig in FeatureFlags incorrect. Wanted true, got false")
 	}
 }
+
+func TestConfigureE2ESlotDuration(t *testing.T) {
+	app := cli.NewApp()
+	set := flag.NewFlagSet("test", 0)
+	set.Uint64(e2eConfigSlotDurationFlag.Name, 2, "test")
+	context := cli.NewContext(app, set, nil)
+	ConfigureBeaconChain(context)
+	if c := Get(); c.E2ESlotDuration != 2 {
+		t.Errorf("E2ESlotDuration in FeatureFlags incorrect. Wanted 2, got %d", c.E2ESlotDuration)
+	}
+	ConfigureValidator(context)
+	if c := Get(); c.E2ESlotDuration != 2 {
+		t.Errorf("E2ESlotDuration in FeatureFlags incorrect. Wanted 2, got %d", c.E2ESlotDuration)
+	}
+}
@@ -9,6 +9,11 @@ var (
 		Name:  "minimal-config",
 		Usage: "Use minimal config with parameters as defined in the spec.",
 	}
+	e2eConfigSlotDurationFlag = cli.Uint64Flag{
+		Name: "e2e-config-slot-duration",
+		Usage: "Override the seconds per slot of the chain config to accelerate end-to-end tests. " +
+			"Every beacon node and validator client of the network must use the same value.",
+	}
 	writeSSZStateTransitionsFlag = cli.BoolFlag{
 		Name:  "interop-write-ssz-state-transitions",
 		Usage: "Write ssz states to disk after attempted state transition",
@@ -192,6 +197,7 @@ var deprecatedFlags = []cli.Flag{
 // ValidatorFlags contains a list of all the feature flags that apply to the validator client.
 var ValidatorFlags = append(deprecatedFlags, []cli.Flag{
 	minimalConfigFlag,
+	e2eConfigSlotDurationFlag,
 	blockDoubleProposals,
 }...)
 
@@ -199,6 +205,7 @@ var ValidatorFlags = append(deprecatedFlags, []cli.Flag{
 var BeaconChainFlags = append(deprecatedFlags, []cli.Flag{
 	noGenesisDelayFlag,
 	minimalConfigFlag,
+	e2eConfigSlotDurationFlag,
 	writeSSZStateTransitionsFlag,
 	enableSSZCache,
 	enableAttestationCacheFlag,
@@ -69,6 +69,8 @@ var appFlags = []cli.Flag{
 	flags.CertFlag,
 	flags.RPCPort,
 	flags.KeyFlag,
+	flags.BeaconCertFlag,
+	flags.BeaconRPCProviderFlag,
 }
 
 func init() {
@@ -79,6 +79,11 @@ func (ss *Server) IsSlashableAttestation(ctx context.Context, req *ethpb.Indexed
 	for atts := range at {
 		atsSlashinngRes.AttesterSlashing = append(atsSlashinngRes.AttesterSlashing, atts...)
 	}
+	for _, slashing := range atsSlashinngRes.AttesterSlashing {
+		if err := ss.SlasherDB.SaveAttesterSlashing(db.Active, slashing); err != nil {
+			return nil, errors.Wrap(err, "slasher service error while trying to save attester slashing")
+		}
+	}
 	return atsSlashinngRes, err
 }
 
@@ -100,6 +105,11 @@ func (ss *Server) IsSlashableBlock(ctx context.Context, psr *slashpb.ProposerSla
 		}
 		pSlashingsResponse.ProposerSlashing = append(pSlashingsResponse.ProposerSlashing, &ethpb.ProposerSlashing{ProposerIndex: psr.ValidatorIndex, Header_1: psr.BlockHeader, Header_2: bh})
 	}
+	for _, slashing := range pSlashingsResponse.ProposerSlashing {
+		if err := ss.SlasherDB.SaveProposerSlashing(db.Active, slashing); err != nil {
+			return nil, errors.Wrap(err, "slasher service error while trying to save proposer slashing")
+		}
+	}
 	if len(pSlashingsResponse.ProposerSlashing) == 0 && !presentInDb {
 		err = ss.SlasherDB.SaveBlockHeader(epoch, psr.ValidatorIndex, psr.BlockHeader)
 		if err != nil {
@@ -109,16 +119,34 @@ func (ss *Server) IsSlashableBlock(ctx context.Context, psr *slashpb.ProposerSla
 	return pSlashingsResponse, nil
 }
 
-// SlashableProposals is a subscription to receive all slashable proposer slashing events found by the watchtower.
+// SlashableProposals streams the proposer slashings found by the watchtower that are still active.
 func (ss *Server) SlashableProposals(req *types.Empty, server slashpb.Slasher_SlashableProposalsServer) error {
-	//TODO(3133): implement stream provider for newly discovered listening to slashable proposals.
-	return status.Error(codes.Unimplemented, "not implemented")
+	//TODO(3133): keep the stream open to send newly discovered slashable proposals.
+	slashings, err := ss.SlasherDB.ProposalSlashingsByStatus(db.Active)
+	if err != nil {
+		return status.Errorf(codes.Internal, "could not retrieve proposer slashings: %v", err)
+	}
+	for _, slashing := range slashings {
+		if err := server.Send(slashing); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
-// SlashableAttestations is a subscription to receive all slashable attester slashing events found by the watchtower.
+// SlashableAttestations streams the attester slashings found by the watchtower that are still active.
 func (ss *Server) SlashableAttestations(req *types.Empty, server slashpb.Slasher_SlashableAttestationsServer) error {
-	//TODO(3133): implement stream provider for newly discovered listening to slashable attestation.
-	return status.Error(codes.Unimplemented, "not implemented")
+	//TODO(3133): keep the stream open to send newly discovered slashable attestations.
+	slashings, err := ss.SlasherDB.AttesterSlashings(db.Active)
+	if err != nil {
+		return status.Errorf(codes.Internal, "could not retrieve attester slashings: %v", err)
+	}
+	for _, slashing := range slashings {
+		if err := server.Send(slashing); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 // DetectSurroundVotes is a method used to return the attestation that were detected
@@ -6,10 +6,12 @@ import (
 	"testing"
 
 	"github.com/gogo/protobuf/proto"
+	"github.com/gogo/protobuf/types"
 	ethpb "github.com/prysmaticlabs/ethereumapis/eth/v1alpha1"
 	slashpb "github.com/prysmaticlabs/prysm/proto/slashing"
 	"github.com/prysmaticlabs/prysm/shared/params"
 	"github.com/prysmaticlabs/prysm/slasher/db"
+	"google.golang.org/grpc"
 )
 
 func TestServer_IsSlashableBlock(t *testing.T) {
@@ -610,3 +612,135 @@ func TestServer_Store_100_Attestations(t *testing.T) {
 	t.Logf("DB size is: %d", s)
 
 }
+
+type proposalsStream struct {
+	grpc.ServerStream
+	slashings []*ethpb.ProposerSlashing
+}
+
+func (s *proposalsStream) Send(slashing *ethpb.ProposerSlashing) error {
+	s.slashings = append(s.slashings, slashing)
+	return nil
+}
+
+func TestServer_SlashableProposals(t *testing.T) {
+	dbs := db.SetupSlasherDB(t)
+	defer db.TeardownSlasherDB(t, dbs)
+	ctx := context.Background()
+	slasherServer := &Server{
+		ctx:       ctx,
+		SlasherDB: dbs,
+	}
+	psr := &slashpb.ProposerSlashingRequest{
+		BlockHeader: &ethpb.SignedBeaconBlockHeader{
+			Header: &ethpb.BeaconBlockHeader{
+				Slot:      1,
+				StateRoot: []byte("A"),
+			},
+		},
+		ValidatorIndex: 1,
+	}
+	psr2 := &slashpb.ProposerSlashingRequest{
+		BlockHeader: &ethpb.SignedBeaconBlockHeader{
+			Header: &ethpb.BeaconBlockHeader{
+				Slot:      1,
+				StateRoot: []byte("B"),
+			},
+		},
+		ValidatorIndex: 1,
+	}
+
+	stream := &proposalsStream{}
+	if err := slasherServer.SlashableProposals(&types.Empty{}, stream); err != nil {
+		t.Fatalf("Could not call RPC method: %v", err)
+	}
+	if len(stream.slashings) != 0 {
+		t.Errorf("Should stream 0 slashing proof before any slashable block: %v", stream.slashings)
+	}
+	if _, err := slasherServer.IsSlashableBlock(ctx, psr); err != nil {
+		t.Errorf("Could not call RPC method: %v", err)
+	}
+	if _, err := slasherServer.IsSlashableBlock(ctx, psr2); err != nil {
+		t.Errorf("Could not call RPC method: %v", err)
+	}
+	want := &ethpb.ProposerSlashing{
+		ProposerIndex: psr.ValidatorIndex,
+		Header_1:      psr2.BlockHeader,
+		Header_2:      psr.BlockHeader,
+	}
+
+	stream = &proposalsStream{}
+	if err := slasherServer.SlashableProposals(&types.Empty{}, stream); err != nil {
+		t.Fatalf("Could not call RPC method: %v", err)
+	}
+	if len(stream.slashings) != 1 {
+		t.Fatalf("Should stream 1 slashing proof: %v", stream.slashings)
+	}
+	if !proto.Equal(stream.slashings[0], want) {
+		t.Errorf("Wanted slashing proof: %v got: %v", want, stream.slashings[0])
+	}
+}
+
+type attestationsStream struct {
+	grpc.ServerStream
+	slashings []*ethpb.AttesterSlashing
+}
+
+func (s *attestationsStream) Send(slashing *ethpb.AttesterSlashing) error {
+	s.slashings = append(s.slashings, slashing)
+	return nil
+}
+
+func TestServer_SlashableAttestations(t *testing.T) {
+	dbs := db.SetupSlasherDB(t)
+	defer db.TeardownSlasherDB(t, dbs)
+	ctx := context.Background()
+	slasherServer := &Server{
+		ctx:       ctx,
+		SlasherDB: dbs,
+	}
+	ia1 := &ethpb.IndexedAttestation{
+		AttestingIndices: []uint64{0},
+		Signature:        []byte("sig2"),
+		Data: &ethpb.AttestationData{
+			Slot:            3*params.BeaconConfig().SlotsPerEpoch + 1,
+			CommitteeIndex:  0,
+			BeaconBlockRoot: []byte("block1"),
+			Source:          &ethpb.Checkpoint{Epoch: 2},
+			Target:          &ethpb.Checkpoint{Epoch: 3},
+		},
+	}
+	ia2 := &ethpb.IndexedAttestation{
+		AttestingIndices: []uint64{0},
+		Signature:        []byte("sig1"),
+		Data: &ethpb.AttestationData{
+			Slot:            3*params.BeaconConfig().SlotsPerEpoch + 1,
+			CommitteeIndex:  0,
+			BeaconBlockRoot: []byte("block2"),
+			Source:          &ethpb.Checkpoint{Epoch: 2},
+			Target:          &ethpb.Checkpoint{Epoch: 3},
+		},
+	}
+	want := &ethpb.AttesterSlashing{
+		Attestation_1: ia2,
+		Attestation_2: ia1,
+	}
+
+	if _, err := slasherServer.IsSlashableAttestation(ctx, ia1); err != nil {
+		t.Errorf("Could not call RPC method: %v", err)
+	}
+	if _, err := slasherServer.IsSlashableAttestation(ctx, ia2); err != nil {
+		t.Errorf("Could not call RPC method: %v", err)
+	}
+
+	stream := &attestationsStream{}
+	if err := slasherServer.SlashableAttestations(&types.Empty{}, stream); err != nil {
+		t.Fatalf("Could not call RPC method: %v", err)
+	}
+	if len(stream.slashings) != 1 {
+		t.Fatalf("Should stream 1 slashing proof: %v", stream.slashings)
+	}
+	if !proto.Equal(stream.slashings[0], want) {
+		t.Errorf("Wanted slashing proof: %v got: %v", want, stream.slashings[0])
+	}
+}
@@ -72,6 +72,8 @@ var appHelpFlagGroups = []flagGroup{
 			flags.CertFlag,
 			flags.KeyFlag,
 			flags.RPCPort,
+			flags.BeaconCertFlag,
+			flags.BeaconRPCProviderFlag,
 		},
 	},
 }
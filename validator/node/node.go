@@ -78,6 +78,12 @@ func NewValidatorClient(ctx *cli.Context) (*ValidatorClient, error) {
 			params.UseDemoBeaconConfig()
 		}
 	}
+	if slotDuration := featureconfig.Get().E2ESlotDuration; slotDuration > 0 {
+		log.WithField("secondsPerSlot", slotDuration).Warn("Using end-to-end test slot duration")
+		c := params.BeaconConfig()
+		c.SecondsPerSlot = slotDuration
+		params.OverrideBeaconConfig(c)
+	}
 
 	keyManager, err := selectKeyManager(ctx)
 	if err != nil {